@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// cachedCatalog is the on-disk form of the last tool list fetched from the portal.
+type cachedCatalog struct {
+	SavedAt time.Time  `json:"saved_at"`
+	Tools   []mcp.Tool `json:"tools"`
+}
+
+// catalogCachePath returns where portal.offline keeps the tool catalog.
+func catalogCachePath() string {
+	return filepath.Join(stateDir().path, "catalog.json")
+}
+
+// saveCatalog writes tools to path, replacing any previous cache.
+func saveCatalog(path string, tools []mcp.Tool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cachedCatalog{SavedAt: time.Now().UTC(), Tools: tools})
+	if err != nil {
+		return err
+	}
+	// Write then rename so a crash never leaves a half-written cache
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadCatalog reads the tool catalog saved by saveCatalog.
+func loadCatalog(path string) (*cachedCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cat cachedCatalog
+	if err := json.Unmarshal(data, &cat); err != nil {
+		return nil, fmt.Errorf("corrupt catalog cache %s: %w", path, err)
+	}
+	if len(cat.Tools) == 0 {
+		return nil, fmt.Errorf("catalog cache %s has no tools", path)
+	}
+	return &cat, nil
+}
+
+// reconnector re-establishes the portal session for tools registered from
+// the cached catalog. Only one connect attempt runs at a time.
+type reconnector struct {
+	mu        sync.Mutex
+	connected bool
+	connect   func(ctx context.Context) error
+}
+
+// ensure connects unless a previous call already succeeded.
+func (r *reconnector) ensure(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.connected {
+		return nil
+	}
+	if err := r.connect(ctx); err != nil {
+		return err
+	}
+	r.connected = true
+	return nil
+}
+
+// offlineHandler wraps next for a tool served from the cached catalog: each
+// call first tries to reach the portal and, while that fails, returns an
+// "offline" tool error instead of forwarding.
+func offlineHandler(conn *reconnector, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if err := conn.ensure(ctx); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf(
+				"vire-portal is offline (%v). This tool is listed from vire-mcp's cached catalog and will work again once the portal is reachable.", err)), nil
+		}
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// runOffline runs vire-mcp in direct mode against mcpURL with portal.offline
+// enabled and returns the exit code and the stdio server it would have served.
+func runOffline(t *testing.T, mcpURL, catalogPath string, offline bool) (int, *server.MCPServer) {
+	t.Helper()
+	cfg := config.NewDefaultConfig()
+	cfg.Portal.Offline = offline
+
+	var served *server.MCPServer
+	code := run(context.Background(), cfg, runEnv{
+		mcpURL:      mcpURL,
+		catalogPath: catalogPath,
+		serve: func(s *server.MCPServer) error {
+			served = s
+			return nil
+		},
+	}, common.NewSilentLogger())
+	return code, served
+}
+
+func seedCatalog(t *testing.T, names ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".vire", "catalog.json")
+	var tools []mcp.Tool
+	for _, name := range names {
+		tools = append(tools, mcp.NewTool(name, mcp.WithDescription("cached "+name)))
+	}
+	if err := saveCatalog(path, tools); err != nil {
+		t.Fatalf("saveCatalog: %v", err)
+	}
+	return path
+}
+
+func TestRun_OfflineLoadsCatalogWhenListToolsFails(t *testing.T) {
+	// A portal without tool capabilities accepts the session but fails tools/list
+	srv := httptest.NewServer(server.NewStreamableHTTPServer(server.NewMCPServer("vire", "test")))
+	defer srv.Close()
+
+	path := seedCatalog(t, "portfolio_get", "market_quote")
+	code, served := runOffline(t, srv.URL+"/mcp/uid", path, true)
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	tools := served.ListTools()
+	if len(tools) != 2 || tools["portfolio_get"] == nil || tools["market_quote"] == nil {
+		t.Errorf("expected cached tools to be registered, got %v", tools)
+	}
+}
+
+func TestRun_OfflinePortalUnreachable(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	}))
+	defer down.Close()
+
+	t.Run("serves cached catalog", func(t *testing.T) {
+		code, served := runOffline(t, down.URL+"/mcp/uid", seedCatalog(t, "portfolio_get"), true)
+		if code != exitOK {
+			t.Fatalf("expected exit code %d, got %d", exitOK, code)
+		}
+		tool := served.GetTool("portfolio_get")
+		if tool == nil {
+			t.Fatal("expected cached tool to be registered")
+		}
+
+		// Calls fail with an offline error while the portal stays down
+		result, err := tool.Handler(context.Background(), mcp.CallToolRequest{})
+		if err != nil {
+			t.Fatalf("expected a tool error result, got %v", err)
+		}
+		if !result.IsError {
+			t.Fatal("expected IsError result")
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if !strings.Contains(text, "offline") {
+			t.Errorf("expected offline message, got %q", text)
+		}
+	})
+
+	t.Run("no cache", func(t *testing.T) {
+		missing := filepath.Join(t.TempDir(), "catalog.json")
+		if code, _ := runOffline(t, down.URL+"/mcp/uid", missing, true); code != exitConnect {
+			t.Errorf("expected exit code %d, got %d", exitConnect, code)
+		}
+	})
+
+	t.Run("offline disabled", func(t *testing.T) {
+		if code, _ := runOffline(t, down.URL+"/mcp/uid", seedCatalog(t, "portfolio_get"), false); code != exitConnect {
+			t.Errorf("expected exit code %d, got %d", exitConnect, code)
+		}
+	})
+}
+
+func TestRun_RefreshesCatalogOnConnect(t *testing.T) {
+	mcpSrv := server.NewMCPServer("vire", "test", server.WithToolCapabilities(true))
+	mcpSrv.AddTool(mcp.NewTool("portfolio_get"), func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("ok"), nil
+	})
+	srv := httptest.NewServer(server.NewStreamableHTTPServer(mcpSrv))
+	defer srv.Close()
+
+	path := seedCatalog(t, "stale_tool")
+	if code, _ := runOffline(t, srv.URL+"/mcp/uid", path, true); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	cat, err := loadCatalog(path)
+	if err != nil {
+		t.Fatalf("loadCatalog: %v", err)
+	}
+	if len(cat.Tools) != 1 || cat.Tools[0].Name != "portfolio_get" {
+		t.Errorf("expected cache refreshed with portfolio_get, got %+v", cat.Tools)
+	}
+
+	// Without portal.offline nothing is written
+	path = filepath.Join(t.TempDir(), "catalog.json")
+	if code, _ := runOffline(t, srv.URL+"/mcp/uid", path, false); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected no catalog cache without portal.offline, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// newHTTPTransport builds the transport for every request vire-mcp makes to
+// the portal (MCP session, OAuth discovery and token exchange). It honours
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY. When caCertFile is set, the PEM
+// certificates in it are trusted in addition to the system roots, for
+// TLS-intercepting corporate proxies.
+func newHTTPTransport(caCertFile string) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = http.ProxyFromEnvironment
+
+	if caCertFile == "" {
+		return t, nil
+	}
+
+	pem, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return nil, fmt.Errorf("read CA cert file: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA cert file %s contains no PEM certificates", caCertFile)
+	}
+	t.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return t, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPTransport_HonoursProxyEnv(t *testing.T) {
+	// http.ProxyFromEnvironment reads the environment once per process, so the
+	// assertions run in a fresh test process started with the proxy variables.
+	if os.Getenv("VIRE_MCP_PROXY_TEST_CHILD") != "1" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestNewHTTPTransport_HonoursProxyEnv$")
+		cmd.Env = append(os.Environ(),
+			"VIRE_MCP_PROXY_TEST_CHILD=1",
+			"HTTPS_PROXY=http://proxy.corp.example:3128",
+			"HTTP_PROXY=http://proxy.corp.example:3128",
+			"NO_PROXY=internal.example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("proxy test process failed: %v\n%s", err, out)
+		}
+		return
+	}
+
+	tr, err := newHTTPTransport("")
+	if err != nil {
+		t.Fatalf("newHTTPTransport: %v", err)
+	}
+	if tr.Proxy == nil {
+		t.Fatal("expected transport to have a proxy func")
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://portal.example.com/mcp", nil)
+	proxyURL, err := tr.Proxy(req)
+	if err != nil {
+		t.Fatalf("Proxy: %v", err)
+	}
+	if proxyURL == nil || proxyURL.Host != "proxy.corp.example:3128" {
+		t.Errorf("expected request to go via proxy.corp.example:3128, got %v", proxyURL)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, "https://internal.example.com/mcp", nil)
+	if proxyURL, _ := tr.Proxy(req); proxyURL != nil {
+		t.Errorf("expected NO_PROXY host to bypass the proxy, got %v", proxyURL)
+	}
+}
+
+func TestNewHTTPTransport_CACertFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "corp-ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the bundle the test server's self-signed certificate is rejected
+	plain, err := newHTTPTransport("")
+	if err != nil {
+		t.Fatalf("newHTTPTransport: %v", err)
+	}
+	if resp, err := (&http.Client{Transport: plain}).Get(srv.URL); err == nil {
+		resp.Body.Close()
+		t.Fatal("expected untrusted certificate to fail verification")
+	}
+
+	tr, err := newHTTPTransport(caFile)
+	if err != nil {
+		t.Fatalf("newHTTPTransport: %v", err)
+	}
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected request to succeed with the CA bundle, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", resp.StatusCode)
+	}
+}
+
+func TestNewHTTPTransport_BadCACertFile(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not-a-cert.pem")
+	if err := os.WriteFile(notPEM, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{
+		"missing": filepath.Join(dir, "missing.pem"),
+		"not pem": notPEM,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := newHTTPTransport(path); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
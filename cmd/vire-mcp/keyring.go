@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyring is the part of an OS keychain KeyringTokenStore needs.
+type keyring interface {
+	// Get returns the secret for service and account, or errKeyringNotFound.
+	Get(service, account string) (string, error)
+	// Set creates or replaces the secret for service and account.
+	Set(service, account, secret string) error
+	// Delete removes the secret for service and account, or returns
+	// errKeyringNotFound when there is none.
+	Delete(service, account string) error
+}
+
+// errKeyringNotFound is returned by keyring.Get and Delete when no secret is stored.
+var errKeyringNotFound = errors.New("keyring: secret not found")
+
+// systemKeyring returns the OS keychain: macOS Keychain (security),
+// Secret Service on Linux (secret-tool) or Windows Credential Manager.
+// It returns an error when the platform or its tooling is unavailable.
+func systemKeyring() (keyring, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err != nil {
+			return nil, fmt.Errorf("macOS keychain: %w", err)
+		}
+		return macKeychain{}, nil
+	case "linux":
+		if _, err := exec.LookPath("secret-tool"); err != nil {
+			return nil, fmt.Errorf("secret service: %w (install libsecret-tools)", err)
+		}
+		return secretService{}, nil
+	case "windows":
+		return newWinCredentialManager()
+	default:
+		return nil, fmt.Errorf("no keyring support on %s", runtime.GOOS)
+	}
+}
+
+// macKeychain stores generic passwords in the login keychain via security(1).
+type macKeychain struct{}
+
+func (macKeychain) Get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 { // errSecItemNotFound
+			return "", errKeyringNotFound
+		}
+		return "", fmt.Errorf("macOS keychain: %w", err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (macKeychain) Set(service, account, secret string) error {
+	// Interactive mode reads the command from stdin, so the secret (hex
+	// encoded via -X) never appears in the process list.
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n",
+		service, account, hex.EncodeToString([]byte(secret))))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("macOS keychain: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (macKeychain) Delete(service, account string) error {
+	out, err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 { // errSecItemNotFound
+			return errKeyringNotFound
+		}
+		return fmt.Errorf("macOS keychain: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// secretService stores secrets via the freedesktop Secret Service
+// (GNOME Keyring, KWallet) using secret-tool(1).
+type secretService struct{}
+
+func (secretService) Get(service, account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits 1 without output when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(out) == 0 && stderr.Len() == 0 {
+			return "", errKeyringNotFound
+		}
+		return "", fmt.Errorf("secret service: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (secretService) Set(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=vire-mcp OAuth token", "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (secretService) Delete(service, account string) error {
+	// secret-tool clear succeeds whether or not anything matched
+	if out, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret service: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
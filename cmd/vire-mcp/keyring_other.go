@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// newWinCredentialManager is only available on Windows.
+func newWinCredentialManager() (keyring, error) {
+	return nil, errors.New("windows credential manager: not available on this platform")
+}
@@ -6,27 +6,53 @@
 // vire-mcp is a pure transport adapter: stdio ↔ HTTP (with optional OAuth 2.1).
 //
 // Configuration priority: defaults < TOML file < environment variables (VIRE_*).
-// The TOML file is auto-discovered from vire-mcp.toml or config/vire-mcp.toml.
+// The TOML file is auto-discovered from vire-mcp.toml or config/vire-mcp.toml,
+// unless -config (or VIRE_CONFIG) names one explicitly.
 //
 // Environment variables:
 //
-//	VIRE_PORTAL_URL  vire-portal URL (default: http://localhost:8080)
-//	VIRE_MCP_URL     full MCP endpoint URL with encrypted UID (bypasses OAuth)
-//	VIRE_LOG_LEVEL   log level       (default: info)
+//	VIRE_CONFIG              TOML config file, as -config (skips auto-discovery)
+//	VIRE_PORTAL_URL          vire-portal URL (default: http://localhost:8080)
+//	VIRE_HOME                state directory for credentials and catalog cache
+//	                         (default: ~/.vire; USERPROFILE\.vire on Windows)
+//	VIRE_MCP_URL             full MCP endpoint URL with encrypted UID (bypasses OAuth)
+//	VIRE_PORTAL_TOKEN_STORE  OAuth token storage: file or keyring (default: file)
+//	VIRE_PORTAL_CA_CERT_FILE extra PEM CA bundle for TLS-intercepting proxies
+//	VIRE_PORTAL_OFFLINE      serve the cached tool catalog when the portal is down
+//	VIRE_PORTAL_CALLBACK_PORT        fixed OAuth callback port (default: random)
+//	VIRE_PORTAL_CALLBACK_PORT_RANGE  OAuth callback ports to scan, e.g. 53682-53692
+//	HTTP_PROXY, HTTPS_PROXY, NO_PROXY  outbound proxy settings
+//	VIRE_LOG_LEVEL           log level       (default: info)
 //
 // When VIRE_MCP_URL is set to a full endpoint URL (e.g., http://host/mcp/encrypted_uid),
 // OAuth is bypassed and the connection uses the embedded user identity. This is useful
 // for Docker containers, CI/CD, or environments without browser access.
+//
+// Exit codes:
+//
+//	0  stdin closed, clean shutdown
+//	1  any other failure (e.g. -logout could not clear the token)
+//	2  configuration error (missing -config file, invalid portal or MCP URL, unusable ca_cert_file,
+//	   configured OAuth callback port busy or invalid)
+//	3  vire-portal could not be reached or the MCP session failed to start
+//	4  OAuth authorization failed
+//	5  transport error (HTTP transport setup or the stdio session failed)
 package main
 
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/client/transport"
@@ -50,7 +76,8 @@ func binDir() string {
 // configSearchPaths returns TOML files to auto-discover (first match wins).
 // Binary-relative paths are tried first so the config is found even when
 // the working directory differs from the binary location (e.g. Claude Desktop
-// launching via WSL). Paths are deduplicated via filepath.Abs.
+// launching via WSL); the state directory (see resolveVireDir) is tried last.
+// Paths are deduplicated via filepath.Abs.
 func configSearchPaths() []string {
 	candidates := []string{
 		"vire-mcp.toml",
@@ -64,6 +91,7 @@ func configSearchPaths() []string {
 		filepath.Join(dir, "config", "vire-mcp.toml"),
 	}
 	paths = append(paths, candidates...)
+	paths = append(paths, filepath.Join(stateDir().path, "vire-mcp.toml"))
 
 	// Deduplicate via absolute path.
 	seen := make(map[string]bool, len(paths))
@@ -82,8 +110,44 @@ func configSearchPaths() []string {
 	return deduped
 }
 
+var (
+	configFlag  = flag.String("config", "", "Configuration file path; skips auto-discovery (env: VIRE_CONFIG)")
+	showVersion = flag.Bool("version", false, "Print version information")
+	versionJSON = flag.Bool("json", false, "With -version, print version, build and git_commit as JSON")
+	logout      = flag.Bool("logout", false, "Clear the stored OAuth token and exit; the next run signs in again")
+)
+
+// Exit codes, so wrapper scripts can tell failure modes apart.
+const (
+	exitOK        = 0
+	exitFailure   = 1 // anything not covered below
+	exitConfig    = 2
+	exitConnect   = 3
+	exitAuth      = 4
+	exitTransport = 5
+)
+
 func main() {
-	cfg := loadConfig()
+	flag.Parse()
+
+	// Handle version flag (stdout is safe: the MCP session has not started)
+	if *showVersion {
+		if err := config.WriteVersion(os.Stdout, "vire-mcp", *versionJSON); err != nil {
+			os.Exit(exitFailure)
+		}
+		os.Exit(exitOK)
+	}
+
+	explicitConfig := *configFlag
+	if explicitConfig == "" {
+		explicitConfig = os.Getenv("VIRE_CONFIG")
+	}
+	cfg, cfgPath, err := loadConfig(explicitConfig)
+	if err != nil {
+		// No logger yet: report on stderr, stdout belongs to the MCP protocol
+		fmt.Fprintf(os.Stderr, "vire-mcp: %v\n", err)
+		os.Exit(exitConfig)
+	}
 
 	// Console output goes to stderr so it won't interfere with stdio MCP on stdout.
 	logger := common.NewLoggerFromConfig(common.LoggingConfig{
@@ -95,8 +159,51 @@ func main() {
 		MaxBackups: cfg.Logging.MaxBackups,
 	})
 
+	// Log where config and state live; HOME and USERPROFILE can disagree under WSL
+	dir := stateDir()
+	logger.Info().
+		Str("vire_home", dir.path).
+		Str("vire_home_source", dir.source).
+		Str("config_file", cfgPath).
+		Str("credentials", credentialsPath()).
+		Str("catalog", catalogCachePath()).
+		Msg("resolved paths")
+
+	// Handle logout flag: forget the OAuth token so the next run re-authorizes
+	if *logout {
+		store := newTokenStore(cfg.Portal.TokenStore, credentialsPath(), systemKeyring, logger)
+		if err := runLogout(context.Background(), store, os.Stderr); err != nil {
+			logger.Error().Str("error", err.Error()).Msg("failed to clear stored credentials")
+			os.Exit(exitFailure)
+		}
+		os.Exit(exitOK)
+	}
+
+	os.Exit(run(context.Background(), cfg, runEnv{
+		mcpURL:          os.Getenv("VIRE_MCP_URL"),
+		credentialsPath: credentialsPath(),
+		catalogPath:     catalogCachePath(),
+		openKeyring:     systemKeyring,
+		serve:           func(s *server.MCPServer) error { return server.ServeStdio(s) },
+	}, logger))
+}
+
+// runEnv is what run takes from the process environment; tests substitute it.
+type runEnv struct {
+	mcpURL          string // VIRE_MCP_URL; empty selects OAuth mode
+	credentialsPath string
+	catalogPath     string // tool catalog cache, used with portal.offline
+	openKeyring     func() (keyring, error)
+	// runOAuth replaces the browser flow when set.
+	runOAuth func(handler *transport.OAuthHandler) error
+	serve    func(s *server.MCPServer) error
+}
+
+// run connects to vire-portal, re-exposes its tools through env.serve and
+// returns the process exit code.
+func run(ctx context.Context, cfg *config.Config, env runEnv, logger *common.Logger) int {
 	// Check for direct MCP URL (bypasses OAuth) or portal URL (with OAuth)
-	mcpURL := os.Getenv("VIRE_MCP_URL")
+	mcpURL := env.mcpURL
 	var portalURL string
 	var directMode bool
 
@@ -104,6 +211,10 @@ func main() {
 		// Direct mode: full MCP endpoint URL with encrypted UID
 		mcpURL = strings.TrimRight(mcpURL, "/")
 		directMode = true
+		if err := validateHTTPURL(mcpURL); err != nil {
+			logger.Error().Str("error", err.Error()).Msg("invalid VIRE_MCP_URL")
+			return exitConfig
+		}
 		// Extract portal URL for logging
 		if idx := strings.Index(mcpURL, "/mcp/"); idx > 0 {
 			portalURL = mcpURL[:idx]
@@ -113,88 +224,166 @@ func main() {
 		logger.Info().Str("mcp_url", mcpURL).Bool("direct_mode", true).Msg("loaded configuration")
 	} else {
 		portalURL = strings.TrimRight(cfg.Portal.URL, "/")
+		if err := validateHTTPURL(portalURL); err != nil {
+			logger.Error().Str("error", err.Error()).Msg("invalid portal URL")
+			return exitConfig
+		}
 		logger.Info().Str("portal_url", portalURL).Bool("direct_mode", false).Msg("loaded configuration")
 	}
 
 	var httpTransport *transport.StreamableHTTP
-	var err error
+	var callbackPort int
+
+	// Shared by the MCP session and OAuth: proxy env vars and portal.ca_cert_file
+	baseTransport, err := newHTTPTransport(cfg.Portal.CACertFile)
+	if err != nil {
+		logger.Error().Str("error", err.Error()).Msg("failed to configure HTTP transport")
+		return exitConfig
+	}
+	httpClient := &http.Client{Transport: baseTransport}
+	oauthHTTPClient := &http.Client{Transport: baseTransport, Timeout: 30 * time.Second}
 
 	if directMode {
 		// Direct mode: connect without OAuth
-		httpTransport, err = transport.NewStreamableHTTP(mcpURL)
+		httpTransport, err = transport.NewStreamableHTTP(mcpURL, transport.WithHTTPBasicClient(httpClient))
 		if err != nil {
 			logger.Error().Str("error", err.Error()).Msg("failed to create HTTP transport")
-			os.Exit(1)
+			return exitTransport
 		}
 	} else {
 		// OAuth mode: allocate port for callback server
-		callbackPort, err := findFreePort()
+		callbackPort, err = selectCallbackPort(cfg.Portal.CallbackPort, cfg.Portal.CallbackPortRange)
 		if err != nil {
 			logger.Error().Str("error", err.Error()).Msg("failed to allocate OAuth callback port")
-			os.Exit(1)
+			if cfg.Portal.CallbackPort != 0 || cfg.Portal.CallbackPortRange != "" {
+				return exitConfig
+			}
+			return exitTransport
 		}
 
-		tokenStore := NewFileTokenStore(filepath.Join(homeDir(), ".vire", "credentials.json"))
+		tokenStore := newTokenStore(cfg.Portal.TokenStore, env.credentialsPath, env.openKeyring, logger)
 
 		// Connect to vire-portal's Streamable HTTP MCP endpoint with OAuth.
 		httpTransport, err = transport.NewStreamableHTTP(
 			portalURL+"/mcp",
+			transport.WithHTTPBasicClient(httpClient),
 			transport.WithHTTPOAuth(transport.OAuthConfig{
 				RedirectURI:           fmt.Sprintf("http://127.0.0.1:%d/callback", callbackPort),
 				TokenStore:            tokenStore,
 				PKCEEnabled:           true,
 				AuthServerMetadataURL: portalURL + "/.well-known/oauth-authorization-server",
+				HTTPClient:            oauthHTTPClient,
 			}),
 		)
 		if err != nil {
 			logger.Error().Str("error", err.Error()).Msg("failed to create HTTP transport")
-			os.Exit(1)
+			return exitTransport
 		}
 	}
 
 	mcpClient := client.NewClient(httpTransport)
 
-	ctx := context.Background()
+	var flow *oauthFlow
+	var connect func(ctx context.Context) error
 	if directMode {
 		// Direct mode: simple connect without OAuth
-		if err := connectDirect(ctx, mcpClient, logger); err != nil {
-			logger.Error().Str("error", err.Error()).Msg("failed to connect to vire-portal")
-			os.Exit(1)
-		}
+		connect = func(ctx context.Context) error { return connectDirect(ctx, mcpClient, logger) }
 	} else {
-		// OAuth mode: connect with OAuth flow
-		callbackPort, _ := findFreePort()
-		if err := connectWithOAuth(ctx, mcpClient, callbackPort, logger); err != nil {
-			logger.Error().Str("error", err.Error()).Msg("failed to connect to vire-portal")
-			os.Exit(1)
+		// OAuth mode: connect with OAuth flow on the port in the redirect URI
+		flow = newOAuthFlow(callbackPort, oauthHTTPClient, logger)
+		if env.runOAuth != nil {
+			flow.runFn = env.runOAuth
 		}
+		connect = func(ctx context.Context) error { return connectWithOAuth(ctx, mcpClient, flow, logger) }
+	}
+
+	// With portal.offline, an unreachable portal is not fatal: the cached
+	// catalog is served below and tools reconnect on first use.
+	useCache := cfg.Portal.Offline && env.catalogPath != ""
+	conn := &reconnector{connect: connect}
+	connected := true
+	if err := conn.ensure(ctx); err != nil {
+		logger.Error().Str("error", err.Error()).Msg("failed to connect to vire-portal")
+		code := exitConnect
+		if !directMode {
+			code = connectExitCode(err)
+		}
+		if !useCache || code != exitConnect {
+			return code
+		}
+		connected = false
 	}
 	defer mcpClient.Close()
 
 	// Discover tools from vire-portal.
-	toolsResult, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
-	if err != nil {
-		logger.Warn().Str("error", err.Error()).Msg("failed to list tools from portal")
+	var tools []mcp.Tool
+	listed := false
+	if connected {
+		toolsResult, err := mcpClient.ListTools(ctx, mcp.ListToolsRequest{})
+		if err != nil {
+			logger.Warn().Str("error", err.Error()).Msg("failed to list tools from portal")
+		} else if toolsResult != nil {
+			tools = toolsResult.Tools
+			listed = true
+		}
 	}
 
-	var tools []mcp.Tool
-	if toolsResult != nil {
-		tools = toolsResult.Tools
+	fromCache := false
+	if useCache {
+		if listed && len(tools) > 0 {
+			if err := saveCatalog(env.catalogPath, tools); err != nil {
+				logger.Warn().Str("error", err.Error()).Str("path", env.catalogPath).Msg("failed to cache tool catalog")
+			}
+		} else if !listed {
+			cat, err := loadCatalog(env.catalogPath)
+			if err != nil {
+				logger.Warn().Str("error", err.Error()).Msg("no cached tool catalog to fall back to")
+				if !connected {
+					return exitConnect
+				}
+			} else {
+				tools = cat.Tools
+				fromCache = true
+				logger.Warn().Int("tools", len(tools)).Str("saved_at", cat.SavedAt.Format(time.RFC3339)).Msg("serving cached tool catalog (offline)")
+			}
+		}
 	}
 
 	// Create local stdio MCP server and register proxy handlers.
 	mcpSrv := server.NewMCPServer("vire", common.GetVersion(), server.WithToolCapabilities(true))
 	for _, tool := range tools {
 		t := tool // capture for closure
-		mcpSrv.AddTool(t, simpleProxyHandler(mcpClient, t.Name, logger))
+		var handler server.ToolHandlerFunc
+		if flow != nil {
+			handler = proxyHandler(mcpClient, t.Name, flow, logger)
+		} else {
+			handler = simpleProxyHandler(mcpClient, t.Name, logger)
+		}
+		if fromCache {
+			handler = offlineHandler(conn, handler)
+		}
+		mcpSrv.AddTool(t, handler)
 	}
 
-	logger.Info().Int("tools", len(tools)).Str("portal_url", portalURL).Msg("vire-mcp ready")
+	logger.Info().Int("tools", len(tools)).Str("portal_url", portalURL).Bool("offline", fromCache).Msg("vire-mcp ready")
 
-	if err := server.ServeStdio(mcpSrv); err != nil {
-		fmt.Fprintf(os.Stderr, "stdio server error: %v\n", err)
-		os.Exit(1)
+	if err := env.serve(mcpSrv); err != nil {
+		logger.Error().Str("error", err.Error()).Msg("stdio server error")
+		return exitTransport
 	}
+	return exitOK
+}
+
+// validateHTTPURL reports whether raw is an absolute http(s) URL.
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http(s) URL", raw)
+	}
+	return nil
 }
 
 // connectDirect starts the MCP client and initializes the session without OAuth.
@@ -226,10 +415,10 @@ func simpleProxyHandler(c *client.Client, toolName string, logger *common.Logger
 
 // connectWithOAuth starts the MCP client and initializes the session,
 // running the OAuth browser flow if either step requires authorization.
-func connectWithOAuth(ctx context.Context, c *client.Client, callbackPort int, logger *common.Logger) error {
+func connectWithOAuth(ctx context.Context, c *client.Client, flow *oauthFlow, logger *common.Logger) error {
 	// Start transport.
 	if err := c.Start(ctx); err != nil {
-		if err = runOAuthIfNeeded(err, callbackPort, logger); err != nil {
+		if err = runOAuthIfNeeded(ctx, err, flow, logger); err != nil {
 			return fmt.Errorf("start: %w", err)
 		}
 		if err = c.Start(ctx); err != nil {
@@ -242,7 +431,7 @@ func connectWithOAuth(ctx context.Context, c *client.Client, callbackPort int, l
 	initReq.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
 	initReq.Params.ClientInfo = mcp.Implementation{Name: "vire-mcp", Version: common.GetVersion()}
 	if _, err := c.Initialize(ctx, initReq); err != nil {
-		if err = runOAuthIfNeeded(err, callbackPort, logger); err != nil {
+		if err = runOAuthIfNeeded(ctx, err, flow, logger); err != nil {
 			return fmt.Errorf("initialize: %w", err)
 		}
 		if _, err = c.Initialize(ctx, initReq); err != nil {
@@ -253,32 +442,47 @@ func connectWithOAuth(ctx context.Context, c *client.Client, callbackPort int, l
 	return nil
 }
 
+// errAuthFailed wraps a failed OAuth flow so callers can tell it apart from
+// the portal being unreachable.
+var errAuthFailed = errors.New("OAuth flow failed")
+
+// connectExitCode maps a connectWithOAuth error to an exit code.
+func connectExitCode(err error) int {
+	var oauthErr *transport.OAuthAuthorizationRequiredError
+	if errors.Is(err, errAuthFailed) || errors.As(err, &oauthErr) {
+		return exitAuth
+	}
+	return exitConnect
+}
+
 // runOAuthIfNeeded checks whether err is an OAuthAuthorizationRequiredError.
-// If so, it runs the browser OAuth flow and returns nil on success.
-// Otherwise it returns the original error unchanged.
-func runOAuthIfNeeded(err error, callbackPort int, logger *common.Logger) error {
+// If so, it runs the browser OAuth flow and returns nil on success or an
+// error wrapping errAuthFailed. Otherwise it returns err unchanged.
+func runOAuthIfNeeded(ctx context.Context, err error, flow *oauthFlow, logger *common.Logger) error {
 	var oauthErr *transport.OAuthAuthorizationRequiredError
 	if !errors.As(err, &oauthErr) {
 		return err
 	}
 	logger.Info().Msg("OAuth authorization required, opening browser")
-	if flowErr := doOAuthFlow(oauthErr.Handler, callbackPort, logger); flowErr != nil {
-		return fmt.Errorf("OAuth flow: %w", flowErr)
+	if flowErr := flow.reauth(ctx, oauthErr.Handler, flow.generation()); flowErr != nil {
+		return fmt.Errorf("%w: %w", errAuthFailed, flowErr)
 	}
 	return nil
 }
 
 // proxyHandler returns a tool handler that forwards calls to vire-portal
-// via the MCP client. On token expiry it re-runs the OAuth flow and retries once.
-func proxyHandler(c *client.Client, toolName string, callbackPort int, logger *common.Logger) server.ToolHandlerFunc {
+// via the MCP client. On token expiry it re-runs the OAuth flow and retries
+// once. Concurrent expired calls share a single flow (see oauthFlow).
+func proxyHandler(c *client.Client, toolName string, flow *oauthFlow, logger *common.Logger) server.ToolHandlerFunc {
 	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		req.Params.Name = toolName
+		seen := flow.generation()
 		result, err := c.CallTool(ctx, req)
 		if err != nil {
 			var oauthErr *transport.OAuthAuthorizationRequiredError
 			if errors.As(err, &oauthErr) {
 				logger.Info().Str("tool", toolName).Msg("re-authenticating (token expired)")
-				if flowErr := doOAuthFlow(oauthErr.Handler, callbackPort, logger); flowErr != nil {
+				if flowErr := flow.reauth(ctx, oauthErr.Handler, seen); flowErr != nil {
 					return nil, fmt.Errorf("re-auth failed: %w", flowErr)
 				}
 				return c.CallTool(ctx, req)
@@ -289,6 +493,55 @@ func proxyHandler(c *client.Client, toolName string, callbackPort int, logger *c
 	}
 }
 
+// selectCallbackPort picks the OAuth callback port: fixed when set (and it
+// must be free), else the first free port in portRange ("start-end"), else
+// a random free port.
+func selectCallbackPort(fixed int, portRange string) (int, error) {
+	if fixed != 0 {
+		if fixed < 1 || fixed > 65535 {
+			return 0, fmt.Errorf("portal.callback_port %d is out of range 1-65535", fixed)
+		}
+		if !portFree(fixed) {
+			return 0, fmt.Errorf("OAuth callback port %d is already in use; stop whatever holds it or change portal.callback_port", fixed)
+		}
+		return fixed, nil
+	}
+	if portRange != "" {
+		start, end, err := parsePortRange(portRange)
+		if err != nil {
+			return 0, err
+		}
+		for port := start; port <= end; port++ {
+			if portFree(port) {
+				return port, nil
+			}
+		}
+		return 0, fmt.Errorf("no free OAuth callback port in portal.callback_port_range %s", portRange)
+	}
+	return findFreePort()
+}
+
+// parsePortRange parses "start-end" into its inclusive bounds.
+func parsePortRange(s string) (int, int, error) {
+	lo, hi, ok := strings.Cut(s, "-")
+	start, errStart := strconv.Atoi(strings.TrimSpace(lo))
+	end, errEnd := strconv.Atoi(strings.TrimSpace(hi))
+	if !ok || errStart != nil || errEnd != nil || start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid portal.callback_port_range %q: want start-end within 1-65535", s)
+	}
+	return start, end, nil
+}
+
+// portFree reports whether port can be bound on the loopback interface.
+func portFree(port int) bool {
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
 // findFreePort returns a free TCP port on the loopback interface.
 func findFreePort() (int, error) {
 	l, err := net.Listen("tcp", "127.0.0.1:0")
@@ -300,32 +553,52 @@ func findFreePort() (int, error) {
 	return port, nil
 }
 
-// homeDir returns the user's home directory.
-func homeDir() string {
-	if h := os.Getenv("HOME"); h != "" {
-		return h
-	}
-	if h := os.Getenv("USERPROFILE"); h != "" {
-		return h
+// credentialsPath returns the file token store location.
+func credentialsPath() string {
+	return filepath.Join(stateDir().path, "credentials.json")
+}
+
+// runLogout clears the stored OAuth token and reports it on w (stderr, since
+// stdout belongs to the MCP protocol).
+func runLogout(ctx context.Context, store tokenStore, w io.Writer) error {
+	if err := store.ClearToken(ctx); err != nil {
+		return err
 	}
-	return "."
+	_, err := fmt.Fprintln(w, "vire-mcp: stored credentials cleared; the next run will sign in again")
+	return err
 }
 
 // loadConfig builds configuration with priority: defaults < TOML file < env vars.
-// Relative log file paths are resolved against the binary directory so that
-// logs land next to the binary regardless of the working directory.
-func loadConfig() *config.Config {
+// An explicit path (from -config or VIRE_CONFIG) is loaded as-is and skips
+// discovery; it is an error if it is missing or unreadable. Relative log file
+// paths are resolved against the binary directory so that logs land next to
+// the binary regardless of the working directory.
+// It also returns the TOML file used, or "" when none was found.
+func loadConfig(explicit string) (*config.Config, string, error) {
 	var cfg *config.Config
+	var cfgPath string
 
-	for _, path := range configSearchPaths() {
-		if _, err := os.Stat(path); err == nil {
-			loaded, err := config.LoadFromFile(path)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "warning: failed to load %s: %v\n", path, err)
+	if explicit != "" {
+		if _, err := os.Stat(explicit); err != nil {
+			return nil, "", fmt.Errorf("config file %s: %w", explicit, err)
+		}
+		loaded, err := config.LoadFromFile(explicit)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load %s: %w", explicit, err)
+		}
+		cfg, cfgPath = loaded, explicit
+	} else {
+		for _, path := range configSearchPaths() {
+			if _, err := os.Stat(path); err == nil {
+				loaded, err := config.LoadFromFile(path)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "warning: failed to load %s: %v\n", path, err)
+					break
+				}
+				cfg = loaded
+				cfgPath = path
 				break
 			}
-			cfg = loaded
-			break
 		}
 	}
 
@@ -352,5 +625,5 @@ func loadConfig() *config.Config {
 		cfg.Logging.FilePath = filepath.Join(binDir(), cfg.Logging.FilePath)
 	}
 
-	return cfg
+	return cfg, cfgPath, nil
 }
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// newPortalStub serves a minimal MCP endpoint at /mcp/ for direct mode.
+func newPortalStub(t *testing.T) *httptest.Server {
+	t.Helper()
+	mcpSrv := server.NewMCPServer("vire", "test", server.WithToolCapabilities(true))
+	srv := httptest.NewServer(server.NewStreamableHTTPServer(mcpSrv))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	portal := newPortalStub(t)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream down", http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	serveOK := func(*server.MCPServer) error { return nil }
+	noKeyring := func() (keyring, error) { return nil, errors.New("unsupported") }
+
+	tests := []struct {
+		name      string
+		portalURL string
+		caCert    string
+		env       runEnv
+		want      int
+	}{
+		{
+			name: "clean shutdown",
+			env:  runEnv{mcpURL: portal.URL + "/mcp/uid", serve: serveOK},
+			want: exitOK,
+		},
+		{
+			name:      "invalid portal URL",
+			portalURL: "localhost:4241",
+			env:       runEnv{serve: serveOK},
+			want:      exitConfig,
+		},
+		{
+			name: "invalid MCP URL",
+			env:  runEnv{mcpURL: "not a url", serve: serveOK},
+			want: exitConfig,
+		},
+		{
+			name:   "missing CA cert file",
+			caCert: filepath.Join(t.TempDir(), "missing.pem"),
+			env:    runEnv{mcpURL: portal.URL + "/mcp/uid", serve: serveOK},
+			want:   exitConfig,
+		},
+		{
+			name: "portal rejects the session",
+			env:  runEnv{mcpURL: broken.URL + "/mcp/uid", serve: serveOK},
+			want: exitConnect,
+		},
+		{
+			name:      "OAuth flow fails",
+			portalURL: portal.URL,
+			env: runEnv{
+				credentialsPath: filepath.Join(t.TempDir(), "credentials.json"),
+				openKeyring:     noKeyring,
+				runOAuth:        func(*transport.OAuthHandler) error { return errors.New("user closed the browser") },
+				serve:           serveOK,
+			},
+			want: exitAuth,
+		},
+		{
+			name: "stdio session fails",
+			env: runEnv{mcpURL: portal.URL + "/mcp/uid", serve: func(*server.MCPServer) error {
+				return errors.New("broken pipe")
+			}},
+			want: exitTransport,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewDefaultConfig()
+			if tt.portalURL != "" {
+				cfg.Portal.URL = tt.portalURL
+			}
+			cfg.Portal.CACertFile = tt.caCert
+
+			if got := run(context.Background(), cfg, tt.env, common.NewSilentLogger()); got != tt.want {
+				t.Errorf("expected exit code %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestConnectExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"flow failed", errors.Join(errors.New("start"), errAuthFailed), exitAuth},
+		{"still unauthorized", &transport.OAuthAuthorizationRequiredError{}, exitAuth},
+		{"network", errors.New("dial tcp: connection refused"), exitConnect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := connectExitCode(tt.err); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// holdPort keeps a loopback port bound until the test ends.
+func holdPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestSelectCallbackPort_Fixed(t *testing.T) {
+	free, err := findFreePort()
+	if err != nil {
+		t.Fatalf("findFreePort: %v", err)
+	}
+	if got, err := selectCallbackPort(free, "1-2"); err != nil || got != free {
+		t.Errorf("expected fixed port %d to win over the range, got %d (%v)", free, got, err)
+	}
+
+	busy := holdPort(t)
+	_, err = selectCallbackPort(busy, "")
+	if err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("expected an in-use error for port %d, got %v", busy, err)
+	}
+
+	if _, err := selectCallbackPort(70000, ""); err == nil {
+		t.Error("expected an error for an out-of-range port")
+	}
+}
+
+func TestSelectCallbackPort_RangeScan(t *testing.T) {
+	busy := holdPort(t)
+	next := busy + 1
+	if !portFree(next) {
+		t.Skipf("port %d is taken on this host", next)
+	}
+
+	got, err := selectCallbackPort(0, fmt.Sprintf("%d-%d", busy, next))
+	if err != nil {
+		t.Fatalf("selectCallbackPort: %v", err)
+	}
+	if got != next {
+		t.Errorf("expected scan to skip busy port %d and pick %d, got %d", busy, next, got)
+	}
+
+	if _, err := selectCallbackPort(0, fmt.Sprintf("%d-%d", busy, busy)); err == nil {
+		t.Error("expected an error when every port in the range is busy")
+	}
+
+	for _, bad := range []string{"53682", "a-b", "9000-8000", "0-10", "65000-70000"} {
+		if _, err := selectCallbackPort(0, bad); err == nil {
+			t.Errorf("expected an error for range %q", bad)
+		}
+	}
+}
+
+func TestSelectCallbackPort_RandomFallback(t *testing.T) {
+	got, err := selectCallbackPort(0, "")
+	if err != nil {
+		t.Fatalf("selectCallbackPort: %v", err)
+	}
+	if got <= 0 {
+		t.Errorf("expected a random free port, got %d", got)
+	}
+}
+
+func TestRun_CallbackPortInUse(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.Portal.URL = newPortalStub(t).URL
+	cfg.Portal.CallbackPort = holdPort(t)
+
+	env := runEnv{
+		credentialsPath: filepath.Join(t.TempDir(), "credentials.json"),
+		serve:           func(*server.MCPServer) error { return nil },
+	}
+	if got := run(context.Background(), cfg, env, common.NewSilentLogger()); got != exitConfig {
+		t.Errorf("expected exit code %d, got %d", exitConfig, got)
+	}
+}
+
+func writeConfig(t *testing.T, path, portalURL string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	body := fmt.Sprintf("[portal]\nurl = %q\n", portalURL)
+	if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestLoadConfig_ExplicitPathWins(t *testing.T) {
+	t.Setenv("VIRE_PORTAL_URL", "")
+	// A discoverable config in the state directory must be ignored
+	home := t.TempDir()
+	t.Setenv("VIRE_HOME", home)
+	writeConfig(t, filepath.Join(home, "vire-mcp.toml"), "http://discovered.example:4241")
+
+	explicit := filepath.Join(t.TempDir(), "custom.toml")
+	writeConfig(t, explicit, "http://explicit.example:4241")
+
+	cfg, path, err := loadConfig(explicit)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if path != explicit {
+		t.Errorf("expected config file %s, got %s", explicit, path)
+	}
+	if cfg.Portal.URL != "http://explicit.example:4241" {
+		t.Errorf("expected portal URL from the explicit file, got %s", cfg.Portal.URL)
+	}
+}
+
+func TestLoadConfig_MissingExplicitPath(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "nope.toml")
+	_, _, err := loadConfig(missing)
+	if err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected an error naming %s, got %v", missing, err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mark3labs/mcp-go/client/transport"
@@ -23,11 +24,77 @@ type callbackResult struct {
 	err   error
 }
 
+// oauthFlow makes sure only one OAuth browser flow runs at a time. Tool calls
+// that hit an expired token together share one flow (one browser tab) and
+// then retry, instead of each opening its own.
+type oauthFlow struct {
+	mu      sync.Mutex
+	gen     uint64     // successful flows so far
+	running *oauthCall // flow in progress, if any
+	runFn   func(handler *transport.OAuthHandler) error
+}
+
+// oauthCall is one flow; done closes once err is set.
+type oauthCall struct {
+	done chan struct{}
+	err  error
+}
+
+// newOAuthFlow returns a guard running doOAuthFlow on callbackPort.
+func newOAuthFlow(callbackPort int, httpClient *http.Client, logger *common.Logger) *oauthFlow {
+	return &oauthFlow{runFn: func(handler *transport.OAuthHandler) error {
+		return doOAuthFlow(handler, callbackPort, httpClient, logger)
+	}}
+}
+
+// generation returns the number of successful flows. Callers record it
+// before a request so reauth can tell whether a flow finished since.
+func (f *oauthFlow) generation() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.gen
+}
+
+// reauth runs the OAuth flow for a caller whose request, sent at generation
+// seen, needs authorization. If a flow has succeeded since then it returns nil
+// straight away; if one is running it waits for that flow's result. Otherwise
+// it starts a new flow.
+func (f *oauthFlow) reauth(ctx context.Context, handler *transport.OAuthHandler, seen uint64) error {
+	f.mu.Lock()
+	if f.gen != seen {
+		f.mu.Unlock()
+		return nil
+	}
+	if call := f.running; call != nil {
+		f.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	call := &oauthCall{done: make(chan struct{})}
+	f.running = call
+	f.mu.Unlock()
+
+	call.err = f.runFn(handler)
+
+	f.mu.Lock()
+	f.running = nil
+	if call.err == nil {
+		f.gen++
+	}
+	f.mu.Unlock()
+	close(call.done)
+	return call.err
+}
+
 // doOAuthFlow runs the OAuth 2.1 authorization code flow with PKCE.
 // It discovers server metadata, registers the client via DCR if needed,
 // opens the user's browser for authorization, waits for the callback,
 // and exchanges the code for tokens.
-func doOAuthFlow(handler *transport.OAuthHandler, callbackPort int, logger *common.Logger) error {
+func doOAuthFlow(handler *transport.OAuthHandler, callbackPort int, httpClient *http.Client, logger *common.Logger) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
@@ -72,7 +139,7 @@ func doOAuthFlow(handler *transport.OAuthHandler, callbackPort int, logger *comm
 	// The browser then opens GET /authorize?client_id=xxx which finds the
 	// pending session — this avoids '&' mangling in browser URLs on Windows/WSL.
 	browserURL := authURL
-	if err := prepareAuthSession(ctx, authURL, logger); err != nil {
+	if err := prepareAuthSession(ctx, httpClient, authURL); err != nil {
 		logger.Warn().Str("error", err.Error()).Msg("POST /authorize failed, opening full URL")
 	} else {
 		// Open just the authorize URL with client_id (no '&' to mangle).
@@ -108,7 +175,7 @@ func doOAuthFlow(handler *transport.OAuthHandler, callbackPort int, logger *comm
 // endpoint, creating a server-side session. The browser can then open
 // GET /authorize?client_id=xxx (a simple URL with no '&') and the portal
 // will find the pending session by client_id.
-func prepareAuthSession(ctx context.Context, authURL string, logger *common.Logger) error {
+func prepareAuthSession(ctx context.Context, httpClient *http.Client, authURL string) error {
 	parsed, err := url.Parse(authURL)
 	if err != nil {
 		return fmt.Errorf("parse authorize URL: %w", err)
@@ -117,7 +184,12 @@ func prepareAuthSession(ctx context.Context, authURL string, logger *common.Logg
 	baseURL := fmt.Sprintf("%s://%s", parsed.Scheme, parsed.Host)
 
 	// POST the query params as form data.
-	resp, err := http.PostForm(baseURL+"/authorize", parsed.Query())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/authorize", strings.NewReader(parsed.Query().Encode()))
+	if err != nil {
+		return fmt.Errorf("POST /authorize: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("POST /authorize: %w", err)
 	}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+)
+
+func TestOAuthFlow_ConcurrentCallersShareOneFlow(t *testing.T) {
+	var flows atomic.Int32
+	release := make(chan struct{})
+	f := &oauthFlow{runFn: func(*transport.OAuthHandler) error {
+		flows.Add(1)
+		<-release
+		return nil
+	}}
+
+	// Every caller's request was sent before the token was refreshed
+	seen := f.generation()
+
+	const callers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- f.reauth(context.Background(), nil, seen)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("expected every caller to succeed, got %v", err)
+		}
+	}
+	if n := flows.Load(); n != 1 {
+		t.Errorf("expected exactly one OAuth flow, got %d", n)
+	}
+	if g := f.generation(); g != seen+1 {
+		t.Errorf("expected generation %d after one flow, got %d", seen+1, g)
+	}
+}
+
+func TestOAuthFlow_FailureSharedThenRetried(t *testing.T) {
+	var flows atomic.Int32
+	release := make(chan struct{})
+	errDenied := errors.New("access_denied")
+	f := &oauthFlow{runFn: func(*transport.OAuthHandler) error {
+		if flows.Add(1) == 1 {
+			<-release
+			return errDenied
+		}
+		return nil
+	}}
+
+	seen := f.generation()
+	first := make(chan error, 1)
+	go func() { first <- f.reauth(context.Background(), nil, seen) }()
+	for flows.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	waiter := make(chan error, 1)
+	go func() { waiter <- f.reauth(context.Background(), nil, seen) }()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if err := <-first; !errors.Is(err, errDenied) {
+		t.Errorf("expected the flow's error, got %v", err)
+	}
+	if err := <-waiter; !errors.Is(err, errDenied) {
+		t.Errorf("expected the waiting caller to share the error, got %v", err)
+	}
+
+	// A failed flow doesn't count, so the next caller starts a new one
+	if err := f.reauth(context.Background(), nil, f.generation()); err != nil {
+		t.Errorf("expected the retry flow to succeed, got %v", err)
+	}
+	if n := flows.Load(); n != 2 {
+		t.Errorf("expected 2 flows, got %d", n)
+	}
+}
+
+func TestOAuthFlow_WaiterHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	f := &oauthFlow{runFn: func(*transport.OAuthHandler) error {
+		<-release
+		return nil
+	}}
+
+	seen := f.generation()
+	go f.reauth(context.Background(), nil, seen) //nolint:errcheck
+	for {
+		f.mu.Lock()
+		running := f.running != nil
+		f.mu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := f.reauth(ctx, nil, seen); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the waiter to give up with its context, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// vireDir is where vire-mcp keeps its state (credentials.json, catalog.json).
+type vireDir struct {
+	path   string
+	source string // what decided path, logged at startup
+}
+
+// resolveVireDir picks the state directory deterministically: VIRE_HOME when
+// set, else .vire under the platform home directory. On Windows that home is
+// USERPROFILE, then HOME. Everywhere else, WSL included, only HOME counts:
+// Claude Desktop can forward a Windows USERPROFILE into WSL that the Linux
+// process must not write to.
+func resolveVireDir(goos string, getenv func(string) string) vireDir {
+	if dir := getenv("VIRE_HOME"); dir != "" {
+		return vireDir{path: filepath.Clean(dir), source: "VIRE_HOME"}
+	}
+	homeVars := []string{"HOME"}
+	if goos == "windows" {
+		homeVars = []string{"USERPROFILE", "HOME"}
+	}
+	for _, name := range homeVars {
+		if h := getenv(name); h != "" {
+			return vireDir{path: filepath.Join(h, ".vire"), source: name}
+		}
+	}
+	return vireDir{path: ".vire", source: "working directory"}
+}
+
+// stateDir returns the resolved state directory for this process.
+func stateDir() vireDir {
+	return resolveVireDir(runtime.GOOS, os.Getenv)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveVireDir(t *testing.T) {
+	tests := []struct {
+		name       string
+		goos       string
+		env        map[string]string
+		wantPath   string
+		wantSource string
+	}{
+		{
+			name:       "VIRE_HOME wins over everything",
+			goos:       "linux",
+			env:        map[string]string{"VIRE_HOME": "/srv/vire", "HOME": "/home/bob", "USERPROFILE": `C:\Users\bob`},
+			wantPath:   filepath.Clean("/srv/vire"),
+			wantSource: "VIRE_HOME",
+		},
+		{
+			name:       "linux uses HOME",
+			goos:       "linux",
+			env:        map[string]string{"HOME": "/home/bob"},
+			wantPath:   filepath.Join("/home/bob", ".vire"),
+			wantSource: "HOME",
+		},
+		{
+			name:       "WSL ignores a forwarded USERPROFILE",
+			goos:       "linux",
+			env:        map[string]string{"HOME": "/home/bob", "USERPROFILE": "/mnt/c/Users/bob"},
+			wantPath:   filepath.Join("/home/bob", ".vire"),
+			wantSource: "HOME",
+		},
+		{
+			name:       "linux never falls back to USERPROFILE",
+			goos:       "linux",
+			env:        map[string]string{"USERPROFILE": "/mnt/c/Users/bob"},
+			wantPath:   ".vire",
+			wantSource: "working directory",
+		},
+		{
+			name:       "windows prefers USERPROFILE over HOME",
+			goos:       "windows",
+			env:        map[string]string{"HOME": "/c/msys/home/bob", "USERPROFILE": `C:\Users\bob`},
+			wantPath:   filepath.Join(`C:\Users\bob`, ".vire"),
+			wantSource: "USERPROFILE",
+		},
+		{
+			name:       "windows falls back to HOME",
+			goos:       "windows",
+			env:        map[string]string{"HOME": "/c/msys/home/bob"},
+			wantPath:   filepath.Join("/c/msys/home/bob", ".vire"),
+			wantSource: "HOME",
+		},
+		{
+			name:       "darwin uses HOME",
+			goos:       "darwin",
+			env:        map[string]string{"HOME": "/Users/bob"},
+			wantPath:   filepath.Join("/Users/bob", ".vire"),
+			wantSource: "HOME",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveVireDir(tt.goos, func(k string) string { return tt.env[k] })
+			if got.path != tt.wantPath || got.source != tt.wantSource {
+				t.Errorf("expected %s (from %s), got %s (from %s)", tt.wantPath, tt.wantSource, got.path, got.source)
+			}
+		})
+	}
+}
+
+func TestStatePaths_FollowVireHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("VIRE_HOME", dir)
+
+	if got, want := credentialsPath(), filepath.Join(dir, "credentials.json"); got != want {
+		t.Errorf("expected credentials at %s, got %s", want, got)
+	}
+	if got, want := catalogCachePath(), filepath.Join(dir, "catalog.json"); got != want {
+		t.Errorf("expected catalog at %s, got %s", want, got)
+	}
+	paths := configSearchPaths()
+	if got, want := paths[len(paths)-1], filepath.Join(dir, "vire-mcp.toml"); got != want {
+		t.Errorf("expected %s as the last config candidate, got %s", want, got)
+	}
+}
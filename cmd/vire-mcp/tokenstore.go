@@ -3,13 +3,58 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/mark3labs/mcp-go/client/transport"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// Keyring entry holding the OAuth token as JSON.
+const (
+	keyringService = "vire-mcp"
+	keyringAccount = "oauth-token"
 )
 
+// tokenStore is a transport.TokenStore that can also forget its token
+// (vire-mcp -logout).
+type tokenStore interface {
+	transport.TokenStore
+	// ClearToken removes the stored token. Clearing an empty store is not an error.
+	ClearToken(ctx context.Context) error
+}
+
+// newTokenStore returns the token store selected by portal.token_store:
+// "keyring" uses the OS keychain from openKeyring, anything else the file at
+// path. When the keychain can't be opened or read, it warns and falls back to
+// the file store.
+func newTokenStore(kind, path string, openKeyring func() (keyring, error), logger *common.Logger) tokenStore {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "file":
+		return NewFileTokenStore(path)
+	case "keyring":
+		kr, err := openKeyring()
+		if err == nil {
+			// Probe once so a locked or missing keychain daemon is caught at startup
+			if _, err = kr.Get(keyringService, keyringAccount); errors.Is(err, errKeyringNotFound) {
+				err = nil
+			}
+		}
+		if err != nil {
+			logger.Warn().Str("error", err.Error()).Str("path", path).Msg("OS keyring unavailable, storing OAuth tokens in a file")
+			return NewFileTokenStore(path)
+		}
+		return NewKeyringTokenStore(kr, keyringService, keyringAccount)
+	default:
+		logger.Warn().Str("token_store", kind).Str("path", path).Msg("unknown portal.token_store, storing OAuth tokens in a file")
+		return NewFileTokenStore(path)
+	}
+}
+
 // FileTokenStore persists OAuth tokens to a JSON file.
 // It implements transport.TokenStore for use with mcp-go's OAuth support.
 type FileTokenStore struct {
@@ -59,3 +104,81 @@ func (s *FileTokenStore) SaveToken(_ context.Context, token *transport.Token) er
 	}
 	return os.WriteFile(s.path, data, 0600)
 }
+
+// ClearToken deletes the token file.
+func (s *FileTokenStore) ClearToken(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// KeyringTokenStore persists OAuth tokens as JSON in an OS keychain entry.
+// It implements transport.TokenStore for use with mcp-go's OAuth support.
+type KeyringTokenStore struct {
+	kr      keyring
+	service string
+	account string
+	mu      sync.RWMutex
+}
+
+// NewKeyringTokenStore creates a token store backed by the given keychain entry.
+func NewKeyringTokenStore(kr keyring, service, account string) *KeyringTokenStore {
+	return &KeyringTokenStore{kr: kr, service: service, account: account}
+}
+
+// GetToken reads the stored token from the keychain.
+// Returns transport.ErrNoToken if the entry is missing or corrupt.
+func (s *KeyringTokenStore) GetToken(ctx context.Context) (*transport.Token, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := s.kr.Get(s.service, s.account)
+	if err != nil {
+		if errors.Is(err, errKeyringNotFound) {
+			return nil, transport.ErrNoToken
+		}
+		return nil, err
+	}
+
+	var token transport.Token
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, transport.ErrNoToken // corrupt entry, treat as absent
+	}
+	return &token, nil
+}
+
+// SaveToken writes the token to the keychain, replacing any previous one.
+func (s *KeyringTokenStore) SaveToken(ctx context.Context, token *transport.Token) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return s.kr.Set(s.service, s.account, string(data))
+}
+
+// ClearToken deletes the keychain entry.
+func (s *KeyringTokenStore) ClearToken(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.kr.Delete(s.service, s.account); err != nil && !errors.Is(err, errKeyringNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client/transport"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// Both stores satisfy the interface mcp-go's OAuth handler expects.
+var (
+	_ transport.TokenStore = (*FileTokenStore)(nil)
+	_ transport.TokenStore = (*KeyringTokenStore)(nil)
+)
+
+// mockKeyring is an in-memory keyring; err, when set, fails every call.
+type mockKeyring struct {
+	items map[string]string
+	err   error
+}
+
+func newMockKeyring() *mockKeyring {
+	return &mockKeyring{items: make(map[string]string)}
+}
+
+func (m *mockKeyring) Get(service, account string) (string, error) {
+	if m.err != nil {
+		return "", m.err
+	}
+	v, ok := m.items[service+"/"+account]
+	if !ok {
+		return "", errKeyringNotFound
+	}
+	return v, nil
+}
+
+func (m *mockKeyring) Set(service, account, secret string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.items[service+"/"+account] = secret
+	return nil
+}
+
+func (m *mockKeyring) Delete(service, account string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.items[service+"/"+account]; !ok {
+		return errKeyringNotFound
+	}
+	delete(m.items, service+"/"+account)
+	return nil
+}
+
+func TestKeyringTokenStore_RoundTrip(t *testing.T) {
+	kr := newMockKeyring()
+	store := NewKeyringTokenStore(kr, keyringService, keyringAccount)
+	ctx := context.Background()
+
+	if _, err := store.GetToken(ctx); !errors.Is(err, transport.ErrNoToken) {
+		t.Fatalf("expected ErrNoToken before save, got %v", err)
+	}
+
+	want := &transport.Token{
+		AccessToken:  "access-123",
+		RefreshToken: "refresh-456",
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+	if err := store.SaveToken(ctx, want); err != nil {
+		t.Fatalf("SaveToken: %v", err)
+	}
+	if _, ok := kr.items[keyringService+"/"+keyringAccount]; !ok {
+		t.Fatal("expected token to be written to the keyring entry")
+	}
+
+	got, err := store.GetToken(ctx)
+	if err != nil {
+		t.Fatalf("GetToken: %v", err)
+	}
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken || !got.ExpiresAt.Equal(want.ExpiresAt) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestKeyringTokenStore_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	kr := newMockKeyring()
+	kr.items[keyringService+"/"+keyringAccount] = "{not json"
+	if _, err := NewKeyringTokenStore(kr, keyringService, keyringAccount).GetToken(ctx); !errors.Is(err, transport.ErrNoToken) {
+		t.Errorf("expected corrupt entry to read as ErrNoToken, got %v", err)
+	}
+
+	errLocked := errors.New("keychain locked")
+	kr = newMockKeyring()
+	kr.err = errLocked
+	store := NewKeyringTokenStore(kr, keyringService, keyringAccount)
+	if _, err := store.GetToken(ctx); !errors.Is(err, errLocked) {
+		t.Errorf("expected keyring failure to surface, got %v", err)
+	}
+	if err := store.SaveToken(ctx, &transport.Token{AccessToken: "x"}); !errors.Is(err, errLocked) {
+		t.Errorf("expected keyring failure on save, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := store.GetToken(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestNewTokenStore_Selection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	logger := common.NewSilentLogger()
+	working := func() (keyring, error) { return newMockKeyring(), nil }
+
+	tests := []struct {
+		name        string
+		kind        string
+		openKeyring func() (keyring, error)
+		wantKeyring bool
+	}{
+		{"default is file", "", working, false},
+		{"file", "file", working, false},
+		{"keyring", "keyring", working, true},
+		{"keyring case-insensitive", " Keyring ", working, true},
+		{"no keyring on platform", "keyring", func() (keyring, error) { return nil, errors.New("unsupported") }, false},
+		{"keyring daemon unavailable", "keyring", func() (keyring, error) {
+			kr := newMockKeyring()
+			kr.err = errors.New("secret service not running")
+			return kr, nil
+		}, false},
+		{"unknown kind", "vault", working, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTokenStore(tt.kind, path, tt.openKeyring, logger)
+			_, isKeyring := store.(*KeyringTokenStore)
+			if isKeyring != tt.wantKeyring {
+				t.Errorf("expected keyring store %v, got %T", tt.wantKeyring, store)
+			}
+			if !tt.wantKeyring {
+				if _, ok := store.(*FileTokenStore); !ok {
+					t.Errorf("expected file store fallback, got %T", store)
+				}
+			}
+		})
+	}
+}
+
+func TestRunLogout_RemovesStoredToken(t *testing.T) {
+	ctx := context.Background()
+	token := &transport.Token{AccessToken: "access-123", TokenType: "Bearer"}
+
+	fileStore := NewFileTokenStore(filepath.Join(t.TempDir(), ".vire", "credentials.json"))
+	keyringStore := NewKeyringTokenStore(newMockKeyring(), keyringService, keyringAccount)
+
+	for name, store := range map[string]tokenStore{"file": fileStore, "keyring": keyringStore} {
+		t.Run(name, func(t *testing.T) {
+			if err := store.SaveToken(ctx, token); err != nil {
+				t.Fatalf("SaveToken: %v", err)
+			}
+
+			var out strings.Builder
+			if err := runLogout(ctx, store, &out); err != nil {
+				t.Fatalf("runLogout: %v", err)
+			}
+			if _, err := store.GetToken(ctx); !errors.Is(err, transport.ErrNoToken) {
+				t.Errorf("expected ErrNoToken after logout, got %v", err)
+			}
+			if !strings.Contains(out.String(), "credentials cleared") {
+				t.Errorf("expected a confirmation message, got %q", out.String())
+			}
+
+			// Logging out again with nothing stored still succeeds
+			if err := runLogout(ctx, store, io.Discard); err != nil {
+				t.Errorf("expected second logout to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRunLogout_KeyringFailure(t *testing.T) {
+	kr := newMockKeyring()
+	kr.err = errors.New("keychain locked")
+	store := NewKeyringTokenStore(kr, keyringService, keyringAccount)
+
+	if err := runLogout(context.Background(), store, io.Discard); err == nil {
+		t.Error("expected keyring failure to be reported")
+	}
+}
@@ -36,6 +36,7 @@ var (
 	serverPortP = flag.Int("p", 0, "Server port (shorthand)")
 	serverHost  = flag.String("host", "", "Server host (overrides config)")
 	showVersion = flag.Bool("version", false, "Print version information")
+	versionJSON = flag.Bool("json", false, "With -version, print version, build and git_commit as JSON")
 )
 
 func init() {
@@ -48,7 +49,9 @@ func main() {
 
 	// Handle version flag
 	if *showVersion {
-		fmt.Printf("vire-portal version %s\n", config.GetVersion())
+		if err := config.WriteVersion(os.Stdout, "vire-portal", *versionJSON); err != nil {
+			os.Exit(1)
+		}
 		os.Exit(0)
 	}
 
@@ -121,6 +124,7 @@ func main() {
 		}
 		serviceID := "service:" + portalID
 		logStore = client.NewHTTPLogStore(cfg.API.URL, serviceID)
+		logStore.SetAllowedHosts(cfg.API.AllowedHosts)
 		logger.AttachLogStore(logStore)
 		logger.Info().Msg("remote log store attached")
 	}
@@ -145,6 +149,21 @@ func main() {
 		Str("url", fmt.Sprintf("http://%s:%d", cfg.Server.Host, cfg.Server.Port)).
 		Msg("server ready")
 
+	// Reload hot-reloadable settings (maintenance mode) on SIGHUP
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			reloaded, err := config.LoadFromFiles(configFiles...)
+			if err != nil {
+				logger.Warn().Str("error", err.Error()).Msg("config reload failed, keeping current settings")
+				continue
+			}
+			srv.SetMaintenance(reloaded.Server.Maintenance)
+			logger.Info().Bool("maintenance", reloaded.Server.Maintenance).Msg("configuration reloaded")
+		}
+	}()
+
 	// Wait for interrupt signal or HTTP shutdown request
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/bobmcallan/vire-portal/internal/auth"
 	"github.com/bobmcallan/vire-portal/internal/client"
@@ -23,16 +27,68 @@ func catalogAdapter(mcpHandler *mcp.Handler) func() []handlers.MCPPageTool {
 		tools := make([]handlers.MCPPageTool, len(catalog))
 		for i, ct := range catalog {
 			tools[i] = handlers.MCPPageTool{
-				Name:        ct.Name,
-				Description: ct.Description,
-				Method:      ct.Method,
-				Path:        ct.Path,
+				Name:              ct.Name,
+				Description:       ct.Description,
+				Method:            ct.Method,
+				Path:              ct.Path,
+				Deprecated:        ct.Deprecated,
+				DeprecatedMessage: ct.DeprecatedMessage,
 			}
 		}
 		return tools
 	}
 }
 
+// toolDetailAdapter looks up a catalog tool and describes it for the tools
+// API, with the same input schema the MCP server advertises.
+func toolDetailAdapter(mcpHandler *mcp.Handler) func(string) (*handlers.ToolDetail, error) {
+	return func(name string) (*handlers.ToolDetail, error) {
+		if mcpHandler == nil {
+			return nil, nil
+		}
+		ct, ok := mcpHandler.Tool(name)
+		if !ok {
+			return nil, nil
+		}
+		schema, err := json.Marshal(mcp.BuildMCPTool(ct).InputSchema)
+		if err != nil {
+			return nil, fmt.Errorf("marshal input schema: %w", err)
+		}
+		return &handlers.ToolDetail{
+			Name:              ct.Name,
+			Description:       ct.Description,
+			Method:            ct.Method,
+			Path:              ct.Path,
+			Deprecated:        ct.Deprecated,
+			DeprecatedMessage: ct.DeprecatedMessage,
+			InputSchema:       schema,
+		}, nil
+	}
+}
+
+// catalogReportAdapter converts the MCP handler's catalog report for the
+// admin catalog endpoint.
+func catalogReportAdapter(mcpHandler *mcp.Handler) func() (*handlers.CatalogReport, error) {
+	return func() (*handlers.CatalogReport, error) {
+		report := mcpHandler.CatalogReport()
+		tools, err := json.Marshal(report.Tools)
+		if err != nil {
+			return nil, fmt.Errorf("marshal catalog: %w", err)
+		}
+		rejected := make([]handlers.CatalogRejection, len(report.Rejected))
+		for i, rt := range report.Rejected {
+			rejected[i] = handlers.CatalogRejection{Name: rt.Name, Reason: rt.Reason}
+		}
+		return &handlers.CatalogReport{
+			Source:    report.Source,
+			FetchedAt: report.FetchedAt,
+			ToolCount: len(report.Tools),
+			Tools:     tools,
+			Rejected:  rejected,
+		}, nil
+	}
+}
+
 // App holds all application components and dependencies.
 type App struct {
 	Config *config.Config
@@ -46,21 +102,74 @@ type App struct {
 	DashboardHandler       *handlers.DashboardHandler
 	StrategyHandler        *handlers.StrategyHandler
 	CashHandler            *handlers.CashHandler
+	DiagnosticsHandler     *handlers.DiagnosticsHandler
+	PreferencesHandler     *handlers.PreferencesHandler
+	GrowthChartHandler     *handlers.GrowthChartHandler
+	AllocationHandler      *handlers.AllocationHandler
+	SyncHandler            *handlers.SyncHandler
 	MCPPageHandler         *handlers.MCPPageHandler
 	ProfileHandler         *handlers.ProfileHandler
 	ServerHealthHandler    *handlers.ServerHealthHandler
+	DeepHealthHandler      *handlers.DeepHealthHandler
+	ToolsHandler           *handlers.ToolsHandler
 	MobileDashboardHandler *handlers.MobileDashboardHandler
 	MCPHandler             *mcp.Handler
 	MCPDevHandler          *mcp.DevHandler
 	OAuthServer            *auth.OAuthServer
 	AdminUsersHandler      *handlers.AdminUsersHandler
+
+	// SessionCookie is the configured portal session cookie.
+	SessionCookie handlers.SessionCookie
+
+	httpClient *http.Client // injected upstream client, nil for the defaults
+	clock      common.Clock // injected clock, nil for the system clock
+}
+
+// Option customises New. The defaults build everything from cfg; options
+// exist so tests can wire the app to mocks without real servers.
+type Option func(*options)
+
+type options struct {
+	httpClient  *http.Client
+	upstreamURL string
+	clock       common.Clock
+}
+
+// WithHTTPClient sends vire-server requests from the MCP proxy and the
+// vire-server client through c, e.g. one whose Transport is a mock.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.httpClient = c }
+}
+
+// WithClock makes time-dependent components, such as OAuth session expiry,
+// read the time from c.
+func WithClock(c common.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// WithUpstreamURL overrides cfg.API.URL as the vire-server base URL. The
+// caller's config is not modified.
+func WithUpstreamURL(url string) Option {
+	return func(o *options) { o.upstreamURL = url }
 }
 
 // New initializes the application with all dependencies.
-func New(cfg *config.Config, logger *common.Logger) (*App, error) {
+func New(cfg *config.Config, logger *common.Logger, opts ...Option) (*App, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.upstreamURL != "" {
+		c := *cfg
+		c.API.URL = o.upstreamURL
+		cfg = &c
+	}
+
 	a := &App{
-		Config: cfg,
-		Logger: logger,
+		Config:     cfg,
+		Logger:     logger,
+		httpClient: o.httpClient,
+		clock:      o.clock,
 	}
 
 	// Validate environment setting
@@ -72,6 +181,9 @@ func New(cfg *config.Config, logger *common.Logger) (*App, error) {
 			Str("environment", cfg.Environment).
 			Msg("unrecognized environment value, defaulting to prod behavior")
 	}
+	if cfg.Server.ReloadTemplates && !cfg.IsDevMode() {
+		logger.Warn().Msg("server.reload_templates is ignored outside dev mode")
+	}
 
 	a.initHandlers()
 
@@ -105,7 +217,22 @@ func New(cfg *config.Config, logger *common.Logger) (*App, error) {
 func (a *App) initHandlers() {
 	jwtSecret := []byte(a.Config.Auth.JWTSecret)
 
+	// Session cookie name/domain are shared by every handler that reads or sets the session.
+	a.SessionCookie = handlers.SessionCookie{
+		Name:   a.Config.Auth.CookieName(),
+		Domain: strings.TrimSpace(a.Config.Auth.SessionCookieDomain),
+	}
+
+	// Dev-only template hot-reload; must be set before page handlers are built.
+	handlers.ConfigureTemplateReload(a.Config.TemplateReload())
+
 	vireClient := client.NewVireClient(a.Config.API.URL)
+	vireClient.SetAllowedHosts(a.Config.API.AllowedHosts)
+	var mcpOpts []mcp.HandlerOption
+	if a.httpClient != nil {
+		vireClient.SetHTTPClient(a.httpClient)
+		mcpOpts = append(mcpOpts, mcp.WithHTTPClient(a.httpClient))
+	}
 
 	// User lookup via vire-server API (used by profile, dashboard, and page handler)
 	userLookup := func(userID string) (*client.UserProfile, error) {
@@ -124,8 +251,11 @@ func (a *App) initHandlers() {
 	a.VersionHandler = handlers.NewVersionHandler(a.Logger)
 	a.VersionHandler.SetAPIURL(a.Config.API.URL)
 	a.AuthHandler = handlers.NewAuthHandler(a.Logger, a.Config.IsDevMode(), a.Config.API.URL, a.Config.Auth.CallbackURL, jwtSecret)
+	a.AuthHandler.SetDevLogin(a.Config.DevLoginEnabled())
+	a.AuthHandler.SetAllowedHosts(a.Config.API.AllowedHosts)
 
-	a.MCPHandler = mcp.NewHandler(a.Config, a.Logger)
+	mcpOpts = append(mcpOpts, mcp.WithUserLookup(userLookup))
+	a.MCPHandler = mcp.NewHandler(a.Config, a.Logger, mcpOpts...)
 	a.MCPDevHandler = mcp.NewDevHandler(
 		a.MCPHandler,
 		jwtSecret,
@@ -135,8 +265,26 @@ func (a *App) initHandlers() {
 	)
 
 	a.ServerHealthHandler = handlers.NewServerHealthHandler(a.Logger, a.Config.API.URL)
+	a.ServerHealthHandler.SetAllowedHosts(a.Config.API.AllowedHosts)
+	a.DeepHealthHandler = handlers.NewDeepHealthHandler(a.Logger, a.ServerHealthHandler)
+	a.DeepHealthHandler.SetCatalogStatusFn(a.MCPHandler.CatalogStatus)
+	a.DeepHealthHandler.SetCatalogStaleAfter(time.Duration(a.Config.MCP.CatalogStaleAfterSeconds) * time.Second)
+	a.VersionHandler.SetCatalogInfoFn(a.MCPHandler.CatalogVersion)
+	a.ToolsHandler = handlers.NewToolsHandler(a.Logger, toolDetailAdapter(a.MCPHandler))
+	a.ToolsHandler.SetOpenAPIFn(a.MCPHandler.OpenAPI)
+	a.ToolsHandler.SetCatalogReportFn(catalogReportAdapter(a.MCPHandler))
 	a.ProfileHandler = handlers.NewProfileHandler(a.Logger, a.Config.IsDevMode(), jwtSecret, userLookup, userSave)
 	a.ProfileHandler.SetAPIURL(a.Config.API.URL)
+	a.ProfileHandler.SetKeyTestFn(vireClient.ValidateKey)
+	if len(a.Config.Audit.Outputs) > 0 {
+		a.ProfileHandler.SetAuditLogger(common.NewDedicatedLogger(common.LoggingConfig{
+			Level:      "info",
+			Outputs:    a.Config.Audit.Outputs,
+			FilePath:   a.Config.Audit.FilePath,
+			MaxSizeMB:  a.Config.Audit.MaxSizeMB,
+			MaxBackups: a.Config.Audit.MaxBackups,
+		}))
+	}
 
 	a.DashboardHandler = handlers.NewDashboardHandler(
 		a.Logger,
@@ -145,6 +293,7 @@ func (a *App) initHandlers() {
 		userLookup,
 	)
 	a.DashboardHandler.SetAPIURL(a.Config.API.URL)
+	a.DashboardHandler.SetConcentrationThreshold(a.Config.User.ConcentrationThresholdPct)
 
 	a.MobileDashboardHandler = handlers.NewMobileDashboardHandler(
 		a.Logger,
@@ -170,15 +319,53 @@ func (a *App) initHandlers() {
 	)
 	a.CashHandler.SetAPIURL(a.Config.API.URL)
 
+	a.DiagnosticsHandler = handlers.NewDiagnosticsHandler(
+		a.Logger,
+		a.Config.IsDevMode(),
+		jwtSecret,
+		userLookup,
+	)
+	a.DiagnosticsHandler.SetAPIURL(a.Config.API.URL)
+
+	a.PreferencesHandler = handlers.NewPreferencesHandler(a.Logger, a.Config.IsDevMode(), jwtSecret)
+	a.PreferencesHandler.SetUserSaveFn(userSave)
+	a.PreferencesHandler.SetPortfolioFn(a.MCPHandler.SetPortfolioPreference)
+	a.PreferencesHandler.SetTimezone(a.Config.User.TimezoneOrDefault())
+	a.PreferencesHandler.SetTimezoneFn(a.MCPHandler.SetTimezonePreference)
+
+	a.GrowthChartHandler = handlers.NewGrowthChartHandler(a.Logger, jwtSecret)
+	a.AllocationHandler = handlers.NewAllocationHandler(a.Logger, jwtSecret)
+	a.SyncHandler = handlers.NewSyncHandler(a.Logger, jwtSecret, userLookup)
+	a.SyncHandler.SetTimezone(a.Config.User.TimezoneOrDefault())
+	a.SyncHandler.SetInvalidateFn(a.DashboardHandler.InvalidateSummary)
+
 	a.PageHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
 		return vireClient.ProxyGet(path, userID)
 	})
 	a.StrategyHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
 		return vireClient.ProxyGet(path, userID)
 	})
+	a.StrategyHandler.SetProxyPutFn(func(path, userID string, body []byte) ([]byte, error) {
+		return vireClient.ProxyPut(path, userID, body)
+	})
 	a.CashHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
 		return vireClient.ProxyGet(path, userID)
 	})
+	a.DiagnosticsHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return vireClient.ProxyGet(path, userID)
+	})
+	a.PreferencesHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return vireClient.ProxyGet(path, userID)
+	})
+	a.GrowthChartHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return vireClient.ProxyGet(path, userID)
+	})
+	a.SyncHandler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return vireClient.ProxyPost(path, userID, body)
+	})
+	a.AllocationHandler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return vireClient.ProxyPost(path, userID, body)
+	})
 	a.DashboardHandler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
 		return vireClient.ProxyGet(path, userID)
 	})
@@ -222,7 +409,22 @@ func (a *App) initHandlers() {
 	)
 	a.AdminUsersHandler.SetAPIURL(a.Config.API.URL)
 
+	for _, h := range []interface{ SetSessionCookie(handlers.SessionCookie) }{
+		a.PageHandler, a.AuthHandler, a.ProfileHandler, a.DashboardHandler,
+		a.MobileDashboardHandler, a.StrategyHandler, a.CashHandler, a.DiagnosticsHandler,
+		a.PreferencesHandler, a.GrowthChartHandler, a.AllocationHandler, a.SyncHandler,
+		a.MCPPageHandler, a.AdminUsersHandler,
+	} {
+		h.SetSessionCookie(a.SessionCookie)
+	}
+
 	a.OAuthServer = auth.NewOAuthServer(a.Config.BaseURL(), a.Config.API.URL, jwtSecret, a.Logger)
+	a.OAuthServer.SetSessionCookieName(a.Config.Auth.CookieName())
+	a.OAuthServer.SetAllowedHosts(a.Config.API.AllowedHosts)
+	if a.clock != nil {
+		a.OAuthServer.SetClock(a.clock)
+		a.DashboardHandler.SetClock(a.clock)
+	}
 	a.AuthHandler.SetOAuthServer(a.OAuthServer)
 
 	a.Logger.Debug().Msg("HTTP handlers initialized")
@@ -211,7 +211,7 @@ func (s *OAuthServer) createAuthSession(clientID, redirectURI, responseType, cod
 
 // setSessionCookieAndRedirect sets the mcp_session_id cookie and redirects
 // to the landing page for login. If the user already has a valid browser
-// session (vire_session cookie), the MCP authorization is completed immediately
+// session (portal session cookie), the MCP authorization is completed immediately
 // to avoid the landing page clearing the existing session.
 func (s *OAuthServer) setSessionCookieAndRedirect(w http.ResponseWriter, r *http.Request, sessionID string) {
 	// Check if user already has a valid browser session
@@ -240,10 +240,10 @@ func (s *OAuthServer) setSessionCookieAndRedirect(w http.ResponseWriter, r *http
 	http.Redirect(w, r, "/?mcp_session="+sessionID, http.StatusFound)
 }
 
-// extractSessionUserID checks the vire_session cookie for a valid JWT and
+// extractSessionUserID checks the portal session cookie for a valid JWT and
 // returns the user ID (sub claim). Returns empty string if no valid session.
 func (s *OAuthServer) extractSessionUserID(r *http.Request) string {
-	cookie, err := r.Cookie("vire_session")
+	cookie, err := r.Cookie(s.cookieName)
 	if err != nil || cookie.Value == "" {
 		return ""
 	}
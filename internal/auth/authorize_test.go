@@ -269,3 +269,33 @@ func TestHandleAuthorize_RedirectURIMismatch(t *testing.T) {
 		t.Errorf("expected 400 for mismatched redirect_uri, got %d", rec.Code)
 	}
 }
+
+func TestExtractSessionUserID_ConfiguredCookieName(t *testing.T) {
+	srv := newTestOAuthServer()
+	srv.SetSessionCookieName("portal_sid")
+
+	token, err := srv.mintAccessToken("user-42", "openid", "client-1", "http://localhost:8500")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/authorize", nil)
+	req.AddCookie(&http.Cookie{Name: "portal_sid", Value: token})
+	if got := srv.extractSessionUserID(req); got != "user-42" {
+		t.Errorf("expected user-42 from configured cookie, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/authorize", nil)
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+	if got := srv.extractSessionUserID(req); got != "" {
+		t.Errorf("expected default cookie name to be ignored, got %q", got)
+	}
+}
+
+func TestSetSessionCookieName_EmptyKeepsDefault(t *testing.T) {
+	srv := newTestOAuthServer()
+	srv.SetSessionCookieName("  ")
+	if srv.cookieName != "vire_session" {
+		t.Errorf("expected vire_session, got %q", srv.cookieName)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
@@ -20,14 +21,21 @@ type OAuthBackend struct {
 }
 
 // NewOAuthBackend creates a new OAuthBackend targeting the given vire-server URL.
+// Requests may only go to apiURL's host until SetAllowedHosts adds others.
 func NewOAuthBackend(apiURL string, logger *common.Logger) *OAuthBackend {
 	return &OAuthBackend{
 		apiURL: apiURL,
-		client: &http.Client{Timeout: 5 * time.Second},
+		client: config.NewHostAllowList(apiURL, nil).Client(5 * time.Second),
 		logger: logger,
 	}
 }
 
+// SetAllowedHosts allows extra upstream hosts ("host" or "host:port") in
+// addition to apiURL's, see config.APIConfig.AllowedHosts.
+func (b *OAuthBackend) SetAllowedHosts(hosts []string) {
+	b.client = config.NewHostAllowList(b.apiURL, hosts).Client(5 * time.Second)
+}
+
 // SaveSession persists a session to the backend.
 func (b *OAuthBackend) SaveSession(sess *AuthSession) error {
 	return b.postJSON("/api/internal/oauth/sessions", sess)
@@ -12,18 +12,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
 // OAuthServer holds all state for the MCP OAuth 2.1 Authorization Server.
 type OAuthServer struct {
-	baseURL   string
-	jwtSecret []byte
-	clients   *ClientStore
-	sessions  *SessionStore
-	codes     *CodeStore
-	tokens    *TokenStore
-	logger    *common.Logger
+	baseURL    string
+	jwtSecret  []byte
+	cookieName string
+	clients    *ClientStore
+	sessions   *SessionStore
+	codes      *CodeStore
+	tokens     *TokenStore
+	backend    *OAuthBackend // nil without an apiURL
+	logger     *common.Logger
 }
 
 // NewOAuthServer creates a new OAuthServer with the given base URL and JWT secret.
@@ -31,17 +34,19 @@ type OAuthServer struct {
 // persistence to vire-server's internal OAuth API.
 func NewOAuthServer(baseURL, apiURL string, jwtSecret []byte, logger *common.Logger) *OAuthServer {
 	s := &OAuthServer{
-		baseURL:   strings.TrimRight(strings.TrimSpace(baseURL), "/"),
-		jwtSecret: jwtSecret,
-		clients:   NewClientStore(),
-		sessions:  NewSessionStore(),
-		codes:     NewCodeStore(),
-		tokens:    NewTokenStore(),
-		logger:    logger,
+		baseURL:    strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		jwtSecret:  jwtSecret,
+		cookieName: config.DefaultSessionCookieName,
+		clients:    NewClientStore(),
+		sessions:   NewSessionStore(),
+		codes:      NewCodeStore(),
+		tokens:     NewTokenStore(),
+		logger:     logger,
 	}
 
 	if apiURL != "" {
 		backend := NewOAuthBackend(apiURL, logger)
+		s.backend = backend
 		s.clients.SetBackend(backend)
 		s.sessions.SetBackend(backend)
 		s.codes.SetBackend(backend)
@@ -51,6 +56,27 @@ func NewOAuthServer(baseURL, apiURL string, jwtSecret []byte, logger *common.Log
 	return s
 }
 
+// SetSessionCookieName sets the name of the portal session cookie checked
+// when an already-logged-in user starts the MCP authorization flow.
+func (s *OAuthServer) SetSessionCookieName(name string) {
+	if name = strings.TrimSpace(name); name != "" {
+		s.cookieName = name
+	}
+}
+
+// SetAllowedHosts allows the OAuth backend extra upstream hosts ("host" or
+// "host:port"), see config.APIConfig.AllowedHosts. Call it before serving.
+func (s *OAuthServer) SetAllowedHosts(hosts []string) {
+	if s.backend != nil {
+		s.backend.SetAllowedHosts(hosts)
+	}
+}
+
+// SetClock sets the clock used to expire pending authorization sessions.
+func (s *OAuthServer) SetClock(c common.Clock) {
+	s.sessions.SetClock(c)
+}
+
 // CompleteAuthorization looks up a pending session, creates an authorization code,
 // stores it, deletes the session, and returns the redirect URL with code and state.
 func (s *OAuthServer) CompleteAuthorization(sessionID, userID string) (string, error) {
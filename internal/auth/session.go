@@ -3,6 +3,8 @@ package auth
 import (
 	"sync"
 	"time"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
 // sessionTTL is the time-to-live for pending MCP authorization sessions.
@@ -26,11 +28,23 @@ type SessionStore struct {
 	mu       sync.RWMutex
 	sessions map[string]*AuthSession
 	backend  *OAuthBackend
+	clock    common.Clock
 }
 
 // NewSessionStore creates a new empty SessionStore.
 func NewSessionStore() *SessionStore {
-	return &SessionStore{sessions: make(map[string]*AuthSession)}
+	return &SessionStore{sessions: make(map[string]*AuthSession), clock: common.SystemClock}
+}
+
+// SetClock sets the clock used to expire sessions.
+func (s *SessionStore) SetClock(c common.Clock) {
+	s.clock = c
+}
+
+// expired reports whether sess is older than sessionTTL at now. A session
+// exactly sessionTTL old is still valid.
+func expired(sess *AuthSession, now time.Time) bool {
+	return now.After(sess.CreatedAt.Add(sessionTTL))
 }
 
 // SetBackend configures the backend for write-through/read-through persistence.
@@ -77,7 +91,7 @@ func (s *SessionStore) Get(sessionID string) (*AuthSession, bool) {
 	if !ok {
 		return nil, false
 	}
-	if time.Now().After(sess.CreatedAt.Add(sessionTTL)) {
+	if expired(sess, s.clock.Now()) {
 		return nil, false
 	}
 	return sess, true
@@ -102,13 +116,13 @@ func (s *SessionStore) Delete(sessionID string) {
 // then the browser opens GET /authorize?client_id=xxx with a truncated URL.
 func (s *SessionStore) GetByClientID(clientID string) *AuthSession {
 	s.mu.RLock()
-	now := time.Now()
+	now := s.clock.Now()
 	var best *AuthSession
 	for _, sess := range s.sessions {
 		if sess.ClientID != clientID {
 			continue
 		}
-		if now.After(sess.CreatedAt.Add(sessionTTL)) {
+		if expired(sess, now) {
 			continue
 		}
 		if best == nil || sess.CreatedAt.After(best.CreatedAt) {
@@ -123,7 +137,7 @@ func (s *SessionStore) GetByClientID(clientID string) *AuthSession {
 			s.logWarn("GetSessionByClientID", err)
 			return nil
 		}
-		if sess != nil && !now.After(sess.CreatedAt.Add(sessionTTL)) {
+		if sess != nil && !expired(sess, now) {
 			// Cache locally
 			s.mu.Lock()
 			s.sessions[sess.SessionID] = sess
@@ -146,9 +160,9 @@ func (s *SessionStore) logWarn(method string, err error) {
 func (s *SessionStore) Cleanup() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	now := time.Now()
+	now := s.clock.Now()
 	for k, sess := range s.sessions {
-		if now.After(sess.CreatedAt.Add(sessionTTL)) {
+		if expired(sess, now) {
 			delete(s.sessions, k)
 		}
 	}
@@ -4,6 +4,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
 func TestSessionStore_PutAndGet(t *testing.T) {
@@ -54,6 +56,38 @@ func TestSessionStore_ExpiredSession(t *testing.T) {
 	}
 }
 
+func TestSessionStore_ExpiryBoundary(t *testing.T) {
+	created := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	clock := common.NewFakeClock(created)
+	store := NewSessionStore()
+	store.SetClock(clock)
+	store.Put(&AuthSession{SessionID: "sess", ClientID: "client", CreatedAt: created})
+
+	clock.Advance(sessionTTL)
+	if _, ok := store.Get("sess"); !ok {
+		t.Error("expected session exactly sessionTTL old to be valid")
+	}
+	if store.GetByClientID("client") == nil {
+		t.Error("expected GetByClientID to return session exactly sessionTTL old")
+	}
+
+	clock.Advance(time.Nanosecond)
+	if _, ok := store.Get("sess"); ok {
+		t.Error("expected session past sessionTTL to be expired")
+	}
+	if store.GetByClientID("client") != nil {
+		t.Error("expected GetByClientID to skip expired session")
+	}
+
+	store.Cleanup()
+	store.mu.RLock()
+	_, exists := store.sessions["sess"]
+	store.mu.RUnlock()
+	if exists {
+		t.Error("expected cleanup to remove session expired on the fake clock")
+	}
+}
+
 func TestSessionStore_Delete(t *testing.T) {
 	store := NewSessionStore()
 	store.Put(&AuthSession{
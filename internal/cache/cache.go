@@ -46,6 +46,14 @@ func MakeKey(userID, method, path string) string {
 	return userID + ":" + method + ":" + path
 }
 
+// MakeVariantKey is MakeKey for a response that also depends on a request
+// header, such as the X-Vire-Timezone timestamps are rendered in. The variant
+// follows the path, so InvalidatePrefix and InvalidateKeyPrefix still match
+// every variant of a path.
+func MakeVariantKey(userID, method, path, variant string) string {
+	return MakeKey(userID, method, path) + "#" + variant
+}
+
 // Get returns a cached response if found and not expired.
 func (c *ResponseCache) Get(key string) (*CachedResponse, bool) {
 	c.mu.RLock()
@@ -107,6 +115,20 @@ func (c *ResponseCache) InvalidatePrefix(prefix string) {
 	}
 }
 
+// InvalidateKeyPrefix removes all entries whose key starts with prefix. Unlike
+// InvalidatePrefix it only matches from the start of the key, so a prefix
+// built with MakeKey for one user never touches another user's entries.
+func (c *ResponseCache) InvalidateKeyPrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.items, key)
+		}
+	}
+}
+
 // evictOldest removes the entry with the lowest insertIdx. Must be called with mu held.
 func (c *ResponseCache) evictOldest() {
 	var oldestKey string
@@ -96,6 +96,56 @@ func TestResponseCache_InvalidatePrefix(t *testing.T) {
 	}
 }
 
+func TestResponseCache_InvalidateKeyPrefix(t *testing.T) {
+	c := New(5*time.Second, 100)
+
+	resp := &CachedResponse{StatusCode: http.StatusOK, Body: []byte("data")}
+
+	c.Set(MakeKey("alice", "GET", "/api/dashboard/summary?portfolio=SMSF"), resp)
+	c.Set(MakeKey("alice", "GET", "/api/portfolios"), resp)
+	c.Set(MakeKey("malice", "GET", "/api/dashboard/summary?portfolio=SMSF"), resp)
+
+	c.InvalidateKeyPrefix(MakeKey("alice", "GET", "/api/dashboard/summary"))
+
+	if _, ok := c.Get(MakeKey("alice", "GET", "/api/dashboard/summary?portfolio=SMSF")); ok {
+		t.Error("expected alice's summary to be invalidated")
+	}
+	if _, ok := c.Get(MakeKey("alice", "GET", "/api/portfolios")); !ok {
+		t.Error("expected alice's other entries to remain")
+	}
+	if _, ok := c.Get(MakeKey("malice", "GET", "/api/dashboard/summary?portfolio=SMSF")); !ok {
+		t.Error("expected another user's entry to remain")
+	}
+}
+
+func TestMakeVariantKey(t *testing.T) {
+	c := New(5*time.Second, 100)
+
+	sydney := MakeVariantKey("alice", "GET", "/api/portfolios/SMSF", "Australia/Sydney")
+	newYork := MakeVariantKey("alice", "GET", "/api/portfolios/SMSF", "America/New_York")
+	if sydney == newYork {
+		t.Fatalf("expected distinct keys per variant, got %q", sydney)
+	}
+
+	resp := &CachedResponse{StatusCode: http.StatusOK, Body: []byte("data")}
+	c.Set(sydney, resp)
+	c.Set(newYork, resp)
+
+	c.InvalidatePrefix("/api/portfolios/SMSF")
+	if _, ok := c.Get(sydney); ok {
+		t.Error("expected InvalidatePrefix to remove the Sydney variant")
+	}
+	if _, ok := c.Get(newYork); ok {
+		t.Error("expected InvalidatePrefix to remove the New York variant")
+	}
+
+	c.Set(sydney, resp)
+	c.InvalidateKeyPrefix(MakeKey("alice", "GET", "/api/portfolios"))
+	if _, ok := c.Get(sydney); ok {
+		t.Error("expected InvalidateKeyPrefix to remove the variant")
+	}
+}
+
 func TestResponseCache_MaxEntries(t *testing.T) {
 	c := New(5*time.Second, 3)
 
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
 	"github.com/phuslu/log"
 	"github.com/ternarybob/arbor/models"
 )
@@ -50,17 +51,26 @@ type HTTPLogStore struct {
 }
 
 // NewHTTPLogStore creates a new HTTPLogStore targeting the given vire-server URL.
+// Requests may only go to baseURL's host until SetAllowedHosts adds others.
 func NewHTTPLogStore(baseURL, serviceID string) *HTTPLogStore {
 	s := &HTTPLogStore{
 		baseURL:    baseURL,
 		serviceID:  serviceID,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+		httpClient: config.NewHostAllowList(baseURL, nil).Client(10 * time.Second),
 		buf:        make([]logIngestEntry, 0, logFlushSize),
 	}
 	s.timer = time.AfterFunc(logFlushInterval, s.timerFlush)
 	return s
 }
 
+// SetAllowedHosts allows extra upstream hosts ("host" or "host:port") in
+// addition to the base URL's, see config.APIConfig.AllowedHosts.
+func (s *HTTPLogStore) SetAllowedHosts(hosts []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.httpClient = config.NewHostAllowList(s.baseURL, hosts).Client(10 * time.Second)
+}
+
 // Store adds a log entry to the buffer. Flushes if buffer reaches logFlushSize.
 func (s *HTTPLogStore) Store(entry models.LogEvent) error {
 	s.mu.Lock()
@@ -127,6 +137,10 @@ func (s *HTTPLogStore) flushLocked() {
 
 // send POSTs entries to vire-server in batches of logMaxBatch.
 func (s *HTTPLogStore) send(entries []logIngestEntry) {
+	s.mu.Lock()
+	httpClient := s.httpClient
+	s.mu.Unlock()
+
 	for i := 0; i < len(entries); i += logMaxBatch {
 		end := i + logMaxBatch
 		if end > len(entries) {
@@ -149,7 +163,7 @@ func (s *HTTPLogStore) send(entries []logIngestEntry) {
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("X-Vire-Service-ID", s.serviceID)
 
-		resp, err := s.httpClient.Do(req)
+		resp, err := httpClient.Do(req)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "vire-portal: log store send error: %v\n", err)
 			continue
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
 )
 
 // UserProfile holds the user profile returned by vire-server.
@@ -16,6 +19,12 @@ type UserProfile struct {
 	Role             string `json:"role"`
 	NavexaKeySet     bool   `json:"navexa_key_set"`
 	NavexaKeyPreview string `json:"navexa_key_preview"`
+	EODHDKeySet      bool   `json:"eodhd_key_set"`
+	EODHDKeyPreview  string `json:"eodhd_key_preview"`
+	GeminiKeySet     bool   `json:"gemini_key_set"`
+	GeminiKeyPreview string `json:"gemini_key_preview"`
+	DefaultPortfolio string `json:"default_portfolio"`
+	Timezone         string `json:"timezone"`
 }
 
 // VireClient communicates with the vire-server REST API.
@@ -25,13 +34,25 @@ type VireClient struct {
 }
 
 // NewVireClient creates a new client targeting the given vire-server URL.
+// Requests may only go to baseURL's host until SetAllowedHosts adds others.
 func NewVireClient(baseURL string) *VireClient {
 	return &VireClient{
 		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: config.NewHostAllowList(baseURL, nil).Client(30 * time.Second),
 	}
 }
 
+// SetAllowedHosts allows extra upstream hosts ("host" or "host:port") in
+// addition to the base URL's, see config.APIConfig.AllowedHosts.
+func (c *VireClient) SetAllowedHosts(hosts []string) {
+	c.httpClient = config.NewHostAllowList(c.baseURL, hosts).Client(30 * time.Second)
+}
+
+// SetHTTPClient replaces the HTTP client used for vire-server requests.
+func (c *VireClient) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
 // GetUser fetches user profile from vire-server.
 // GET /api/users/{id} -> { status: "ok", data: UserProfile }
 func (c *VireClient) GetUser(userID string) (*UserProfile, error) {
@@ -288,6 +309,18 @@ func (c *VireClient) AdminUpdateUserRole(serviceID, userID, role string) error {
 	return nil
 }
 
+// ProxyError is returned by ProxyGet, ProxyPut and ProxyPost when vire-server
+// responds with a non-2xx status. Callers can use errors.As to recover the
+// status code.
+type ProxyError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Body)
+}
+
 // ProxyGet performs a GET request to vire-server at the given path,
 // injecting the X-Vire-User-ID header for authentication.
 // Returns the raw response body bytes on success (2xx), or an error.
@@ -296,6 +329,36 @@ func (c *VireClient) ProxyGet(path string, userID string) ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	return c.doProxy(req, userID)
+}
+
+// ProxyPut performs a PUT request with a JSON body to vire-server at the given
+// path, injecting the X-Vire-User-ID header for authentication.
+// Returns the raw response body bytes on success (2xx), or an error.
+func (c *VireClient) ProxyPut(path string, userID string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPut, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.doProxy(req, userID)
+}
+
+// ProxyPost performs a POST request with an optional JSON body to vire-server
+// at the given path, injecting the X-Vire-User-ID header for authentication.
+// Returns the raw response body bytes on success (2xx), or an error.
+func (c *VireClient) ProxyPost(path string, userID string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.doProxy(req, userID)
+}
+
+// doProxy sends a proxied request and returns the body for 2xx responses,
+// or a *ProxyError for any other status.
+func (c *VireClient) doProxy(req *http.Request, userID string) ([]byte, error) {
 	if userID != "" {
 		req.Header.Set("X-Vire-User-ID", userID)
 	}
@@ -312,7 +375,7 @@ func (c *VireClient) ProxyGet(path string, userID string) ([]byte, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(body))
+		return nil, &ProxyError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return body, nil
@@ -357,3 +420,50 @@ func (c *VireClient) UpdateUser(userID string, fields map[string]string) (*UserP
 
 	return &result.Data, nil
 }
+
+// KeyValidation is the result of probing a provider API key.
+type KeyValidation struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message"`
+}
+
+// ValidateKey asks vire-server to probe a provider API key without storing it.
+// POST /api/users/{id}/keys/validate with { provider, key } -> { valid, message }
+// Errors never include the response body, which may echo the key.
+func (c *VireClient) ValidateKey(userID, provider, key string) (*KeyValidation, error) {
+	jsonData, err := json.Marshal(map[string]string{
+		"provider": provider,
+		"key":      key,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/api/users/"+url.PathEscape(userID)+"/keys/validate", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vire-User-ID", userID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vire-server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %d", resp.StatusCode)
+	}
+
+	var result KeyValidation
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &result, nil
+}
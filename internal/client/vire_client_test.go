@@ -2,8 +2,11 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -86,6 +89,101 @@ func TestProxyGet_NetworkError(t *testing.T) {
 	}
 }
 
+func TestProxyGet_Non2xxReturnsProxyError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	_, err := c.ProxyGet("/api/portfolios/missing", "")
+	var perr *ProxyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ProxyError, got %T", err)
+	}
+	if perr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", perr.StatusCode)
+	}
+}
+
+func TestProxyGet_RefusesNonAllowListedHost(t *testing.T) {
+	hit := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+		w.Write([]byte(`{}`))
+	}))
+	defer other.Close()
+
+	// vire-server redirects to a host that is not allow-listed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	if _, err := c.ProxyGet("/api/glossary", "alice"); err == nil || !strings.Contains(err.Error(), "not in the allow-list") {
+		t.Fatalf("expected allow-list refusal, got %v", err)
+	}
+	if hit {
+		t.Error("expected non-allow-listed host not to be contacted")
+	}
+
+	c.SetAllowedHosts([]string{strings.TrimPrefix(other.URL, "http://")})
+	if _, err := c.ProxyGet("/api/glossary", "alice"); err != nil {
+		t.Fatalf("expected allow-listed host to be reachable, got %v", err)
+	}
+	if !hit {
+		t.Error("expected allow-listed host to be contacted")
+	}
+}
+
+// --- ProxyPut Tests ---
+
+func TestProxyPut_SendsBodyAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.Header.Get("X-Vire-User-ID") != "alice" {
+			t.Errorf("expected X-Vire-User-ID=alice, got %s", r.Header.Get("X-Vire-User-ID"))
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %s", r.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		w.Write(b)
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	body, err := c.ProxyPut("/api/portfolios/SMSF/strategy", "alice", []byte(`{"notes":"x"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(body) != `{"notes":"x"}` {
+		t.Errorf("unexpected body: %s", string(body))
+	}
+}
+
+func TestProxyPut_Non2xxReturnsProxyError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"bad strategy"}`))
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	_, err := c.ProxyPut("/api/portfolios/SMSF/strategy", "alice", []byte(`{}`))
+	var perr *ProxyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *ProxyError, got %T", err)
+	}
+	if perr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", perr.StatusCode)
+	}
+}
+
 func TestGetUser_Success(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/users/alice" {
@@ -219,6 +317,63 @@ func TestUpdateUser_ServerError(t *testing.T) {
 	}
 }
 
+func TestValidateKey_Valid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/users/alice/keys/validate" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["provider"] != "eodhd" || body["key"] != "good-key" {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Write([]byte(`{"valid":true,"message":"Key accepted"}`))
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	result, err := c.ValidateKey("alice", "eodhd", "good-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Valid || result.Message != "Key accepted" {
+		t.Errorf("expected valid result, got %+v", result)
+	}
+}
+
+func TestValidateKey_Invalid(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"valid":false,"message":"Unauthorized"}`))
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	result, err := c.ValidateKey("alice", "navexa", "bad-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Valid {
+		t.Error("expected invalid result")
+	}
+}
+
+func TestValidateKey_ServerErrorOmitsBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"probe failed for key secret-key"}`))
+	}))
+	defer srv.Close()
+
+	c := NewVireClient(srv.URL)
+	_, err := c.ValidateKey("alice", "navexa", "secret-key")
+	if err == nil {
+		t.Fatal("expected error for server error")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("error must not include the response body: %v", err)
+	}
+}
+
 func TestUpsertUser_Created(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/api/users/upsert" {
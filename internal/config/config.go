@@ -2,18 +2,38 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
 // AuthConfig contains authentication settings.
 type AuthConfig struct {
-	JWTSecret   string `toml:"jwt_secret"`
-	CallbackURL string `toml:"callback_url"`
-	PortalURL   string `toml:"portal_url"`
+	JWTSecret           string `toml:"jwt_secret"`
+	CallbackURL         string `toml:"callback_url"`
+	PortalURL           string `toml:"portal_url"`
+	SessionCookieName   string `toml:"session_cookie_name"`
+	SessionCookieDomain string `toml:"session_cookie_domain"`
+	// DevLogin enables the dev login endpoint (POST /api/auth/test-login)
+	// outside environment=dev. It is always on in dev and rejected in prod.
+	DevLogin bool `toml:"dev_login"`
+}
+
+// DefaultSessionCookieName is the session cookie name used when none is configured.
+const DefaultSessionCookieName = "vire_session"
+
+// CookieName returns the configured session cookie name, falling back to
+// DefaultSessionCookieName when unset.
+func (a AuthConfig) CookieName() string {
+	if name := strings.TrimSpace(a.SessionCookieName); name != "" {
+		return name
+	}
+	return DefaultSessionCookieName
 }
 
 // ServiceConfig contains service registration settings for admin API access.
@@ -25,20 +45,59 @@ type ServiceConfig struct {
 // MCPConfig contains MCP handler settings.
 type MCPConfig struct {
 	CatalogRetries int `toml:"catalog_retries"`
+	// CatalogStaleAfterSeconds marks the catalog check in /api/health/deep
+	// degraded once the last successful catalog fetch is older than this.
+	// Zero disables the check.
+	CatalogStaleAfterSeconds int `toml:"catalog_stale_after_seconds"`
+	MaxInflight              int `toml:"max_inflight"`
+	QueueTimeoutSeconds      int `toml:"queue_timeout_seconds"`
+	// MaxMessageBytes caps an inbound JSON-RPC request body on /mcp (a
+	// batch counts as one message). Non-positive uses DefaultMCPMaxMessageBytes.
+	MaxMessageBytes int64 `toml:"max_message_bytes"`
+	// ToolTimeoutSeconds is the per-call deadline for catalog tools that
+	// don't set their own timeout_seconds.
+	ToolTimeoutSeconds int `toml:"tool_timeout_seconds"`
+	// NeutralTerms maps directional terms in sentiment and impact text to
+	// the neutral wording shown instead, e.g. "bullish" = "positive". Unset
+	// uses common.DefaultNeutralTerms; an empty table disables rewriting.
+	NeutralTerms map[string]string `toml:"neutral_terms"`
+
+	// Upstream connection pool for MCP proxy requests to vire-server.
+	MaxIdleConnsPerHost    int `toml:"max_idle_conns_per_host"`
+	IdleConnTimeoutSeconds int `toml:"idle_conn_timeout_seconds"`
+	DialTimeoutSeconds     int `toml:"dial_timeout_seconds"`
+}
+
+// MessageLimit returns the inbound MCP message size cap, falling back to
+// DefaultMCPMaxMessageBytes when unset.
+func (m MCPConfig) MessageLimit() int64 {
+	if m.MaxMessageBytes > 0 {
+		return m.MaxMessageBytes
+	}
+	return DefaultMCPMaxMessageBytes
 }
 
 // Config represents the application configuration.
 type Config struct {
-	Environment string        `toml:"environment"`
-	AdminUsers  string        `toml:"admin_users"`
-	Server      ServerConfig  `toml:"server"`
-	API         APIConfig     `toml:"api"`
-	Portal      PortalConfig  `toml:"portal"`
-	Auth        AuthConfig    `toml:"auth"`
-	Service     ServiceConfig `toml:"service"`
-	User        UserConfig    `toml:"user"`
-	Logging     LoggingConfig `toml:"logging"`
-	MCP         MCPConfig     `toml:"mcp"`
+	Environment string         `toml:"environment"`
+	AdminUsers  string         `toml:"admin_users"`
+	Server      ServerConfig   `toml:"server"`
+	API         APIConfig      `toml:"api"`
+	Portal      PortalConfig   `toml:"portal"`
+	Auth        AuthConfig     `toml:"auth"`
+	Service     ServiceConfig  `toml:"service"`
+	User        UserConfig     `toml:"user"`
+	Logging     LoggingConfig  `toml:"logging"`
+	Audit       AuditConfig    `toml:"audit"`
+	CORS        CORSConfig     `toml:"cors"`
+	Security    SecurityConfig `toml:"security"`
+	MCP         MCPConfig      `toml:"mcp"`
+}
+
+// TemplateReload reports whether page templates should be re-parsed on
+// every request: server.reload_templates is set and the portal is in dev mode.
+func (c *Config) TemplateReload() bool {
+	return c.Server.ReloadTemplates && c.IsDevMode()
 }
 
 // IsDevMode returns true when the environment is set to "dev" or "development" (case-insensitive, trimmed).
@@ -47,6 +106,12 @@ func (c *Config) IsDevMode() bool {
 	return strings.ToLower(strings.TrimSpace(c.Environment)) == "dev"
 }
 
+// DevLoginEnabled reports whether the dev login endpoint is enabled: always
+// in dev mode, otherwise only when auth.dev_login is set.
+func (c *Config) DevLoginEnabled() bool {
+	return c.IsDevMode() || c.Auth.DevLogin
+}
+
 // AdminEmails parses the comma-separated AdminUsers string into a slice of
 // trimmed, lowercased email addresses. Empty entries are filtered out.
 func (c *Config) AdminEmails() []string {
@@ -77,17 +142,76 @@ func normalizeEnvironment(env string) string {
 	}
 }
 
-// BaseURL returns the portal's external base URL.
+// BasePath returns the normalized sub-path the portal is mounted under
+// ("/vire"), or "" when it is served from the root.
+func (c *Config) BasePath() string {
+	p := strings.Trim(strings.TrimSpace(c.Server.BasePath), "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// BaseURL returns the portal's external base URL, including the base path.
 // Uses Auth.PortalURL if set, otherwise builds from server host and port.
+// A PortalURL that already ends with the base path is used as is.
 func (c *Config) BaseURL() string {
+	basePath := c.BasePath()
 	if c.Auth.PortalURL != "" {
-		return strings.TrimRight(c.Auth.PortalURL, "/")
+		base := strings.TrimRight(c.Auth.PortalURL, "/")
+		if basePath != "" && strings.HasSuffix(base, basePath) {
+			return base
+		}
+		return base + basePath
 	}
 	host := c.Server.Host
 	if host == "" || host == "0.0.0.0" {
 		host = "localhost"
 	}
-	return fmt.Sprintf("http://%s:%d", host, c.Server.Port)
+	return fmt.Sprintf("http://%s:%d%s", host, c.Server.Port, basePath)
+}
+
+// validateUpstreamURL checks that raw is an absolute http(s) URL with a host
+// that can be dialled. It rejects credentials, query strings and fragments
+// (which would leak into logs or every proxied path), unspecified addresses
+// (0.0.0.0, ::), and link-local or multicast IPs such as the cloud metadata
+// address 169.254.169.254. IPv6 literals must be bracketed ("http://[::1]:4242").
+// Loopback and private addresses are allowed: vire-server usually runs there.
+func validateUpstreamURL(raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("must use http or https (got %q)", raw)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("must include a host (got %q)", raw)
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("has an invalid port %q", port)
+		}
+	}
+	if u.User != nil {
+		return fmt.Errorf("must not contain credentials")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("must not contain a query string or fragment (got %q)", raw)
+	}
+	if strings.Contains(host, ":") && !strings.Contains(u.Host, "[") {
+		return fmt.Errorf("IPv6 hosts must be bracketed, e.g. http://[::1]:4242 (got %q)", raw)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		switch {
+		case ip.IsUnspecified():
+			return fmt.Errorf("host %s is unspecified and cannot be dialled", host)
+		case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast(), ip.IsMulticast():
+			return fmt.Errorf("host %s is a link-local or multicast address", host)
+		}
+	}
+	return nil
 }
 
 // Validate checks mandatory configuration fields and returns a list of issues.
@@ -98,6 +222,8 @@ func (c *Config) Validate() []string {
 	// api.url is always required — the portal cannot function without vire-server.
 	if strings.TrimSpace(c.API.URL) == "" {
 		issues = append(issues, "api.url is required (set in TOML or via VIRE_API_URL)")
+	} else if err := validateUpstreamURL(c.API.URL); err != nil {
+		issues = append(issues, fmt.Sprintf("api.url %v", err))
 	}
 
 	// auth.jwt_secret is required in production.
@@ -105,35 +231,138 @@ func (c *Config) Validate() []string {
 		issues = append(issues, "auth.jwt_secret is required in production (set in TOML, via VIRE_AUTH_JWT_SECRET, or use environment=dev)")
 	}
 
+	// auth.dev_login must never reach production.
+	if c.Auth.DevLogin && strings.ToLower(normalizeEnvironment(c.Environment)) == "prod" {
+		issues = append(issues, "auth.dev_login must not be enabled in production (unset it or VIRE_AUTH_DEV_LOGIN)")
+	}
+
 	// server.port must be in a valid range.
 	if c.Server.Port < 1 || c.Server.Port > 65535 {
 		issues = append(issues, fmt.Sprintf("server.port must be between 1 and 65535 (got %d)", c.Server.Port))
 	}
 
+	// server.pprof is only ever served behind the admin token.
+	if c.Server.Pprof && strings.TrimSpace(c.Server.AdminToken) == "" {
+		issues = append(issues, "server.admin_token is required when server.pprof is enabled (set in TOML or via VIRE_SERVER_ADMIN_TOKEN)")
+	}
+
+	// user.concentration_threshold_pct is a portfolio weight percentage.
+	if t := c.User.ConcentrationThresholdPct; t < 0 || t > 100 {
+		issues = append(issues, fmt.Sprintf("user.concentration_threshold_pct must be between 0 and 100 (got %g)", t))
+	}
+
+	// user.timezone must be a zone the tz database knows.
+	if tz := strings.TrimSpace(c.User.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			issues = append(issues, fmt.Sprintf("user.timezone %q is not a known IANA timezone", tz))
+		}
+	}
+
+	// logging.sample rules need a path and a non-negative rate.
+	for i, rule := range c.Logging.Sample {
+		if strings.TrimSpace(rule.Path) == "" {
+			issues = append(issues, fmt.Sprintf("logging.sample[%d].path is required", i))
+		}
+		if rule.Every < 0 {
+			issues = append(issues, fmt.Sprintf("logging.sample[%d].every must be 0 or more (got %d)", i, rule.Every))
+		}
+	}
+
 	return issues
 }
 
 // APIConfig contains vire-server API connection settings.
 type APIConfig struct {
 	URL string `toml:"url"`
+	// AllowedHosts lists extra upstream hosts ("host" or "host:port") the
+	// proxy and health checks may reach. The host of URL is always allowed.
+	AllowedHosts []string `toml:"allowed_hosts"`
 }
 
 // PortalConfig contains vire-portal connection settings.
 // Used by vire-mcp to know which portal instance to connect to.
 type PortalConfig struct {
 	URL string `toml:"url"`
+	// TokenStore selects where vire-mcp keeps OAuth tokens: "file"
+	// (~/.vire/credentials.json, the default) or "keyring" (the OS keychain).
+	TokenStore string `toml:"token_store"`
+	// CACertFile is a PEM bundle vire-mcp trusts in addition to the system
+	// roots, for TLS-intercepting corporate proxies.
+	CACertFile string `toml:"ca_cert_file"`
+	// Offline makes vire-mcp cache the tool catalog on every successful
+	// connect and fall back to that cache when the portal is unreachable.
+	Offline bool `toml:"offline"`
+	// CallbackPort pins vire-mcp's OAuth callback listener to one loopback
+	// port, for OAuth clients registered with a fixed redirect URI.
+	CallbackPort int `toml:"callback_port"`
+	// CallbackPortRange ("start-end") is scanned for a free callback port
+	// when CallbackPort is unset. Empty picks a random port.
+	CallbackPortRange string `toml:"callback_port_range"`
 }
 
 // UserConfig contains per-user settings injected as X-Vire-* headers.
 type UserConfig struct {
 	Portfolios      []string `toml:"portfolios"`
 	DisplayCurrency string   `toml:"display_currency"`
+	// ConcentrationThresholdPct flags holdings whose portfolio weight exceeds
+	// it as overweight in the holdings table. 0 disables the flag.
+	ConcentrationThresholdPct float64 `toml:"concentration_threshold_pct"`
+	// Timezone is the IANA zone timestamps are displayed in, e.g.
+	// "America/New_York". Users can override it per browser.
+	Timezone string `toml:"timezone"`
+}
+
+// DefaultPortfolio returns the first configured portfolio, or "" when none
+// is configured.
+func (u UserConfig) DefaultPortfolio() string {
+	if len(u.Portfolios) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(u.Portfolios[0])
+}
+
+// DisplayCurrencyOrDefault returns the configured display currency, falling
+// back to DefaultDisplayCurrency when unset.
+func (u UserConfig) DisplayCurrencyOrDefault() string {
+	if c := strings.TrimSpace(u.DisplayCurrency); c != "" {
+		return c
+	}
+	return DefaultDisplayCurrency
+}
+
+// TimezoneOrDefault returns the configured display timezone, falling back
+// to DefaultTimezone when unset.
+func (u UserConfig) TimezoneOrDefault() string {
+	if tz := strings.TrimSpace(u.Timezone); tz != "" {
+		return tz
+	}
+	return DefaultTimezone
 }
 
 // ServerConfig contains HTTP server settings.
 type ServerConfig struct {
-	Port int    `toml:"port"`
-	Host string `toml:"host"`
+	Port         int    `toml:"port"`
+	Host         string `toml:"host"`
+	MaxBodyBytes int64  `toml:"max_body_bytes"` // limit for POST/PUT/PATCH request bodies
+	Maintenance  bool   `toml:"maintenance"`    // serve 503 maintenance responses (reloaded on SIGHUP)
+	BasePath     string `toml:"base_path"`      // sub-path the portal is mounted under, e.g. "/vire"
+	// RedirectTrailingSlash 301-redirects GET/HEAD "/path/" to "/path".
+	RedirectTrailingSlash bool `toml:"redirect_trailing_slash"`
+	// Pprof serves net/http/pprof under /debug/pprof/ to requests carrying
+	// AdminToken as a bearer token. Off by default.
+	Pprof      bool   `toml:"pprof"`
+	AdminToken string `toml:"admin_token"`
+	// ReloadTemplates re-parses page templates from the on-disk pages
+	// directory on every request, so edits show without a restart. Only
+	// honoured in dev mode.
+	ReloadTemplates bool `toml:"reload_templates"`
+	// HTTP server timeouts in seconds; non-positive values use the defaults.
+	// Streaming endpoints (/mcp, the log tail) are exempt from the write
+	// timeout.
+	ReadHeaderTimeoutSeconds int `toml:"read_header_timeout_seconds"`
+	ReadTimeoutSeconds       int `toml:"read_timeout_seconds"`
+	WriteTimeoutSeconds      int `toml:"write_timeout_seconds"`
+	IdleTimeoutSeconds       int `toml:"idle_timeout_seconds"`
 }
 
 // LoggingConfig contains logging settings.
@@ -144,6 +373,77 @@ type LoggingConfig struct {
 	FilePath   string   `toml:"file_path"`
 	MaxSizeMB  int      `toml:"max_size_mb"`
 	MaxBackups int      `toml:"max_backups"`
+	// Sample thins successful request logs for high-volume paths
+	// (health checks, MCP polling). Non-2xx requests are always logged.
+	Sample []LogSampleRule `toml:"sample"`
+}
+
+// LogSampleRule samples the request logs of matching paths. Path is an exact
+// request path, or a prefix when it ends in "*". Every logs one in Every
+// successful requests; 0 logs only non-2xx requests.
+type LogSampleRule struct {
+	Path  string `toml:"path"`
+	Every int    `toml:"every"`
+}
+
+// FileOutputPath returns the log file path when "file" is among the outputs,
+// or "" when file logging is off.
+func (l LoggingConfig) FileOutputPath() string {
+	for _, out := range l.Outputs {
+		if strings.TrimSpace(out) == "file" {
+			return strings.TrimSpace(l.FilePath)
+		}
+	}
+	return ""
+}
+
+// AuditConfig contains settings for the audit log, which is written separately
+// from the application log. An empty Outputs list disables audit logging.
+type AuditConfig struct {
+	Outputs    []string `toml:"outputs"`
+	FilePath   string   `toml:"file_path"`
+	MaxSizeMB  int      `toml:"max_size_mb"`
+	MaxBackups int      `toml:"max_backups"`
+}
+
+// CORSConfig contains cross-origin settings for the /mcp and /api/ endpoints.
+// AllowedOrigins entries are exact origins ("https://app.example.com"),
+// wildcard subdomains ("https://*.example.com") or "*" for any origin.
+// "*" is ignored when AllowCredentials is set, so credentials are only ever
+// granted to explicitly listed origins.
+type CORSConfig struct {
+	AllowedOrigins   []string `toml:"allowed_origins"`
+	AllowedMethods   []string `toml:"allowed_methods"`
+	AllowedHeaders   []string `toml:"allowed_headers"`
+	AllowCredentials bool     `toml:"allow_credentials"`
+	MaxAgeSeconds    int      `toml:"max_age_seconds"`
+}
+
+// SecurityConfig contains the security response headers. An empty
+// ContentSecurityPolicy uses DefaultContentSecurityPolicy. With
+// CSPReportOnly set the policy is sent as
+// Content-Security-Policy-Report-Only, so violations are reported in the
+// browser console without blocking anything.
+type SecurityConfig struct {
+	ContentSecurityPolicy string `toml:"content_security_policy"`
+	CSPReportOnly         bool   `toml:"csp_report_only"`
+}
+
+// CSP returns the configured Content-Security-Policy, falling back to
+// DefaultContentSecurityPolicy when unset.
+func (s SecurityConfig) CSP() string {
+	if csp := strings.TrimSpace(s.ContentSecurityPolicy); csp != "" {
+		return csp
+	}
+	return DefaultContentSecurityPolicy
+}
+
+// CSPHeader returns the header the policy is sent in.
+func (s SecurityConfig) CSPHeader() string {
+	if s.CSPReportOnly {
+		return "Content-Security-Policy-Report-Only"
+	}
+	return "Content-Security-Policy"
 }
 
 // LoadFromFile loads configuration with priority: defaults -> file -> env.
@@ -195,6 +495,37 @@ func applyEnvOverrides(config *Config) {
 	if host := os.Getenv("VIRE_SERVER_HOST"); host != "" {
 		config.Server.Host = host
 	}
+	if maxBody := os.Getenv("VIRE_SERVER_MAX_BODY_BYTES"); maxBody != "" {
+		if n, err := strconv.ParseInt(maxBody, 10, 64); err == nil && n > 0 {
+			config.Server.MaxBodyBytes = n
+		}
+	}
+	if basePath := os.Getenv("VIRE_SERVER_BASE_PATH"); basePath != "" {
+		config.Server.BasePath = basePath
+	}
+	if redirect := os.Getenv("VIRE_SERVER_REDIRECT_TRAILING_SLASH"); redirect != "" {
+		if b, err := strconv.ParseBool(redirect); err == nil {
+			config.Server.RedirectTrailingSlash = b
+		}
+	}
+	if pprof := os.Getenv("VIRE_SERVER_PPROF"); pprof != "" {
+		if b, err := strconv.ParseBool(pprof); err == nil {
+			config.Server.Pprof = b
+		}
+	}
+	if reload := os.Getenv("VIRE_SERVER_RELOAD_TEMPLATES"); reload != "" {
+		if b, err := strconv.ParseBool(reload); err == nil {
+			config.Server.ReloadTemplates = b
+		}
+	}
+	if adminToken := os.Getenv("VIRE_SERVER_ADMIN_TOKEN"); adminToken != "" {
+		config.Server.AdminToken = adminToken
+	}
+	if maintenance := os.Getenv("VIRE_SERVER_MAINTENANCE"); maintenance != "" {
+		if b, err := strconv.ParseBool(maintenance); err == nil {
+			config.Server.Maintenance = b
+		}
+	}
 	if level := os.Getenv("VIRE_LOG_LEVEL"); level != "" {
 		config.Logging.Level = level
 	}
@@ -206,12 +537,82 @@ func applyEnvOverrides(config *Config) {
 	if apiURL := os.Getenv("VIRE_API_URL"); apiURL != "" {
 		config.API.URL = apiURL
 	}
+	if hosts := os.Getenv("VIRE_API_ALLOWED_HOSTS"); hosts != "" {
+		config.API.AllowedHosts = splitList(hosts)
+	}
 	if portfolio := os.Getenv("VIRE_DEFAULT_PORTFOLIO"); portfolio != "" {
 		config.User.Portfolios = []string{portfolio}
 	}
 	if currency := os.Getenv("VIRE_DISPLAY_CURRENCY"); currency != "" {
 		config.User.DisplayCurrency = currency
 	}
+	if tz := os.Getenv("VIRE_TIMEZONE"); tz != "" {
+		config.User.Timezone = tz
+	}
+	if threshold := os.Getenv("VIRE_CONCENTRATION_THRESHOLD_PCT"); threshold != "" {
+		if f, err := strconv.ParseFloat(threshold, 64); err == nil {
+			config.User.ConcentrationThresholdPct = f
+		}
+	}
+	if inflight := os.Getenv("VIRE_MCP_MAX_INFLIGHT"); inflight != "" {
+		if n, err := strconv.Atoi(inflight); err == nil && n > 0 {
+			config.MCP.MaxInflight = n
+		}
+	}
+	if wait := os.Getenv("VIRE_MCP_QUEUE_TIMEOUT_SECONDS"); wait != "" {
+		if n, err := strconv.Atoi(wait); err == nil && n > 0 {
+			config.MCP.QueueTimeoutSeconds = n
+		}
+	}
+	if stale := os.Getenv("VIRE_MCP_CATALOG_STALE_AFTER_SECONDS"); stale != "" {
+		if n, err := strconv.Atoi(stale); err == nil && n >= 0 {
+			config.MCP.CatalogStaleAfterSeconds = n
+		}
+	}
+	if wait := os.Getenv("VIRE_MCP_TOOL_TIMEOUT_SECONDS"); wait != "" {
+		if n, err := strconv.Atoi(wait); err == nil && n > 0 {
+			config.MCP.ToolTimeoutSeconds = n
+		}
+	}
+	if size := os.Getenv("VIRE_MCP_MAX_MESSAGE_BYTES"); size != "" {
+		if n, err := strconv.ParseInt(size, 10, 64); err == nil && n > 0 {
+			config.MCP.MaxMessageBytes = n
+		}
+	}
+
+	// Audit log overrides ("none" disables audit logging)
+	if outputs := os.Getenv("VIRE_AUDIT_OUTPUTS"); outputs != "" {
+		config.Audit.Outputs = nil
+		if strings.ToLower(strings.TrimSpace(outputs)) != "none" {
+			config.Audit.Outputs = splitList(outputs)
+		}
+	}
+	if auditPath := os.Getenv("VIRE_AUDIT_FILE_PATH"); auditPath != "" {
+		config.Audit.FilePath = auditPath
+	}
+
+	// CORS overrides ("none" disables cross-origin access)
+	if origins := os.Getenv("VIRE_CORS_ALLOWED_ORIGINS"); origins != "" {
+		config.CORS.AllowedOrigins = []string{}
+		if strings.ToLower(strings.TrimSpace(origins)) != "none" {
+			config.CORS.AllowedOrigins = splitList(origins)
+		}
+	}
+	if creds := os.Getenv("VIRE_CORS_ALLOW_CREDENTIALS"); creds != "" {
+		if b, err := strconv.ParseBool(creds); err == nil {
+			config.CORS.AllowCredentials = b
+		}
+	}
+
+	// Security header overrides
+	if csp := os.Getenv("VIRE_SECURITY_CSP"); csp != "" {
+		config.Security.ContentSecurityPolicy = csp
+	}
+	if reportOnly := os.Getenv("VIRE_SECURITY_CSP_REPORT_ONLY"); reportOnly != "" {
+		if b, err := strconv.ParseBool(reportOnly); err == nil {
+			config.Security.CSPReportOnly = b
+		}
+	}
 
 	// Admin users override
 	if adminUsers := os.Getenv("VIRE_ADMIN_USERS"); adminUsers != "" {
@@ -233,10 +634,40 @@ func applyEnvOverrides(config *Config) {
 	if callbackURL := os.Getenv("VIRE_AUTH_CALLBACK_URL"); callbackURL != "" {
 		config.Auth.CallbackURL = callbackURL
 	}
+	if cookieName := os.Getenv("VIRE_AUTH_SESSION_COOKIE_NAME"); cookieName != "" {
+		config.Auth.SessionCookieName = cookieName
+	}
+	if cookieDomain := os.Getenv("VIRE_AUTH_SESSION_COOKIE_DOMAIN"); cookieDomain != "" {
+		config.Auth.SessionCookieDomain = cookieDomain
+	}
+	if devLogin := os.Getenv("VIRE_AUTH_DEV_LOGIN"); devLogin != "" {
+		if b, err := strconv.ParseBool(devLogin); err == nil {
+			config.Auth.DevLogin = b
+		}
+	}
 	if portalURL := os.Getenv("VIRE_PORTAL_URL"); portalURL != "" {
 		config.Auth.PortalURL = portalURL
 		config.Portal.URL = portalURL
 	}
+	if tokenStore := os.Getenv("VIRE_PORTAL_TOKEN_STORE"); tokenStore != "" {
+		config.Portal.TokenStore = tokenStore
+	}
+	if caCertFile := os.Getenv("VIRE_PORTAL_CA_CERT_FILE"); caCertFile != "" {
+		config.Portal.CACertFile = caCertFile
+	}
+	if offline := os.Getenv("VIRE_PORTAL_OFFLINE"); offline != "" {
+		if b, err := strconv.ParseBool(offline); err == nil {
+			config.Portal.Offline = b
+		}
+	}
+	if callbackPort := os.Getenv("VIRE_PORTAL_CALLBACK_PORT"); callbackPort != "" {
+		if p, err := strconv.Atoi(callbackPort); err == nil {
+			config.Portal.CallbackPort = p
+		}
+	}
+	if portRange := os.Getenv("VIRE_PORTAL_CALLBACK_PORT_RANGE"); portRange != "" {
+		config.Portal.CallbackPortRange = portRange
+	}
 }
 
 // ApplyFlagOverrides applies command-line flag overrides to config.
@@ -248,3 +679,14 @@ func ApplyFlagOverrides(config *Config, port int, host string) {
 		config.Server.Host = host
 	}
 }
+
+// splitList splits a comma-separated value into trimmed, non-empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -1,13 +1,78 @@
 package config
 
+// DefaultMaxBodyBytes is the default request body limit (1MB).
+const DefaultMaxBodyBytes = 1 << 20
+
+// Default HTTP server timeouts. The header timeout cuts off slowloris
+// clients; the write timeout is generous because proxied vire-server calls
+// can take minutes.
+const (
+	DefaultReadHeaderTimeoutSeconds = 10
+	DefaultReadTimeoutSeconds       = 30
+	DefaultWriteTimeoutSeconds      = 300
+	DefaultIdleTimeoutSeconds       = 120
+)
+
+// DefaultMCPMaxInflight is the default cap on concurrent MCP proxy requests
+// to vire-server.
+const DefaultMCPMaxInflight = 32
+
+// DefaultMCPQueueTimeoutSeconds is how long an MCP proxy request waits for
+// a free slot before failing with a "server busy" error.
+const DefaultMCPQueueTimeoutSeconds = 30
+
+// DefaultMCPToolTimeoutSeconds is the per-call deadline for catalog tools
+// without a timeout_seconds of their own.
+const DefaultMCPToolTimeoutSeconds = 300
+
+// DefaultMCPMaxMessageBytes caps inbound JSON-RPC messages on /mcp (4MB).
+const DefaultMCPMaxMessageBytes = 4 << 20
+
+// Default upstream connection pool settings for the MCP proxy. Idle
+// connections per host match the in-flight cap so a busy proxy can keep
+// every connection alive between calls.
+const (
+	DefaultMCPMaxIdleConnsPerHost    = DefaultMCPMaxInflight
+	DefaultMCPIdleConnTimeoutSeconds = 90
+	DefaultMCPDialTimeoutSeconds     = 10
+)
+
+// DefaultDisplayCurrency is the display currency used when user.display_currency
+// is unset (vire-server's own default).
+const DefaultDisplayCurrency = "AUD"
+
+// DefaultTimezone is the display timezone used when user.timezone is unset.
+const DefaultTimezone = "Australia/Sydney"
+
+// DefaultConcentrationThresholdPct is the holding weight above which the
+// holdings table flags a position as overweight.
+const DefaultConcentrationThresholdPct = 10.0
+
+// DefaultContentSecurityPolicy allows the portal's own assets, the
+// jsDelivr CDN that serves Alpine.js, Chart.js and marked, and Google
+// Fonts. Inline styles are needed for x-cloak and Alpine style bindings;
+// 'unsafe-eval' is needed by Alpine's expression evaluator.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src 'self' https://fonts.gstatic.com; " +
+	"script-src 'self' 'unsafe-inline' 'unsafe-eval' https://cdn.jsdelivr.net; " +
+	"connect-src 'self' https://cdn.jsdelivr.net"
+
 // NewDefaultConfig creates a configuration with default values.
 func NewDefaultConfig() *Config {
 	return &Config{
 		Environment: "prod",
 		AdminUsers:  "",
 		Server: ServerConfig{
-			Port: 8080,
-			Host: "0.0.0.0",
+			Port:                  8080,
+			Host:                  "0.0.0.0",
+			MaxBodyBytes:          DefaultMaxBodyBytes,
+			RedirectTrailingSlash: true,
+
+			ReadHeaderTimeoutSeconds: DefaultReadHeaderTimeoutSeconds,
+			ReadTimeoutSeconds:       DefaultReadTimeoutSeconds,
+			WriteTimeoutSeconds:      DefaultWriteTimeoutSeconds,
+			IdleTimeoutSeconds:       DefaultIdleTimeoutSeconds,
 		},
 		API: APIConfig{
 			URL: "http://localhost:8080",
@@ -16,14 +81,17 @@ func NewDefaultConfig() *Config {
 			URL: "http://localhost:8080",
 		},
 		Auth: AuthConfig{
-			JWTSecret:   "",
-			CallbackURL: "http://localhost:8080/auth/callback",
-			PortalURL:   "",
+			JWTSecret:           "",
+			CallbackURL:         "http://localhost:8080/auth/callback",
+			PortalURL:           "",
+			SessionCookieName:   DefaultSessionCookieName,
+			SessionCookieDomain: "",
 		},
 		Service: ServiceConfig{},
 		User: UserConfig{
-			Portfolios:      []string{},
-			DisplayCurrency: "",
+			Portfolios:                []string{},
+			DisplayCurrency:           "",
+			ConcentrationThresholdPct: DefaultConcentrationThresholdPct,
 		},
 		Logging: LoggingConfig{
 			Level:    "info",
@@ -31,8 +99,26 @@ func NewDefaultConfig() *Config {
 			Outputs:  []string{"console", "file"},
 			FilePath: "logs/vire-portal.log",
 		},
+		Audit: AuditConfig{
+			Outputs:  []string{"file"},
+			FilePath: "logs/vire-portal-audit.log",
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Authorization"},
+			MaxAgeSeconds:  600,
+		},
 		MCP: MCPConfig{
-			CatalogRetries: 3,
+			CatalogRetries:      3,
+			MaxInflight:         DefaultMCPMaxInflight,
+			QueueTimeoutSeconds: DefaultMCPQueueTimeoutSeconds,
+			MaxMessageBytes:     DefaultMCPMaxMessageBytes,
+			ToolTimeoutSeconds:  DefaultMCPToolTimeoutSeconds,
+
+			MaxIdleConnsPerHost:    DefaultMCPMaxIdleConnsPerHost,
+			IdleConnTimeoutSeconds: DefaultMCPIdleConnTimeoutSeconds,
+			DialTimeoutSeconds:     DefaultMCPDialTimeoutSeconds,
 		},
 	}
 }
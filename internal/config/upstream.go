@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HostAllowList is the set of upstream hosts the portal may send requests to.
+// Entries are "host:port" (exact) or "host" (any port), matched case-insensitively.
+type HostAllowList struct {
+	entries map[string]bool
+}
+
+// NewHostAllowList allows the host and port of upstreamURL plus any extra
+// entries (see APIConfig.AllowedHosts). An unparseable upstreamURL adds nothing,
+// so requests are refused unless extra allows them.
+func NewHostAllowList(upstreamURL string, extra []string) *HostAllowList {
+	l := &HostAllowList{entries: make(map[string]bool)}
+	if u, err := url.Parse(upstreamURL); err == nil && u.Hostname() != "" {
+		l.entries[hostPortKey(u)] = true
+	}
+	for _, e := range extra {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if e == "" {
+			continue
+		}
+		if host, port, err := net.SplitHostPort(e); err == nil {
+			l.entries[net.JoinHostPort(host, port)] = true
+		} else {
+			l.entries[strings.Trim(e, "[]")] = true
+		}
+	}
+	return l
+}
+
+// hostPortKey returns u's lower-cased host and effective port ("host:443").
+func hostPortKey(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+// Check returns an error if u's host is not allow-listed.
+func (l *HostAllowList) Check(u *url.URL) error {
+	key := hostPortKey(u)
+	if l.entries[key] || l.entries[strings.ToLower(u.Hostname())] {
+		return nil
+	}
+	return fmt.Errorf("upstream host %s is not in the allow-list", key)
+}
+
+// RoundTripper wraps next so requests to hosts outside the allow-list are
+// refused before dialing.
+func (l *HostAllowList) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return allowListTransport{list: l, next: next}
+}
+
+// Client returns an HTTP client with the given timeout (0 for none) whose
+// requests, including followed redirects, are limited to the allow-list.
+func (l *HostAllowList) Client(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: l.RoundTripper(nil)}
+}
+
+// allowListTransport enforces a HostAllowList on each request.
+type allowListTransport struct {
+	list *HostAllowList
+	next http.RoundTripper
+}
+
+func (t allowListTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.list.Check(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
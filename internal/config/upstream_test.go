@@ -0,0 +1,63 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHostAllowList_Check(t *testing.T) {
+	l := NewHostAllowList("http://vire-server:4242", []string{"backup.internal", "[::1]:9000", " "})
+
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{"http://vire-server:4242/api/health", true},
+		{"http://VIRE-SERVER:4242/api/health", true},
+		{"http://vire-server:8080/api/health", false}, // configured host, other port
+		{"http://backup.internal:1234/x", true},       // bare host allows any port
+		{"https://backup.internal/x", true},
+		{"http://[::1]:9000/x", true},
+		{"http://[::1]:9001/x", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://evil.example.com/", false},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.target)
+		if err := l.Check(u); (err == nil) != tt.allowed {
+			t.Errorf("Check(%s): expected allowed=%v, got err=%v", tt.target, tt.allowed, err)
+		}
+	}
+}
+
+func TestHostAllowList_DefaultPortFromScheme(t *testing.T) {
+	l := NewHostAllowList("https://vire.example.com", nil)
+	u, _ := url.Parse("https://vire.example.com:443/api")
+	if err := l.Check(u); err != nil {
+		t.Errorf("expected implicit https port 443 allowed, got %v", err)
+	}
+	u, _ = url.Parse("http://vire.example.com/api")
+	if err := l.Check(u); err == nil {
+		t.Error("expected port 80 refused when only https upstream configured")
+	}
+}
+
+func TestHostAllowList_RoundTripperRefusesBeforeDialing(t *testing.T) {
+	hit := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer other.Close()
+
+	client := &http.Client{Transport: NewHostAllowList("http://vire-server:4242", nil).RoundTripper(nil)}
+	_, err := client.Get(other.URL)
+	if err == nil || !strings.Contains(err.Error(), "not in the allow-list") {
+		t.Errorf("expected allow-list error, got %v", err)
+	}
+	if hit {
+		t.Error("expected non-allow-listed host not to be contacted")
+	}
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 )
 
 // Version information (set via -ldflags during build).
@@ -30,3 +32,25 @@ func GetGitCommit() string {
 func GetFullVersion() string {
 	return fmt.Sprintf("%s (build: %s, commit: %s)", Version, Build, GitCommit)
 }
+
+// VersionInfo is the machine-readable version printed by -version -json.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Build     string `json:"build"`
+	GitCommit string `json:"git_commit"`
+}
+
+// GetVersionInfo returns the current version, build and commit.
+func GetVersionInfo() VersionInfo {
+	return VersionInfo{Version: GetVersion(), Build: GetBuild(), GitCommit: GetGitCommit()}
+}
+
+// WriteVersion writes the -version output for the named binary to w:
+// "<name> version X", or GetVersionInfo as a JSON object when asJSON is set.
+func WriteVersion(w io.Writer, name string, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(GetVersionInfo())
+	}
+	_, err := fmt.Fprintf(w, "%s version %s\n", name, GetVersion())
+	return err
+}
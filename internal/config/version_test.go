@@ -1,6 +1,10 @@
 package config
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
 
 func TestGetVersion(t *testing.T) {
 	// Default should be "dev"
@@ -31,3 +35,34 @@ func TestGetFullVersion(t *testing.T) {
 		t.Errorf("expected full version %q, got %q", expected, fv)
 	}
 }
+
+func TestWriteVersion_Text(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteVersion(&buf, "vire-portal", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := buf.String(); got != "vire-portal version dev\n" {
+		t.Errorf("expected text version line, got %q", got)
+	}
+}
+
+func TestWriteVersion_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteVersion(&buf, "vire-portal", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", buf.String(), err)
+	}
+	want := map[string]string{"version": "dev", "build": "unknown", "git_commit": "unknown"}
+	if len(got) != len(want) {
+		t.Errorf("expected exactly %v, got %v", want, got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+)
+
+// AllocationHandler serves a portfolio's sector and style weights in a shape
+// charting libraries take directly (parallel labels and data arrays).
+type AllocationHandler struct {
+	logger        *common.Logger
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	proxyPostFn   func(path, userID string, body []byte) ([]byte, error)
+}
+
+// NewAllocationHandler creates a new portfolio allocation handler.
+func NewAllocationHandler(logger *common.Logger, jwtSecret []byte) *AllocationHandler {
+	return &AllocationHandler{
+		logger:    logger,
+		jwtSecret: jwtSecret,
+	}
+}
+
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *AllocationHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
+// SetProxyPostFn sets the proxy POST function used to run the portfolio review.
+func (h *AllocationHandler) SetProxyPostFn(fn func(path, userID string, body []byte) ([]byte, error)) {
+	h.proxyPostFn = fn
+}
+
+// allocationSeries is one chart's data: Data[i] is the weight (%) of Labels[i].
+type allocationSeries struct {
+	Labels []string  `json:"labels"`
+	Data   []float64 `json:"data"`
+}
+
+// allocationResult is the JSON response of HandleAllocation.
+type allocationResult struct {
+	Portfolio string           `json:"portfolio"`
+	Sectors   allocationSeries `json:"sectors"`
+	Styles    allocationSeries `json:"styles"`
+}
+
+// HandleAllocation handles GET /api/portfolios/{name}/allocation.
+// Sector weights come from the review's portfolio_balance.sector_allocations,
+// heaviest first; style weights are its defensive, growth and income weights.
+// A review without balance data returns empty series rather than an error.
+// vire-server 4xx responses are relayed.
+func (h *AllocationHandler) HandleAllocation(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteErrorCode(w, http.StatusUnauthorized, ErrCodeUnauthorized, "authentication required")
+		return
+	}
+	name := r.PathValue("name")
+	if name == "" {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "portfolio name is required")
+		return
+	}
+	if h.proxyPostFn == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "allocation service unavailable")
+		return
+	}
+
+	body, err := h.proxyPostFn("/api/portfolios/"+url.PathEscape(name)+"/review", claims.Sub, nil)
+	if err != nil {
+		var perr *client.ProxyError
+		if errors.As(err, &perr) && perr.StatusCode >= 400 && perr.StatusCode < 500 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(perr.StatusCode)
+			w.Write([]byte(perr.Body))
+			return
+		}
+		if h.logger != nil {
+			h.logger.Warn().Str("portfolio", name).Str("error", err.Error()).Msg("failed to load review for allocation")
+		}
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamUnavailable, "failed to load portfolio review")
+		return
+	}
+
+	var review models.PortfolioReview
+	if err := json.Unmarshal(body, &review); err != nil {
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "invalid portfolio review")
+		return
+	}
+	WriteJSON(w, http.StatusOK, buildAllocation(name, review.PortfolioBalance))
+}
+
+// buildAllocation converts a review's balance into chart series. A nil
+// balance yields empty (not null) series.
+func buildAllocation(name string, balance *models.PortfolioBalance) allocationResult {
+	result := allocationResult{
+		Portfolio: name,
+		Sectors:   allocationSeries{Labels: []string{}, Data: []float64{}},
+		Styles:    allocationSeries{Labels: []string{}, Data: []float64{}},
+	}
+	if balance == nil {
+		return result
+	}
+
+	sectors := make([]models.SectorAllocation, 0, len(balance.SectorAllocations))
+	for _, s := range balance.SectorAllocations {
+		if s.Sector == "" {
+			s.Sector = "Other"
+		}
+		sectors = append(sectors, s)
+	}
+	sort.SliceStable(sectors, func(i, j int) bool { return sectors[i].Weight > sectors[j].Weight })
+	for _, s := range sectors {
+		result.Sectors.Labels = append(result.Sectors.Labels, s.Sector)
+		result.Sectors.Data = append(result.Sectors.Data, s.Weight)
+	}
+
+	result.Styles.Labels = []string{"Defensive", "Growth", "Income"}
+	result.Styles.Data = []float64{balance.DefensiveWeight, balance.GrowthWeight, balance.IncomeWeight}
+	return result
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+)
+
+func newAllocationRequest(name string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/portfolios/"+name+"/allocation", nil)
+	req.SetPathValue("name", name)
+	addAuthCookie(req, "dev_user")
+	return req
+}
+
+func TestAllocation_SectorAndStyleWeights(t *testing.T) {
+	handler := NewAllocationHandler(nil, []byte(testJWTSecret))
+	var gotPath, gotUser string
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		gotPath, gotUser = path, userID
+		return []byte(`{"portfolio_name":"SMSF","portfolio_balance":{
+			"sector_allocations":[
+				{"sector":"Financials","weight":25.5,"holdings":["CBA"]},
+				{"sector":"Materials","weight":40,"holdings":["BHP","RIO"]},
+				{"sector":"","weight":4.5,"holdings":["XYZ"]}
+			],
+			"defensive_weight":30,"growth_weight":55,"income_weight":15,"concentration_risk":"medium"}}`), nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleAllocation(w, newAllocationRequest("SMSF"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotPath != "/api/portfolios/SMSF/review" || gotUser != "dev_user" {
+		t.Errorf("unexpected proxy call: path=%s user=%s", gotPath, gotUser)
+	}
+	want := `{"portfolio":"SMSF","sectors":{"labels":["Materials","Financials","Other"],"data":[40,25.5,4.5]},"styles":{"labels":["Defensive","Growth","Income"],"data":[30,55,15]}}`
+	if got := w.Body.String(); got != want+"\n" && got != want {
+		t.Errorf("unexpected body:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestAllocation_NoBalanceData(t *testing.T) {
+	handler := NewAllocationHandler(nil, []byte(testJWTSecret))
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return []byte(`{"portfolio_name":"Empty","holding_reviews":[]}`), nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleAllocation(w, newAllocationRequest("Empty"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	want := `{"portfolio":"Empty","sectors":{"labels":[],"data":[]},"styles":{"labels":[],"data":[]}}`
+	if got := w.Body.String(); got != want+"\n" && got != want {
+		t.Errorf("unexpected body:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestAllocation_RelaysUpstream4xx(t *testing.T) {
+	handler := NewAllocationHandler(nil, []byte(testJWTSecret))
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return nil, &client.ProxyError{StatusCode: http.StatusNotFound, Body: `{"error":"portfolio not found"}`}
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleAllocation(w, newAllocationRequest("Missing"))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestAllocation_RequiresAuth(t *testing.T) {
+	handler := NewAllocationHandler(nil, []byte(testJWTSecret))
+	req := httptest.NewRequest("GET", "/api/portfolios/SMSF/allocation", nil)
+	req.SetPathValue("name", "SMSF")
+
+	w := httptest.NewRecorder()
+	handler.HandleAllocation(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
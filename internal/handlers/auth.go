@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
@@ -76,10 +77,10 @@ func ValidateJWT(token string, secret []byte) (*JWTClaims, error) {
 	return &claims, nil
 }
 
-// IsLoggedIn checks the vire_session cookie and validates the JWT.
+// isLoggedIn validates the session JWT from the cookie named cookieName.
 // In dev mode, also accepts X-Test-Session header for browser testing.
 // Returns (true, claims) if valid, (false, nil) otherwise.
-func IsLoggedIn(r *http.Request, secret []byte) (bool, *JWTClaims) {
+func isLoggedIn(r *http.Request, secret []byte, cookieName string) (bool, *JWTClaims) {
 	// First check for test header (for browser testing in dev mode)
 	if testToken := r.Header.Get("X-Test-Session"); testToken != "" {
 		claims, err := ValidateJWT(testToken, secret)
@@ -88,7 +89,7 @@ func IsLoggedIn(r *http.Request, secret []byte) (bool, *JWTClaims) {
 		}
 	}
 
-	cookie, err := r.Cookie("vire_session")
+	cookie, err := r.Cookie(cookieName)
 	if err != nil || cookie.Value == "" {
 		return false, nil
 	}
@@ -109,12 +110,15 @@ type OAuthCompleter interface {
 
 // AuthHandler handles authentication-related requests.
 type AuthHandler struct {
-	logger      *common.Logger
-	devMode     bool
-	apiURL      string
-	callbackURL string
-	jwtSecret   []byte
-	oauthServer OAuthCompleter
+	logger        *common.Logger
+	devMode       bool
+	devLogin      bool // enables HandleTestLogin; defaults to devMode
+	apiURL        string
+	callbackURL   string
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	oauthServer   OAuthCompleter
+	upstream      http.RoundTripper // limits vire-server requests to the host allow-list
 }
 
 // NewAuthHandler creates a new auth handler.
@@ -122,12 +126,31 @@ func NewAuthHandler(logger *common.Logger, devMode bool, apiURL string, callback
 	return &AuthHandler{
 		logger:      logger,
 		devMode:     devMode,
+		devLogin:    devMode,
 		apiURL:      apiURL,
 		callbackURL: callbackURL,
 		jwtSecret:   jwtSecret,
+		upstream:    config.NewHostAllowList(apiURL, nil).RoundTripper(nil),
 	}
 }
 
+// SetAllowedHosts allows extra upstream hosts ("host" or "host:port") in
+// addition to apiURL's, see config.APIConfig.AllowedHosts.
+func (h *AuthHandler) SetAllowedHosts(hosts []string) {
+	h.upstream = config.NewHostAllowList(h.apiURL, hosts).RoundTripper(nil)
+}
+
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *AuthHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
+// SetDevLogin enables or disables the dev login endpoint (see
+// config.Config.DevLoginEnabled).
+func (h *AuthHandler) SetDevLogin(enabled bool) {
+	h.devLogin = enabled
+}
+
 // SetOAuthServer sets the OAuth server for MCP session completion.
 func (h *AuthHandler) SetOAuthServer(s OAuthCompleter) {
 	h.oauthServer = s
@@ -155,7 +178,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 	bodyJSON, _ := json.Marshal(body)
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Transport: h.upstream, Timeout: 10 * time.Second}
 	resp, err := client.Post(h.apiURL+"/api/auth/login", "application/json", bytes.NewReader(bodyJSON))
 	if err != nil {
 		if h.logger != nil {
@@ -203,13 +226,7 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Set the session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "vire_session",
-		Value:    result.Data.Token,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-	})
+	http.SetCookie(w, h.sessionCookie.issue(result.Data.Token, isSecureCookie(r, h.devMode)))
 
 	http.Redirect(w, r, "/dashboard", http.StatusFound)
 }
@@ -252,6 +269,7 @@ func (h *AuthHandler) proxyOAuthRedirect(w http.ResponseWriter, r *http.Request,
 	}
 
 	client := &http.Client{
+		Transport: h.upstream,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -317,6 +335,7 @@ func (h *AuthHandler) proxyOAuthCallback(w http.ResponseWriter, r *http.Request)
 	}
 
 	client := &http.Client{
+		Transport: h.upstream,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -343,7 +362,7 @@ func (h *AuthHandler) proxyOAuthCallback(w http.ResponseWriter, r *http.Request)
 }
 
 // HandleOAuthCallback handles the OAuth callback from vire-server.
-// GET /auth/callback?token=<jwt> -> sets vire_session cookie, redirects to /dashboard.
+// GET /auth/callback?token=<jwt> -> sets the session cookie, redirects to /dashboard.
 // If mcp_session_id cookie is present, completes the MCP OAuth flow instead.
 func (h *AuthHandler) HandleOAuthCallback(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
@@ -357,13 +376,7 @@ func (h *AuthHandler) HandleOAuthCallback(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "vire_session",
-		Value:    token,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-	})
+	http.SetCookie(w, h.sessionCookie.issue(token, isSecureCookie(r, h.devMode)))
 
 	http.Redirect(w, r, "/dashboard", http.StatusFound)
 }
@@ -437,36 +450,30 @@ func extractJWTSubFromToken(token string) string {
 
 // HandleLogout clears the session cookie and redirects to the landing page.
 func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     "vire_session",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-	})
+	http.SetCookie(w, h.sessionCookie.expire(isSecureCookie(r, h.devMode)))
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
-// HandleTestLogin is a dev-mode only endpoint for browser testing.
+// HandleTestLogin is a dev login endpoint for browser testing, enabled in dev
+// mode or by auth.dev_login.
 // It performs login and returns the session token as JSON instead of redirecting.
 // This allows browser tests to receive the token and set it manually.
 // POST /api/auth/test-login
 func (h *AuthHandler) HandleTestLogin(w http.ResponseWriter, r *http.Request) {
-	if !h.devMode {
-		http.Error(w, "Not available in production", http.StatusForbidden)
+	if !h.devLogin {
+		WriteErrorCode(w, http.StatusForbidden, ErrCodeForbidden, "not available in production")
 		return
 	}
 
 	if err := r.ParseForm(); err != nil {
-		http.Error(w, `{"error":"bad_request"}`, http.StatusBadRequest)
+		writeBodyReadError(w, err, "bad request")
 		return
 	}
 
 	username := strings.TrimSpace(r.FormValue("username"))
 	password := r.FormValue("password")
 	if username == "" || password == "" {
-		http.Error(w, `{"error":"missing_credentials"}`, http.StatusBadRequest)
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeMissingCredentials, "username and password are required")
 		return
 	}
 
@@ -476,20 +483,20 @@ func (h *AuthHandler) HandleTestLogin(w http.ResponseWriter, r *http.Request) {
 	}
 	bodyJSON, _ := json.Marshal(body)
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Transport: h.upstream, Timeout: 10 * time.Second}
 	resp, err := client.Post(h.apiURL+"/api/auth/login", "application/json", bytes.NewReader(bodyJSON))
 	if err != nil {
 		if h.logger != nil {
 			h.logger.Error().Str("error", err.Error()).Msg("test login: failed to reach vire-server")
 		}
-		http.Error(w, `{"error":"server_unavailable"}`, http.StatusServiceUnavailable)
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeUpstreamUnavailable, "authentication server is unavailable")
 		return
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
 	if err != nil {
-		http.Error(w, `{"error":"read_failed"}`, http.StatusInternalServerError)
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "failed to read authentication server response")
 		return
 	}
 
@@ -497,7 +504,7 @@ func (h *AuthHandler) HandleTestLogin(w http.ResponseWriter, r *http.Request) {
 		if h.logger != nil {
 			h.logger.Error().Int("status", resp.StatusCode).Str("body", string(respBody)).Msg("test login: vire-server login failed")
 		}
-		http.Error(w, `{"error":"invalid_credentials"}`, http.StatusUnauthorized)
+		WriteErrorCode(w, http.StatusUnauthorized, ErrCodeInvalidCredentials, "invalid username or password")
 		return
 	}
 
@@ -508,7 +515,7 @@ func (h *AuthHandler) HandleTestLogin(w http.ResponseWriter, r *http.Request) {
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil || result.Data.Token == "" {
-		http.Error(w, `{"error":"invalid_response"}`, http.StatusInternalServerError)
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "invalid authentication server response")
 		return
 	}
 
@@ -516,6 +523,6 @@ func (h *AuthHandler) HandleTestLogin(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{
 		"status":  "ok",
 		"token":   result.Data.Token,
-		"message": "Use this token in X-Test-Session header or set as vire_session cookie",
+		"message": "Use this token in X-Test-Session header or set as " + h.sessionCookie.cookieName() + " cookie",
 	})
 }
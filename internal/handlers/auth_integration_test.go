@@ -275,3 +275,43 @@ func TestOAuthRedirect_GitHubCallbackChain(t *testing.T) {
 		t.Error("expected cookie value to match the provided token")
 	}
 }
+
+func TestTestLogin_ErrorCodes(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer mockServer.Close()
+
+	tests := []struct {
+		name     string
+		devMode  bool
+		devLogin bool
+		body     string
+		status   int
+		code     string
+	}{
+		{"prod mode", false, false, "username=u&password=p", http.StatusForbidden, ErrCodeForbidden},
+		{"dev login outside dev mode", false, true, "username=u&password=wrong", http.StatusUnauthorized, ErrCodeInvalidCredentials},
+		{"missing credentials", true, true, "username=u", http.StatusBadRequest, ErrCodeMissingCredentials},
+		{"rejected credentials", true, true, "username=u&password=wrong", http.StatusUnauthorized, ErrCodeInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuthHandler(nil, tt.devMode, mockServer.URL, "http://localhost:8500/auth/callback", []byte(""))
+			handler.SetDevLogin(tt.devLogin)
+
+			req := httptest.NewRequest("POST", "/api/auth/test-login", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			handler.HandleTestLogin(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if code := errorCode(t, w); code != tt.code {
+				t.Errorf("expected code %s, got %q", tt.code, code)
+			}
+		})
+	}
+}
@@ -214,7 +214,7 @@ func TestValidateJWT_StressEmptySecret_AcceptsForgedToken(t *testing.T) {
 func TestIsLoggedIn_StressEmptyCookieValue(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: ""})
-	loggedIn, _ := IsLoggedIn(req, []byte{})
+	loggedIn, _ := SessionCookie{}.IsLoggedIn(req, []byte{})
 	if loggedIn {
 		t.Error("expected not logged in with empty cookie value")
 	}
@@ -223,7 +223,7 @@ func TestIsLoggedIn_StressEmptyCookieValue(t *testing.T) {
 func TestIsLoggedIn_StressExpiredToken(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildExpiredJWT("alice")})
-	loggedIn, _ := IsLoggedIn(req, []byte{})
+	loggedIn, _ := SessionCookie{}.IsLoggedIn(req, []byte{})
 	if loggedIn {
 		t.Error("expected not logged in with expired token")
 	}
@@ -236,7 +236,7 @@ func TestIsLoggedIn_StressWrongSecretRejectsToken(t *testing.T) {
 
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
-	loggedIn, _ := IsLoggedIn(req, wrongSecret)
+	loggedIn, _ := SessionCookie{}.IsLoggedIn(req, wrongSecret)
 	if loggedIn {
 		t.Error("SECURITY: IsLoggedIn accepted token signed with wrong secret")
 	}
@@ -253,7 +253,7 @@ func TestIsLoggedIn_StressConcurrentValidation(t *testing.T) {
 			defer wg.Done()
 			req := httptest.NewRequest("GET", "/", nil)
 			req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
-			loggedIn, claims := IsLoggedIn(req, secret)
+			loggedIn, claims := SessionCookie{}.IsLoggedIn(req, secret)
 			if !loggedIn || claims == nil || claims.Sub != "alice" {
 				t.Error("concurrent IsLoggedIn failed")
 			}
@@ -1176,7 +1176,7 @@ func TestOAuthCallback_StressArbitraryTokenStored(t *testing.T) {
 	// Now verify that IsLoggedIn correctly rejects this expired token
 	req2 := httptest.NewRequest("GET", "/dashboard", nil)
 	req2.AddCookie(sessionCookie)
-	loggedIn, _ := IsLoggedIn(req2, []byte("secret"))
+	loggedIn, _ := SessionCookie{}.IsLoggedIn(req2, []byte("secret"))
 	if loggedIn {
 		t.Error("SECURITY: expired token from callback is accepted by IsLoggedIn")
 	}
@@ -1454,8 +1454,8 @@ func TestGetServerVersion_StressHostileVersionValues(t *testing.T) {
 }
 
 func TestGetServerVersion_StressServerRedirects(t *testing.T) {
-	// Test that GetServerVersion follows redirects (Go's default behavior).
-	// A malicious server could redirect to internal endpoints.
+	// A redirect to another host is refused by the upstream allow-list, so a
+	// malicious server cannot point the probe at internal endpoints.
 	finalServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{"version":"redirected"}`))
 	}))
@@ -1466,14 +1466,23 @@ func TestGetServerVersion_StressServerRedirects(t *testing.T) {
 	}))
 	defer redirectServer.Close()
 
-	// By default, Go's http.Client follows up to 10 redirects
-	version := GetServerVersion(redirectServer.URL)
-	if version != "redirected" {
-		t.Errorf("expected 'redirected', got %q", version)
+	if version := GetServerVersion(redirectServer.URL); version != "unavailable" {
+		t.Errorf("expected cross-host redirect to be refused, got %q", version)
+	}
+
+	// Redirects within the configured host are still followed
+	sameHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/version" {
+			http.Redirect(w, r, "/api/v2/version", http.StatusFound)
+			return
+		}
+		w.Write([]byte(`{"version":"redirected"}`))
+	}))
+	defer sameHost.Close()
+
+	if version := GetServerVersion(sameHost.URL); version != "redirected" {
+		t.Errorf("expected same-host redirect to be followed, got %q", version)
 	}
-	// FINDING: GetServerVersion follows redirects. If apiURL is ever user-controlled,
-	// this could be exploited for SSRF. Since it comes from config, this is mitigated.
-	t.Log("NOTE: GetServerVersion follows redirects. Ensure apiURL is never user-controlled.")
 }
 
 func TestGetServerVersion_StressRedirectLoop(t *testing.T) {
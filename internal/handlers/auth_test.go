@@ -219,7 +219,7 @@ func TestIsLoggedIn_ValidCookie(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
 
-	loggedIn, result := IsLoggedIn(req, secret)
+	loggedIn, result := SessionCookie{}.IsLoggedIn(req, secret)
 	if !loggedIn {
 		t.Error("expected IsLoggedIn to return true")
 	}
@@ -234,7 +234,7 @@ func TestIsLoggedIn_ValidCookie(t *testing.T) {
 func TestIsLoggedIn_NoCookie(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 
-	loggedIn, claims := IsLoggedIn(req, []byte("secret"))
+	loggedIn, claims := SessionCookie{}.IsLoggedIn(req, []byte("secret"))
 	if loggedIn {
 		t.Error("expected IsLoggedIn to return false with no cookie")
 	}
@@ -247,7 +247,7 @@ func TestIsLoggedIn_InvalidCookie(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: "not-a-jwt"})
 
-	loggedIn, claims := IsLoggedIn(req, []byte("secret"))
+	loggedIn, claims := SessionCookie{}.IsLoggedIn(req, []byte("secret"))
 	if loggedIn {
 		t.Error("expected IsLoggedIn to return false for invalid cookie")
 	}
@@ -267,7 +267,7 @@ func TestIsLoggedIn_ExpiredCookie(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
 
-	loggedIn, result := IsLoggedIn(req, secret)
+	loggedIn, result := SessionCookie{}.IsLoggedIn(req, secret)
 	if loggedIn {
 		t.Error("expected IsLoggedIn to return false for expired token")
 	}
@@ -283,7 +283,7 @@ func TestIsLoggedIn_EmptySecret(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
 
-	loggedIn, result := IsLoggedIn(req, []byte{})
+	loggedIn, result := SessionCookie{}.IsLoggedIn(req, []byte{})
 	if !loggedIn {
 		t.Error("expected IsLoggedIn to return true with empty secret and valid unsigned token")
 	}
@@ -725,6 +725,112 @@ func TestHandleOAuthCallback_CookieAttributes(t *testing.T) {
 	}
 }
 
+// --- Configurable session cookie ---
+
+func TestSessionCookie_Defaults(t *testing.T) {
+	c := SessionCookie{}.issue("token", false)
+	if c.Name != "vire_session" {
+		t.Errorf("expected default cookie name vire_session, got %s", c.Name)
+	}
+	if c.Domain != "" {
+		t.Errorf("expected host-only cookie by default, got domain %s", c.Domain)
+	}
+}
+
+func TestHandleOAuthCallback_ConfiguredCookieNameAndDomain(t *testing.T) {
+	handler := NewAuthHandler(nil, true, "http://localhost:8080", "http://localhost:8500/auth/callback", []byte(""))
+	handler.SetSessionCookie(SessionCookie{Name: "portal_sid", Domain: "portal.example.com"})
+
+	req := httptest.NewRequest("GET", "/auth/callback?token=valid-token", nil)
+	w := httptest.NewRecorder()
+
+	handler.HandleOAuthCallback(w, req)
+
+	var sessionCookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "vire_session" {
+			t.Error("default cookie name should not be set when a custom name is configured")
+		}
+		if c.Name == "portal_sid" {
+			sessionCookie = c
+		}
+	}
+	if sessionCookie == nil {
+		t.Fatal("expected portal_sid cookie")
+	}
+	if sessionCookie.Domain != "portal.example.com" {
+		t.Errorf("expected cookie domain portal.example.com, got %s", sessionCookie.Domain)
+	}
+}
+
+func TestLogoutHandler_ConfiguredCookieNameAndDomain(t *testing.T) {
+	handler := NewAuthHandler(nil, true, "", "", []byte{})
+	handler.SetSessionCookie(SessionCookie{Name: "portal_sid", Domain: "portal.example.com"})
+
+	req := httptest.NewRequest("POST", "/api/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "portal_sid", Value: "some-token"})
+	w := httptest.NewRecorder()
+
+	handler.HandleLogout(w, req)
+
+	var sessionCookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "portal_sid" {
+			sessionCookie = c
+		}
+	}
+	if sessionCookie == nil {
+		t.Fatal("expected portal_sid cookie in logout response")
+	}
+	if sessionCookie.MaxAge >= 0 {
+		t.Errorf("expected negative MaxAge to clear the cookie, got %d", sessionCookie.MaxAge)
+	}
+	// The clearing cookie must carry the same domain or the browser keeps the original.
+	if sessionCookie.Domain != "portal.example.com" {
+		t.Errorf("expected cookie domain portal.example.com, got %s", sessionCookie.Domain)
+	}
+}
+
+func TestIsLoggedIn_ConfiguredCookieName(t *testing.T) {
+	cookie := SessionCookie{Name: "portal_sid"}
+	secret := []byte("test-secret")
+	token := buildSignedJWT(map[string]interface{}{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}, secret)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "portal_sid", Value: token})
+	if loggedIn, _ := cookie.IsLoggedIn(req, secret); !loggedIn {
+		t.Error("expected IsLoggedIn to accept the configured cookie name")
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+	if loggedIn, _ := cookie.IsLoggedIn(req, secret); loggedIn {
+		t.Error("expected IsLoggedIn to ignore the default cookie name when a custom name is configured")
+	}
+}
+
+func TestSessionCookie_PerHandler(t *testing.T) {
+	// Two handlers in one process keep their own cookie settings
+	portal := NewAuthHandler(nil, true, "", "", []byte{})
+	portal.SetSessionCookie(SessionCookie{Name: "portal_sid"})
+	other := NewAuthHandler(nil, true, "", "", []byte{})
+
+	for _, tc := range []struct {
+		handler *AuthHandler
+		want    string
+	}{{portal, "portal_sid"}, {other, "vire_session"}} {
+		w := httptest.NewRecorder()
+		tc.handler.HandleLogout(w, httptest.NewRequest("POST", "/api/auth/logout", nil))
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != tc.want {
+			t.Errorf("expected only %s cleared, got %v", tc.want, cookies)
+		}
+	}
+}
+
 // --- Logout still works with new constructor ---
 
 func TestLogoutHandler_WorksWithNewConstructor(t *testing.T) {
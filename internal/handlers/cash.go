@@ -5,7 +5,6 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
-	"path/filepath"
 
 	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
@@ -14,21 +13,19 @@ import (
 
 // CashHandler serves the cash page with cash transaction display.
 type CashHandler struct {
-	logger       *common.Logger
-	templates    *template.Template
-	devMode      bool
-	jwtSecret    []byte
-	userLookupFn func(string) (*client.UserProfile, error)
-	apiURL       string
-	proxyGetFn   func(path, userID string) ([]byte, error)
+	logger        *common.Logger
+	templates     *pageTemplates
+	devMode       bool
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	userLookupFn  func(string) (*client.UserProfile, error)
+	apiURL        string
+	proxyGetFn    func(path, userID string) ([]byte, error)
 }
 
 // NewCashHandler creates a new cash handler.
 func NewCashHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *CashHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &CashHandler{
 		logger:       logger,
@@ -39,6 +36,11 @@ func NewCashHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userL
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *CashHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *CashHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -51,7 +53,7 @@ func (h *CashHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, error)
 
 // ServeHTTP renders the cash page.
 func (h *CashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 	// Redirect unauthenticated users to landing page
 	if !loggedIn {
@@ -99,12 +101,14 @@ func (h *CashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Page":             "cash",
+		"BasePath":         BasePath(r),
+		"Locale":           RequestLocale(r),
 		"DevMode":          h.devMode,
 		"LoggedIn":         loggedIn,
 		"NavexaKeyMissing": navexaKeyMissing,
 		"UserRole":         userRole,
 		"PortalVersion":    config.GetVersion(),
-		"ServerVersion":    GetServerVersion(h.apiURL),
+		"ServerVersion":    CachedServerVersion(h.apiURL),
 		"PortfoliosJSON":   portfoliosJSON,
 		"TransactionsJSON": transactionsJSON,
 	}
@@ -5,33 +5,39 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/cache"
 	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
 )
 
 // DashboardHandler serves the dashboard page with portfolio management UI.
 type DashboardHandler struct {
-	logger       *common.Logger
-	templates    *template.Template
-	devMode      bool
-	jwtSecret    []byte
-	userLookupFn func(string) (*client.UserProfile, error)
-	apiURL       string
-	proxyGetFn   func(path, userID string) ([]byte, error)
+	logger        *common.Logger
+	templates     *pageTemplates
+	devMode       bool
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	userLookupFn  func(string) (*client.UserProfile, error)
+	apiURL        string
+	proxyGetFn    func(path, userID string) ([]byte, error)
+	// overweightPct flags holdings above this weight in the SSR holdings table.
+	overweightPct float64
+	// summaryCache holds each user's /api/dashboard/summary responses, see
+	// HandleSummary. clock stamps their Last-Modified and dates the SSR
+	// holdings table's stale-price notice.
+	summaryCache *cache.ResponseCache
+	clock        common.Clock
 }
 
 // NewDashboardHandler creates a new dashboard handler.
 func NewDashboardHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *DashboardHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &DashboardHandler{
 		logger:       logger,
@@ -39,14 +45,33 @@ func NewDashboardHandler(logger *common.Logger, devMode bool, jwtSecret []byte,
 		devMode:      devMode,
 		jwtSecret:    jwtSecret,
 		userLookupFn: userLookupFn,
+		summaryCache: cache.New(summaryCacheTTL, summaryCacheMaxEntries),
+		clock:        common.SystemClock,
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *DashboardHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *DashboardHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
 }
 
+// SetConcentrationThreshold sets the holding weight (percent) above which the
+// SSR holdings table flags a position as overweight. 0 disables the flag.
+func (h *DashboardHandler) SetConcentrationThreshold(pct float64) {
+	h.overweightPct = pct
+}
+
+// SetClock sets the clock used to stamp cached summaries' Last-Modified and
+// to date the holdings table's stale-price notice.
+func (h *DashboardHandler) SetClock(c common.Clock) {
+	h.clock = c
+}
+
 // SetProxyGetFn sets the proxy GET function for SSR data fetching.
 func (h *DashboardHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, error)) {
 	h.proxyGetFn = fn
@@ -54,7 +79,7 @@ func (h *DashboardHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, e
 
 // ServeHTTP renders the dashboard page.
 func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 	// Redirect unauthenticated users to landing page
 	if !loggedIn {
@@ -101,6 +126,7 @@ func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	glossaryJSON = "null"
 	selectedJSON = `""`
 	selectedPortfolio := ""
+	var holdingsHTML template.HTML
 
 	if h.proxyGetFn != nil && claims != nil && claims.Sub != "" {
 		ssrStart := time.Now()
@@ -114,100 +140,87 @@ func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				h.logger.Info().Int64("duration_ms", time.Since(t1).Milliseconds()).Msg("dashboard SSR: portfolios")
 			}
 
-			// Parse to find default/selected portfolio name
-			var pData struct {
-				Portfolios []struct {
-					Name string `json:"name"`
-				} `json:"portfolios"`
-				Default string `json:"default"`
+			// Priority: URL path > default > first portfolio
+			selected := resolvePortfolio(body, urlPortfolio)
+			selectedPortfolio = selected
+			if b, err := json.Marshal(selected); err == nil {
+				selectedJSON = template.JS(b)
 			}
-			if json.Unmarshal(body, &pData) == nil {
-				// Priority: URL path > default > first portfolio
-				selected := urlPortfolio
-				if selected != "" {
-					// Validate URL portfolio exists in the list
-					found := false
-					for _, p := range pData.Portfolios {
-						if p.Name == selected {
-							found = true
-							break
+
+			if selected != "" {
+				// Fetch portfolio data, timeline, watchlist, glossary in parallel
+				escapedName := url.PathEscape(selected)
+				userID := claims.Sub
+				var wg sync.WaitGroup
+				wg.Add(4)
+
+				go func() {
+					defer wg.Done()
+					t2 := time.Now()
+					if pBody, err := h.proxyGetFn("/api/portfolios/"+escapedName, userID); err == nil {
+						portfolioJSON = template.JS(pBody)
+						if h.logger != nil {
+							h.logger.Info().Int64("duration_ms", time.Since(t2).Milliseconds()).Str("portfolio", selected).Msg("dashboard SSR: portfolio data")
 						}
+					} else if h.logger != nil {
+						h.logger.Warn().Int64("duration_ms", time.Since(t2).Milliseconds()).Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: portfolio data failed")
 					}
-					if !found {
-						selected = ""
-					}
-				}
-				if selected == "" {
-					selected = pData.Default
-				}
-				if selected == "" && len(pData.Portfolios) > 0 {
-					selected = pData.Portfolios[0].Name
-				}
-				selectedPortfolio = selected
-				if b, err := json.Marshal(selected); err == nil {
-					selectedJSON = template.JS(b)
-				}
+				}()
 
-				if selected != "" {
-					// Fetch portfolio data, timeline, watchlist, glossary in parallel
-					escapedName := url.PathEscape(selected)
-					userID := claims.Sub
-					var wg sync.WaitGroup
-					wg.Add(4)
-
-					go func() {
-						defer wg.Done()
-						t2 := time.Now()
-						if pBody, err := h.proxyGetFn("/api/portfolios/"+escapedName, userID); err == nil {
-							portfolioJSON = template.JS(pBody)
-							if h.logger != nil {
-								h.logger.Info().Int64("duration_ms", time.Since(t2).Milliseconds()).Str("portfolio", selected).Msg("dashboard SSR: portfolio data")
-							}
-						} else if h.logger != nil {
-							h.logger.Warn().Int64("duration_ms", time.Since(t2).Milliseconds()).Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: portfolio data failed")
+				go func() {
+					defer wg.Done()
+					t3 := time.Now()
+					if tBody, err := h.proxyGetFn("/api/portfolios/"+escapedName+"/timeline", userID); err == nil {
+						timelineJSON = template.JS(tBody)
+						if h.logger != nil {
+							h.logger.Info().Int64("duration_ms", time.Since(t3).Milliseconds()).Str("portfolio", selected).Msg("dashboard SSR: timeline")
 						}
-					}()
-
-					go func() {
-						defer wg.Done()
-						t3 := time.Now()
-						if tBody, err := h.proxyGetFn("/api/portfolios/"+escapedName+"/timeline", userID); err == nil {
-							timelineJSON = template.JS(tBody)
-							if h.logger != nil {
-								h.logger.Info().Int64("duration_ms", time.Since(t3).Milliseconds()).Str("portfolio", selected).Msg("dashboard SSR: timeline")
-							}
-						} else if h.logger != nil {
-							h.logger.Warn().Int64("duration_ms", time.Since(t3).Milliseconds()).Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: timeline failed")
-						}
-					}()
-
-					go func() {
-						defer wg.Done()
-						t4 := time.Now()
-						if wBody, err := h.proxyGetFn("/api/portfolios/"+escapedName+"/watchlist", userID); err == nil {
-							watchlistJSON = template.JS(wBody)
-							if h.logger != nil {
-								h.logger.Info().Int64("duration_ms", time.Since(t4).Milliseconds()).Str("portfolio", selected).Msg("dashboard SSR: watchlist")
-							}
-						} else if h.logger != nil {
-							h.logger.Warn().Int64("duration_ms", time.Since(t4).Milliseconds()).Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: watchlist failed")
+					} else if h.logger != nil {
+						h.logger.Warn().Int64("duration_ms", time.Since(t3).Milliseconds()).Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: timeline failed")
+					}
+				}()
+
+				go func() {
+					defer wg.Done()
+					t4 := time.Now()
+					if wBody, err := h.proxyGetFn("/api/portfolios/"+escapedName+"/watchlist", userID); err == nil {
+						watchlistJSON = template.JS(wBody)
+						if h.logger != nil {
+							h.logger.Info().Int64("duration_ms", time.Since(t4).Milliseconds()).Str("portfolio", selected).Msg("dashboard SSR: watchlist")
 						}
-					}()
-
-					go func() {
-						defer wg.Done()
-						t5 := time.Now()
-						if gBody, err := h.proxyGetFn("/api/glossary", userID); err == nil {
-							glossaryJSON = template.JS(gBody)
-							if h.logger != nil {
-								h.logger.Info().Int64("duration_ms", time.Since(t5).Milliseconds()).Msg("dashboard SSR: glossary")
-							}
-						} else if h.logger != nil {
-							h.logger.Warn().Int64("duration_ms", time.Since(t5).Milliseconds()).Msg("dashboard SSR: glossary failed")
+					} else if h.logger != nil {
+						h.logger.Warn().Int64("duration_ms", time.Since(t4).Milliseconds()).Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: watchlist failed")
+					}
+				}()
+
+				go func() {
+					defer wg.Done()
+					t5 := time.Now()
+					if gBody, err := h.proxyGetFn("/api/glossary", userID); err == nil {
+						glossaryJSON = template.JS(gBody)
+						if h.logger != nil {
+							h.logger.Info().Int64("duration_ms", time.Since(t5).Milliseconds()).Msg("dashboard SSR: glossary")
 						}
-					}()
+					} else if h.logger != nil {
+						h.logger.Warn().Int64("duration_ms", time.Since(t5).Milliseconds()).Msg("dashboard SSR: glossary failed")
+					}
+				}()
 
-					wg.Wait()
+				wg.Wait()
+
+				// Server-rendered holdings table for the no-JS fallback;
+				// ?group_by=sector buckets it by sector
+				var portfolio models.Portfolio
+				if portfolioJSON != "null" && json.Unmarshal([]byte(portfolioJSON), &portfolio) == nil {
+					if rendered, err := renderHoldingsHTML(portfolio, holdingsTableOptions{
+						GroupBy:       r.URL.Query().Get("group_by"),
+						OverweightPct: h.overweightPct,
+						Clock:         h.clock,
+					}); err == nil {
+						holdingsHTML = rendered
+					} else if h.logger != nil {
+						h.logger.Warn().Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard SSR: holdings table failed")
+					}
 				}
 			}
 		} else if h.logger != nil {
@@ -221,12 +234,14 @@ func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Page":              "dashboard",
+		"BasePath":          BasePath(r),
+		"Locale":            RequestLocale(r),
 		"DevMode":           h.devMode,
 		"LoggedIn":          loggedIn,
 		"NavexaKeyMissing":  navexaKeyMissing,
 		"UserRole":          userRole,
 		"PortalVersion":     config.GetVersion(),
-		"ServerVersion":     GetServerVersion(h.apiURL),
+		"ServerVersion":     CachedServerVersion(h.apiURL),
 		"PortfoliosJSON":    portfoliosJSON,
 		"PortfolioJSON":     portfolioJSON,
 		"TimelineJSON":      timelineJSON,
@@ -234,6 +249,7 @@ func (h *DashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"GlossaryJSON":      glossaryJSON,
 		"SelectedPortfolio": selectedPortfolio,
 		"SelectedJSON":      selectedJSON,
+		"HoldingsHTML":      holdingsHTML,
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "dashboard.html", data); err != nil {
@@ -905,9 +905,12 @@ func TestStrategyHandler_StressReadOnlySectionsPresent(t *testing.T) {
 		t.Error("expected planItems binding in strategy page")
 	}
 
-	// No save buttons
-	if strings.Contains(body, `saveStrategy()`) {
-		t.Error("strategy page should not have saveStrategy() button (read-only)")
+	// Strategy is editable as JSON; the plan stays read-only
+	if !strings.Contains(body, `saveStrategy()`) {
+		t.Error("expected saveStrategy() button in strategy page")
+	}
+	if !strings.Contains(body, `x-model="strategyText"`) {
+		t.Error("expected strategy JSON editor bound to strategyText")
 	}
 	if strings.Contains(body, `savePlan()`) {
 		t.Error("strategy page should not have savePlan() button (read-only)")
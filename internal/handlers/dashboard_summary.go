@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/cache"
+)
+
+// summaryTopMovers is the number of holdings returned in DashboardSummary.TopMovers.
+const summaryTopMovers = 5
+
+// Summary cache lifetime and size. The dashboard polls the summary, so a
+// short TTL saves most portfolio fetches without serving data much older
+// than a poll interval.
+const (
+	summaryCacheTTL        = 15 * time.Second
+	summaryCacheMaxEntries = 1000
+)
+
+// summaryCachePath is the cache key path shared by a user's summaries;
+// the requested portfolio is appended as a query.
+const summaryCachePath = "/api/dashboard/summary"
+
+// summaryCacheKey returns the cache key for userID's summary of the
+// requested portfolio ("" for their default).
+func summaryCacheKey(userID, portfolio string) string {
+	return cache.MakeKey(userID, http.MethodGet, summaryCachePath+"?portfolio="+url.QueryEscape(portfolio))
+}
+
+// InvalidateSummary drops userID's cached summaries for every portfolio,
+// e.g. after a sync. The next request fetches fresh data with a new
+// Last-Modified. Other users' entries are untouched.
+func (h *DashboardHandler) InvalidateSummary(userID string) {
+	h.summaryCache.InvalidateKeyPrefix(cache.MakeKey(userID, http.MethodGet, summaryCachePath+"?"))
+}
+
+// DashboardSummary is the compact portfolio summary returned by GET /api/dashboard/summary.
+type DashboardSummary struct {
+	Portfolio    string         `json:"portfolio"`
+	Currency     string         `json:"currency"`
+	TotalValue   float64        `json:"total_value"`
+	DayChange    *float64       `json:"day_change"`
+	DayChangePct *float64       `json:"day_change_pct"`
+	HoldingCount int            `json:"holding_count"`
+	TopMovers    []SummaryMover `json:"top_movers"`
+}
+
+// SummaryMover is a single holding's day move.
+type SummaryMover struct {
+	Ticker    string   `json:"ticker"`
+	Name      string   `json:"name"`
+	Change    *float64 `json:"change"`
+	ChangePct float64  `json:"change_pct"`
+}
+
+// summaryPortfolio is the subset of the vire-server portfolio response used by the summary.
+type summaryPortfolio struct {
+	Name           string  `json:"name"`
+	Currency       string  `json:"currency"`
+	PortfolioValue float64 `json:"portfolio_value"`
+	Holdings       []struct {
+		Ticker                  string   `json:"ticker"`
+		Name                    string   `json:"name"`
+		Units                   float64  `json:"units"`
+		CurrentPrice            *float64 `json:"current_price"`
+		YesterdayClosePrice     *float64 `json:"yesterday_close_price"`
+		YesterdayPriceChangePct *float64 `json:"yesterday_price_change_pct"`
+	} `json:"holdings"`
+	Changes struct {
+		Yesterday struct {
+			PortfolioValue struct {
+				HasPrevious bool    `json:"has_previous"`
+				RawChange   float64 `json:"raw_change"`
+				PctChange   float64 `json:"pct_change"`
+			} `json:"portfolio_value"`
+		} `json:"yesterday"`
+	} `json:"changes"`
+}
+
+// resolvePortfolio picks the portfolio to display from a GET /api/portfolios response.
+// Priority: requested (if it exists in the list) > server default > first portfolio.
+// Returns "" if the body cannot be parsed or the user has no portfolios.
+func resolvePortfolio(portfoliosBody []byte, requested string) string {
+	var pData struct {
+		Portfolios []struct {
+			Name string `json:"name"`
+		} `json:"portfolios"`
+		Default string `json:"default"`
+	}
+	if json.Unmarshal(portfoliosBody, &pData) != nil {
+		return ""
+	}
+
+	if requested != "" {
+		for _, p := range pData.Portfolios {
+			if p.Name == requested {
+				return requested
+			}
+		}
+	}
+	if pData.Default != "" {
+		return pData.Default
+	}
+	if len(pData.Portfolios) > 0 {
+		return pData.Portfolios[0].Name
+	}
+	return ""
+}
+
+// HandleSummary serves GET /api/dashboard/summary.
+// Returns total value, day change, and top movers for the requested portfolio
+// (?portfolio=NAME) or the user's default portfolio. Responses are cached
+// per user and requested portfolio for summaryCacheTTL and carry
+// Last-Modified; a cached response the client already has (If-Modified-Since
+// not before Last-Modified) is answered with 304.
+func (h *DashboardHandler) HandleSummary(w http.ResponseWriter, r *http.Request) {
+	if !RequireMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+
+	if h.userLookupFn != nil {
+		user, err := h.userLookupFn(claims.Sub)
+		if err == nil && user != nil && !user.NavexaKeySet {
+			WriteErrorAction(w, http.StatusPreconditionFailed, ErrCodeKeyRequired,
+				"Add your Navexa API key on the profile page to load portfolio data.", "/profile")
+			return
+		}
+	}
+
+	if h.proxyGetFn == nil {
+		WriteError(w, http.StatusServiceUnavailable, "portfolio data unavailable")
+		return
+	}
+
+	requested := r.URL.Query().Get("portfolio")
+	key := summaryCacheKey(claims.Sub, requested)
+	if cached, ok := h.summaryCache.Get(key); ok {
+		writeSummary(w, r, cached, true)
+		return
+	}
+
+	listBody, err := h.proxyGetFn("/api/portfolios", claims.Sub)
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Warn().Str("error", err.Error()).Msg("dashboard summary: portfolios failed")
+		}
+		WriteError(w, http.StatusBadGateway, "failed to load portfolios")
+		return
+	}
+
+	selected := resolvePortfolio(listBody, requested)
+	if selected == "" {
+		WriteError(w, http.StatusNotFound, "no portfolios found")
+		return
+	}
+
+	body, err := h.proxyGetFn("/api/portfolios/"+url.PathEscape(selected), claims.Sub)
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Warn().Str("portfolio", selected).Str("error", err.Error()).Msg("dashboard summary: portfolio data failed")
+		}
+		WriteError(w, http.StatusBadGateway, "failed to load portfolio")
+		return
+	}
+
+	var p summaryPortfolio
+	if err := json.Unmarshal(body, &p); err != nil {
+		WriteError(w, http.StatusBadGateway, "invalid portfolio response")
+		return
+	}
+
+	out, err := json.Marshal(buildDashboardSummary(selected, p))
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "failed to encode summary")
+		return
+	}
+	headers := http.Header{}
+	headers.Set("Last-Modified", h.clock.Now().UTC().Format(http.TimeFormat))
+	resp := &cache.CachedResponse{StatusCode: http.StatusOK, Headers: headers, Body: append(out, '\n')}
+	h.summaryCache.Set(key, resp)
+	// A fresh fetch is always sent in full: it may share a Last-Modified
+	// second with the copy it replaces.
+	writeSummary(w, r, resp, false)
+}
+
+// writeSummary writes a summary response with its Last-Modified. When
+// notModified is allowed and the request's If-Modified-Since is not before
+// Last-Modified, it answers 304 without a body.
+func writeSummary(w http.ResponseWriter, r *http.Request, resp *cache.CachedResponse, notModified bool) {
+	lastModified := resp.Headers.Get("Last-Modified")
+	w.Header().Set("Last-Modified", lastModified)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if notModified {
+		modified, err := http.ParseTime(lastModified)
+		since, sinceErr := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err == nil && sinceErr == nil && !modified.After(since) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(resp.Body)
+}
+
+// buildDashboardSummary reduces a portfolio response to a DashboardSummary.
+func buildDashboardSummary(name string, p summaryPortfolio) DashboardSummary {
+	summary := DashboardSummary{
+		Portfolio:    name,
+		Currency:     p.Currency,
+		TotalValue:   p.PortfolioValue,
+		HoldingCount: len(p.Holdings),
+		TopMovers:    []SummaryMover{},
+	}
+
+	if yv := p.Changes.Yesterday.PortfolioValue; yv.HasPrevious {
+		raw, pct := yv.RawChange, yv.PctChange
+		summary.DayChange = &raw
+		summary.DayChangePct = &pct
+	}
+
+	for _, hl := range p.Holdings {
+		if hl.YesterdayPriceChangePct == nil {
+			continue
+		}
+		mover := SummaryMover{Ticker: hl.Ticker, Name: hl.Name, ChangePct: *hl.YesterdayPriceChangePct}
+		if hl.CurrentPrice != nil && hl.YesterdayClosePrice != nil {
+			change := (*hl.CurrentPrice - *hl.YesterdayClosePrice) * hl.Units
+			mover.Change = &change
+		}
+		summary.TopMovers = append(summary.TopMovers, mover)
+	}
+
+	sort.SliceStable(summary.TopMovers, func(i, j int) bool {
+		return math.Abs(summary.TopMovers[i].ChangePct) > math.Abs(summary.TopMovers[j].ChangePct)
+	})
+	if len(summary.TopMovers) > summaryTopMovers {
+		summary.TopMovers = summary.TopMovers[:summaryTopMovers]
+	}
+
+	return summary
+}
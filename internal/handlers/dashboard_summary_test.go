@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+func summaryProxyFn(path, userID string) ([]byte, error) {
+	switch path {
+	case "/api/portfolios":
+		return []byte(`{"portfolios":[{"name":"SMSF"},{"name":"Personal"}],"default":"SMSF"}`), nil
+	case "/api/portfolios/SMSF":
+		return []byte(`{
+			"name":"SMSF","currency":"AUD","portfolio_value":10000,
+			"changes":{"yesterday":{"portfolio_value":{"has_previous":true,"raw_change":-150,"pct_change":-1.5}}},
+			"holdings":[
+				{"ticker":"BHP","name":"BHP Group","units":10,"current_price":45,"yesterday_close_price":44,"yesterday_price_change_pct":2.27},
+				{"ticker":"CBA","name":"Commonwealth Bank","units":5,"current_price":100,"yesterday_close_price":110,"yesterday_price_change_pct":-9.09},
+				{"ticker":"VAS","name":"Vanguard ETF","units":1,"current_price":90}
+			]}`), nil
+	case "/api/portfolios/Personal":
+		return []byte(`{"name":"Personal","currency":"USD","portfolio_value":500,"holdings":[]}`), nil
+	}
+	return nil, fmt.Errorf("unexpected path %s", path)
+}
+
+func TestDashboardSummary_RequiresAuth(t *testing.T) {
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(summaryProxyFn)
+
+	req := httptest.NewRequest("GET", "/api/dashboard/summary", nil)
+	w := httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", w.Code)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["status"] != "error" {
+		t.Errorf("expected status error, got %q", resp["status"])
+	}
+}
+
+func TestDashboardSummary_ConfiguredCookieName(t *testing.T) {
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetSessionCookie(SessionCookie{Name: "portal_sid"})
+	handler.SetProxyGetFn(summaryProxyFn)
+
+	req := httptest.NewRequest("GET", "/api/dashboard/summary", nil)
+	req.AddCookie(&http.Cookie{Name: "portal_sid", Value: createTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 with the configured session cookie, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/dashboard/summary", nil)
+	addAuthCookie(req, "dev_user") // default vire_session name
+	w = httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for the default cookie name, got %d", w.Code)
+	}
+}
+
+func TestDashboardSummary_NavexaKeyMissing(t *testing.T) {
+	lookupFn := func(userID string) (*client.UserProfile, error) {
+		return &client.UserProfile{Username: userID, NavexaKeySet: false}, nil
+	}
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), lookupFn)
+	proxyCalled := false
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		proxyCalled = true
+		return summaryProxyFn(path, userID)
+	})
+
+	req := httptest.NewRequest("GET", "/api/dashboard/summary", nil)
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected status 412, got %d", w.Code)
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["status"] != "error" || resp["code"] != ErrCodeKeyRequired {
+		t.Errorf("expected error envelope with code %s, got %v", ErrCodeKeyRequired, resp)
+	}
+	if resp["action"] != "/profile" {
+		t.Errorf("expected action /profile, got %q", resp["action"])
+	}
+	if proxyCalled {
+		t.Error("expected no vire-server calls when the Navexa key is missing")
+	}
+}
+
+func TestDashboardSummary_DefaultPortfolio(t *testing.T) {
+	lookupFn := func(userID string) (*client.UserProfile, error) {
+		return &client.UserProfile{Username: userID, NavexaKeySet: true}, nil
+	}
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), lookupFn)
+	handler.SetProxyGetFn(summaryProxyFn)
+
+	req := httptest.NewRequest("GET", "/api/dashboard/summary", nil)
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var summary DashboardSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if summary.Portfolio != "SMSF" {
+		t.Errorf("expected default portfolio SMSF, got %s", summary.Portfolio)
+	}
+	if summary.TotalValue != 10000 {
+		t.Errorf("expected total_value 10000, got %v", summary.TotalValue)
+	}
+	if summary.DayChange == nil || *summary.DayChange != -150 {
+		t.Errorf("expected day_change -150, got %v", summary.DayChange)
+	}
+	if summary.HoldingCount != 3 {
+		t.Errorf("expected holding_count 3, got %d", summary.HoldingCount)
+	}
+	if len(summary.TopMovers) != 2 {
+		t.Fatalf("expected 2 top movers (holdings without a day change skipped), got %d", len(summary.TopMovers))
+	}
+	if summary.TopMovers[0].Ticker != "CBA" {
+		t.Errorf("expected largest absolute mover CBA first, got %s", summary.TopMovers[0].Ticker)
+	}
+	if summary.TopMovers[0].Change == nil || *summary.TopMovers[0].Change != -50 {
+		t.Errorf("expected CBA change -50, got %v", summary.TopMovers[0].Change)
+	}
+}
+
+func TestDashboardSummary_RequestedPortfolio(t *testing.T) {
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(summaryProxyFn)
+
+	req := httptest.NewRequest("GET", "/api/dashboard/summary?portfolio=Personal", nil)
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var summary DashboardSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if summary.Portfolio != "Personal" {
+		t.Errorf("expected portfolio Personal, got %s", summary.Portfolio)
+	}
+	if summary.DayChange != nil {
+		t.Errorf("expected nil day_change without previous value, got %v", *summary.DayChange)
+	}
+	if summary.TopMovers == nil {
+		t.Error("expected empty top_movers array, got nil")
+	}
+}
+
+func TestResolvePortfolio(t *testing.T) {
+	list := []byte(`{"portfolios":[{"name":"A"},{"name":"B"}],"default":"B"}`)
+	noDefault := []byte(`{"portfolios":[{"name":"A"},{"name":"B"}]}`)
+
+	tests := []struct {
+		name      string
+		body      []byte
+		requested string
+		expected  string
+	}{
+		{"requested exists", list, "A", "A"},
+		{"requested unknown falls back to default", list, "Z", "B"},
+		{"default", list, "", "B"},
+		{"first when no default", noDefault, "", "A"},
+		{"empty list", []byte(`{"portfolios":[]}`), "", ""},
+		{"invalid JSON", []byte(`not json`), "A", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolvePortfolio(tt.body, tt.requested); got != tt.expected {
+				t.Errorf("resolvePortfolio() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// userSummaryProxy serves each user a portfolio worth their own value and
+// counts portfolio fetches.
+type userSummaryProxy struct {
+	values  map[string]float64
+	fetches int
+}
+
+func (p *userSummaryProxy) get(path, userID string) ([]byte, error) {
+	switch path {
+	case "/api/portfolios":
+		return []byte(`{"portfolios":[{"name":"SMSF"}],"default":"SMSF"}`), nil
+	case "/api/portfolios/SMSF":
+		p.fetches++
+		return []byte(fmt.Sprintf(`{"name":"SMSF","currency":"AUD","portfolio_value":%g,"holdings":[]}`, p.values[userID])), nil
+	}
+	return nil, fmt.Errorf("unexpected path %s", path)
+}
+
+func getSummary(t *testing.T, handler *DashboardHandler, userID, ifModifiedSince string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/dashboard/summary?portfolio=SMSF", nil)
+	addAuthCookie(req, userID)
+	if ifModifiedSince != "" {
+		req.Header.Set("If-Modified-Since", ifModifiedSince)
+	}
+	w := httptest.NewRecorder()
+	handler.HandleSummary(w, req)
+	return w
+}
+
+func summaryValue(t *testing.T, w *httptest.ResponseRecorder) float64 {
+	t.Helper()
+	var summary DashboardSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("failed to decode summary: %v", err)
+	}
+	return summary.TotalValue
+}
+
+func TestDashboardSummary_CacheIsPerUser(t *testing.T) {
+	proxy := &userSummaryProxy{values: map[string]float64{"alice": 1000, "bob": 2000}}
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(proxy.get)
+
+	if v := summaryValue(t, getSummary(t, handler, "alice", "")); v != 1000 {
+		t.Errorf("expected alice's value 1000, got %v", v)
+	}
+	if v := summaryValue(t, getSummary(t, handler, "bob", "")); v != 2000 {
+		t.Errorf("expected bob's value 2000 (not alice's cached summary), got %v", v)
+	}
+	if v := summaryValue(t, getSummary(t, handler, "alice", "")); v != 1000 {
+		t.Errorf("expected alice's cached value 1000, got %v", v)
+	}
+	if proxy.fetches != 2 {
+		t.Errorf("expected one portfolio fetch per user, got %d", proxy.fetches)
+	}
+
+	// Invalidating bob's summaries leaves alice's cached
+	handler.InvalidateSummary("bob")
+	getSummary(t, handler, "alice", "")
+	getSummary(t, handler, "bob", "")
+	if proxy.fetches != 3 {
+		t.Errorf("expected only bob's summary refetched, got %d fetches", proxy.fetches)
+	}
+}
+
+func TestDashboardSummary_NotModified(t *testing.T) {
+	proxy := &userSummaryProxy{values: map[string]float64{"alice": 1000}}
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(proxy.get)
+	clock := common.NewFakeClock(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+	handler.SetClock(clock)
+
+	first := getSummary(t, handler, "alice", "")
+	lastModified := first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || lastModified != "Sun, 01 Mar 2026 10:00:00 GMT" {
+		t.Fatalf("expected 200 with Last-Modified, got %d %q", first.Code, lastModified)
+	}
+
+	// The client's copy is current: 304, no body
+	w := getSummary(t, handler, "alice", lastModified)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected empty 304, got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Last-Modified") != lastModified {
+		t.Errorf("expected Last-Modified on 304, got %q", w.Header().Get("Last-Modified"))
+	}
+
+	// An older copy gets the cached body
+	w = getSummary(t, handler, "alice", "Sun, 01 Mar 2026 09:59:59 GMT")
+	if w.Code != http.StatusOK || summaryValue(t, w) != 1000 {
+		t.Errorf("expected 200 with the cached summary for an older copy, got %d", w.Code)
+	}
+
+	// Another user's If-Modified-Since never reaches alice's entry
+	w = getSummary(t, handler, "bob", lastModified)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for bob's first request, got %d", w.Code)
+	}
+
+	// After a sync invalidates the cache, fresh data is sent in full
+	proxy.values["alice"] = 1100
+	clock.Advance(5 * time.Second)
+	handler.InvalidateSummary("alice")
+	w = getSummary(t, handler, "alice", lastModified)
+	if w.Code != http.StatusOK || summaryValue(t, w) != 1100 {
+		t.Errorf("expected 200 with refreshed data after invalidation, got %d", w.Code)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "Sun, 01 Mar 2026 10:00:05 GMT" {
+		t.Errorf("expected a new Last-Modified, got %q", got)
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// HealthCheck is the result of a single deep health subcheck.
+type HealthCheck struct {
+	Status      string `json:"status"` // "ok", "degraded" or "down"
+	Critical    bool   `json:"critical"`
+	LatencyMS   int64  `json:"latency_ms"`
+	Error       string `json:"error,omitempty"`
+	ToolCount   *int   `json:"tool_count,omitempty"`
+	LastFetchAt string `json:"last_fetch_at,omitempty"`
+	// LastFetchAgeSeconds is the age of the last successful catalog fetch.
+	LastFetchAgeSeconds *int64 `json:"last_fetch_age_seconds,omitempty"`
+}
+
+// DeepHealthHandler aggregates portal, vire-server, and catalog health.
+type DeepHealthHandler struct {
+	logger          *common.Logger
+	serverHealth    *ServerHealthHandler
+	catalogStatusFn func() (int, time.Time)
+	catalogStale    time.Duration // 0 disables the staleness check
+}
+
+// NewDeepHealthHandler creates a deep health handler. serverHealth provides
+// the upstream vire-server probe.
+func NewDeepHealthHandler(logger *common.Logger, serverHealth *ServerHealthHandler) *DeepHealthHandler {
+	return &DeepHealthHandler{logger: logger, serverHealth: serverHealth}
+}
+
+// SetCatalogStatusFn sets the function reporting the MCP catalog tool count
+// and last successful fetch time.
+func (h *DeepHealthHandler) SetCatalogStatusFn(fn func() (int, time.Time)) {
+	h.catalogStatusFn = fn
+}
+
+// SetCatalogStaleAfter marks the catalog check degraded once the last
+// successful catalog fetch is older than d. Zero disables the check.
+func (h *DeepHealthHandler) SetCatalogStaleAfter(d time.Duration) {
+	h.catalogStale = d
+}
+
+// ServeHTTP handles GET /api/health/deep.
+// Returns 200 when every critical check passes, 503 otherwise with the
+// failing checks listed. A degraded check (e.g. a stale catalog) reports
+// "degraded" with a 200.
+func (h *DeepHealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !RequireMethod(w, r, "GET") {
+		return
+	}
+
+	checks := map[string]HealthCheck{
+		"portal":      {Status: "ok", Critical: true},
+		"vire_server": h.checkServer(r.Context()),
+		"catalog":     h.checkCatalog(),
+	}
+
+	failing := []string{}
+	degraded := []string{}
+	for _, name := range []string{"portal", "vire_server", "catalog"} {
+		switch c := checks[name]; {
+		case c.Status == "degraded":
+			degraded = append(degraded, name)
+		case c.Critical && c.Status != "ok":
+			failing = append(failing, name)
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	if len(degraded) > 0 {
+		status = "degraded"
+	}
+	if len(failing) > 0 {
+		status, code = "down", http.StatusServiceUnavailable
+		if h.logger != nil {
+			h.logger.Warn().Strs("failing", failing).Msg("deep health check failed")
+		}
+	}
+
+	resp := map[string]interface{}{
+		"status":   status,
+		"checks":   checks,
+		"failing":  failing,
+		"degraded": degraded,
+	}
+	if len(failing) > 0 {
+		resp["code"] = ErrCodeDependencyDown
+	}
+	WriteJSON(w, code, resp)
+}
+
+func (h *DeepHealthHandler) checkServer(ctx context.Context) HealthCheck {
+	check := HealthCheck{Status: "ok", Critical: true}
+	if h.serverHealth == nil {
+		check.Status = "down"
+		check.Error = "not configured"
+		return check
+	}
+	start := time.Now()
+	err := h.serverHealth.Probe(ctx)
+	check.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		check.Status = "down"
+		check.Error = "vire-server unreachable or unhealthy"
+	}
+	return check
+}
+
+func (h *DeepHealthHandler) checkCatalog() HealthCheck {
+	check := HealthCheck{Status: "ok", Critical: true}
+	if h.catalogStatusFn == nil {
+		check.Status = "down"
+		check.Error = "not configured"
+		return check
+	}
+	count, fetchedAt := h.catalogStatusFn()
+	check.ToolCount = &count
+	var age time.Duration
+	if !fetchedAt.IsZero() {
+		age = time.Since(fetchedAt)
+		ageSeconds := int64(age.Seconds())
+		check.LastFetchAt = fetchedAt.UTC().Format(time.RFC3339)
+		check.LastFetchAgeSeconds = &ageSeconds
+	}
+	switch {
+	case count == 0:
+		check.Status = "down"
+		check.Error = "catalog not loaded"
+	case h.catalogStale > 0 && age > h.catalogStale:
+		check.Status = "degraded"
+		check.Error = "catalog stale: last fetched " + age.Truncate(time.Second).String() + " ago"
+	}
+	return check
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type deepHealthResponse struct {
+	Status   string                 `json:"status"`
+	Checks   map[string]HealthCheck `json:"checks"`
+	Failing  []string               `json:"failing"`
+	Degraded []string               `json:"degraded"`
+	Code     string                 `json:"code"`
+}
+
+func serveDeepHealth(t *testing.T, handler *DeepHealthHandler) (int, deepHealthResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/health/deep", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var resp deepHealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return w.Code, resp
+}
+
+func TestDeepHealthHandler_AllHealthy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	handler := NewDeepHealthHandler(nil, NewServerHealthHandler(nil, upstream.URL))
+	handler.SetCatalogStatusFn(func() (int, time.Time) {
+		return 42, time.Now().Add(-90 * time.Second)
+	})
+
+	code, resp := serveDeepHealth(t, handler)
+
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if resp.Status != "ok" {
+		t.Errorf("expected status ok, got %s", resp.Status)
+	}
+	if len(resp.Failing) != 0 {
+		t.Errorf("expected no failing checks, got %v", resp.Failing)
+	}
+	for _, name := range []string{"portal", "vire_server", "catalog"} {
+		if resp.Checks[name].Status != "ok" {
+			t.Errorf("expected %s ok, got %+v", name, resp.Checks[name])
+		}
+	}
+	catalog := resp.Checks["catalog"]
+	if catalog.ToolCount == nil || *catalog.ToolCount != 42 {
+		t.Errorf("expected tool_count 42, got %v", catalog.ToolCount)
+	}
+	if catalog.LastFetchAgeSeconds == nil || *catalog.LastFetchAgeSeconds < 90 {
+		t.Errorf("expected last_fetch_age_seconds >= 90, got %v", catalog.LastFetchAgeSeconds)
+	}
+}
+
+func TestDeepHealthHandler_UpstreamDown(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	handler := NewDeepHealthHandler(nil, NewServerHealthHandler(nil, upstream.URL))
+	handler.SetCatalogStatusFn(func() (int, time.Time) { return 10, time.Now() })
+
+	code, resp := serveDeepHealth(t, handler)
+
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", code)
+	}
+	if resp.Status != "down" {
+		t.Errorf("expected status down, got %s", resp.Status)
+	}
+	if len(resp.Failing) != 1 || resp.Failing[0] != "vire_server" {
+		t.Errorf("expected failing [vire_server], got %v", resp.Failing)
+	}
+	if resp.Code != ErrCodeDependencyDown {
+		t.Errorf("expected code %s, got %q", ErrCodeDependencyDown, resp.Code)
+	}
+	if resp.Checks["catalog"].Status != "ok" {
+		t.Errorf("expected catalog ok, got %+v", resp.Checks["catalog"])
+	}
+}
+
+func TestDeepHealthHandler_CatalogNotLoaded(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	handler := NewDeepHealthHandler(nil, NewServerHealthHandler(nil, upstream.URL))
+	handler.SetCatalogStatusFn(func() (int, time.Time) { return 0, time.Time{} })
+
+	code, resp := serveDeepHealth(t, handler)
+
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", code)
+	}
+	if len(resp.Failing) != 1 || resp.Failing[0] != "catalog" {
+		t.Errorf("expected failing [catalog], got %v", resp.Failing)
+	}
+	if resp.Checks["catalog"].LastFetchAgeSeconds != nil {
+		t.Error("expected no last_fetch_age_seconds when the catalog never loaded")
+	}
+}
+
+func TestDeepHealthHandler_StaleCatalog(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	handler := NewDeepHealthHandler(nil, NewServerHealthHandler(nil, upstream.URL))
+	handler.SetCatalogStatusFn(func() (int, time.Time) {
+		return 42, time.Now().Add(-2 * time.Hour)
+	})
+
+	// Without a threshold an old catalog is still ok
+	if code, resp := serveDeepHealth(t, handler); code != http.StatusOK || resp.Status != "ok" {
+		t.Fatalf("expected ok with staleness check disabled, got %d %s", code, resp.Status)
+	}
+
+	handler.SetCatalogStaleAfter(time.Hour)
+	code, resp := serveDeepHealth(t, handler)
+
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200 for a degraded check, got %d", code)
+	}
+	if resp.Status != "degraded" {
+		t.Errorf("expected status degraded, got %s", resp.Status)
+	}
+	if len(resp.Failing) != 0 || len(resp.Degraded) != 1 || resp.Degraded[0] != "catalog" {
+		t.Errorf("expected degraded [catalog] and nothing failing, got degraded %v failing %v", resp.Degraded, resp.Failing)
+	}
+	catalog := resp.Checks["catalog"]
+	if catalog.Status != "degraded" || catalog.Error == "" {
+		t.Errorf("expected degraded catalog check with a reason, got %+v", catalog)
+	}
+	if catalog.LastFetchAgeSeconds == nil || *catalog.LastFetchAgeSeconds < 7200 {
+		t.Errorf("expected last_fetch_age_seconds >= 7200, got %v", catalog.LastFetchAgeSeconds)
+	}
+}
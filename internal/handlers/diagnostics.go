@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+	"github.com/bobmcallan/vire-portal/internal/config"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// maxDiagnosticsLimit caps the limit query param forwarded to vire-server.
+const maxDiagnosticsLimit = 1000
+
+// maxCorrelationIDLen bounds the correlation_id filter forwarded to vire-server.
+const maxCorrelationIDLen = 128
+
+// diagnosticsPreferredColumns are shown first, in this order, when present.
+// Remaining columns follow alphabetically.
+var diagnosticsPreferredColumns = []string{"timestamp", "time", "level", "source", "correlation_id", "message"}
+
+// diagnosticsField is a top-level scalar from the diagnostics response.
+type diagnosticsField struct {
+	Key   string
+	Value string
+}
+
+// diagnosticsTable is an array of objects from the diagnostics response
+// (e.g. "logs"), flattened to string cells.
+type diagnosticsTable struct {
+	Title   string
+	Columns []string
+	Rows    [][]string
+}
+
+// DiagnosticsHandler serves the diagnostics page, which renders vire-server's
+// GET /api/diagnostics for the logged-in user.
+type DiagnosticsHandler struct {
+	logger        *common.Logger
+	templates     *pageTemplates
+	devMode       bool
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	userLookupFn  func(string) (*client.UserProfile, error)
+	apiURL        string
+	proxyGetFn    func(path, userID string) ([]byte, error)
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler.
+func NewDiagnosticsHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *DiagnosticsHandler {
+	templates := parsePageTemplates(PagesFS())
+
+	return &DiagnosticsHandler{
+		logger:       logger,
+		templates:    templates,
+		devMode:      devMode,
+		jwtSecret:    jwtSecret,
+		userLookupFn: userLookupFn,
+	}
+}
+
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *DiagnosticsHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
+// SetAPIURL sets the API URL for server version fetching.
+func (h *DiagnosticsHandler) SetAPIURL(apiURL string) {
+	h.apiURL = apiURL
+}
+
+// SetProxyGetFn sets the proxy GET function used to fetch diagnostics.
+func (h *DiagnosticsHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, error)) {
+	h.proxyGetFn = fn
+}
+
+// diagnosticsQuery builds the vire-server query from the page's
+// correlation_id and limit params (the get_diagnostics tool's params).
+// Invalid values are dropped rather than forwarded.
+func diagnosticsQuery(r *http.Request) (correlationID string, limit int, q url.Values) {
+	q = url.Values{}
+	correlationID = strings.TrimSpace(r.URL.Query().Get("correlation_id"))
+	if len(correlationID) > maxCorrelationIDLen {
+		correlationID = ""
+	}
+	if correlationID != "" {
+		q.Set("correlation_id", correlationID)
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(r.URL.Query().Get("limit"))); err == nil && n > 0 {
+		limit = min(n, maxDiagnosticsLimit)
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	return correlationID, limit, q
+}
+
+// ServeHTTP renders the diagnostics page.
+func (h *DiagnosticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+
+	// Redirect unauthenticated users to landing page
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	var userRole string
+	if h.userLookupFn != nil {
+		user, err := h.userLookupFn(claims.Sub)
+		if err == nil && user != nil {
+			userRole = user.Role
+		}
+	}
+
+	correlationID, limit, q := diagnosticsQuery(r)
+
+	var summary []diagnosticsField
+	var tables []diagnosticsTable
+	var fetchErr string
+	if h.proxyGetFn == nil {
+		fetchErr = "Diagnostics are not configured."
+	} else {
+		path := "/api/diagnostics"
+		if len(q) > 0 {
+			path += "?" + q.Encode()
+		}
+		body, err := h.proxyGetFn(path, claims.Sub)
+		if err != nil {
+			if h.logger != nil {
+				h.logger.Error().Str("error", err.Error()).Msg("failed to fetch diagnostics")
+			}
+			fetchErr = "Failed to load diagnostics. Ensure vire-server is running."
+		} else if summary, tables, err = parseDiagnostics(body); err != nil {
+			fetchErr = "vire-server returned an unreadable diagnostics response."
+		}
+	}
+
+	limitValue := ""
+	if limit > 0 {
+		limitValue = strconv.Itoa(limit)
+	}
+
+	data := map[string]interface{}{
+		"Page":          "diagnostics",
+		"BasePath":      BasePath(r),
+		"Locale":        RequestLocale(r),
+		"DevMode":       h.devMode,
+		"LoggedIn":      loggedIn,
+		"UserRole":      userRole,
+		"PortalVersion": config.GetVersion(),
+		"ServerVersion": CachedServerVersion(h.apiURL),
+		"CorrelationID": correlationID,
+		"Limit":         limitValue,
+		"Summary":       summary,
+		"Tables":        tables,
+		"FetchError":    fetchErr,
+	}
+
+	if err := h.templates.ExecuteTemplate(w, "diagnostics.html", data); err != nil {
+		if h.logger != nil {
+			h.logger.Error().Str("template", "diagnostics.html").Str("error", err.Error()).Msg("failed to render diagnostics page")
+		}
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// parseDiagnostics splits a diagnostics response into top-level scalar
+// fields and tables (one per array of objects). A bare array becomes a single
+// "entries" table. Values are returned as plain strings; escaping is left to
+// the template.
+func parseDiagnostics(body []byte) ([]diagnosticsField, []diagnosticsTable, error) {
+	var raw interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	var summary []diagnosticsField
+	var tables []diagnosticsTable
+	switch v := raw.(type) {
+	case []interface{}:
+		tables = append(tables, diagnosticsTableFrom("entries", v))
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if items, ok := v[k].([]interface{}); ok && isObjectList(items) {
+				tables = append(tables, diagnosticsTableFrom(k, items))
+				continue
+			}
+			summary = append(summary, diagnosticsField{Key: k, Value: formatDiagnosticsValue(v[k])})
+		}
+	default:
+		summary = append(summary, diagnosticsField{Key: "result", Value: formatDiagnosticsValue(v)})
+	}
+	return summary, tables, nil
+}
+
+// isObjectList reports whether items is non-empty and every item is an object.
+func isObjectList(items []interface{}) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// diagnosticsTableFrom builds a table whose columns are the union of the
+// items' keys, preferred columns first.
+func diagnosticsTableFrom(title string, items []interface{}) diagnosticsTable {
+	seen := make(map[string]bool)
+	var rest []string
+	for _, item := range items {
+		obj, _ := item.(map[string]interface{})
+		for k := range obj {
+			if !seen[k] {
+				seen[k] = true
+				rest = append(rest, k)
+			}
+		}
+	}
+
+	var columns []string
+	for _, c := range diagnosticsPreferredColumns {
+		if seen[c] {
+			columns = append(columns, c)
+			delete(seen, c)
+		}
+	}
+	var others []string
+	for _, c := range rest {
+		if seen[c] {
+			others = append(others, c)
+		}
+	}
+	sort.Strings(others)
+	columns = append(columns, others...)
+
+	table := diagnosticsTable{Title: title, Columns: columns}
+	for _, item := range items {
+		obj, _ := item.(map[string]interface{})
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			if val, ok := obj[c]; ok {
+				row[i] = formatDiagnosticsValue(val)
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// formatDiagnosticsValue renders a JSON value as a table cell: strings as is,
+// numbers without exponent noise, and anything nested as compact JSON.
+func formatDiagnosticsValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
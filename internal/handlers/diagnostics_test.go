@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDiagnosticsHandler_UnauthenticatedRedirect(t *testing.T) {
+	called := false
+	handler := NewDiagnosticsHandler(nil, false, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		called = true
+		return []byte(`{}`), nil
+	})
+
+	req := httptest.NewRequest("GET", "/diagnostics", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Errorf("expected 302 redirect, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/" {
+		t.Errorf("expected redirect to /, got %q", loc)
+	}
+	if called {
+		t.Error("expected no upstream call for an unauthenticated request")
+	}
+}
+
+func TestDiagnosticsHandler_ForwardsQueryParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  url.Values
+	}{
+		{"none", "", url.Values{}},
+		{"both", "?correlation_id=abc-123&limit=25", url.Values{"correlation_id": {"abc-123"}, "limit": {"25"}}},
+		{"limit capped", "?limit=50000", url.Values{"limit": {"1000"}}},
+		{"invalid limit dropped", "?limit=-3&correlation_id=+x+", url.Values{"correlation_id": {"x"}}},
+		{"oversized correlation id dropped", "?correlation_id=" + strings.Repeat("a", maxCorrelationIDLen+1), url.Values{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotUser string
+			handler := NewDiagnosticsHandler(nil, false, []byte(testJWTSecret), nil)
+			handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+				gotPath, gotUser = path, userID
+				return []byte(`{"logs":[]}`), nil
+			})
+
+			req := httptest.NewRequest("GET", "/diagnostics"+tt.query, nil)
+			addAuthCookie(req, "user-1")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", w.Code)
+			}
+			if gotUser != "user-1" {
+				t.Errorf("expected user-1 to be forwarded, got %q", gotUser)
+			}
+			u, err := url.Parse(gotPath)
+			if err != nil || u.Path != "/api/diagnostics" {
+				t.Fatalf("expected /api/diagnostics, got %q", gotPath)
+			}
+			if got := u.Query(); got.Encode() != tt.want.Encode() {
+				t.Errorf("expected query %q, got %q", tt.want.Encode(), got.Encode())
+			}
+		})
+	}
+}
+
+func TestDiagnosticsHandler_RendersEscapedEntries(t *testing.T) {
+	handler := NewDiagnosticsHandler(nil, false, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return []byte(`{"uptime_seconds":3600,"logs":[
+			{"level":"error","message":"<script>alert(1)</script>","correlation_id":"abc-123","timestamp":"2026-03-01T09:00:00Z"}
+		]}`), nil
+	})
+
+	req := httptest.NewRequest("GET", "/diagnostics", nil)
+	addAuthCookie(req, "user-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if strings.Contains(body, "<script>alert(1)</script>") {
+		t.Error("expected message to be HTML-escaped")
+	}
+	if !strings.Contains(body, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Error("expected escaped message in the table")
+	}
+	if !strings.Contains(body, "abc-123") || !strings.Contains(body, "uptime_seconds") || !strings.Contains(body, "3600") {
+		t.Error("expected correlation ID and summary fields in the page")
+	}
+	// Preferred columns come first, in order
+	if ts, lvl, msg := strings.Index(body, "<th>timestamp</th>"), strings.Index(body, "<th>level</th>"), strings.Index(body, "<th>message</th>"); ts < 0 || !(ts < lvl && lvl < msg) {
+		t.Error("expected timestamp, level, message column order")
+	}
+}
+
+func TestDiagnosticsHandler_UpstreamErrorShowsWarning(t *testing.T) {
+	handler := NewDiagnosticsHandler(nil, false, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return nil, errors.New("vire-server unavailable")
+	})
+
+	req := httptest.NewRequest("GET", "/diagnostics", nil)
+	addAuthCookie(req, "user-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with warning, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Failed to load diagnostics") {
+		t.Error("expected fetch error banner")
+	}
+}
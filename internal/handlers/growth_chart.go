@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// Growth chart geometry and cache lifetime. The timeline changes at most
+// daily, so a few minutes of browser caching is safe.
+const (
+	growthChartWidth   = 800
+	growthChartHeight  = 320
+	growthChartPadding = 16
+	growthChartGrid    = 4 // horizontal grid intervals
+	growthChartMaxAge  = 300
+)
+
+// Chart colours match the dashboard growth chart (black line, grey grid).
+var (
+	growthChartBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	growthChartGridColor  = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	growthChartLineColor  = color.RGBA{0x00, 0x00, 0x00, 0xff}
+)
+
+// GrowthChartHandler renders a portfolio's value history as a PNG line chart,
+// so clients that can't run the dashboard's JavaScript can embed the chart.
+type GrowthChartHandler struct {
+	logger        *common.Logger
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	proxyGetFn    func(path, userID string) ([]byte, error)
+}
+
+// NewGrowthChartHandler creates a new growth chart handler.
+func NewGrowthChartHandler(logger *common.Logger, jwtSecret []byte) *GrowthChartHandler {
+	return &GrowthChartHandler{
+		logger:    logger,
+		jwtSecret: jwtSecret,
+	}
+}
+
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *GrowthChartHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
+// SetProxyGetFn sets the proxy GET function used to fetch the timeline.
+func (h *GrowthChartHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, error)) {
+	h.proxyGetFn = fn
+}
+
+// HandleGrowthPNG handles GET /api/portfolios/{name}/growth.png.
+// It plots total portfolio value over time from the vire-server timeline.
+// vire-server 4xx responses (e.g. 404 for an unknown portfolio) are relayed.
+// The ETag is derived from the timeline, so unchanged data answers 304.
+func (h *GrowthChartHandler) HandleGrowthPNG(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteErrorCode(w, http.StatusUnauthorized, ErrCodeUnauthorized, "authentication required")
+		return
+	}
+	name := r.PathValue("name")
+	if name == "" {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "portfolio name is required")
+		return
+	}
+	if h.proxyGetFn == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "growth chart service unavailable")
+		return
+	}
+
+	body, err := h.proxyGetFn("/api/portfolios/"+url.PathEscape(name)+"/timeline", claims.Sub)
+	if err != nil {
+		var perr *client.ProxyError
+		if errors.As(err, &perr) && perr.StatusCode >= 400 && perr.StatusCode < 500 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(perr.StatusCode)
+			w.Write([]byte(perr.Body))
+			return
+		}
+		if h.logger != nil {
+			h.logger.Warn().Str("portfolio", name).Str("error", err.Error()).Msg("failed to load timeline for growth chart")
+		}
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamUnavailable, "failed to load portfolio timeline")
+		return
+	}
+
+	values, err := growthValues(body)
+	if err != nil {
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "invalid portfolio timeline")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("Cache-Control", "private, max-age="+strconv.Itoa(growthChartMaxAge))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderGrowthChart(values, growthChartWidth, growthChartHeight)); err != nil {
+		WriteError(w, http.StatusInternalServerError, "failed to render growth chart")
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// growthValues extracts the total value of each timeline data point, in
+// order. Like the dashboard chart, it reads portfolio_value and falls back
+// to value.
+func growthValues(timeline []byte) ([]float64, error) {
+	var data struct {
+		DataPoints []struct {
+			PortfolioValue *float64 `json:"portfolio_value"`
+			Value          *float64 `json:"value"`
+		} `json:"data_points"`
+	}
+	if err := json.Unmarshal(timeline, &data); err != nil {
+		return nil, err
+	}
+	values := make([]float64, 0, len(data.DataPoints))
+	for _, p := range data.DataPoints {
+		switch {
+		case p.PortfolioValue != nil:
+			values = append(values, *p.PortfolioValue)
+		case p.Value != nil:
+			values = append(values, *p.Value)
+		default:
+			values = append(values, 0)
+		}
+	}
+	return values, nil
+}
+
+// renderGrowthChart draws values as a line across a width x height image
+// with horizontal grid lines. The y axis spans the series' min to max; a
+// flat series is drawn mid-height. Fewer than two values draw the grid only.
+func renderGrowthChart(values []float64, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: growthChartBackground}, image.Point{}, draw.Src)
+
+	left, top := growthChartPadding, growthChartPadding
+	right, bottom := width-growthChartPadding-1, height-growthChartPadding-1
+	for i := 0; i <= growthChartGrid; i++ {
+		y := top + (bottom-top)*i/growthChartGrid
+		for x := left; x <= right; x++ {
+			img.Set(x, y, growthChartGridColor)
+		}
+	}
+	if len(values) < 2 {
+		return img
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	point := func(i int) (int, int) {
+		x := left + (right-left)*i/(len(values)-1)
+		if hi == lo {
+			return x, (top + bottom) / 2
+		}
+		return x, bottom - int(float64(bottom-top)*(values[i]-lo)/(hi-lo)+0.5)
+	}
+
+	x0, y0 := point(0)
+	for i := 1; i < len(values); i++ {
+		x1, y1 := point(i)
+		drawLine(img, x0, y0, x1, y1, growthChartLineColor)
+		x0, y0 = x1, y1
+	}
+	return img
+}
+
+// drawLine draws a 2px-thick line from (x0,y0) to (x1,y1) with Bresenham's
+// algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := absInt(x1-x0), -absInt(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		img.Set(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// absInt returns the absolute value of n.
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
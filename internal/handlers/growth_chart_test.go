@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+)
+
+func newGrowthChartRequest(name string) *http.Request {
+	req := httptest.NewRequest("GET", "/api/portfolios/"+name+"/growth.png", nil)
+	req.SetPathValue("name", name)
+	addAuthCookie(req, "dev_user")
+	return req
+}
+
+func TestGrowthChart_RendersPNG(t *testing.T) {
+	handler := NewGrowthChartHandler(nil, []byte(testJWTSecret))
+	var gotPath string
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		gotPath = path
+		return []byte(`{"data_points":[
+			{"date":"2026-01-01","portfolio_value":900},
+			{"date":"2026-02-01","portfolio_value":950},
+			{"date":"2026-03-01","value":1020}
+		]}`), nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleGrowthPNG(w, newGrowthChartRequest("SMSF"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotPath != "/api/portfolios/SMSF/timeline" {
+		t.Errorf("expected timeline path, got %s", gotPath)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected image/png, got %s", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "private, max-age=300" {
+		t.Errorf("expected private caching, got %q", cc)
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("expected a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != growthChartWidth || b.Dy() != growthChartHeight {
+		t.Errorf("expected %dx%d, got %dx%d", growthChartWidth, growthChartHeight, b.Dx(), b.Dy())
+	}
+
+	// The line is drawn: the first point (the minimum) sits on the bottom edge
+	r, g, b, _ := img.At(growthChartPadding, growthChartHeight-growthChartPadding-1).RGBA()
+	if r != 0 || g != 0 || b != 0 {
+		t.Errorf("expected the line to start at the bottom left, got rgb(%d,%d,%d)", r>>8, g>>8, b>>8)
+	}
+
+	// Unchanged timeline answers 304
+	req := newGrowthChartRequest("SMSF")
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	handler.HandleGrowthPNG(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status 304 for a matching ETag, got %d", w.Code)
+	}
+}
+
+func TestGrowthChart_UnknownPortfolio(t *testing.T) {
+	handler := NewGrowthChartHandler(nil, []byte(testJWTSecret))
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return nil, &client.ProxyError{StatusCode: http.StatusNotFound, Body: `{"error":"portfolio not found"}`}
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleGrowthPNG(w, newGrowthChartRequest("Nope"))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected a JSON error, got %s", ct)
+	}
+}
+
+func TestGrowthChart_RequiresAuth(t *testing.T) {
+	handler := NewGrowthChartHandler(nil, []byte(testJWTSecret))
+
+	req := httptest.NewRequest("GET", "/api/portfolios/SMSF/growth.png", nil)
+	req.SetPathValue("name", "SMSF")
+	w := httptest.NewRecorder()
+	handler.HandleGrowthPNG(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
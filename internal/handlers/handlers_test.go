@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -17,6 +18,7 @@ import (
 	"time"
 
 	"github.com/bobmcallan/vire-portal/internal/client"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
 // testJWTSecret is the secret used for signing test JWTs
@@ -123,6 +125,30 @@ func TestVersionHandler_ReturnsJSON(t *testing.T) {
 	}
 }
 
+func TestVersionHandler_IncludesCatalogInfo(t *testing.T) {
+	handler := NewVersionHandler(nil)
+	handler.SetCatalogInfoFn(func() (string, int) { return "abc123", 42 })
+
+	req := httptest.NewRequest("GET", "/api/version", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if body["catalog_hash"] != "abc123" {
+		t.Errorf("expected catalog_hash abc123, got %q", body["catalog_hash"])
+	}
+	if body["catalog_tool_count"] != "42" {
+		t.Errorf("expected catalog_tool_count 42, got %q", body["catalog_tool_count"])
+	}
+	if _, ok := body["portal_version"]; !ok {
+		t.Error("expected portal_version field to be kept")
+	}
+}
+
 func TestVersionHandler_RejectsNonGET(t *testing.T) {
 	handler := NewVersionHandler(nil)
 
@@ -159,6 +185,45 @@ func TestRequireMethod_Mismatch(t *testing.T) {
 	}
 }
 
+func TestRequireMethod_HEADAllowedForGET(t *testing.T) {
+	req := httptest.NewRequest("HEAD", "/test", nil)
+	w := httptest.NewRecorder()
+
+	if !RequireMethod(w, req, "GET") {
+		t.Error("expected HEAD to be accepted for a GET handler")
+	}
+}
+
+func TestRequireMethod_OPTIONSAdvertisesAllow(t *testing.T) {
+	req := httptest.NewRequest("OPTIONS", "/test", nil)
+	w := httptest.NewRecorder()
+
+	if RequireMethod(w, req, "GET") {
+		t.Error("expected OPTIONS to be answered by RequireMethod")
+	}
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Allow"); got != "GET, HEAD, OPTIONS" {
+		t.Errorf("expected Allow 'GET, HEAD, OPTIONS', got %q", got)
+	}
+}
+
+func TestRequireMethods_MismatchSetsAllow(t *testing.T) {
+	req := httptest.NewRequest("DELETE", "/test", nil)
+	w := httptest.NewRecorder()
+
+	if RequireMethods(w, req, "GET", "POST") {
+		t.Error("expected DELETE to be rejected")
+	}
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", w.Code)
+	}
+	if got := w.Header().Get("Allow"); got != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("expected Allow 'GET, POST, HEAD, OPTIONS', got %q", got)
+	}
+}
+
 func TestWriteJSON(t *testing.T) {
 	w := httptest.NewRecorder()
 
@@ -201,6 +266,69 @@ func TestWriteError(t *testing.T) {
 	if body["status"] != "error" {
 		t.Errorf("expected status 'error', got %s", body["status"])
 	}
+	if _, ok := body["code"]; ok {
+		t.Errorf("expected no code field from WriteError, got %q", body["code"])
+	}
+}
+
+func TestWriteErrorCode(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	WriteErrorCode(w, http.StatusBadRequest, ErrCodeInvalidKey, "key rejected")
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if body["code"] != "INVALID_KEY" {
+		t.Errorf("expected code INVALID_KEY, got %q", body["code"])
+	}
+	if body["error"] != "key rejected" || body["status"] != "error" {
+		t.Errorf("expected standard error fields, got %v", body)
+	}
+	if _, ok := body["action"]; ok {
+		t.Errorf("expected no action field without an action, got %v", body)
+	}
+}
+
+// TestErrorCodes_Stable pins the wire values of the error codes; clients
+// match on them, so changing one is a breaking API change.
+func TestErrorCodes_Stable(t *testing.T) {
+	codes := map[string]string{
+		ErrCodeBadRequest:          "BAD_REQUEST",
+		ErrCodeUnauthorized:        "UNAUTHORIZED",
+		ErrCodeForbidden:           "FORBIDDEN",
+		ErrCodeBodyTooLarge:        "BODY_TOO_LARGE",
+		ErrCodeMissingCredentials:  "MISSING_CREDENTIALS",
+		ErrCodeInvalidCredentials:  "INVALID_CREDENTIALS",
+		ErrCodeUnknownProvider:     "UNKNOWN_PROVIDER",
+		ErrCodeKeyRequired:         "KEY_REQUIRED",
+		ErrCodeInvalidKey:          "INVALID_KEY",
+		ErrCodeServiceUnavailable:  "SERVICE_UNAVAILABLE",
+		ErrCodeUpstreamUnavailable: "UPSTREAM_UNAVAILABLE",
+		ErrCodeUpstreamError:       "UPSTREAM_ERROR",
+		ErrCodeDependencyDown:      "DEPENDENCY_DOWN",
+	}
+	for got, want := range codes {
+		if got != want {
+			t.Errorf("expected error code %s, got %s", want, got)
+		}
+	}
+}
+
+// errorCode decodes the "code" field from a JSON response body.
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	code, _ := body["code"].(string)
+	return code
 }
 
 // --- Auth Handler Tests ---
@@ -399,6 +527,30 @@ func TestMCPPageHandler_ShowsEmptyToolsMessage(t *testing.T) {
 	}
 }
 
+func TestMCPPageHandler_MarksDeprecatedTools(t *testing.T) {
+	tools := []MCPPageTool{
+		{Name: "old_tool", Description: "Old", Method: "GET", Path: "/api/old", Deprecated: true, DeprecatedMessage: "use new_tool"},
+		{Name: "new_tool", Description: "New", Method: "GET", Path: "/api/new"},
+	}
+	catalogFn := func() []MCPPageTool { return tools }
+
+	handler := NewMCPPageHandler(nil, false, 8500, []byte(testJWTSecret), catalogFn, nil)
+
+	req := httptest.NewRequest("GET", "/mcp-info", nil)
+	addAuthCookie(req, "test-user")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if strings.Count(body, `class="tool-deprecated"`) != 1 {
+		t.Error("expected exactly one row marked tool-deprecated")
+	}
+	if !strings.Contains(body, "DEPRECATED") || !strings.Contains(body, "use new_tool") {
+		t.Error("expected DEPRECATED tag with message on the MCP page")
+	}
+}
+
 func TestMCPPageHandler_XSSEscaping(t *testing.T) {
 	tools := []MCPPageTool{
 		{Name: "<script>alert('xss')</script>", Description: "<img onerror=alert(1) src=x>"},
@@ -739,6 +891,80 @@ func TestLogoutHandler_CookieAttributes(t *testing.T) {
 	}
 }
 
+func TestLoginHandler_CookieSecureFlag(t *testing.T) {
+	// Secure must be set in prod so the session is never sent over plaintext HTTP.
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok",
+			"data":   map[string]interface{}{"token": buildTestJWT("dev_user")},
+		})
+	}))
+	defer mockServer.Close()
+
+	tests := []struct {
+		name           string
+		devMode        bool
+		forwardedProto string
+		expectSecure   bool
+	}{
+		{"prod", false, "", true},
+		{"dev", true, "", false},
+		{"dev behind https proxy", true, "https", true},
+		{"dev behind http proxy", true, "http", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewAuthHandler(nil, tt.devMode, mockServer.URL, "http://localhost:8500/auth/callback", []byte{})
+
+			req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader("username=dev_user&password=dev123"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.forwardedProto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.forwardedProto)
+			}
+			w := httptest.NewRecorder()
+			handler.HandleLogin(w, req)
+
+			var sessionCookie *http.Cookie
+			for _, c := range w.Result().Cookies() {
+				if c.Name == "vire_session" {
+					sessionCookie = c
+				}
+			}
+			if sessionCookie == nil {
+				t.Fatal("expected session cookie")
+			}
+			if sessionCookie.Secure != tt.expectSecure {
+				t.Errorf("expected Secure=%v, got %v", tt.expectSecure, sessionCookie.Secure)
+			}
+		})
+	}
+}
+
+func TestLogoutHandler_CookieSecureFlag(t *testing.T) {
+	for _, devMode := range []bool{false, true} {
+		handler := NewAuthHandler(nil, devMode, "", "", []byte{})
+
+		req := httptest.NewRequest("POST", "/api/auth/logout", nil)
+		w := httptest.NewRecorder()
+		handler.HandleLogout(w, req)
+
+		var sessionCookie *http.Cookie
+		for _, c := range w.Result().Cookies() {
+			if c.Name == "vire_session" {
+				sessionCookie = c
+			}
+		}
+		if sessionCookie == nil {
+			t.Fatal("expected session cookie in logout response")
+		}
+		if sessionCookie.Secure != !devMode {
+			t.Errorf("devMode=%v: expected Secure=%v, got %v", devMode, !devMode, sessionCookie.Secure)
+		}
+	}
+}
+
 func TestLogoutHandler_RedirectIsHardcoded(t *testing.T) {
 	// Verify logout redirect cannot be influenced by query params (open redirect)
 	handler := NewAuthHandler(nil, true, "", "", []byte{})
@@ -1223,14 +1449,15 @@ func TestProfileHandler_POST_UnknownUser(t *testing.T) {
 }
 
 func TestProfileHandler_POST_HostileInputs(t *testing.T) {
-	// Verify hostile API key inputs are stored as-is (not interpreted) and don't crash.
+	// Verify hostile API key inputs are stored as-is (not interpreted) and don't crash, for every key field.
 	// html/template handles escaping on output — the storage layer should accept arbitrary strings.
 	var savedKeys []string
+	field := "navexa_key"
 	lookupFn := func(userID string) (*client.UserProfile, error) {
 		return &client.UserProfile{Username: "dev_user"}, nil
 	}
 	saveFn := func(userID string, fields map[string]string) error {
-		savedKeys = append(savedKeys, fields["navexa_key"])
+		savedKeys = append(savedKeys, fields[field])
 		return nil
 	}
 
@@ -1251,31 +1478,416 @@ func TestProfileHandler_POST_HostileInputs(t *testing.T) {
 		{"spaces around key", "  real-key  ", "real-key"},
 	}
 
-	for _, tc := range hostileInputs {
-		t.Run(tc.name, func(t *testing.T) {
-			savedKeys = nil
-			token := buildTestJWT("dev_user")
-			formData := url.Values{"navexa_key": {tc.input}}
-			req := httptest.NewRequest("POST", "/profile", strings.NewReader(formData.Encode()))
+	for _, field = range apiKeyFields {
+		for _, tc := range hostileInputs {
+			t.Run(field+"/"+tc.name, func(t *testing.T) {
+				savedKeys = nil
+				token := buildTestJWT("dev_user")
+				formData := url.Values{field: {tc.input}}
+				req := httptest.NewRequest("POST", "/profile", strings.NewReader(formData.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+				w := httptest.NewRecorder()
+
+				handler.HandleSaveProfile(w, req)
+
+				if w.Code != http.StatusFound {
+					t.Errorf("expected 302 for input %q, got %d", tc.name, w.Code)
+				}
+				if tc.expected == "" {
+					// Blank submits leave the stored key unchanged
+					if len(savedKeys) != 0 {
+						t.Errorf("expected no save call for blank input, got %d", len(savedKeys))
+					}
+					return
+				}
+				if len(savedKeys) != 1 {
+					t.Fatalf("expected 1 save call, got %d", len(savedKeys))
+				}
+				if savedKeys[0] != tc.expected {
+					t.Errorf("expected saved key %q, got %q", tc.expected, savedKeys[0])
+				}
+			})
+		}
+	}
+}
+
+func TestProfileHandler_POST_SavesAllProviderKeys(t *testing.T) {
+	var saved map[string]string
+	saveFn := func(userID string, fields map[string]string) error {
+		saved = fields
+		return nil
+	}
+
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	formData := url.Values{
+		"navexa_key": {" nx-key "},
+		"eodhd_key":  {"\teodhd-key\n"},
+		"gemini_key": {"  gemini-key"},
+	}
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleSaveProfile(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", w.Code)
+	}
+	expected := map[string]string{"navexa_key": "nx-key", "eodhd_key": "eodhd-key", "gemini_key": "gemini-key"}
+	for field, want := range expected {
+		if saved[field] != want {
+			t.Errorf("expected %s %q, got %q", field, want, saved[field])
+		}
+	}
+}
+
+func TestProfileHandler_POST_OnlySubmittedKeysSaved(t *testing.T) {
+	var saved map[string]string
+	saveFn := func(userID string, fields map[string]string) error {
+		saved = fields
+		return nil
+	}
+
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader("eodhd_key=eodhd-key"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleSaveProfile(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", w.Code)
+	}
+	if len(saved) != 1 || saved["eodhd_key"] != "eodhd-key" {
+		t.Errorf("expected only eodhd_key to be saved, got %v", saved)
+	}
+}
+
+func TestProfileHandler_POST_ClearIntent(t *testing.T) {
+	var saveCalls []map[string]string
+	saveFn := func(userID string, fields map[string]string) error {
+		saveCalls = append(saveCalls, fields)
+		return nil
+	}
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	tests := []struct {
+		name      string
+		form      string
+		status    int
+		location  string
+		wantSaved map[string]string
+	}{
+		{"blank submit keeps key", "navexa_key=+++", http.StatusFound, "/profile", nil},
+		{"explicit clear removes key", "clear_key=eodhd_key", http.StatusFound, "/profile?saved=1", map[string]string{"eodhd_key": ""}},
+		{"clear unknown field", "clear_key=password", http.StatusBadRequest, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saveCalls = nil
+			req := httptest.NewRequest("POST", "/profile", strings.NewReader(tt.form))
 			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-			req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+			req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
 			w := httptest.NewRecorder()
 
 			handler.HandleSaveProfile(w, req)
 
-			if w.Code != http.StatusFound {
-				t.Errorf("expected 302 for input %q, got %d", tc.name, w.Code)
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.location != "" && w.Header().Get("Location") != tt.location {
+				t.Errorf("expected redirect to %s, got %s", tt.location, w.Header().Get("Location"))
+			}
+			if tt.wantSaved == nil {
+				if len(saveCalls) != 0 {
+					t.Errorf("expected no save call, got %v", saveCalls)
+				}
+				return
+			}
+			if len(saveCalls) != 1 || len(saveCalls[0]) != len(tt.wantSaved) {
+				t.Fatalf("expected one save with %v, got %v", tt.wantSaved, saveCalls)
+			}
+			for field, want := range tt.wantSaved {
+				if got, ok := saveCalls[0][field]; !ok || got != want {
+					t.Errorf("expected %s saved as %q, got %q", field, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestProfileHandler_GET_RemoveKeyControls(t *testing.T) {
+	lookupFn := func(userID string) (*client.UserProfile, error) {
+		return &client.UserProfile{Username: "dev_user", NavexaKeySet: true, NavexaKeyPreview: "ab12"}, nil
+	}
+	handler := NewProfileHandler(nil, true, []byte{}, lookupFn, nil)
+
+	req := httptest.NewRequest("GET", "/profile", nil)
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleProfile(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `name="clear_key" value="navexa_key"`) {
+		t.Error("expected remove control for the configured Navexa key")
+	}
+	if strings.Contains(body, `value="eodhd_key"`) || strings.Contains(body, `value="gemini_key"`) {
+		t.Error("expected no remove control for unset keys")
+	}
+}
+
+func TestProfileHandler_POST_AuditsKeyChanges(t *testing.T) {
+	saveFn := func(userID string, fields map[string]string) error { return nil }
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	var auditBuf bytes.Buffer
+	handler.SetAuditLogger(common.NewDedicatedLoggerWithOutput("info", &auditBuf))
+
+	formData := url.Values{"navexa_key": {"super-secret-navexa"}, "clear_key": {"gemini_key"}}
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleSaveProfile(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected status 302, got %d", w.Code)
+	}
+	audit := auditBuf.String()
+	lines := strings.Split(strings.TrimSpace(audit), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d: %q", len(lines), audit)
+	}
+	for _, want := range []string{"user_id=dev_user", "field=navexa_key", "action=set", "field=gemini_key", "action=cleared", "timestamp="} {
+		if !strings.Contains(audit, want) {
+			t.Errorf("expected %q in audit log, got %q", want, audit)
+		}
+	}
+	if strings.Contains(audit, "super-secret-navexa") {
+		t.Error("SECURITY: key value written to audit log")
+	}
+}
+
+func TestProfileHandler_POST_NoAuditOnFailedSave(t *testing.T) {
+	saveFn := func(userID string, fields map[string]string) error { return fmt.Errorf("storage down") }
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	var auditBuf bytes.Buffer
+	handler.SetAuditLogger(common.NewDedicatedLoggerWithOutput("info", &auditBuf))
+
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader("navexa_key=k"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleSaveProfile(w, req)
+
+	if auditBuf.Len() != 0 {
+		t.Errorf("expected no audit entry for a failed save, got %q", auditBuf.String())
+	}
+}
+
+func TestProfileHandler_POST_NoKeyFields(t *testing.T) {
+	saveCalled := false
+	saveFn := func(userID string, fields map[string]string) error {
+		saveCalled = true
+		return nil
+	}
+
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader("other=value"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleSaveProfile(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+	if saveCalled {
+		t.Error("expected no save without key fields")
+	}
+}
+
+func TestProfileHandler_GET_MasksEachProviderKey(t *testing.T) {
+	lookupFn := func(userID string) (*client.UserProfile, error) {
+		return &client.UserProfile{
+			Username:         "dev_user",
+			NavexaKeySet:     true,
+			NavexaKeyPreview: "nx12",
+			EODHDKeySet:      true,
+			EODHDKeyPreview:  "eo34",
+			GeminiKeySet:     false,
+		}, nil
+	}
+
+	handler := NewProfileHandler(nil, true, []byte{}, lookupFn, nil)
+
+	req := httptest.NewRequest("GET", "/profile", nil)
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+
+	handler.HandleProfile(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"****nx12", "****eo34", `name="eodhd_key"`, `name="gemini_key"`, "GEMINI API KEY"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in profile page", want)
+		}
+	}
+	if strings.Count(body, "No API key configured.") != 1 {
+		t.Errorf("expected only the Gemini key to be reported missing, got %d", strings.Count(body, "No API key configured."))
+	}
+}
+
+func postTestKey(handler *ProfileHandler, body string, withAuth bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/settings/test-key", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if withAuth {
+		req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	}
+	w := httptest.NewRecorder()
+	handler.HandleTestKey(w, req)
+	return w
+}
+
+func TestProfileHandler_TestKey_ValidAndInvalid(t *testing.T) {
+	saveCalled := false
+	saveFn := func(userID string, fields map[string]string) error {
+		saveCalled = true
+		return nil
+	}
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+	handler.SetKeyTestFn(func(userID, provider, key string) (*client.KeyValidation, error) {
+		if key == "good-key" {
+			return &client.KeyValidation{Valid: true, Message: "Key accepted"}, nil
+		}
+		return &client.KeyValidation{Valid: false, Message: "Key " + key + " rejected"}, nil
+	})
+
+	tests := []struct {
+		name    string
+		key     string
+		valid   bool
+		message string
+	}{
+		{"valid", "good-key", true, "Key accepted"},
+		{"invalid", "bad-key", false, "Key **** rejected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postTestKey(handler, `{"provider":"navexa","key":"`+tt.key+`"}`, true)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", w.Code)
+			}
+			var resp map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
 			}
-			if len(savedKeys) != 1 {
-				t.Fatalf("expected 1 save call, got %d", len(savedKeys))
+			if resp["valid"] != tt.valid {
+				t.Errorf("expected valid %v, got %v", tt.valid, resp["valid"])
+			}
+			if resp["message"] != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, resp["message"])
+			}
+			if code, _ := resp["code"].(string); !tt.valid && code != ErrCodeInvalidKey {
+				t.Errorf("expected code %s for rejected key, got %q", ErrCodeInvalidKey, code)
+			} else if tt.valid && code != "" {
+				t.Errorf("expected no code for valid key, got %q", code)
+			}
+		})
+	}
+
+	if saveCalled {
+		t.Error("expected test-key not to persist the key")
+	}
+}
+
+func TestProfileHandler_TestKey_UpstreamErrorSanitized(t *testing.T) {
+	handler := NewProfileHandler(nil, true, []byte{}, nil, nil)
+	handler.SetKeyTestFn(func(userID, provider, key string) (*client.KeyValidation, error) {
+		return nil, fmt.Errorf("dial tcp: probe with %s failed", key)
+	})
+
+	w := postTestKey(handler, `{"provider":"eodhd","key":"secret-key"}`, true)
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if strings.Contains(body, "secret-key") || strings.Contains(body, "dial tcp") {
+		t.Errorf("expected sanitized error response, got %s", body)
+	}
+}
+
+func TestProfileHandler_TestKey_BadRequests(t *testing.T) {
+	handler := NewProfileHandler(nil, true, []byte{}, nil, nil)
+	handler.SetKeyTestFn(func(userID, provider, key string) (*client.KeyValidation, error) {
+		t.Error("key test function should not be called")
+		return nil, nil
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		auth   bool
+		status int
+		code   string
+	}{
+		{"no session", `{"provider":"navexa","key":"k"}`, false, http.StatusUnauthorized, ErrCodeUnauthorized},
+		{"unknown provider", `{"provider":"unknown","key":"k"}`, true, http.StatusBadRequest, ErrCodeUnknownProvider},
+		{"empty key", `{"provider":"gemini","key":"  "}`, true, http.StatusBadRequest, ErrCodeKeyRequired},
+		{"invalid body", `not json`, true, http.StatusBadRequest, ErrCodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postTestKey(handler, tt.body, tt.auth)
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
 			}
-			if savedKeys[0] != tc.expected {
-				t.Errorf("expected saved key %q, got %q", tc.expected, savedKeys[0])
+			if code := errorCode(t, w); code != tt.code {
+				t.Errorf("expected code %s, got %q", tt.code, code)
 			}
 		})
 	}
 }
 
+func TestProfileHandler_POST_BodyOverLimitReturns413(t *testing.T) {
+	saveCalled := false
+	saveFn := func(userID string, fields map[string]string) error {
+		saveCalled = true
+		return nil
+	}
+
+	handler := NewProfileHandler(nil, true, []byte{}, nil, saveFn)
+
+	req := httptest.NewRequest("POST", "/profile", strings.NewReader("navexa_key="+strings.Repeat("A", 2048)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: buildTestJWT("dev_user")})
+	w := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(w, req.Body, 1024)
+
+	handler.HandleSaveProfile(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "request body exceeds 1024 bytes") {
+		t.Errorf("expected size limit error, got %s", w.Body.String())
+	}
+	if saveCalled {
+		t.Error("expected save not to be called for oversized body")
+	}
+}
+
 func TestProfileHandler_POST_VeryLongKey(t *testing.T) {
 	// The 1MB body size limit from middleware protects against extreme payloads,
 	// but test that a moderately long key doesn't crash the handler.
@@ -1769,7 +2381,7 @@ func TestHeadTemplate_AlpineJSNotDeferred(t *testing.T) {
 		t.Error("expected common.js script tag in head")
 	}
 	// Alpine.js must have defer
-	if !strings.Contains(body, "defer src=\"https://cdn.jsdelivr.net/npm/alpinejs") {
+	if !strings.Contains(body, `<script defer src="`+alpineSrc("")+`"></script>`) {
 		t.Error("expected Alpine.js to be loaded with defer")
 	}
 }
@@ -2108,6 +2720,9 @@ func TestServerHealthHandler_Returns503WhenUpstreamUnreachable(t *testing.T) {
 	if body["status"] != "down" {
 		t.Errorf("expected status down, got %s", body["status"])
 	}
+	if body["code"] != ErrCodeUpstreamUnavailable {
+		t.Errorf("expected code %s, got %q", ErrCodeUpstreamUnavailable, body["code"])
+	}
 }
 
 func TestServerHealthHandler_Returns503WhenUpstreamReturnsNon200(t *testing.T) {
@@ -2286,8 +2901,8 @@ func TestServerHealthHandler_SlowUpstream(t *testing.T) {
 }
 
 func TestServerHealthHandler_UpstreamRedirect(t *testing.T) {
-	// If the upstream redirects, http.DefaultClient follows it by default.
-	// Verify the handler doesn't crash and treats the final response status correctly.
+	// Redirects are followed only to allow-listed hosts. A redirect to another
+	// host is refused (reported as down) unless SetAllowedHosts permits it.
 	redirectTarget := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"status":"ok"}`))
@@ -2301,14 +2916,17 @@ func TestServerHealthHandler_UpstreamRedirect(t *testing.T) {
 
 	handler := NewServerHealthHandler(nil, upstream.URL)
 
-	req := httptest.NewRequest("GET", "/api/server-health", nil)
 	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/server-health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for redirect to non-allow-listed host, got %d", w.Code)
+	}
 
-	handler.ServeHTTP(w, req)
-
-	// http.DefaultClient follows redirects; the final response should be 200
+	handler.SetAllowedHosts([]string{strings.TrimPrefix(redirectTarget.URL, "http://")})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/api/server-health", nil))
 	if w.Code != http.StatusOK {
-		t.Errorf("expected 200 after redirect, got %d", w.Code)
+		t.Errorf("expected 200 after redirect to allow-listed host, got %d", w.Code)
 	}
 }
 
@@ -2680,6 +3298,111 @@ func TestServePage_ContainsServerVersion(t *testing.T) {
 	}
 }
 
+func TestServePage_SlowServerVersionDoesNotBlockRender(t *testing.T) {
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"version":"9.9.9"}`))
+	}))
+	defer upstream.Close()
+	defer close(release)
+
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	handler.SetAPIURL(upstream.URL)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+
+	start := time.Now()
+	handler.ServePage("landing.html", "home")(w, req)
+	elapsed := time.Since(start)
+
+	if elapsed > serverVersionColdWait+500*time.Millisecond {
+		t.Errorf("expected render not to wait on slow upstream, took %v", elapsed)
+	}
+	if !strings.Contains(w.Body.String(), "Server: "+serverVersionPending) {
+		t.Errorf("expected footer to show pending server version while fetch is in flight")
+	}
+}
+
+func TestCachedServerVersion_ServesCachedValue(t *testing.T) {
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"version":"1.2.3"}`))
+	}))
+	defer upstream.Close()
+
+	for i := 0; i < 5; i++ {
+		if v := CachedServerVersion(upstream.URL); v != "1.2.3" {
+			t.Fatalf("expected cached version 1.2.3, got %q", v)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected 1 upstream fetch within TTL, got %d", n)
+	}
+}
+
+func TestCachedServerVersion_UnavailableAfterFailedFetch(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer upstream.Close()
+
+	// The failed fetch is retried once, so it can outlast the cold wait and
+	// render "pending" first.
+	v := CachedServerVersion(upstream.URL)
+	for deadline := time.Now().Add(serverVersionTimeout); v == serverVersionPending && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		v = CachedServerVersion(upstream.URL)
+	}
+	if v != "unavailable" {
+		t.Errorf("expected unavailable after failed fetch, got %q", v)
+	}
+}
+
+func TestGetServerVersion_RetriesOnce(t *testing.T) {
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"version":"1.2.3"}`))
+	}))
+	defer upstream.Close()
+
+	if v := GetServerVersion(upstream.URL); v != "1.2.3" {
+		t.Errorf("expected version after retry, got %q", v)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected 2 upstream requests, got %d", n)
+	}
+}
+
+func TestGetServerVersion_UnavailableWhenDown(t *testing.T) {
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	start := time.Now()
+	v := GetServerVersion(upstream.URL)
+	elapsed := time.Since(start)
+
+	if v != "unavailable" {
+		t.Errorf("expected unavailable, got %q", v)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected exactly 2 attempts, got %d", n)
+	}
+	if elapsed > serverVersionRetryDelay+time.Second {
+		t.Errorf("expected a prompt failure, took %v", elapsed)
+	}
+}
+
 func TestDashboardHandler_ContainsVersionFooter(t *testing.T) {
 	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
 
@@ -1,20 +1,62 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 )
 
 // RequireMethod validates that the HTTP request uses the specified method.
 // Returns true if the method matches, false otherwise (and writes error response).
+// See RequireMethods for HEAD and OPTIONS handling.
 func RequireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
-	if r.Method == method || (method == http.MethodGet && r.Method == http.MethodHead) {
-		return true
+	return RequireMethods(w, r, method)
+}
+
+// RequireMethods validates that the HTTP request uses one of the allowed
+// methods. Allowing GET also allows HEAD; net/http discards the body of HEAD
+// responses, so handlers can serve HEAD exactly like GET. OPTIONS is answered
+// with 204 and an Allow header listing the accepted methods. Returns true if
+// the handler should proceed; otherwise the response has been written.
+func RequireMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	allow := allowedMethods(methods)
+	for _, m := range allow {
+		if r.Method == m && m != http.MethodOptions {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(allow, ", "))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return false
 	}
 	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	return false
 }
 
+// allowedMethods expands methods with HEAD (when GET is allowed) and OPTIONS.
+func allowedMethods(methods []string) []string {
+	allow := make([]string, 0, len(methods)+2)
+	hasGet, hasHead := false, false
+	for _, m := range methods {
+		if m == http.MethodOptions {
+			continue
+		}
+		allow = append(allow, m)
+		hasGet = hasGet || m == http.MethodGet
+		hasHead = hasHead || m == http.MethodHead
+	}
+	if hasGet && !hasHead {
+		allow = append(allow, http.MethodHead)
+	}
+	return append(allow, http.MethodOptions)
+}
+
 // WriteJSON writes a JSON response with the specified status code and data.
 func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) error {
 	w.Header().Set("Content-Type", "application/json")
@@ -22,10 +64,130 @@ func WriteJSON(w http.ResponseWriter, statusCode int, data interface{}) error {
 	return json.NewEncoder(w).Encode(data)
 }
 
-// WriteError writes a standard error JSON response.
+// Stable error codes returned in the "code" field of error responses.
+// Clients should match on these rather than the human-readable message.
+const (
+	ErrCodeBadRequest          = "BAD_REQUEST"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeNotFound            = "NOT_FOUND"
+	ErrCodeInternal            = "INTERNAL_ERROR"
+	ErrCodeBodyTooLarge        = "BODY_TOO_LARGE"
+	ErrCodeMissingCredentials  = "MISSING_CREDENTIALS"
+	ErrCodeInvalidCredentials  = "INVALID_CREDENTIALS"
+	ErrCodeUnknownProvider     = "UNKNOWN_PROVIDER"
+	ErrCodeKeyRequired         = "KEY_REQUIRED"
+	ErrCodeInvalidKey          = "INVALID_KEY"
+	ErrCodeServiceUnavailable  = "SERVICE_UNAVAILABLE"
+	ErrCodeUpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
+	ErrCodeUpstreamError       = "UPSTREAM_ERROR"
+	ErrCodeDependencyDown      = "DEPENDENCY_DOWN"
+)
+
+// WriteError writes a standard error JSON response without an error code.
 func WriteError(w http.ResponseWriter, statusCode int, message string) error {
-	return WriteJSON(w, statusCode, map[string]string{
+	return WriteErrorCode(w, statusCode, "", message)
+}
+
+// WriteErrorCode writes a standard error JSON response with a stable error
+// code: {"status":"error","error":message,"code":code}. The code field is
+// omitted when code is empty.
+func WriteErrorCode(w http.ResponseWriter, statusCode int, code, message string) error {
+	return WriteErrorAction(w, statusCode, code, message, "")
+}
+
+// WriteErrorAction is WriteErrorCode with an "action" field naming the page
+// where the user can resolve the error (e.g. "/profile"). The action field
+// is omitted when action is empty.
+func WriteErrorAction(w http.ResponseWriter, statusCode int, code, message, action string) error {
+	resp := map[string]string{
 		"status": "error",
 		"error":  message,
-	})
+	}
+	if code != "" {
+		resp["code"] = code
+	}
+	if action != "" {
+		resp["action"] = action
+	}
+	return WriteJSON(w, statusCode, resp)
+}
+
+// WriteBodyTooLarge writes a 413 JSON error for a request body over limit bytes.
+func WriteBodyTooLarge(w http.ResponseWriter, limit int64) error {
+	return WriteErrorCode(w, http.StatusRequestEntityTooLarge, ErrCodeBodyTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
+
+// writeBodyReadError writes 413 when err comes from an http.MaxBytesReader
+// limit, and 400 with msg for any other body read failure.
+func writeBodyReadError(w http.ResponseWriter, err error, msg string) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return WriteBodyTooLarge(w, maxErr.Limit)
+	}
+	return WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, msg)
+}
+
+// jsonErrorPosition converts a byte offset in data to a 1-based line and column.
+func jsonErrorPosition(data []byte, offset int64) (line, column int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	prefix := data[:offset]
+	line = bytes.Count(prefix, []byte("\n")) + 1
+	column = int(offset) - (bytes.LastIndexByte(prefix, '\n') + 1)
+	if column < 1 {
+		column = 1
+	}
+	return line, column
+}
+
+// writeJSONParseError writes a 400 response describing why data failed to
+// decode, including the line and column when the decoder reports an offset.
+func writeJSONParseError(w http.ResponseWriter, data []byte, err error) error {
+	resp := map[string]interface{}{
+		"status": "error",
+		"error":  "invalid JSON: " + err.Error(),
+	}
+
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+		expected := typeErr.Type.String()
+		if k := typeErr.Type.Kind(); k == reflect.Map || k == reflect.Struct {
+			expected = "object"
+		}
+		resp["error"] = fmt.Sprintf("invalid JSON: expected %s, got %s", expected, typeErr.Value)
+	}
+	if offset >= 0 {
+		line, column := jsonErrorPosition(data, offset)
+		resp["line"] = line
+		resp["column"] = column
+	}
+
+	return WriteJSON(w, http.StatusBadRequest, resp)
+}
+
+// basePathKey is the request context key for the portal's mount sub-path.
+type basePathKey struct{}
+
+// WithBasePath returns a shallow copy of r whose context carries the sub-path
+// the portal is mounted under (e.g. "/vire").
+func WithBasePath(r *http.Request, basePath string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), basePathKey{}, basePath))
+}
+
+// BasePath returns the sub-path the portal is mounted under, or "" when it is
+// served from the root. Page templates prefix their links with it.
+func BasePath(r *http.Request) string {
+	p, _ := r.Context().Value(basePathKey{}).(string)
+	return p
 }
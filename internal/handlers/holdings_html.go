@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"slices"
+	"strings"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+)
+
+// holdingsTableTemplate renders a holdings table using the component-library
+// table classes. html/template escapes all holding fields. Named groups get a
+// header row and a subtotal row; the single unnamed group of an ungrouped
+// table gets neither.
+var holdingsTableTemplate = template.Must(template.New("holdings-table").Parse(`
+{{- if .StaleFor}}<p class="holdings-stale-note">Prices may be stale: last synced {{.StaleFor}} ago.</p>
+{{end -}}
+<div class="table-wrap">
+<table class="tool-table">
+<thead>
+<tr><th>Ticker</th><th>Name</th><th class="text-right">Value</th><th class="text-right">Weight%</th><th class="text-right">Return $</th><th class="text-right">Return %</th></tr>
+</thead>
+<tbody>
+{{- range .Groups}}
+{{- if .Name}}
+<tr class="holdings-group-row"><td colspan="6">{{.Name}}</td></tr>
+{{- end}}
+{{- range .Rows}}
+<tr><td class="tool-name">{{.Ticker}}</td><td>{{.Name}}</td><td class="text-right">{{.Value}}</td><td class="text-right{{if .Overweight}} holding-overweight{{end}}">{{if .Overweight}}▲ {{end}}{{.Weight}}</td><td class="text-right {{.GainClass}}">{{.Return}}</td><td class="text-right {{.GainClass}}">{{.ReturnPct}}</td></tr>
+{{- end}}
+{{- if .Name}}
+<tr class="holdings-subtotal-row"><td class="tool-name">SUBTOTAL</td><td></td><td class="text-right">{{.Subtotal.Value}}</td><td class="text-right">{{.Subtotal.Weight}}</td><td class="text-right {{.Subtotal.GainClass}}">{{.Subtotal.Return}}</td><td class="text-right"></td></tr>
+{{- end}}
+{{- end}}
+</tbody>
+<tfoot>
+<tr class="holdings-total-row"><td class="tool-name">TOTAL</td><td></td><td class="text-right text-bold">{{.TotalValue}}</td><td class="text-right"></td><td class="text-right text-bold {{.TotalGainClass}}">{{.TotalReturn}}</td><td class="text-right"></td></tr>
+</tfoot>
+</table>
+</div>
+{{- if .Overweight}}
+<p class="holdings-overweight-note">▲ Overweight (above {{.Threshold}}): {{range $i, $t := .Overweight}}{{if $i}}, {{end}}{{$t}}{{end}}</p>
+{{- end}}`))
+
+// noHoldingsHTML replaces the table when a portfolio has no open holdings,
+// instead of a header and TOTAL row around an empty body.
+const noHoldingsHTML template.HTML = `<p class="text-muted">No holdings found in this portfolio.</p>`
+
+// holdingsTableOptions controls how renderHoldingsHTML lays out the table.
+type holdingsTableOptions struct {
+	// GroupBy is "sector" to bucket holdings by sector; anything else
+	// renders a single ungrouped table.
+	GroupBy string
+	// OverweightPct flags holdings whose weight exceeds it; 0 disables.
+	OverweightPct float64
+	// Clock dates the stale-price notice; nil disables it.
+	Clock common.Clock
+}
+
+// holdingsGroupBySector buckets the holdings table under sector headers.
+const holdingsGroupBySector = "sector"
+
+// uncategorizedSector labels holdings without a sector when grouping.
+const uncategorizedSector = "Uncategorized"
+
+// holdingsRow is a single pre-formatted row of the holdings table.
+type holdingsRow struct {
+	Ticker, Name, Value, Weight, Return, ReturnPct, GainClass string
+	Overweight                                                bool
+}
+
+// holdingsGroup is a run of rows under one group header. Name is empty for
+// an ungrouped table.
+type holdingsGroup struct {
+	Name     string
+	Rows     []holdingsRow
+	Subtotal holdingsRow
+
+	value, weight, ret float64
+}
+
+// gainClass mirrors the dashboard's client-side gainClass helper.
+func gainClass(v float64) string {
+	switch {
+	case v > 0:
+		return "gain-positive"
+	case v < 0:
+		return "gain-negative"
+	default:
+		return ""
+	}
+}
+
+// renderHoldingsHTML renders the open holdings of a portfolio as an HTML table.
+// Holding values are converted to the portfolio currency with the portfolio's
+// FX rate and formatted with the same helpers as the text formatters.
+// A portfolio with no open holdings renders noHoldingsHTML.
+// With GroupBy "sector", holdings are bucketed under sector headers with
+// per-sector subtotals, sectors in name order and "Uncategorized" last.
+// Holdings above OverweightPct get a marker on their weight, and a note
+// under the table lists their tickers. A portfolio last synced longer ago
+// than common.FreshnessPortfolio gets a stale-price notice above the table.
+func renderHoldingsHTML(p models.Portfolio, opts holdingsTableOptions) (template.HTML, error) {
+	currency := p.Currency
+	data := struct {
+		Groups                                  []*holdingsGroup
+		TotalValue, TotalReturn, TotalGainClass string
+		Threshold                               string
+		Overweight                              []string
+		StaleFor                                string
+	}{}
+
+	groups := map[string]*holdingsGroup{}
+	var totalValue, totalReturn float64
+	for _, h := range p.Holdings {
+		if h.Units == 0 {
+			continue
+		}
+		value := common.ConvertCurrency(h.HoldingValueMarket, h.Currency, currency, p.FXRate)
+		ret := common.ConvertCurrency(h.HoldingReturnNet, h.Currency, currency, p.FXRate)
+		totalValue += value
+		totalReturn += ret
+
+		var name string
+		if opts.GroupBy == holdingsGroupBySector {
+			name = strings.TrimSpace(h.Sector)
+			if name == "" {
+				name = uncategorizedSector
+			}
+		}
+		g, ok := groups[name]
+		if !ok {
+			g = &holdingsGroup{Name: name}
+			groups[name] = g
+			data.Groups = append(data.Groups, g)
+		}
+		overweight := opts.OverweightPct > 0 && h.HoldingWeightPct > opts.OverweightPct
+		if overweight {
+			data.Overweight = append(data.Overweight, h.Ticker)
+		}
+		g.value += value
+		g.weight += h.HoldingWeightPct
+		g.ret += ret
+		g.Rows = append(g.Rows, holdingsRow{
+			Ticker:     h.Ticker,
+			Name:       h.Name,
+			Value:      common.FormatMoneyWithCurrency(value, currency),
+			Weight:     strings.TrimPrefix(common.FormatSignedPct(h.HoldingWeightPct), "+"),
+			Return:     common.FormatSignedMoneyWithCurrency(ret, currency),
+			ReturnPct:  common.FormatSignedPct(h.HoldingReturnNetPct),
+			GainClass:  gainClass(h.HoldingReturnNet),
+			Overweight: overweight,
+		})
+	}
+	if len(data.Groups) == 0 {
+		return noHoldingsHTML, nil
+	}
+
+	slices.SortStableFunc(data.Groups, func(a, b *holdingsGroup) int {
+		switch {
+		case a.Name == b.Name:
+			return 0
+		case a.Name == uncategorizedSector:
+			return 1
+		case b.Name == uncategorizedSector:
+			return -1
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for _, g := range data.Groups {
+		g.Subtotal = holdingsRow{
+			Value:     common.FormatMoneyWithCurrency(g.value, currency),
+			Weight:    strings.TrimPrefix(common.FormatSignedPct(g.weight), "+"),
+			Return:    common.FormatSignedMoneyWithCurrency(g.ret, currency),
+			GainClass: gainClass(g.ret),
+		}
+	}
+	data.TotalValue = common.FormatMoneyWithCurrency(totalValue, currency)
+	data.TotalReturn = common.FormatSignedMoneyWithCurrency(totalReturn, currency)
+	data.TotalGainClass = gainClass(totalReturn)
+	data.Threshold = strings.TrimPrefix(common.FormatSignedPct(opts.OverweightPct), "+")
+	if opts.Clock != nil && !p.LastSynced.IsZero() && !common.IsFreshAt(opts.Clock, p.LastSynced, common.FreshnessPortfolio) {
+		data.StaleFor = common.FormatDuration(opts.Clock.Now().Sub(p.LastSynced))
+	}
+
+	var buf bytes.Buffer
+	if err := holdingsTableTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
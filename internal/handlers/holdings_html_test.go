@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+)
+
+func TestRenderHoldingsHTML_EscapesHoldingName(t *testing.T) {
+	p := models.Portfolio{
+		Currency: "AUD",
+		Holdings: []models.Holding{
+			{Ticker: "EVIL", Name: `<script>alert("xss")</script>`, Units: 1, HoldingValueMarket: 100, Currency: "AUD"},
+		},
+	}
+
+	out, err := renderHoldingsHTML(p, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	html := string(out)
+	if strings.Contains(html, "<script>") {
+		t.Error("holding name must be HTML-escaped, found raw <script> tag")
+	}
+	if !strings.Contains(html, "&lt;script&gt;") {
+		t.Error("expected escaped script tag in output")
+	}
+}
+
+func TestRenderHoldingsHTML_TableClasses(t *testing.T) {
+	p := models.Portfolio{
+		Currency: "AUD",
+		Holdings: []models.Holding{
+			{Ticker: "BHP", Name: "BHP Group", Units: 10, HoldingValueMarket: 450, HoldingReturnNet: 50, Currency: "AUD"},
+			{Ticker: "CBA", Name: "Commonwealth Bank", Units: 5, HoldingValueMarket: 500, HoldingReturnNet: -20, Currency: "AUD"},
+		},
+	}
+
+	out, err := renderHoldingsHTML(p, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	html := string(out)
+	for _, want := range []string{"table-wrap", "tool-table", "holdings-total-row", "gain-positive", "gain-negative", "A$950.00"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in rendered holdings table", want)
+		}
+	}
+}
+
+func TestRenderHoldingsHTML_ConvertsToPortfolioCurrency(t *testing.T) {
+	p := models.Portfolio{
+		Currency: "AUD",
+		FXRate:   0.5,
+		Holdings: []models.Holding{
+			{Ticker: "AAPL", Name: "Apple", Units: 1, HoldingValueMarket: 100, Currency: "USD"},
+		},
+	}
+
+	out, err := renderHoldingsHTML(p, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(out), "A$200.00") {
+		t.Errorf("expected USD holding converted to A$200.00, got %s", out)
+	}
+}
+
+func TestRenderHoldingsHTML_SkipsClosedPositions(t *testing.T) {
+	p := models.Portfolio{
+		Currency: "AUD",
+		Holdings: []models.Holding{
+			{Ticker: "OPEN", Units: 1, HoldingValueMarket: 10},
+			{Ticker: "SOLD", Units: 0},
+		},
+	}
+
+	out, err := renderHoldingsHTML(p, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(out), "SOLD") {
+		t.Error("expected closed position to be excluded")
+	}
+}
+
+func TestRenderHoldingsHTML_NoHoldings(t *testing.T) {
+	tests := []struct {
+		name     string
+		holdings []models.Holding
+	}{
+		{"empty portfolio", nil},
+		{"only closed positions", []models.Holding{{Ticker: "SOLD", Units: 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := renderHoldingsHTML(models.Portfolio{Currency: "AUD", Holdings: tt.holdings}, holdingsTableOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			html := string(out)
+			if !strings.Contains(html, "No holdings found in this portfolio") {
+				t.Errorf("expected no-holdings message, got %s", html)
+			}
+			if strings.Contains(html, "<table") {
+				t.Error("expected no table for a portfolio without holdings")
+			}
+		})
+	}
+
+	out, err := renderHoldingsHTML(models.Portfolio{
+		Currency: "AUD",
+		Holdings: []models.Holding{{Ticker: "BHP", Units: 10, HoldingValueMarket: 450}},
+	}, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if html := string(out); !strings.Contains(html, "<table") || strings.Contains(html, "No holdings found") {
+		t.Errorf("expected a holdings table for a populated portfolio, got %s", html)
+	}
+}
+
+func TestRenderHoldingsHTML_GroupBySector(t *testing.T) {
+	p := models.Portfolio{
+		Currency: "AUD",
+		Holdings: []models.Holding{
+			{Ticker: "CBA", Units: 5, HoldingValueMarket: 500, HoldingWeightPct: 40, HoldingReturnNet: -20, Sector: "Financials"},
+			{Ticker: "BHP", Units: 10, HoldingValueMarket: 450, HoldingWeightPct: 36, HoldingReturnNet: 50, Sector: "Materials"},
+			{Ticker: "MYST", Units: 1, HoldingValueMarket: 100, HoldingWeightPct: 8},
+			{Ticker: "NAB", Units: 3, HoldingValueMarket: 200, HoldingWeightPct: 16, HoldingReturnNet: 10, Sector: "Financials"},
+		},
+	}
+
+	out, err := renderHoldingsHTML(p, holdingsTableOptions{GroupBy: "sector"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	html := string(out)
+
+	// Sector headers in name order, Uncategorized last, each holding under its sector
+	order := []string{">Financials<", ">CBA<", ">NAB<", "A$700.00", ">Materials<", ">BHP<", "A$450.00", ">Uncategorized<", ">MYST<", "A$100.00", "A$1,250.00"}
+	pos := 0
+	for _, want := range order {
+		i := strings.Index(html[pos:], want)
+		if i < 0 {
+			t.Fatalf("expected %q after position %d in grouped table:\n%s", want, pos, html)
+		}
+		pos += i + len(want)
+	}
+	if n := strings.Count(html, "holdings-group-row"); n != 3 {
+		t.Errorf("expected 3 sector headers, got %d", n)
+	}
+	if n := strings.Count(html, "holdings-subtotal-row"); n != 3 {
+		t.Errorf("expected 3 sector subtotals, got %d", n)
+	}
+	if !strings.Contains(html, "56.00%") {
+		t.Error("expected the Financials subtotal weight 56.00%")
+	}
+
+	// Without group_by the table stays flat
+	out, err = renderHoldingsHTML(p, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(out), "holdings-group-row") || strings.Contains(string(out), "holdings-subtotal-row") {
+		t.Error("expected no sector rows without group_by")
+	}
+}
+
+func TestRenderHoldingsHTML_FlagsOverweightHoldings(t *testing.T) {
+	p := models.Portfolio{
+		Currency: "AUD",
+		Holdings: []models.Holding{
+			{Ticker: "BHP", Units: 10, HoldingValueMarket: 1500, HoldingWeightPct: 15},
+			{Ticker: "CBA", Units: 5, HoldingValueMarket: 800, HoldingWeightPct: 8},
+			{Ticker: "EDGE", Units: 1, HoldingValueMarket: 1000, HoldingWeightPct: 10},
+		},
+	}
+
+	out, err := renderHoldingsHTML(p, holdingsTableOptions{OverweightPct: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	html := string(out)
+	if n := strings.Count(html, "holding-overweight"); n != 1 {
+		t.Errorf("expected only the 15%% holding flagged, got %d flags", n)
+	}
+	if !strings.Contains(html, "▲ 15.00%") {
+		t.Errorf("expected a marker on the 15%% weight, got %s", html)
+	}
+	if !strings.Contains(html, "Overweight (above 10.00%): BHP</p>") {
+		t.Errorf("expected a summary listing only BHP, got %s", html)
+	}
+
+	// A zero threshold disables the flag
+	out, err = renderHoldingsHTML(p, holdingsTableOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(out), "verweight") {
+		t.Error("expected no overweight flags without a threshold")
+	}
+}
+
+func TestDashboardHandler_SSR_HoldingsNoscriptFallback(t *testing.T) {
+	handler := NewDashboardHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		if path == "/api/portfolios" {
+			return []byte(`{"portfolios":[{"name":"Test"}],"default":"Test"}`), nil
+		}
+		if path == "/api/portfolios/Test" {
+			return []byte(`{"currency":"AUD","holdings":[{"ticker":"BHP","name":"BHP <b>Group</b>","units":1,"holding_value_market":45}]}`), nil
+		}
+		return []byte(`{}`), nil
+	})
+
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	addAuthCookie(req, "test-user")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<noscript>") {
+		t.Error("expected noscript holdings fallback in dashboard page")
+	}
+	if !strings.Contains(body, "BHP &lt;b&gt;Group&lt;/b&gt;") {
+		t.Error("expected escaped holding name in noscript holdings table")
+	}
+}
+
+func TestRenderHoldingsHTML_StaleNotice(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	clock := common.NewFakeClock(now)
+	holdings := []models.Holding{
+		{Ticker: "BHP", Name: "BHP Group", Units: 10, HoldingValueMarket: 450, Currency: "AUD"},
+	}
+
+	tests := []struct {
+		name       string
+		lastSynced time.Time
+		clock      common.Clock
+		want       string
+	}{
+		{"synced days ago", now.Add(-3*24*time.Hour - 2*time.Hour), clock, "last synced 3 days ago"},
+		{"synced an hour ago", now.Add(-time.Hour), clock, "last synced 1 hour ago"},
+		{"fresh", now.Add(-5 * time.Minute), clock, ""},
+		{"never synced", time.Time{}, clock, ""},
+		{"no clock", now.Add(-3 * 24 * time.Hour), nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := models.Portfolio{Currency: "AUD", LastSynced: tt.lastSynced, Holdings: holdings}
+			out, err := renderHoldingsHTML(p, holdingsTableOptions{Clock: tt.clock})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			html := string(out)
+			if tt.want == "" {
+				if strings.Contains(html, "holdings-stale-note") {
+					t.Errorf("expected no stale notice, got %s", html)
+				}
+				return
+			}
+			if !strings.Contains(html, "holdings-stale-note") || !strings.Contains(html, tt.want) {
+				t.Errorf("expected stale notice %q, got %s", tt.want, html)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"html/template"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -16,21 +17,19 @@ import (
 
 // PageHandler serves HTML pages rendered with Go templates.
 type PageHandler struct {
-	logger       *common.Logger
-	templates    *template.Template
-	devMode      bool
-	jwtSecret    []byte
-	apiURL       string
-	userLookupFn func(string) (*client.UserProfile, error)
-	proxyGetFn   func(path, userID string) ([]byte, error)
+	logger        *common.Logger
+	templates     *pageTemplates
+	devMode       bool
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	apiURL        string
+	userLookupFn  func(string) (*client.UserProfile, error)
+	proxyGetFn    func(path, userID string) ([]byte, error)
 }
 
 // NewPageHandler creates a new page handler that loads templates from the pages directory.
 func NewPageHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *PageHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &PageHandler{
 		logger:       logger,
@@ -41,6 +40,11 @@ func NewPageHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userL
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *PageHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *PageHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -89,14 +93,15 @@ func FindPagesDir() string {
 // ServePage creates a handler function for serving a specific page template.
 func (h *PageHandler) ServePage(templateName string, pageName string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+		loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 		// Auto-logout on landing page: clear session cookie
 		if pageName == "home" {
 			http.SetCookie(w, &http.Cookie{
-				Name:     "vire_session",
+				Name:     h.sessionCookie.cookieName(),
 				Value:    "",
 				Path:     "/",
+				Domain:   h.sessionCookie.Domain,
 				MaxAge:   -1,
 				HttpOnly: true,
 				SameSite: http.SameSiteStrictMode,
@@ -113,11 +118,13 @@ func (h *PageHandler) ServePage(templateName string, pageName string) http.Handl
 
 		data := map[string]interface{}{
 			"Page":          pageName,
+			"BasePath":      BasePath(r),
+			"Locale":        RequestLocale(r),
 			"DevMode":       h.devMode,
 			"LoggedIn":      loggedIn,
 			"UserRole":      userRole,
 			"PortalVersion": config.GetVersion(),
-			"ServerVersion": GetServerVersion(h.apiURL),
+			"ServerVersion": CachedServerVersion(h.apiURL),
 		}
 
 		if err := h.templates.ExecuteTemplate(w, templateName, data); err != nil {
@@ -132,7 +139,7 @@ func (h *PageHandler) ServePage(templateName string, pageName string) http.Handl
 // ServeErrorPage renders the error page with server-side resolved error message.
 func (h *PageHandler) ServeErrorPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+		loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 		var userRole string
 		if loggedIn && h.userLookupFn != nil && claims != nil && claims.Sub != "" {
 			if user, err := h.userLookupFn(claims.Sub); err == nil && user != nil {
@@ -155,11 +162,13 @@ func (h *PageHandler) ServeErrorPage() http.HandlerFunc {
 
 		data := map[string]interface{}{
 			"Page":          "error",
+			"BasePath":      BasePath(r),
+			"Locale":        RequestLocale(r),
 			"DevMode":       h.devMode,
 			"LoggedIn":      loggedIn,
 			"UserRole":      userRole,
 			"PortalVersion": config.GetVersion(),
-			"ServerVersion": GetServerVersion(h.apiURL),
+			"ServerVersion": CachedServerVersion(h.apiURL),
 			"ErrorMessage":  msg,
 		}
 		h.templates.ExecuteTemplate(w, "error.html", data)
@@ -171,7 +180,7 @@ func (h *PageHandler) ServeLandingPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Auto-logout: clear session cookie
 		http.SetCookie(w, &http.Cookie{
-			Name: "vire_session", Value: "", Path: "/",
+			Name: h.sessionCookie.cookieName(), Value: "", Path: "/", Domain: h.sessionCookie.Domain,
 			MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode,
 		})
 
@@ -179,11 +188,13 @@ func (h *PageHandler) ServeLandingPage() http.HandlerFunc {
 
 		data := map[string]interface{}{
 			"Page":          "home",
+			"BasePath":      BasePath(r),
+			"Locale":        RequestLocale(r),
 			"DevMode":       h.devMode,
 			"LoggedIn":      false,
 			"UserRole":      "",
 			"PortalVersion": config.GetVersion(),
-			"ServerVersion": GetServerVersion(h.apiURL),
+			"ServerVersion": CachedServerVersion(h.apiURL),
 			"ServerStatus":  serverUp,
 		}
 		h.templates.ExecuteTemplate(w, "landing.html", data)
@@ -194,8 +205,8 @@ func checkServerHealth(apiURL string) bool {
 	if apiURL == "" {
 		return false
 	}
-	client := &http.Client{Timeout: 3 * time.Second}
-	resp, err := client.Get(apiURL + "/api/health")
+	// Only apiURL's host is allowed, so a redirect cannot take the probe elsewhere
+	resp, err := config.NewHostAllowList(apiURL, nil).Client(3 * time.Second).Get(apiURL + "/api/health")
 	if err != nil {
 		return false
 	}
@@ -220,7 +231,7 @@ type GlossaryCategory struct {
 // ServeGlossaryPage renders the glossary page with server-side fetched data.
 func (h *PageHandler) ServeGlossaryPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+		loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 		var userRole string
 		if loggedIn && h.userLookupFn != nil && claims != nil && claims.Sub != "" {
 			if user, err := h.userLookupFn(claims.Sub); err == nil && user != nil {
@@ -246,11 +257,13 @@ func (h *PageHandler) ServeGlossaryPage() http.HandlerFunc {
 
 		data := map[string]interface{}{
 			"Page":          "glossary",
+			"BasePath":      BasePath(r),
+			"Locale":        RequestLocale(r),
 			"DevMode":       h.devMode,
 			"LoggedIn":      loggedIn,
 			"UserRole":      userRole,
 			"PortalVersion": config.GetVersion(),
-			"ServerVersion": GetServerVersion(h.apiURL),
+			"ServerVersion": CachedServerVersion(h.apiURL),
 			"Categories":    categories,
 			"FetchError":    fetchError,
 			"TermParam":     r.URL.Query().Get("term"),
@@ -262,7 +275,7 @@ func (h *PageHandler) ServeGlossaryPage() http.HandlerFunc {
 // ServeChangelogPage renders the changelog page with JSON hydration.
 func (h *PageHandler) ServeChangelogPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+		loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 		var userRole string
 		if loggedIn && h.userLookupFn != nil && claims != nil && claims.Sub != "" {
 			if user, err := h.userLookupFn(claims.Sub); err == nil && user != nil {
@@ -285,11 +298,13 @@ func (h *PageHandler) ServeChangelogPage() http.HandlerFunc {
 
 		data := map[string]interface{}{
 			"Page":          "changelog",
+			"BasePath":      BasePath(r),
+			"Locale":        RequestLocale(r),
 			"DevMode":       h.devMode,
 			"LoggedIn":      loggedIn,
 			"UserRole":      userRole,
 			"PortalVersion": config.GetVersion(),
-			"ServerVersion": GetServerVersion(h.apiURL),
+			"ServerVersion": CachedServerVersion(h.apiURL),
 			"EntriesJSON":   entriesJSON,
 		}
 		h.templates.ExecuteTemplate(w, "changelog.html", data)
@@ -299,7 +314,7 @@ func (h *PageHandler) ServeChangelogPage() http.HandlerFunc {
 // ServeHelpPage renders the help page with JSON hydration for feedback.
 func (h *PageHandler) ServeHelpPage() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+		loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 		if !loggedIn {
 			http.Redirect(w, r, "/", http.StatusFound)
 			return
@@ -332,11 +347,13 @@ func (h *PageHandler) ServeHelpPage() http.HandlerFunc {
 
 		data := map[string]interface{}{
 			"Page":          "help",
+			"BasePath":      BasePath(r),
+			"Locale":        RequestLocale(r),
 			"DevMode":       h.devMode,
 			"LoggedIn":      loggedIn,
 			"UserRole":      userRole,
 			"PortalVersion": config.GetVersion(),
-			"ServerVersion": GetServerVersion(h.apiURL),
+			"ServerVersion": CachedServerVersion(h.apiURL),
 			"FeedbackJSON":  feedbackJSON,
 			"FeedbackTotal": feedbackTotal,
 		}
@@ -344,22 +361,63 @@ func (h *PageHandler) ServeHelpPage() http.HandlerFunc {
 	}
 }
 
-// StaticFileHandler serves static files (CSS, JS, images).
+// StaticFileHandler serves static files (CSS, JS, images) from PagesFS.
 func (h *PageHandler) StaticFileHandler(w http.ResponseWriter, r *http.Request) {
-	pagesDir := FindPagesDir()
-	staticDir := filepath.Join(pagesDir, "static")
-
-	// Remove /static/ prefix from URL path
-	path := r.URL.Path[len("/static/"):]
-	fullPath := filepath.Join(staticDir, path)
-
-	// Security: prevent directory traversal
-	absStaticDir, _ := filepath.Abs(staticDir)
-	absFullPath, _ := filepath.Abs(fullPath)
-	if len(absFullPath) < len(absStaticDir) || absFullPath[:len(absStaticDir)] != absStaticDir {
+	// Remove /static/ prefix from URL path. fs.ValidPath rejects ".."
+	// elements, so requests cannot escape the static directory.
+	name := strings.TrimPrefix(r.URL.Path, "/static/")
+	if name == "" || !fs.ValidPath(name) {
 		http.NotFound(w, r)
 		return
 	}
+	http.ServeFileFS(w, r, PagesFS(), "static/"+name)
+}
+
+// assetCacheControl lets browsers keep the favicon and manifest for a day.
+const assetCacheControl = "public, max-age=86400"
 
-	http.ServeFile(w, r, fullPath)
+// FaviconHandler serves /favicon.ico from the static assets, so browsers
+// that request it directly get the icon instead of a 404.
+func (h *PageHandler) FaviconHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "image/x-icon")
+	w.Header().Set("Cache-Control", assetCacheControl)
+	http.ServeFileFS(w, r, PagesFS(), "static/favicon.ico")
+}
+
+// webManifest is the web app manifest served at /manifest.webmanifest.
+type webManifest struct {
+	Name            string            `json:"name"`
+	ShortName       string            `json:"short_name"`
+	StartURL        string            `json:"start_url"`
+	Display         string            `json:"display"`
+	BackgroundColor string            `json:"background_color"`
+	ThemeColor      string            `json:"theme_color"`
+	Icons           []webManifestIcon `json:"icons"`
+}
+
+// webManifestIcon is one icon in a webManifest.
+type webManifestIcon struct {
+	Src   string `json:"src"`
+	Sizes string `json:"sizes"`
+	Type  string `json:"type"`
+}
+
+// ManifestHandler serves a basic web app manifest. URLs include the base
+// path so an installed app opens under the portal's sub-path.
+func (h *PageHandler) ManifestHandler(w http.ResponseWriter, r *http.Request) {
+	base := BasePath(r)
+	manifest := webManifest{
+		Name:            "Vire",
+		ShortName:       "Vire",
+		StartURL:        base + "/dashboard",
+		Display:         "standalone",
+		BackgroundColor: "#ffffff",
+		ThemeColor:      "#000000",
+		Icons: []webManifestIcon{
+			{Src: base + "/favicon.ico", Sizes: "16x16", Type: "image/x-icon"},
+		},
+	}
+	w.Header().Set("Content-Type", "application/manifest+json")
+	w.Header().Set("Cache-Control", assetCacheControl)
+	json.NewEncoder(w).Encode(manifest)
 }
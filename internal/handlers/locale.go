@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocalePreferenceCookie holds the number/currency locale the user picked
+// explicitly. It overrides the browser's Accept-Language.
+const LocalePreferenceCookie = "vire_locale"
+
+// localePreferenceMaxAge keeps the locale for a year.
+const localePreferenceMaxAge = 365 * 24 * 60 * 60
+
+// DefaultLocale is used when neither the cookie nor Accept-Language names a
+// supported locale.
+const DefaultLocale = "en-AU"
+
+// supportedLocales are the locales pages can be rendered in, keyed by
+// lower-case tag.
+var supportedLocales = map[string]string{
+	"en-au": "en-AU",
+	"en-nz": "en-NZ",
+	"en-gb": "en-GB",
+	"en-us": "en-US",
+}
+
+// languageFallbacks maps a bare language to the locale used for it.
+var languageFallbacks = map[string]string{
+	"en": "en-AU",
+}
+
+// RequestLocale returns the locale to render r's page in: the
+// vire_locale cookie when it names a supported locale, otherwise the best
+// supported match for Accept-Language, otherwise DefaultLocale.
+func RequestLocale(r *http.Request) string {
+	if c, err := r.Cookie(LocalePreferenceCookie); err == nil {
+		if locale, ok := supportedLocale(c.Value); ok {
+			return locale
+		}
+	}
+	return acceptLanguageLocale(r.Header.Get("Accept-Language"))
+}
+
+// acceptLanguageLocale returns the best supported locale for an
+// Accept-Language header, or DefaultLocale.
+func acceptLanguageLocale(header string) string {
+	for _, tag := range ParseAcceptLanguage(header) {
+		if locale, ok := supportedLocale(tag); ok {
+			return locale
+		}
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if locale, ok := languageFallbacks[lang]; ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// supportedLocale returns the canonical form of tag if it is supported.
+// Underscores are accepted in place of hyphens ("en_US").
+func supportedLocale(tag string) (string, bool) {
+	locale, ok := supportedLocales[strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))]
+	return locale, ok
+}
+
+// ParseAcceptLanguage returns the language tags in an Accept-Language
+// header, highest quality first. Tags with q=0, "*", and malformed entries
+// are dropped; equal qualities keep header order.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	out := make([]string, len(tags))
+	for i, t := range tags {
+		out[i] = t.tag
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"en-US", []string{"en-US"}},
+		{"fr;q=0.5, en-GB, de;q=0.8", []string{"en-GB", "de", "fr"}},
+		{"en-NZ;q=0.9, en-US;q=0.9", []string{"en-NZ", "en-US"}},
+		{"*, en-US;q=0, ja;q=bad, en-AU;q=0.1", []string{"en-AU"}},
+	}
+	for _, tt := range tests {
+		got := ParseAcceptLanguage(tt.header)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ParseAcceptLanguage(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		want   string
+	}{
+		{"default", "", "", "en-AU"},
+		{"exact match", "en-us", "", "en-US"},
+		{"first supported by quality", "fr, en-GB;q=0.7, en-US;q=0.9", "", "en-US"},
+		{"bare language", "en", "", "en-AU"},
+		{"unsupported", "ja, de", "", "en-AU"},
+		{"cookie overrides header", "en-US", "en-GB", "en-GB"},
+		{"underscore cookie", "", "en_nz", "en-NZ"},
+		{"unsupported cookie ignored", "en-US", "xx-YY", "en-US"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: LocalePreferenceCookie, Value: tt.cookie})
+			}
+			if got := RequestLocale(req); got != tt.want {
+				t.Errorf("RequestLocale() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServePage_RendersRequestLocale(t *testing.T) {
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.8")
+	w := httptest.NewRecorder()
+	handler.ServePage("landing.html", "home")(w, req)
+
+	if !strings.Contains(w.Body.String(), `<meta name="vire-locale" content="en-US">`) {
+		t.Error("expected page to render the vire-locale meta from Accept-Language")
+	}
+}
+
+func TestPreferencesHandler_Locale(t *testing.T) {
+	handler := NewPreferencesHandler(nil, true, []byte(testJWTSecret))
+
+	req := httptest.NewRequest("POST", "/api/preferences/locale", strings.NewReader(`{"locale":"en-us"}`))
+	w := httptest.NewRecorder()
+	handler.HandleLocale(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == LocalePreferenceCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "en-US" {
+		t.Errorf("expected %s cookie set to 'en-US', got %v", LocalePreferenceCookie, cookie)
+	}
+
+	req = httptest.NewRequest("POST", "/api/preferences/locale", strings.NewReader(`{"locale":"fr-FR"}`))
+	w = httptest.NewRecorder()
+	handler.HandleLocale(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unsupported locale, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/preferences/locale", strings.NewReader(`{"locale":""}`))
+	req.Header.Set("Accept-Language", "en-GB")
+	w = httptest.NewRecorder()
+	handler.HandleLocale(w, req)
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["locale"] != "en-GB" {
+		t.Errorf("expected cleared locale to report en-GB, got %s", w.Body.String())
+	}
+	cleared := false
+	for _, c := range w.Result().Cookies() {
+		if c.Name == LocalePreferenceCookie && c.MaxAge < 0 {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("expected empty locale to clear the cookie")
+	}
+}
@@ -2,9 +2,7 @@ package handlers
 
 import (
 	"fmt"
-	"html/template"
 	"net/http"
-	"path/filepath"
 
 	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
@@ -13,19 +11,22 @@ import (
 
 // MCPPageTool holds display-only fields for a tool on the MCP page.
 type MCPPageTool struct {
-	Name        string
-	Description string
-	Method      string
-	Path        string
+	Name              string
+	Description       string
+	Method            string
+	Path              string
+	Deprecated        bool
+	DeprecatedMessage string
 }
 
 // MCPPageHandler serves the MCP info page showing connection details and tools.
 type MCPPageHandler struct {
 	logger         *common.Logger
-	templates      *template.Template
+	templates      *pageTemplates
 	devMode        bool
 	port           int
 	jwtSecret      []byte
+	sessionCookie  SessionCookie
 	catalogFn      func() []MCPPageTool
 	userLookupFn   func(string) (*client.UserProfile, error)
 	devMCPEndpoint func(userID string) string
@@ -35,10 +36,7 @@ type MCPPageHandler struct {
 
 // NewMCPPageHandler creates a new MCP page handler.
 func NewMCPPageHandler(logger *common.Logger, devMode bool, port int, jwtSecret []byte, catalogFn func() []MCPPageTool, userLookupFn func(string) (*client.UserProfile, error)) *MCPPageHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &MCPPageHandler{
 		logger:       logger,
@@ -51,6 +49,11 @@ func NewMCPPageHandler(logger *common.Logger, devMode bool, port int, jwtSecret
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *MCPPageHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *MCPPageHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -68,7 +71,7 @@ func (h *MCPPageHandler) SetDevMCPEndpointFn(fn func(userID string) string) {
 
 // ServeHTTP renders the MCP info page.
 func (h *MCPPageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 	if !loggedIn {
 		http.Redirect(w, r, "/", http.StatusFound)
@@ -103,6 +106,8 @@ func (h *MCPPageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Page":           "mcp",
+		"BasePath":       BasePath(r),
+		"Locale":         RequestLocale(r),
 		"DevMode":        h.devMode,
 		"LoggedIn":       loggedIn,
 		"Tools":          tools,
@@ -113,7 +118,7 @@ func (h *MCPPageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"Port":           h.port,
 		"UserRole":       userRole,
 		"PortalVersion":  config.GetVersion(),
-		"ServerVersion":  GetServerVersion(h.apiURL),
+		"ServerVersion":  CachedServerVersion(h.apiURL),
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "mcp.html", data); err != nil {
@@ -5,7 +5,6 @@ import (
 	"html/template"
 	"net/http"
 	"net/url"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -17,21 +16,19 @@ import (
 
 // MobileDashboardHandler serves the mobile-optimized dashboard page.
 type MobileDashboardHandler struct {
-	logger       *common.Logger
-	templates    *template.Template
-	devMode      bool
-	jwtSecret    []byte
-	userLookupFn func(string) (*client.UserProfile, error)
-	apiURL       string
-	proxyGetFn   func(path, userID string) ([]byte, error)
+	logger        *common.Logger
+	templates     *pageTemplates
+	devMode       bool
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	userLookupFn  func(string) (*client.UserProfile, error)
+	apiURL        string
+	proxyGetFn    func(path, userID string) ([]byte, error)
 }
 
 // NewMobileDashboardHandler creates a new mobile dashboard handler.
 func NewMobileDashboardHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *MobileDashboardHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &MobileDashboardHandler{
 		logger:       logger,
@@ -42,6 +39,11 @@ func NewMobileDashboardHandler(logger *common.Logger, devMode bool, jwtSecret []
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *MobileDashboardHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *MobileDashboardHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -54,7 +56,7 @@ func (h *MobileDashboardHandler) SetProxyGetFn(fn func(path, userID string) ([]b
 
 // ServeHTTP renders the mobile dashboard page.
 func (h *MobileDashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 	if !loggedIn {
 		http.Redirect(w, r, "/", http.StatusFound)
@@ -177,12 +179,14 @@ func (h *MobileDashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 
 	data := map[string]interface{}{
 		"Page":              "mobile",
+		"BasePath":          BasePath(r),
+		"Locale":            RequestLocale(r),
 		"DevMode":           h.devMode,
 		"LoggedIn":          loggedIn,
 		"NavexaKeyMissing":  navexaKeyMissing,
 		"UserRole":          userRole,
 		"PortalVersion":     config.GetVersion(),
-		"ServerVersion":     GetServerVersion(h.apiURL),
+		"ServerVersion":     CachedServerVersion(h.apiURL),
 		"PortfoliosJSON":    portfoliosJSON,
 		"PortfolioJSON":     portfolioJSON,
 		"TimelineJSON":      timelineJSON,
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// PortfolioPreferenceCookie holds the portfolio last selected in the UI.
+const PortfolioPreferenceCookie = "vire_portfolio"
+
+// portfolioPreferenceMaxAge keeps the selection for a year.
+const portfolioPreferenceMaxAge = 365 * 24 * 60 * 60
+
+// PreferencesHandler stores per-user UI preferences.
+type PreferencesHandler struct {
+	logger         *common.Logger
+	devMode        bool
+	jwtSecret      []byte
+	sessionCookie  SessionCookie
+	proxyGetFn     func(path, userID string) ([]byte, error)
+	userSaveFn     func(userID string, fields map[string]string) error
+	setPortfolioFn func(userID, name string)
+	setTimezoneFn  func(userID, timezone string)
+	timezone       string // configured user.timezone
+}
+
+// NewPreferencesHandler creates a new preferences handler.
+func NewPreferencesHandler(logger *common.Logger, devMode bool, jwtSecret []byte) *PreferencesHandler {
+	return &PreferencesHandler{
+		logger:    logger,
+		devMode:   devMode,
+		jwtSecret: jwtSecret,
+	}
+}
+
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *PreferencesHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
+// SetProxyGetFn sets the proxy GET function used to list the user's portfolios.
+func (h *PreferencesHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, error)) {
+	h.proxyGetFn = fn
+}
+
+// SetUserSaveFn sets the function that saves a logged-in user's portfolio and
+// timezone preferences to their vire-server profile.
+func (h *PreferencesHandler) SetUserSaveFn(fn func(userID string, fields map[string]string) error) {
+	h.userSaveFn = fn
+}
+
+// SetPortfolioFn sets the function notified of a saved portfolio selection
+// (the MCP handler's SetPortfolioPreference).
+func (h *PreferencesHandler) SetPortfolioFn(fn func(userID, name string)) {
+	h.setPortfolioFn = fn
+}
+
+// SetTimezone sets the configured display timezone, reported when a user
+// clears their own.
+func (h *PreferencesHandler) SetTimezone(timezone string) {
+	h.timezone = timezone
+}
+
+// SetTimezoneFn sets the function notified of a logged-in user's saved
+// timezone (the MCP handler's SetTimezonePreference).
+func (h *PreferencesHandler) SetTimezoneFn(fn func(userID, timezone string)) {
+	h.setTimezoneFn = fn
+}
+
+// HandlePortfolio handles POST /api/preferences/portfolio.
+// Body: {"name":"Personal"}. The name must be one of the user's portfolios.
+// The selection is saved as default_portfolio on the user's profile, becomes
+// their default portfolio for MCP tool calls and is mirrored in the
+// vire_portfolio cookie. Returns {"portfolio":name}.
+func (h *PreferencesHandler) HandlePortfolio(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteErrorCode(w, http.StatusUnauthorized, ErrCodeUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&req); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "name is required")
+		return
+	}
+
+	if h.proxyGetFn == nil || h.userSaveFn == nil || h.setPortfolioFn == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "preferences unavailable")
+		return
+	}
+
+	body, err := h.proxyGetFn("/api/portfolios", claims.Sub)
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Warn().Str("error", err.Error()).Msg("failed to list portfolios for preference")
+		}
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "unable to verify portfolio")
+		return
+	}
+	var list struct {
+		Portfolios []struct {
+			Name string `json:"name"`
+		} `json:"portfolios"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "unable to verify portfolio")
+		return
+	}
+	found := false
+	for _, p := range list.Portfolios {
+		if p.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "unknown portfolio")
+		return
+	}
+
+	if err := h.userSaveFn(claims.Sub, map[string]string{"default_portfolio": name}); err != nil {
+		if h.logger != nil {
+			h.logger.Warn().Str("error", err.Error()).Msg("failed to save portfolio preference")
+		}
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "unable to save portfolio")
+		return
+	}
+	h.setPortfolioFn(claims.Sub, name)
+	http.SetCookie(w, &http.Cookie{
+		Name:     PortfolioPreferenceCookie,
+		Value:    name,
+		Path:     "/",
+		MaxAge:   portfolioPreferenceMaxAge,
+		HttpOnly: true,
+		Secure:   isSecureCookie(r, h.devMode),
+		SameSite: http.SameSiteLaxMode,
+	})
+	WriteJSON(w, http.StatusOK, map[string]string{"portfolio": name})
+}
+
+// HandleLocale handles POST /api/preferences/locale.
+// Body: {"locale":"en-US"}. The locale must be supported; it is stored in the
+// vire_locale cookie and overrides Accept-Language for rendered pages. An
+// empty locale clears the cookie. Returns {"locale":locale}, where an empty
+// request reports the locale now chosen from Accept-Language.
+func (h *PreferencesHandler) HandleLocale(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&req); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body")
+		return
+	}
+
+	cookie := &http.Cookie{
+		Name:     LocalePreferenceCookie,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   isSecureCookie(r, h.devMode),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if strings.TrimSpace(req.Locale) == "" {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+		WriteJSON(w, http.StatusOK, map[string]string{"locale": acceptLanguageLocale(r.Header.Get("Accept-Language"))})
+		return
+	}
+	locale, ok := supportedLocale(req.Locale)
+	if !ok {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "unsupported locale")
+		return
+	}
+	cookie.Value = locale
+	cookie.MaxAge = localePreferenceMaxAge
+	http.SetCookie(w, cookie)
+	WriteJSON(w, http.StatusOK, map[string]string{"locale": locale})
+}
+
+// HandleTimezone handles POST /api/preferences/timezone.
+// Body: {"timezone":"America/New_York"}. The timezone must be an IANA zone;
+// it is stored in the vire_timezone cookie, forwarded to vire-server as
+// X-Vire-Timezone and used to render timestamps. For a logged-in user it is
+// also saved as timezone on their profile and sent on their MCP tool calls. An empty timezone clears the preference.
+// Returns {"timezone":tz}, where an empty request reports the configured zone.
+func (h *PreferencesHandler) HandleTimezone(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&req); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body")
+		return
+	}
+
+	tz := ""
+	if strings.TrimSpace(req.Timezone) != "" {
+		valid, ok := validTimezone(req.Timezone)
+		if !ok {
+			WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "unknown timezone")
+			return
+		}
+		tz = valid
+	}
+
+	if loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret); loggedIn && claims != nil && claims.Sub != "" && h.userSaveFn != nil {
+		if err := h.userSaveFn(claims.Sub, map[string]string{"timezone": tz}); err != nil {
+			if h.logger != nil {
+				h.logger.Warn().Str("error", err.Error()).Msg("failed to save timezone preference")
+			}
+			WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "unable to save timezone")
+			return
+		}
+		if h.setTimezoneFn != nil {
+			h.setTimezoneFn(claims.Sub, tz)
+		}
+	}
+
+	cookie := &http.Cookie{
+		Name:     TimezonePreferenceCookie,
+		Value:    tz,
+		Path:     "/",
+		MaxAge:   timezonePreferenceMaxAge,
+		HttpOnly: true,
+		Secure:   isSecureCookie(r, h.devMode),
+		SameSite: http.SameSiteLaxMode,
+	}
+	if tz == "" {
+		cookie.MaxAge = -1
+		tz = h.timezone
+	}
+	http.SetCookie(w, cookie)
+	WriteJSON(w, http.StatusOK, map[string]string{"timezone": tz})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestPreferencesHandler records profile saves in profiles and MCP
+// notifications in saved.
+func newTestPreferencesHandler(saved map[string]string, profiles map[string]map[string]string) *PreferencesHandler {
+	handler := NewPreferencesHandler(nil, true, []byte(testJWTSecret))
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return []byte(`{"portfolios":[{"name":"SMSF"},{"name":"Personal"}],"default":"SMSF"}`), nil
+	})
+	handler.SetUserSaveFn(func(userID string, fields map[string]string) error {
+		profiles[userID] = fields
+		return nil
+	})
+	handler.SetPortfolioFn(func(userID, name string) {
+		saved[userID] = name
+	})
+	return handler
+}
+
+func TestPreferencesHandler_Portfolio_Valid(t *testing.T) {
+	saved := map[string]string{}
+	profiles := map[string]map[string]string{}
+	handler := newTestPreferencesHandler(saved, profiles)
+
+	req := httptest.NewRequest("POST", "/api/preferences/portfolio", strings.NewReader(`{"name":"Personal"}`))
+	addAuthCookie(req, "user-1")
+	w := httptest.NewRecorder()
+	handler.HandlePortfolio(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := profiles["user-1"]["default_portfolio"]; got != "Personal" {
+		t.Errorf("expected default_portfolio 'Personal' saved to profile, got %v", profiles["user-1"])
+	}
+	if saved["user-1"] != "Personal" {
+		t.Errorf("expected preference 'Personal' for user-1, got %q", saved["user-1"])
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["portfolio"] != "Personal" {
+		t.Errorf("expected {\"portfolio\":\"Personal\"}, got %s", w.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == PortfolioPreferenceCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "Personal" {
+		t.Errorf("expected %s cookie set to 'Personal', got %v", PortfolioPreferenceCookie, cookie)
+	}
+}
+
+func TestPreferencesHandler_Portfolio_Rejected(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		auth   bool
+		status int
+	}{
+		{"unauthenticated", `{"name":"Personal"}`, false, http.StatusUnauthorized},
+		{"invalid body", `{`, true, http.StatusBadRequest},
+		{"empty name", `{"name":"  "}`, true, http.StatusBadRequest},
+		{"unknown portfolio", `{"name":"Other"}`, true, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := map[string]string{}
+			profiles := map[string]map[string]string{}
+			handler := newTestPreferencesHandler(saved, profiles)
+
+			req := httptest.NewRequest("POST", "/api/preferences/portfolio", strings.NewReader(tt.body))
+			if tt.auth {
+				addAuthCookie(req, "user-1")
+			}
+			w := httptest.NewRecorder()
+			handler.HandlePortfolio(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, w.Code)
+			}
+			if len(saved) != 0 || len(profiles) != 0 {
+				t.Errorf("expected no preference saved, got %v / %v", saved, profiles)
+			}
+		})
+	}
+}
+
+func TestPreferencesHandler_Portfolio_SaveFailed(t *testing.T) {
+	saved := map[string]string{}
+	handler := newTestPreferencesHandler(saved, map[string]map[string]string{})
+	handler.SetUserSaveFn(func(userID string, fields map[string]string) error {
+		return errors.New("vire-server unavailable")
+	})
+
+	req := httptest.NewRequest("POST", "/api/preferences/portfolio", strings.NewReader(`{"name":"Personal"}`))
+	addAuthCookie(req, "user-1")
+	w := httptest.NewRecorder()
+	handler.HandlePortfolio(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", w.Code)
+	}
+	if len(saved) != 0 {
+		t.Errorf("expected MCP preference untouched after failed save, got %v", saved)
+	}
+	for _, c := range w.Result().Cookies() {
+		if c.Name == PortfolioPreferenceCookie {
+			t.Errorf("expected no %s cookie after failed save", PortfolioPreferenceCookie)
+		}
+	}
+}
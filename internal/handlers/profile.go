@@ -1,9 +1,10 @@
 package handlers
 
 import (
-	"html/template"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -15,21 +16,21 @@ import (
 // ProfileHandler serves the profile page and handles profile updates.
 type ProfileHandler struct {
 	logger         *common.Logger
-	templates      *template.Template
+	templates      *pageTemplates
 	devMode        bool
 	jwtSecret      []byte
+	sessionCookie  SessionCookie
 	userLookupFn   func(string) (*client.UserProfile, error)
 	userSaveFn     func(string, map[string]string) error
 	devMCPEndpoint func(userID string) string
 	apiURL         string
+	keyTestFn      func(userID, provider, key string) (*client.KeyValidation, error)
+	auditLogger    *common.Logger
 }
 
 // NewProfileHandler creates a new profile handler.
 func NewProfileHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error), userSaveFn func(string, map[string]string) error) *ProfileHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &ProfileHandler{
 		logger:       logger,
@@ -41,11 +42,26 @@ func NewProfileHandler(logger *common.Logger, devMode bool, jwtSecret []byte, us
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *ProfileHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetDevMCPEndpointFn sets the function to generate dev-mode MCP endpoints.
 func (h *ProfileHandler) SetDevMCPEndpointFn(fn func(userID string) string) {
 	h.devMCPEndpoint = fn
 }
 
+// SetKeyTestFn sets the function used to validate a provider key without saving it.
+func (h *ProfileHandler) SetKeyTestFn(fn func(userID, provider, key string) (*client.KeyValidation, error)) {
+	h.keyTestFn = fn
+}
+
+// SetAuditLogger sets the logger that records API key changes.
+func (h *ProfileHandler) SetAuditLogger(logger *common.Logger) {
+	h.auditLogger = logger
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *ProfileHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -53,7 +69,7 @@ func (h *ProfileHandler) SetAPIURL(apiURL string) {
 
 // HandleProfile serves GET /profile.
 func (h *ProfileHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 	// Redirect unauthenticated users to landing page
 	if !loggedIn {
@@ -88,14 +104,20 @@ func (h *ProfileHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Page":             "profile",
+		"BasePath":         BasePath(r),
+		"Locale":           RequestLocale(r),
 		"DevMode":          h.devMode,
 		"LoggedIn":         loggedIn,
 		"NavexaKeySet":     false,
 		"NavexaKeyPreview": "",
+		"EODHDKeySet":      false,
+		"EODHDKeyPreview":  "",
+		"GeminiKeySet":     false,
+		"GeminiKeyPreview": "",
 		"Saved":            r.URL.Query().Get("saved") == "1",
 		"CSRFToken":        csrfToken,
 		"PortalVersion":    config.GetVersion(),
-		"ServerVersion":    GetServerVersion(h.apiURL),
+		"ServerVersion":    CachedServerVersion(h.apiURL),
 		"UserEmail":        userEmail,
 		"UserName":         userName,
 		"AuthMethod":       authMethod,
@@ -107,12 +129,16 @@ func (h *ProfileHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
 		if err == nil && user != nil {
 			data["NavexaKeySet"] = user.NavexaKeySet
 			data["NavexaKeyPreview"] = user.NavexaKeyPreview
+			data["EODHDKeySet"] = user.EODHDKeySet
+			data["EODHDKeyPreview"] = user.EODHDKeyPreview
+			data["GeminiKeySet"] = user.GeminiKeySet
+			data["GeminiKeyPreview"] = user.GeminiKeyPreview
 			data["UserRole"] = user.Role
 		}
 	}
 
 	if h.devMode && claims != nil {
-		if cookie, err := r.Cookie("vire_session"); err == nil {
+		if cookie, err := r.Cookie(h.sessionCookie.cookieName()); err == nil {
 			data["JWTToken"] = cookie.Value
 		}
 		data["JWTSub"] = claims.Sub
@@ -138,9 +164,22 @@ func (h *ProfileHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// apiKeyFields are the provider API key form fields accepted by HandleSaveProfile.
+var apiKeyFields = []string{"navexa_key", "eodhd_key", "gemini_key"}
+
+// isAPIKeyField reports whether field is one of apiKeyFields.
+func isAPIKeyField(field string) bool {
+	for _, f := range apiKeyFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleSaveProfile handles POST /profile.
 func (h *ProfileHandler) HandleSaveProfile(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 	if !loggedIn || claims == nil || claims.Sub == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -152,23 +191,162 @@ func (h *ProfileHandler) HandleSaveProfile(w http.ResponseWriter, r *http.Reques
 	}
 
 	if err := r.ParseForm(); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			WriteBodyTooLarge(w, maxErr.Limit)
+			return
+		}
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
 
-	navexaKey := strings.TrimSpace(r.FormValue("navexa_key"))
+	// Only non-empty fields present in the form are saved, so each key can be
+	// updated from its own form without clearing the others, and a blank
+	// submit leaves the stored key unchanged. Removing a key requires the
+	// explicit clear_key intent naming the field.
+	fields := make(map[string]string)
+	submitted := false
+	for _, field := range apiKeyFields {
+		if _, ok := r.PostForm[field]; ok {
+			submitted = true
+			if value := strings.TrimSpace(r.PostForm.Get(field)); value != "" {
+				fields[field] = value
+			}
+		}
+	}
+	if clear := r.PostForm.Get("clear_key"); clear != "" {
+		if !isAPIKeyField(clear) {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		submitted = true
+		fields[clear] = ""
+	}
+	if !submitted {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if len(fields) == 0 {
+		// Blank submit: nothing changed
+		http.Redirect(w, r, "/profile", http.StatusFound)
+		return
+	}
 
-	if err := h.userSaveFn(claims.Sub, map[string]string{"navexa_key": navexaKey}); err != nil {
+	if err := h.userSaveFn(claims.Sub, fields); err != nil {
 		if h.logger != nil {
 			h.logger.Error().Str("error", err.Error()).Msg("failed to save user profile")
 		}
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	h.auditKeyChanges(claims.Sub, fields)
 
 	http.Redirect(w, r, "/profile?saved=1", http.StatusFound)
 }
 
+// keyProviders maps the providers accepted by HandleTestKey to their form field.
+var keyProviders = map[string]string{
+	"navexa": "navexa_key",
+	"eodhd":  "eodhd_key",
+	"gemini": "gemini_key",
+}
+
+// HandleTestKey handles POST /api/settings/test-key.
+// Body: {"provider":"navexa","key":"..."}. Validates the key via vire-server
+// without persisting it and returns {"valid":bool,"message":string}.
+// The key is never logged and is masked out of any message returned.
+func (h *ProfileHandler) HandleTestKey(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteErrorCode(w, http.StatusUnauthorized, ErrCodeUnauthorized, "authentication required")
+		return
+	}
+
+	var req struct {
+		Provider string `json:"provider"`
+		Key      string `json:"key"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64<<10)).Decode(&req); err != nil {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "invalid request body")
+		return
+	}
+	provider := strings.ToLower(strings.TrimSpace(req.Provider))
+	key := strings.TrimSpace(req.Key)
+	if _, ok := keyProviders[provider]; !ok {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeUnknownProvider, "unknown provider")
+		return
+	}
+	if key == "" {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeKeyRequired, "key is required")
+		return
+	}
+
+	if h.keyTestFn == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "key validation unavailable")
+		return
+	}
+
+	result, err := h.keyTestFn(claims.Sub, provider, key)
+	if err != nil || result == nil {
+		if h.logger != nil {
+			msg := "unknown error"
+			if err != nil {
+				msg = strings.ReplaceAll(err.Error(), key, "****")
+			}
+			h.logger.Warn().Str("provider", provider).Str("error", msg).Msg("key validation failed")
+		}
+		WriteJSON(w, http.StatusBadGateway, map[string]interface{}{
+			"valid":   false,
+			"message": "Unable to validate the key right now. Try again later.",
+			"code":    ErrCodeUpstreamError,
+		})
+		return
+	}
+
+	message := strings.ReplaceAll(result.Message, key, "****")
+	if message == "" {
+		if result.Valid {
+			message = "Key is valid."
+		} else {
+			message = "Key was rejected by the provider."
+		}
+	}
+	resp := map[string]interface{}{
+		"valid":   result.Valid,
+		"message": message,
+	}
+	if !result.Valid {
+		resp["code"] = ErrCodeInvalidKey
+	}
+	WriteJSON(w, http.StatusOK, resp)
+}
+
+// auditKeyChanges writes one audit entry per changed key field. Only the field
+// name and whether it was set or cleared are recorded, never the value.
+func (h *ProfileHandler) auditKeyChanges(userID string, fields map[string]string) {
+	if h.auditLogger == nil {
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, field := range apiKeyFields {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+		action := "set"
+		if value == "" {
+			action = "cleared"
+		}
+		h.auditLogger.Info().
+			Str("event", "api_key_change").
+			Str("user_id", userID).
+			Str("field", field).
+			Str("action", action).
+			Str("timestamp", now).
+			Msg("audit: api key changed")
+	}
+}
+
 // ExtractJWTSub base64url-decodes the JWT payload (middle segment)
 // and returns the "sub" claim. Returns empty string on any failure.
 // Deprecated: Use IsLoggedIn and JWTClaims.Sub instead.
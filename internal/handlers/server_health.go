@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
@@ -12,39 +14,56 @@ import (
 type ServerHealthHandler struct {
 	logger *common.Logger
 	apiURL string
+	client *http.Client
 }
 
-// NewServerHealthHandler creates a new server health handler.
+// NewServerHealthHandler creates a new server health handler. Only the host
+// of apiURL may be probed; see SetAllowedHosts.
 func NewServerHealthHandler(logger *common.Logger, apiURL string) *ServerHealthHandler {
-	return &ServerHealthHandler{logger: logger, apiURL: apiURL}
+	h := &ServerHealthHandler{logger: logger, apiURL: apiURL}
+	h.SetAllowedHosts(nil)
+	return h
 }
 
-// ServeHTTP handles GET /api/server-health.
-func (h *ServerHealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if !RequireMethod(w, r, "GET") {
-		return
-	}
+// SetAllowedHosts allows extra upstream hosts ("host" or "host:port") in
+// addition to the host of apiURL.
+func (h *ServerHealthHandler) SetAllowedHosts(hosts []string) {
+	h.client = &http.Client{Transport: config.NewHostAllowList(h.apiURL, hosts).RoundTripper(nil)}
+}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+// Probe checks vire-server's /api/health with a 3 second timeout.
+// Returns nil when the upstream responds 200.
+func (h *ServerHealthHandler) Probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", h.apiURL+"/api/health", nil)
 	if err != nil {
-		WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "down"})
-		return
+		return err
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := h.client.Do(req)
 	if err != nil {
-		WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "down"})
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vire-server returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ServeHTTP handles GET /api/server-health.
+func (h *ServerHealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !RequireMethod(w, r, "GET") {
+		return
+	}
+
+	if err := h.Probe(r.Context()); err != nil {
+		WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "down", "code": ErrCodeUpstreamUnavailable})
 		return
 	}
 
-	WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "down"})
+	WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
+)
+
+// SessionCookie names the portal session cookie and the domain it is scoped
+// to. The zero value is a host-only cookie named config.DefaultSessionCookieName.
+type SessionCookie struct {
+	Name   string
+	Domain string // empty means host-only
+}
+
+// cookieName returns the configured name, or config.DefaultSessionCookieName.
+func (c SessionCookie) cookieName() string {
+	if name := strings.TrimSpace(c.Name); name != "" {
+		return name
+	}
+	return config.DefaultSessionCookieName
+}
+
+// IsLoggedIn checks the session cookie named by c and validates the JWT.
+// Returns (true, claims) if valid, (false, nil) otherwise.
+func (c SessionCookie) IsLoggedIn(r *http.Request, secret []byte) (bool, *JWTClaims) {
+	return isLoggedIn(r, secret, c.cookieName())
+}
+
+// issue builds the session cookie carrying the given JWT.
+func (c SessionCookie) issue(token string, secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     c.cookieName(),
+		Value:    token,
+		Path:     "/",
+		Domain:   c.Domain,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// expire builds a cookie that clears the session cookie.
+func (c SessionCookie) expire(secure bool) *http.Cookie {
+	cookie := c.issue("", secure)
+	cookie.MaxAge = -1
+	return cookie
+}
+
+// isSecureCookie reports whether the session cookie should carry the Secure flag.
+// Production always sets it. In dev mode it is set only when the request arrived
+// over HTTPS, either directly or via a TLS-terminating reverse proxy that sets
+// X-Forwarded-Proto.
+func isSecureCookie(r *http.Request, devMode bool) bool {
+	if !devMode {
+		return true
+	}
+	if r.TLS != nil {
+		return true
+	}
+	proto, _, _ := strings.Cut(r.Header.Get("X-Forwarded-Proto"), ",")
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
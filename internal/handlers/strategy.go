@@ -2,10 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"html/template"
+	"io"
 	"net/http"
 	"net/url"
-	"path/filepath"
 
 	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
@@ -14,21 +15,20 @@ import (
 
 // StrategyHandler serves the strategy page with portfolio strategy and plan editors.
 type StrategyHandler struct {
-	logger       *common.Logger
-	templates    *template.Template
-	devMode      bool
-	jwtSecret    []byte
-	userLookupFn func(string) (*client.UserProfile, error)
-	apiURL       string
-	proxyGetFn   func(path, userID string) ([]byte, error)
+	logger        *common.Logger
+	templates     *pageTemplates
+	devMode       bool
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	userLookupFn  func(string) (*client.UserProfile, error)
+	apiURL        string
+	proxyGetFn    func(path, userID string) ([]byte, error)
+	proxyPutFn    func(path, userID string, body []byte) ([]byte, error)
 }
 
 // NewStrategyHandler creates a new strategy handler.
 func NewStrategyHandler(logger *common.Logger, devMode bool, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *StrategyHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &StrategyHandler{
 		logger:       logger,
@@ -39,6 +39,11 @@ func NewStrategyHandler(logger *common.Logger, devMode bool, jwtSecret []byte, u
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *StrategyHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *StrategyHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -49,9 +54,14 @@ func (h *StrategyHandler) SetProxyGetFn(fn func(path, userID string) ([]byte, er
 	h.proxyGetFn = fn
 }
 
+// SetProxyPutFn sets the proxy PUT function for saving strategies.
+func (h *StrategyHandler) SetProxyPutFn(fn func(path, userID string, body []byte) ([]byte, error)) {
+	h.proxyPutFn = fn
+}
+
 // ServeHTTP renders the strategy page.
 func (h *StrategyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 
 	// Redirect unauthenticated users to landing page
 	if !loggedIn {
@@ -103,12 +113,14 @@ func (h *StrategyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Page":             "strategy",
+		"BasePath":         BasePath(r),
+		"Locale":           RequestLocale(r),
 		"DevMode":          h.devMode,
 		"LoggedIn":         loggedIn,
 		"NavexaKeyMissing": navexaKeyMissing,
 		"UserRole":         userRole,
 		"PortalVersion":    config.GetVersion(),
-		"ServerVersion":    GetServerVersion(h.apiURL),
+		"ServerVersion":    CachedServerVersion(h.apiURL),
 		"PortfoliosJSON":   portfoliosJSON,
 		"StrategyJSON":     strategyJSON,
 		"PlanJSON":         planJSON,
@@ -121,3 +133,141 @@ func (h *StrategyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
+
+// maxStrategyBodySize limits the strategy JSON accepted by HandlePutStrategy.
+const maxStrategyBodySize = 1 << 20
+
+// strategyPath returns the vire-server strategy path for the {name} path value.
+func strategyPath(r *http.Request) string {
+	return "/api/portfolios/" + url.PathEscape(r.PathValue("name")) + "/strategy"
+}
+
+// HandleGetStrategy handles GET /api/portfolios/{name}/strategy.
+// Proxies to vire-server and returns the strategy JSON unchanged.
+func (h *StrategyHandler) HandleGetStrategy(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if r.PathValue("name") == "" {
+		WriteError(w, http.StatusBadRequest, "portfolio name is required")
+		return
+	}
+	if h.proxyGetFn == nil {
+		WriteError(w, http.StatusServiceUnavailable, "strategy service unavailable")
+		return
+	}
+
+	body, err := h.proxyGetFn(strategyPath(r), claims.Sub)
+	if err != nil {
+		h.writeProxyError(w, err, "failed to load strategy")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// HandlePutStrategy handles PUT /api/portfolios/{name}/strategy.
+// The body must be a JSON object; syntax errors are rejected with the line and
+// column of the problem before anything is forwarded to vire-server.
+// When the body carries the "version" it was loaded at, the save is rejected
+// with 409 if vire-server already holds a newer version.
+func (h *StrategyHandler) HandlePutStrategy(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteError(w, http.StatusUnauthorized, "authentication required")
+		return
+	}
+	if r.PathValue("name") == "" {
+		WriteError(w, http.StatusBadRequest, "portfolio name is required")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStrategyBodySize+1))
+	if err != nil {
+		writeBodyReadError(w, err, "failed to read request body")
+		return
+	}
+	if len(body) > maxStrategyBodySize {
+		WriteError(w, http.StatusRequestEntityTooLarge, "strategy exceeds 1MB")
+		return
+	}
+
+	var strategy map[string]interface{}
+	if err := json.Unmarshal(body, &strategy); err != nil {
+		writeJSONParseError(w, body, err)
+		return
+	}
+
+	if h.proxyPutFn == nil {
+		WriteError(w, http.StatusServiceUnavailable, "strategy service unavailable")
+		return
+	}
+
+	if loaded, ok := strategy["version"].(float64); ok && h.proxyGetFn != nil {
+		current, err := h.currentStrategyVersion(strategyPath(r), claims.Sub)
+		if err != nil {
+			h.writeProxyError(w, err, "failed to check strategy version")
+			return
+		}
+		if current > int(loaded) {
+			WriteJSON(w, http.StatusConflict, map[string]interface{}{
+				"status":          "error",
+				"error":           "version_conflict",
+				"message":         "Strategy was changed since it was loaded. Reload to see the latest version.",
+				"current_version": current,
+				"loaded_version":  int(loaded),
+			})
+			return
+		}
+	}
+
+	respBody, err := h.proxyPutFn(strategyPath(r), claims.Sub, body)
+	if err != nil {
+		h.writeProxyError(w, err, "failed to save strategy")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(respBody)
+}
+
+// currentStrategyVersion returns the version of the strategy stored on
+// vire-server, or 0 when no strategy has been saved yet.
+func (h *StrategyHandler) currentStrategyVersion(path, userID string) (int, error) {
+	body, err := h.proxyGetFn(path, userID)
+	if err != nil {
+		var perr *client.ProxyError
+		if errors.As(err, &perr) && perr.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var current struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(body, &current); err != nil {
+		return 0, err
+	}
+	return current.Version, nil
+}
+
+// writeProxyError relays a vire-server error status (4xx) to the caller, and
+// maps transport failures and 5xx responses to 502.
+func (h *StrategyHandler) writeProxyError(w http.ResponseWriter, err error, msg string) {
+	var perr *client.ProxyError
+	if errors.As(err, &perr) && perr.StatusCode >= 400 && perr.StatusCode < 500 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(perr.StatusCode)
+		w.Write([]byte(perr.Body))
+		return
+	}
+	if h.logger != nil {
+		h.logger.Warn().Str("error", err.Error()).Msg(msg)
+	}
+	WriteError(w, http.StatusBadGateway, msg)
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+)
+
+func TestStrategyAPI_RequiresAuth(t *testing.T) {
+	handler := NewStrategyHandler(nil, true, []byte(testJWTSecret), nil)
+
+	req := httptest.NewRequest("PUT", "/api/portfolios/SMSF/strategy", strings.NewReader(`{}`))
+	req.SetPathValue("name", "SMSF")
+	w := httptest.NewRecorder()
+	handler.HandlePutStrategy(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
+
+func TestStrategyAPI_PutInvalidJSONReportsPosition(t *testing.T) {
+	handler := NewStrategyHandler(nil, true, []byte(testJWTSecret), nil)
+	proxyCalled := false
+	handler.SetProxyPutFn(func(path, userID string, body []byte) ([]byte, error) {
+		proxyCalled = true
+		return body, nil
+	})
+
+	tests := []struct {
+		name   string
+		body   string
+		line   float64
+		column float64
+	}{
+		{"trailing comma", "{\n  \"notes\": \"hold\",\n}", 3, 1},
+		{"missing colon", "{\n  \"notes\" \"hold\"\n}", 2, 11},
+		{"not an object", "[1, 2]", 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/api/portfolios/SMSF/strategy", strings.NewReader(tt.body))
+			req.SetPathValue("name", "SMSF")
+			addAuthCookie(req, "dev_user")
+			w := httptest.NewRecorder()
+			handler.HandlePutStrategy(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", w.Code)
+			}
+			var resp map[string]interface{}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !strings.HasPrefix(resp["error"].(string), "invalid JSON") {
+				t.Errorf("expected invalid JSON error, got %v", resp["error"])
+			}
+			if resp["line"] != tt.line || resp["column"] != tt.column {
+				t.Errorf("expected line %v column %v, got line %v column %v", tt.line, tt.column, resp["line"], resp["column"])
+			}
+		})
+	}
+
+	if proxyCalled {
+		t.Error("expected invalid JSON to be rejected before forwarding to vire-server")
+	}
+}
+
+func TestStrategyAPI_RoundTrip(t *testing.T) {
+	handler := NewStrategyHandler(nil, true, []byte(testJWTSecret), nil)
+
+	stored := map[string][]byte{}
+	handler.SetProxyPutFn(func(path, userID string, body []byte) ([]byte, error) {
+		stored[userID+path] = body
+		return body, nil
+	})
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		if body, ok := stored[userID+path]; ok {
+			return body, nil
+		}
+		return nil, &client.ProxyError{StatusCode: http.StatusNotFound, Body: `{"error":"not found"}`}
+	})
+
+	strategy := `{"notes":"Buy quality, hold long","risk_level":"moderate"}`
+
+	req := httptest.NewRequest("PUT", "/api/portfolios/My%20Fund/strategy", strings.NewReader(strategy))
+	req.SetPathValue("name", "My Fund")
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandlePutStrategy(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := stored["dev_user/api/portfolios/My%20Fund/strategy"]; !ok {
+		t.Fatalf("expected strategy forwarded to escaped path, got %v", stored)
+	}
+
+	req = httptest.NewRequest("GET", "/api/portfolios/My%20Fund/strategy", nil)
+	req.SetPathValue("name", "My Fund")
+	addAuthCookie(req, "dev_user")
+	w = httptest.NewRecorder()
+	handler.HandleGetStrategy(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if w.Body.String() != strategy {
+		t.Errorf("expected saved strategy back, got %s", w.Body.String())
+	}
+}
+
+func TestStrategyAPI_GetRelaysServerStatus(t *testing.T) {
+	handler := NewStrategyHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return nil, &client.ProxyError{StatusCode: http.StatusNotFound, Body: `{"error":"no strategy"}`}
+	})
+
+	req := httptest.NewRequest("GET", "/api/portfolios/SMSF/strategy", nil)
+	req.SetPathValue("name", "SMSF")
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandleGetStrategy(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func versionedStrategyHandler(serverVersion int, saved *[]byte) *StrategyHandler {
+	handler := NewStrategyHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return []byte(fmt.Sprintf(`{"notes":"current","version":%d}`, serverVersion)), nil
+	})
+	handler.SetProxyPutFn(func(path, userID string, body []byte) ([]byte, error) {
+		*saved = body
+		return []byte(fmt.Sprintf(`{"notes":"saved","version":%d}`, serverVersion+1)), nil
+	})
+	return handler
+}
+
+func TestStrategyAPI_PutStaleVersionConflict(t *testing.T) {
+	var saved []byte
+	handler := versionedStrategyHandler(5, &saved)
+
+	req := httptest.NewRequest("PUT", "/api/portfolios/SMSF/strategy", strings.NewReader(`{"notes":"mine","version":4}`))
+	req.SetPathValue("name", "SMSF")
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandlePutStrategy(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp["error"] != "version_conflict" {
+		t.Errorf("expected error version_conflict, got %v", resp["error"])
+	}
+	if resp["current_version"] != float64(5) {
+		t.Errorf("expected current_version 5, got %v", resp["current_version"])
+	}
+	if resp["loaded_version"] != float64(4) {
+		t.Errorf("expected loaded_version 4, got %v", resp["loaded_version"])
+	}
+	if saved != nil {
+		t.Error("expected stale save not to be forwarded to vire-server")
+	}
+}
+
+func TestStrategyAPI_PutMatchingVersionSaves(t *testing.T) {
+	var saved []byte
+	handler := versionedStrategyHandler(5, &saved)
+
+	req := httptest.NewRequest("PUT", "/api/portfolios/SMSF/strategy", strings.NewReader(`{"notes":"mine","version":5}`))
+	req.SetPathValue("name", "SMSF")
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandlePutStrategy(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if string(saved) != `{"notes":"mine","version":5}` {
+		t.Errorf("expected body forwarded unchanged, got %s", saved)
+	}
+	if !strings.Contains(w.Body.String(), `"version":6`) {
+		t.Errorf("expected saved strategy with new version, got %s", w.Body.String())
+	}
+}
+
+func TestStrategyAPI_PutFirstSaveWithoutExistingStrategy(t *testing.T) {
+	handler := NewStrategyHandler(nil, true, []byte(testJWTSecret), nil)
+	handler.SetProxyGetFn(func(path, userID string) ([]byte, error) {
+		return nil, &client.ProxyError{StatusCode: http.StatusNotFound, Body: `{"error":"not found"}`}
+	})
+	handler.SetProxyPutFn(func(path, userID string, body []byte) ([]byte, error) {
+		return body, nil
+	})
+
+	req := httptest.NewRequest("PUT", "/api/portfolios/SMSF/strategy", strings.NewReader(`{"notes":"new","version":0}`))
+	req.SetPathValue("name", "SMSF")
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandlePutStrategy(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+}
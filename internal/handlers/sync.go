@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+)
+
+// SyncHandler triggers a Navexa sync of a portfolio from the portal UI,
+// the same operation as the sync_portfolio MCP tool.
+type SyncHandler struct {
+	logger        *common.Logger
+	jwtSecret     []byte
+	sessionCookie SessionCookie
+	userLookupFn  func(string) (*client.UserProfile, error)
+	proxyPostFn   func(path, userID string, body []byte) ([]byte, error)
+	timezone      string // configured user.timezone
+	invalidateFn  func(userID string)
+}
+
+// NewSyncHandler creates a new portfolio sync handler.
+func NewSyncHandler(logger *common.Logger, jwtSecret []byte, userLookupFn func(string) (*client.UserProfile, error)) *SyncHandler {
+	return &SyncHandler{
+		logger:       logger,
+		jwtSecret:    jwtSecret,
+		userLookupFn: userLookupFn,
+	}
+}
+
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *SyncHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
+// SetProxyPostFn sets the proxy POST function used to start the sync.
+func (h *SyncHandler) SetProxyPostFn(fn func(path, userID string, body []byte) ([]byte, error)) {
+	h.proxyPostFn = fn
+}
+
+// SetInvalidateFn sets the function called with the user's ID after a
+// successful sync, to drop their cached portfolio data (the dashboard's
+// InvalidateSummary).
+func (h *SyncHandler) SetInvalidateFn(fn func(userID string)) {
+	h.invalidateFn = fn
+}
+
+// SetTimezone sets the configured display timezone, used for users without
+// a vire_timezone preference.
+func (h *SyncHandler) SetTimezone(timezone string) {
+	h.timezone = timezone
+}
+
+// syncResult is the JSON response of HandleSync.
+type syncResult struct {
+	Status     string    `json:"status"`
+	Portfolio  string    `json:"portfolio"`
+	Holdings   int       `json:"holdings"`
+	LastSynced time.Time `json:"last_synced"`
+	// LastSyncedDisplay is LastSynced in the user's timezone with its zone
+	// abbreviation, e.g. "1 Mar 2026 21:00 AEDT".
+	LastSyncedDisplay string `json:"last_synced_display,omitempty"`
+	Summary           string `json:"summary"`
+}
+
+// HandleSync handles POST /api/portfolios/{name}/sync.
+// The sync needs the user's Navexa API key, so users without one get a 400
+// KEY_REQUIRED error (the condition behind the dashboard's key-missing
+// banner) before anything is sent to vire-server. vire-server 4xx responses
+// are relayed.
+func (h *SyncHandler) HandleSync(w http.ResponseWriter, r *http.Request) {
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
+	if !loggedIn || claims == nil || claims.Sub == "" {
+		WriteErrorCode(w, http.StatusUnauthorized, ErrCodeUnauthorized, "authentication required")
+		return
+	}
+	name := r.PathValue("name")
+	if name == "" {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeBadRequest, "portfolio name is required")
+		return
+	}
+	if h.proxyPostFn == nil || h.userLookupFn == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "sync service unavailable")
+		return
+	}
+
+	user, err := h.userLookupFn(claims.Sub)
+	if err != nil || user == nil {
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamUnavailable, "failed to load user profile")
+		return
+	}
+	if !user.NavexaKeySet {
+		WriteErrorCode(w, http.StatusBadRequest, ErrCodeKeyRequired, "Navexa API key not configured. Set your API key in Profile to enable portfolio sync.")
+		return
+	}
+
+	body, err := h.proxyPostFn("/api/portfolios/"+url.PathEscape(name)+"/sync", claims.Sub, nil)
+	if err != nil {
+		var perr *client.ProxyError
+		if errors.As(err, &perr) && perr.StatusCode >= 400 && perr.StatusCode < 500 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(perr.StatusCode)
+			w.Write([]byte(perr.Body))
+			return
+		}
+		if h.logger != nil {
+			h.logger.Warn().Str("portfolio", name).Str("error", err.Error()).Msg("portfolio sync failed")
+		}
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamUnavailable, "portfolio sync failed")
+		return
+	}
+
+	if h.invalidateFn != nil {
+		h.invalidateFn(claims.Sub)
+	}
+
+	var p models.Portfolio
+	if err := json.Unmarshal(body, &p); err != nil {
+		WriteErrorCode(w, http.StatusBadGateway, ErrCodeUpstreamError, "invalid sync response")
+		return
+	}
+	if p.Name == "" {
+		p.Name = name
+	}
+	WriteJSON(w, http.StatusOK, syncResult{
+		Status:            "ok",
+		Portfolio:         p.Name,
+		Holdings:          openHoldings(p),
+		LastSynced:        p.LastSynced,
+		LastSyncedDisplay: common.FormatTimestamp(p.LastSynced, common.LoadTimezone(RequestTimezone(r, h.timezone))),
+		Summary:           formatSyncResult(p),
+	})
+}
+
+// openHoldings counts the holdings of p with units held.
+func openHoldings(p models.Portfolio) int {
+	n := 0
+	for _, h := range p.Holdings {
+		if h.Units != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// formatSyncResult summarises a synced portfolio in one line, e.g.
+// "Synced SMSF: 12 holdings, A$250,000.00".
+func formatSyncResult(p models.Portfolio) string {
+	noun := "holdings"
+	n := openHoldings(p)
+	if n == 1 {
+		noun = "holding"
+	}
+	return fmt.Sprintf("Synced %s: %d %s, %s", p.Name, n, noun, common.FormatMoneyWithCurrency(p.PortfolioValue, p.Currency))
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+)
+
+func newSyncRequest(name string) *http.Request {
+	req := httptest.NewRequest("POST", "/api/portfolios/"+name+"/sync", nil)
+	req.SetPathValue("name", name)
+	addAuthCookie(req, "dev_user")
+	return req
+}
+
+func newSyncHandler(keySet bool) *SyncHandler {
+	return NewSyncHandler(nil, []byte(testJWTSecret), func(userID string) (*client.UserProfile, error) {
+		return &client.UserProfile{Username: userID, NavexaKeySet: keySet}, nil
+	})
+}
+
+func TestSync_NoNavexaKey(t *testing.T) {
+	handler := newSyncHandler(false)
+	called := false
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		called = true
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleSync(w, newSyncRequest("SMSF"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp["code"] != ErrCodeKeyRequired {
+		t.Errorf("expected code %s, got %q", ErrCodeKeyRequired, resp["code"])
+	}
+	if called {
+		t.Error("expected no sync request without a Navexa key")
+	}
+}
+
+func TestSync_Success(t *testing.T) {
+	handler := newSyncHandler(true)
+	var gotPath, gotUser string
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		gotPath, gotUser = path, userID
+		return []byte(`{"name":"SMSF","currency":"AUD","portfolio_value":1500,"last_synced":"2026-03-01T10:00:00Z",
+			"holdings":[{"ticker":"BHP","units":10},{"ticker":"CBA","units":5},{"ticker":"OLD","units":0}]}`), nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleSync(w, newSyncRequest("SMSF"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if gotPath != "/api/portfolios/SMSF/sync" || gotUser != "dev_user" {
+		t.Errorf("unexpected proxy call: path=%s user=%s", gotPath, gotUser)
+	}
+	var resp syncResult
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Status != "ok" || resp.Portfolio != "SMSF" || resp.Holdings != 2 {
+		t.Errorf("unexpected result: %+v", resp)
+	}
+	if resp.LastSynced.IsZero() {
+		t.Error("expected last_synced to be set")
+	}
+	if want := "Synced SMSF: 2 holdings, A$1,500.00"; resp.Summary != want {
+		t.Errorf("expected summary %q, got %q", want, resp.Summary)
+	}
+	if want := "1 Mar 2026 10:00 UTC"; resp.LastSyncedDisplay != want {
+		t.Errorf("expected last_synced_display %q without a timezone, got %q", want, resp.LastSyncedDisplay)
+	}
+}
+
+func TestSync_LastSyncedInUserTimezone(t *testing.T) {
+	handler := newSyncHandler(true)
+	handler.SetTimezone("Australia/Sydney")
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return []byte(`{"name":"SMSF","last_synced":"2026-03-01T10:00:00Z"}`), nil
+	})
+
+	tests := []struct {
+		cookie string
+		want   string
+	}{
+		{"", "1 Mar 2026 21:00 AEDT"},
+		{"America/New_York", "1 Mar 2026 05:00 EST"},
+		{"Nowhere/Special", "1 Mar 2026 21:00 AEDT"},
+	}
+	for _, tt := range tests {
+		req := newSyncRequest("SMSF")
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: TimezonePreferenceCookie, Value: tt.cookie})
+		}
+		w := httptest.NewRecorder()
+		handler.HandleSync(w, req)
+
+		var resp syncResult
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if resp.LastSyncedDisplay != tt.want {
+			t.Errorf("cookie %q: expected last_synced_display %q, got %q", tt.cookie, tt.want, resp.LastSyncedDisplay)
+		}
+	}
+}
+
+func TestSync_InvalidatesUserCache(t *testing.T) {
+	handler := newSyncHandler(true)
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return []byte(`{"name":"SMSF"}`), nil
+	})
+	var invalidated []string
+	handler.SetInvalidateFn(func(userID string) {
+		invalidated = append(invalidated, userID)
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleSync(w, newSyncRequest("SMSF"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(invalidated) != 1 || invalidated[0] != "dev_user" {
+		t.Errorf("expected dev_user's cache invalidated once, got %v", invalidated)
+	}
+}
+
+func TestSync_RelaysUpstream4xx(t *testing.T) {
+	handler := newSyncHandler(true)
+	handler.SetProxyPostFn(func(path, userID string, body []byte) ([]byte, error) {
+		return nil, &client.ProxyError{StatusCode: http.StatusNotFound, Body: `{"error":"portfolio not found"}`}
+	})
+
+	w := httptest.NewRecorder()
+	handler.HandleSync(w, newSyncRequest("Missing"))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", w.Code)
+	}
+}
+
+func TestSync_Unauthenticated(t *testing.T) {
+	handler := newSyncHandler(true)
+	req := httptest.NewRequest("POST", "/api/portfolios/SMSF/sync", nil)
+	req.SetPathValue("name", "SMSF")
+
+	w := httptest.NewRecorder()
+	handler.HandleSync(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", w.Code)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"sync/atomic"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/pages"
+)
+
+// TemplateFuncs returns the functions available to all page templates.
+// Number formatting is backed by the common formatters so server-rendered
+// tables match the markdown output.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"money":       common.FormatMoney,
+		"signedMoney": common.FormatSignedMoney,
+		"signedPct":   common.FormatSignedPct,
+		"marketCap":   common.FormatMarketCap,
+		"alpineSrc":   alpineSrc,
+	}
+}
+
+// alpineVersion is the Alpine.js release the pages are written against.
+// scripts/vendor-alpine.sh downloads the same version.
+const alpineVersion = "3.14.9"
+
+// alpineAsset is where scripts/vendor-alpine.sh puts the Alpine.js bundle
+// in the pages tree.
+const alpineAsset = "static/vendor/alpine.min.js"
+
+// alpineSrc returns the Alpine.js script URL: the self-hosted copy under
+// /static/ when it has been vendored into the pages tree, otherwise the
+// same pinned version from the jsDelivr CDN.
+func alpineSrc(basePath string) string {
+	if _, err := fs.Stat(PagesFS(), alpineAsset); err == nil {
+		return basePath + "/" + alpineAsset
+	}
+	return "https://cdn.jsdelivr.net/npm/alpinejs@" + alpineVersion + "/dist/cdn.min.js"
+}
+
+// PagesFS returns the pages tree: the copy embedded in the binary, or the
+// directory found by FindPagesDir when built with -tags nopagesembed.
+func PagesFS() fs.FS {
+	if pages.FS != nil {
+		return pages.FS
+	}
+	return os.DirFS(FindPagesDir())
+}
+
+// templateReload is set once at startup by ConfigureTemplateReload. Handlers
+// read it when they are constructed.
+var templateReload atomic.Bool
+
+// ConfigureTemplateReload makes handlers constructed afterwards re-parse
+// their templates from the on-disk pages directory on every request, so
+// template edits show without a restart. For development only.
+func ConfigureTemplateReload(enabled bool) {
+	templateReload.Store(enabled)
+}
+
+// pageTemplates is the template set a page handler renders with. Normally
+// it is parsed once; with reload set, each execution re-parses the
+// templates from FindPagesDir first.
+type pageTemplates struct {
+	*template.Template
+	reload bool
+}
+
+// ExecuteTemplate renders the named template. When reloading, a template
+// that no longer parses is reported to w instead of rendering stale output.
+func (t *pageTemplates) ExecuteTemplate(w io.Writer, name string, data any) error {
+	if !t.reload {
+		return t.Template.ExecuteTemplate(w, name, data)
+	}
+	fresh, err := parseTemplateFS(os.DirFS(FindPagesDir()))
+	if err != nil {
+		fmt.Fprintf(w, "template reload failed: %v", err)
+		return err
+	}
+	return fresh.ExecuteTemplate(w, name, data)
+}
+
+// parsePageTemplates parses the page templates and partials in fsys
+// with TemplateFuncs registered. Panics on parse errors, like template.Must.
+// The result re-parses from disk on each execution when
+// ConfigureTemplateReload(true) was called.
+func parsePageTemplates(fsys fs.FS) *pageTemplates {
+	return &pageTemplates{
+		Template: template.Must(parseTemplateFS(fsys)),
+		reload:   templateReload.Load(),
+	}
+}
+
+// parseTemplateFS parses the page templates and partials in fsys.
+func parseTemplateFS(fsys fs.FS) (*template.Template, error) {
+	templates, err := template.New("").Funcs(TemplateFuncs()).ParseFS(fsys, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := templates.ParseFS(fsys, "partials/*.html"); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"html/template"
+	"io/fs"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/pages"
+)
+
+func TestTemplateFuncs_MoneyMatchesCommon(t *testing.T) {
+	tmpl := template.Must(template.New("t").Funcs(TemplateFuncs()).Parse(`{{money .Value}}`))
+
+	for _, v := range []float64{0, 1234567.891, -42.5} {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, map[string]float64{"Value": v}); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		if want := common.FormatMoney(v); sb.String() != want {
+			t.Errorf("money %v: got %q, want %q", v, sb.String(), want)
+		}
+	}
+}
+
+func TestTemplateFuncs_SignedAndMarketCap(t *testing.T) {
+	tmpl := template.Must(template.New("t").Funcs(TemplateFuncs()).Parse(
+		`{{signedMoney .Gain}}|{{signedPct .Pct}}|{{marketCap .Cap}}`))
+
+	var sb strings.Builder
+	data := map[string]float64{"Gain": 150, "Pct": -3.456, "Cap": 2.5e9}
+	if err := tmpl.Execute(&sb, data); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	// html/template entity-escapes "+"; compare the rendered text.
+	want := common.FormatSignedMoney(150) + "|" + common.FormatSignedPct(-3.456) + "|" + common.FormatMarketCap(2.5e9)
+	if got := html.UnescapeString(sb.String()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParsePageTemplates_RegistersFuncs(t *testing.T) {
+	templates := parsePageTemplates(os.DirFS(FindPagesDir()))
+	if templates.Lookup("dashboard.html") == nil {
+		t.Fatal("expected dashboard.html to be parsed")
+	}
+	if _, err := templates.New("funcs_probe").Parse(`{{money 1}}`); err != nil {
+		t.Errorf("expected money func on page templates: %v", err)
+	}
+}
+
+func TestPagesFS_EmbeddedWithoutDiskPages(t *testing.T) {
+	if pages.FS == nil {
+		t.Skip("built with -tags nopagesembed")
+	}
+	t.Chdir(t.TempDir())
+	if _, err := os.Stat(FindPagesDir() + "/landing.html"); err == nil {
+		t.Fatal("expected no on-disk pages directory")
+	}
+
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	w := httptest.NewRecorder()
+	handler.ServePage("landing.html", "home")(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "<html") {
+		t.Errorf("expected landing page rendered from embedded FS, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.StaticFileHandler(w, httptest.NewRequest("GET", "/static/css/portal.css", nil))
+	if w.Code != 200 || w.Body.Len() == 0 {
+		t.Errorf("expected portal.css served from embedded FS, got %d", w.Code)
+	}
+}
+
+func TestStaticFileHandler_RejectsTraversal(t *testing.T) {
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	for _, path := range []string{"/static/../landing.html", "/static/css/../../landing.html", "/static/"} {
+		req := httptest.NewRequest("GET", "/static/x", nil)
+		req.URL.Path = path
+		w := httptest.NewRecorder()
+		handler.StaticFileHandler(w, req)
+		if w.Code != 404 {
+			t.Errorf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+}
+
+// editLanding copies the on-disk pages into a temp dir, chdirs there, and
+// returns a function that appends marker to the copied landing page body.
+func editLanding(t *testing.T) func(marker string) {
+	t.Helper()
+	tmp := t.TempDir()
+	if err := os.CopyFS(filepath.Join(tmp, "pages"), os.DirFS(FindPagesDir())); err != nil {
+		t.Fatalf("copy pages: %v", err)
+	}
+	t.Chdir(tmp)
+	return func(marker string) {
+		path := filepath.Join(tmp, "pages", "landing.html")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read landing: %v", err)
+		}
+		edited := strings.Replace(string(data), "</body>", marker+"</body>", 1)
+		if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+			t.Fatalf("write landing: %v", err)
+		}
+	}
+}
+
+func renderLanding(handler *PageHandler) string {
+	w := httptest.NewRecorder()
+	handler.ServePage("landing.html", "home")(w, httptest.NewRequest("GET", "/", nil))
+	return w.Body.String()
+}
+
+func TestPageTemplates_ReloadReflectsEdits(t *testing.T) {
+	edit := editLanding(t)
+	ConfigureTemplateReload(true)
+	t.Cleanup(func() { ConfigureTemplateReload(false) })
+
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	if strings.Contains(renderLanding(handler), "hot-reload-marker") {
+		t.Fatal("marker present before edit")
+	}
+
+	edit("<p>hot-reload-marker</p>")
+	if !strings.Contains(renderLanding(handler), "hot-reload-marker") {
+		t.Error("expected template edit to show without reconstructing the handler")
+	}
+}
+
+func TestPageTemplates_ParseOnceWithoutReload(t *testing.T) {
+	edit := editLanding(t)
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+
+	edit("<p>hot-reload-marker</p>")
+	if strings.Contains(renderLanding(handler), "hot-reload-marker") {
+		t.Error("expected templates parsed once when reload is off")
+	}
+}
+
+func TestPageTemplates_ReloadReportsParseErrors(t *testing.T) {
+	edit := editLanding(t)
+	ConfigureTemplateReload(true)
+	t.Cleanup(func() { ConfigureTemplateReload(false) })
+
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	edit("{{if}}")
+	if body := renderLanding(handler); !strings.Contains(body, "template reload failed") {
+		t.Errorf("expected reload parse error in body, got %q", body)
+	}
+}
+
+func TestFaviconHandler(t *testing.T) {
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	w := httptest.NewRecorder()
+	handler.FaviconHandler(w, httptest.NewRequest("GET", "/favicon.ico", nil))
+
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		t.Errorf("expected image content type, got %q", ct)
+	}
+	if cc := w.Header().Get("Cache-Control"); !strings.Contains(cc, "max-age=") {
+		t.Errorf("expected caching header, got %q", cc)
+	}
+	if w.Body.Len() == 0 {
+		t.Error("expected favicon body")
+	}
+}
+
+func TestManifestHandler(t *testing.T) {
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	req := WithBasePath(httptest.NewRequest("GET", "/manifest.webmanifest", nil), "/vire")
+	w := httptest.NewRecorder()
+	handler.ManifestHandler(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/manifest+json" {
+		t.Errorf("expected manifest content type, got %q", ct)
+	}
+	var m webManifest
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatalf("invalid manifest JSON: %v", err)
+	}
+	if m.StartURL != "/vire/dashboard" || len(m.Icons) != 1 || m.Icons[0].Src != "/vire/favicon.ico" {
+		t.Errorf("expected base-path URLs in manifest, got %+v", m)
+	}
+}
+
+// withVendoredAlpine serves the pages tree with a stub Alpine.js bundle at
+// alpineAsset for the rest of the test.
+func withVendoredAlpine(t *testing.T) {
+	t.Helper()
+	orig := pages.FS
+	pages.FS = overlayFS{
+		FS:    PagesFS(),
+		extra: fstest.MapFS{alpineAsset: {Data: []byte("/* alpine */")}},
+	}
+	t.Cleanup(func() { pages.FS = orig })
+}
+
+// overlayFS serves extra's files in front of FS. Directories always come
+// from FS.
+type overlayFS struct {
+	fs.FS
+	extra fstest.MapFS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if _, ok := o.extra[name]; ok {
+		return o.extra.Open(name)
+	}
+	return o.FS.Open(name)
+}
+
+func TestHeadTemplate_SelfHostedAlpine(t *testing.T) {
+	withVendoredAlpine(t)
+
+	handler := NewPageHandler(nil, true, []byte{}, nil)
+	body := renderLanding(handler)
+
+	if !strings.Contains(body, `<script defer src="/static/vendor/alpine.min.js"></script>`) {
+		t.Error("expected head to load the self-hosted Alpine.js with defer")
+	}
+	if strings.Contains(body, "cdn.jsdelivr.net/npm/alpinejs") {
+		t.Error("expected no Alpine.js CDN reference once vendored")
+	}
+	if strings.Contains(body, `defer src="/static/common.js"`) {
+		t.Error("common.js must NOT be deferred")
+	}
+
+	w := httptest.NewRecorder()
+	handler.StaticFileHandler(w, httptest.NewRequest("GET", "/static/vendor/alpine.min.js", nil))
+	if w.Code != 200 || w.Body.String() != "/* alpine */" {
+		t.Errorf("expected vendored Alpine.js served from /static/, got %d", w.Code)
+	}
+}
+
+func TestAlpineSrc_FallsBackToPinnedCDN(t *testing.T) {
+	orig := pages.FS
+	pages.FS = fstest.MapFS{}
+	t.Cleanup(func() { pages.FS = orig })
+
+	got := alpineSrc("/vire")
+	if got != "https://cdn.jsdelivr.net/npm/alpinejs@"+alpineVersion+"/dist/cdn.min.js" {
+		t.Errorf("expected pinned CDN URL without a vendored bundle, got %q", got)
+	}
+
+	pages.FS = fstest.MapFS{alpineAsset: {Data: []byte("x")}}
+	if got := alpineSrc("/vire"); got != "/vire/static/vendor/alpine.min.js" {
+		t.Errorf("expected base-path-aware local URL, got %q", got)
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimezonePreferenceCookie holds the display timezone the user picked
+// explicitly. It overrides the configured user.timezone.
+const TimezonePreferenceCookie = "vire_timezone"
+
+// timezonePreferenceMaxAge keeps the timezone for a year.
+const timezonePreferenceMaxAge = 365 * 24 * 60 * 60
+
+// RequestTimezone returns the IANA timezone to display r's timestamps in:
+// the vire_timezone cookie when it names a known zone, otherwise fallback
+// (the configured user.timezone).
+func RequestTimezone(r *http.Request, fallback string) string {
+	if c, err := r.Cookie(TimezonePreferenceCookie); err == nil {
+		if tz, ok := validTimezone(c.Value); ok {
+			return tz
+		}
+	}
+	return fallback
+}
+
+// validTimezone returns tz trimmed if the tz database knows it. "Local" is
+// rejected since it would mean the portal host's zone.
+func validTimezone(tz string) (string, bool) {
+	tz = strings.TrimSpace(tz)
+	if tz == "" || tz == "Local" {
+		return "", false
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "", false
+	}
+	return tz, true
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestTimezone(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		want   string
+	}{
+		{"fallback", "", "Australia/Sydney"},
+		{"cookie", "America/New_York", "America/New_York"},
+		{"unknown cookie ignored", "Nowhere/Special", "Australia/Sydney"},
+		{"local cookie ignored", "Local", "Australia/Sydney"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: TimezonePreferenceCookie, Value: tt.cookie})
+			}
+			if got := RequestTimezone(req, "Australia/Sydney"); got != tt.want {
+				t.Errorf("RequestTimezone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreferencesHandler_Timezone(t *testing.T) {
+	handler := NewPreferencesHandler(nil, true, []byte(testJWTSecret))
+	handler.SetTimezone("Australia/Sydney")
+	recorded := map[string]string{}
+	handler.SetTimezoneFn(func(userID, timezone string) {
+		recorded[userID] = timezone
+	})
+	profiles := map[string]map[string]string{}
+	handler.SetUserSaveFn(func(userID string, fields map[string]string) error {
+		profiles[userID] = fields
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/api/preferences/timezone", strings.NewReader(`{"timezone":"America/New_York"}`))
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandleTimezone(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == TimezonePreferenceCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "America/New_York" {
+		t.Errorf("expected %s cookie set to 'America/New_York', got %v", TimezonePreferenceCookie, cookie)
+	}
+	if recorded["dev_user"] != "America/New_York" {
+		t.Errorf("expected timezone recorded for dev_user, got %v", recorded)
+	}
+	if got := profiles["dev_user"]["timezone"]; got != "America/New_York" {
+		t.Errorf("expected timezone saved to dev_user's profile, got %v", profiles["dev_user"])
+	}
+
+	req = httptest.NewRequest("POST", "/api/preferences/timezone", strings.NewReader(`{"timezone":"Mars/Olympus_Mons"}`))
+	w = httptest.NewRecorder()
+	handler.HandleTimezone(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for unknown timezone, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("POST", "/api/preferences/timezone", strings.NewReader(`{"timezone":""}`))
+	addAuthCookie(req, "dev_user")
+	w = httptest.NewRecorder()
+	handler.HandleTimezone(w, req)
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["timezone"] != "Australia/Sydney" {
+		t.Errorf("expected cleared timezone to report Australia/Sydney, got %s", w.Body.String())
+	}
+	if _, ok := recorded["dev_user"]; !ok || recorded["dev_user"] != "" {
+		t.Errorf("expected timezone cleared for dev_user, got %v", recorded)
+	}
+	if tz, ok := profiles["dev_user"]["timezone"]; !ok || tz != "" {
+		t.Errorf("expected timezone cleared on dev_user's profile, got %v", profiles["dev_user"])
+	}
+	cleared := false
+	for _, c := range w.Result().Cookies() {
+		if c.Name == TimezonePreferenceCookie && c.MaxAge < 0 {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("expected empty timezone to clear the cookie")
+	}
+}
+
+func TestPreferencesHandler_Timezone_SaveFailed(t *testing.T) {
+	handler := NewPreferencesHandler(nil, true, []byte(testJWTSecret))
+	recorded := map[string]string{}
+	handler.SetTimezoneFn(func(userID, timezone string) {
+		recorded[userID] = timezone
+	})
+	handler.SetUserSaveFn(func(userID string, fields map[string]string) error {
+		return errors.New("vire-server unavailable")
+	})
+
+	req := httptest.NewRequest("POST", "/api/preferences/timezone", strings.NewReader(`{"timezone":"America/New_York"}`))
+	addAuthCookie(req, "dev_user")
+	w := httptest.NewRecorder()
+	handler.HandleTimezone(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", w.Code)
+	}
+	if len(recorded) != 0 {
+		t.Errorf("expected MCP timezone untouched after failed save, got %v", recorded)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// ToolDetail describes one MCP catalog tool for GET /api/tools/{name}.
+// InputSchema is the JSON Schema MCP clients see for the tool's arguments.
+type ToolDetail struct {
+	Name              string          `json:"name"`
+	Description       string          `json:"description"`
+	Method            string          `json:"method"`
+	Path              string          `json:"path"`
+	Deprecated        bool            `json:"deprecated,omitempty"`
+	DeprecatedMessage string          `json:"deprecated_message,omitempty"`
+	InputSchema       json.RawMessage `json:"input_schema"`
+}
+
+// CatalogReport is the admin view of the MCP catalog for
+// GET /api/admin/catalog. Tools is the validated catalog as served;
+// Rejected lists the entries dropped during validation.
+type CatalogReport struct {
+	Source    string             `json:"source"`
+	FetchedAt time.Time          `json:"fetched_at,omitzero"`
+	ToolCount int                `json:"tool_count"`
+	Tools     json.RawMessage    `json:"tools"`
+	Rejected  []CatalogRejection `json:"rejected"`
+}
+
+// CatalogRejection is a catalog entry dropped during validation and why.
+type CatalogRejection struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ToolsHandler serves structured descriptions of the MCP catalog tools.
+type ToolsHandler struct {
+	logger    *common.Logger
+	toolFn    func(name string) (*ToolDetail, error)
+	openAPIFn func() []byte
+	reportFn  func() (*CatalogReport, error)
+}
+
+// NewToolsHandler creates a tools handler. toolFn returns the named tool
+// from the validated catalog, or nil when there is no such tool.
+func NewToolsHandler(logger *common.Logger, toolFn func(name string) (*ToolDetail, error)) *ToolsHandler {
+	return &ToolsHandler{logger: logger, toolFn: toolFn}
+}
+
+// SetOpenAPIFn sets the function returning the catalog's OpenAPI document.
+func (h *ToolsHandler) SetOpenAPIFn(fn func() []byte) {
+	h.openAPIFn = fn
+}
+
+// SetCatalogReportFn sets the function returning the admin catalog report.
+func (h *ToolsHandler) SetCatalogReportFn(fn func() (*CatalogReport, error)) {
+	h.reportFn = fn
+}
+
+// HandleAdminCatalog handles GET /api/admin/catalog (admin token only).
+// Returns the validated catalog the portal is serving and the entries
+// rejected during validation, with reasons.
+func (h *ToolsHandler) HandleAdminCatalog(w http.ResponseWriter, r *http.Request) {
+	if h.reportFn == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "catalog unavailable")
+		return
+	}
+	report, err := h.reportFn()
+	if err != nil {
+		if h.logger != nil {
+			h.logger.Error().Str("error", err.Error()).Msg("failed to build catalog report")
+		}
+		WriteError(w, http.StatusInternalServerError, "failed to build catalog report")
+		return
+	}
+	if report.Rejected == nil {
+		report.Rejected = []CatalogRejection{}
+	}
+	WriteJSON(w, http.StatusOK, report)
+}
+
+// HandleOpenAPI handles GET /api/tools/openapi.json.
+// Serves the OpenAPI 3 document generated from the tool catalog.
+func (h *ToolsHandler) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	var doc []byte
+	if h.openAPIFn != nil {
+		doc = h.openAPIFn()
+	}
+	if doc == nil {
+		WriteErrorCode(w, http.StatusServiceUnavailable, ErrCodeServiceUnavailable, "OpenAPI document unavailable")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc)
+}
+
+// HandleTool handles GET /api/tools/{name}.
+// Returns the tool's description, method, path and input schema, or 404 if
+// the catalog has no such tool.
+func (h *ToolsHandler) HandleTool(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var tool *ToolDetail
+	if h.toolFn != nil {
+		var err error
+		tool, err = h.toolFn(name)
+		if err != nil {
+			if h.logger != nil {
+				h.logger.Error().Str("tool", name).Str("error", err.Error()).Msg("failed to describe tool")
+			}
+			WriteError(w, http.StatusInternalServerError, "failed to describe tool")
+			return
+		}
+	}
+	if tool == nil {
+		WriteErrorCode(w, http.StatusNotFound, ErrCodeNotFound, fmt.Sprintf("unknown tool %q", name))
+		return
+	}
+	WriteJSON(w, http.StatusOK, tool)
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveTool(h *ToolsHandler, name string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tools/{name}", h.HandleTool)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/api/tools/"+name, nil))
+	return w
+}
+
+func TestToolsHandler_KnownTool(t *testing.T) {
+	h := NewToolsHandler(nil, func(name string) (*ToolDetail, error) {
+		if name != "get_quote" {
+			return nil, nil
+		}
+		return &ToolDetail{
+			Name:        "get_quote",
+			Description: "Get a <live> quote",
+			Method:      "GET",
+			Path:        "/api/market/quote/{ticker}",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"ticker":{"type":"string"}},"required":["ticker"]}`),
+		}, nil
+	})
+
+	w := serveTool(h, "get_quote")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+
+	var got struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Method      string `json:"method"`
+		Path        string `json:"path"`
+		InputSchema struct {
+			Properties map[string]map[string]any `json:"properties"`
+			Required   []string                  `json:"required"`
+		} `json:"input_schema"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Name != "get_quote" || got.Method != "GET" || got.Path != "/api/market/quote/{ticker}" {
+		t.Errorf("unexpected tool fields: %+v", got)
+	}
+	if got.Description != "Get a <live> quote" {
+		t.Errorf("expected description round-trip, got %q", got.Description)
+	}
+	if got.InputSchema.Properties["ticker"]["type"] != "string" || len(got.InputSchema.Required) != 1 {
+		t.Errorf("expected ticker schema, got %+v", got.InputSchema)
+	}
+}
+
+func TestToolsHandler_UnknownTool(t *testing.T) {
+	h := NewToolsHandler(nil, func(string) (*ToolDetail, error) { return nil, nil })
+
+	w := serveTool(h, "nope")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["code"] != ErrCodeNotFound {
+		t.Errorf("expected %s error, got %s", ErrCodeNotFound, w.Body.String())
+	}
+}
+
+func TestToolsHandler_ErrorNotLeaked(t *testing.T) {
+	h := NewToolsHandler(nil, func(string) (*ToolDetail, error) {
+		return nil, errors.New("marshal input schema: secret internals")
+	})
+
+	w := serveTool(h, "get_quote")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "secret internals") {
+		t.Errorf("internal error leaked to client: %s", w.Body.String())
+	}
+}
+
+func TestToolsHandler_OpenAPI(t *testing.T) {
+	h := NewToolsHandler(nil, nil)
+
+	w := httptest.NewRecorder()
+	h.HandleOpenAPI(w, httptest.NewRequest("GET", "/api/tools/openapi.json", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a document, got %d", w.Code)
+	}
+
+	h.SetOpenAPIFn(func() []byte { return []byte(`{"openapi":"3.0.3","paths":{}}`) })
+	w = httptest.NewRecorder()
+	h.HandleOpenAPI(w, httptest.NewRequest("GET", "/api/tools/openapi.json", nil))
+	if w.Code != http.StatusOK || w.Body.String() != `{"openapi":"3.0.3","paths":{}}` {
+		t.Errorf("expected cached document, got %d %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+}
+
+func TestToolsHandler_AdminCatalog(t *testing.T) {
+	h := NewToolsHandler(nil, nil)
+
+	w := httptest.NewRecorder()
+	h.HandleAdminCatalog(w, httptest.NewRequest("GET", "/api/admin/catalog", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a report, got %d", w.Code)
+	}
+
+	h.SetCatalogReportFn(func() (*CatalogReport, error) {
+		return &CatalogReport{
+			Source:    "http://vire-server/api/mcp/tools",
+			ToolCount: 1,
+			Tools:     json.RawMessage(`[{"name":"get_quote","method":"GET","path":"/api/market/quote/{ticker}"}]`),
+			Rejected: []CatalogRejection{
+				{Name: "get_quote", Reason: `duplicate tool name "get_quote"`},
+				{Name: "evil", Reason: `tool "evil" has invalid path "/etc/passwd" (must start with /api/)`},
+			},
+		}, nil
+	})
+	w = httptest.NewRecorder()
+	h.HandleAdminCatalog(w, httptest.NewRequest("GET", "/api/admin/catalog", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got struct {
+		ToolCount int `json:"tool_count"`
+		Tools     []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+		Rejected []CatalogRejection `json:"rejected"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.ToolCount != 1 || len(got.Tools) != 1 || got.Tools[0].Name != "get_quote" {
+		t.Errorf("expected the served catalog, got %s", w.Body.String())
+	}
+	if len(got.Rejected) != 2 || !strings.Contains(got.Rejected[1].Reason, "invalid path") {
+		t.Errorf("expected both rejections with reasons, got %+v", got.Rejected)
+	}
+	if strings.Contains(w.Body.String(), "fetched_at") {
+		t.Error("expected zero fetched_at to be omitted")
+	}
+
+	h.SetCatalogReportFn(func() (*CatalogReport, error) { return nil, errors.New("secret internals") })
+	w = httptest.NewRecorder()
+	h.HandleAdminCatalog(w, httptest.NewRequest("GET", "/api/admin/catalog", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "secret internals") {
+		t.Errorf("expected generic 500, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"html/template"
 	"net/http"
-	"path/filepath"
 
 	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
@@ -13,9 +11,10 @@ import (
 // AdminUsersHandler serves the admin users page.
 type AdminUsersHandler struct {
 	logger           *common.Logger
-	templates        *template.Template
+	templates        *pageTemplates
 	devMode          bool
 	jwtSecret        []byte
+	sessionCookie    SessionCookie
 	userLookupFn     func(string) (*client.UserProfile, error)
 	adminListUsersFn func(string) ([]client.AdminUser, error)
 	serviceUserID    string
@@ -31,10 +30,7 @@ func NewAdminUsersHandler(
 	adminListUsersFn func(string) ([]client.AdminUser, error),
 	serviceUserID string,
 ) *AdminUsersHandler {
-	pagesDir := FindPagesDir()
-
-	templates := template.Must(template.ParseGlob(filepath.Join(pagesDir, "*.html")))
-	template.Must(templates.ParseGlob(filepath.Join(pagesDir, "partials", "*.html")))
+	templates := parsePageTemplates(PagesFS())
 
 	return &AdminUsersHandler{
 		logger:           logger,
@@ -47,6 +43,11 @@ func NewAdminUsersHandler(
 	}
 }
 
+// SetSessionCookie sets the session cookie name and domain used by the handler.
+func (h *AdminUsersHandler) SetSessionCookie(c SessionCookie) {
+	h.sessionCookie = c
+}
+
 // SetAPIURL sets the API URL for server version fetching.
 func (h *AdminUsersHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
@@ -54,7 +55,7 @@ func (h *AdminUsersHandler) SetAPIURL(apiURL string) {
 
 // ServeHTTP renders the admin users page.
 func (h *AdminUsersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	loggedIn, claims := IsLoggedIn(r, h.jwtSecret)
+	loggedIn, claims := h.sessionCookie.IsLoggedIn(r, h.jwtSecret)
 	if !loggedIn {
 		http.Redirect(w, r, "/", http.StatusFound)
 		return
@@ -91,6 +92,8 @@ func (h *AdminUsersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	data := map[string]interface{}{
 		"Page":          "users",
+		"BasePath":      BasePath(r),
+		"Locale":        RequestLocale(r),
 		"DevMode":       h.devMode,
 		"LoggedIn":      loggedIn,
 		"UserRole":      userRole,
@@ -98,7 +101,7 @@ func (h *AdminUsersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"UserCount":     len(users),
 		"FetchError":    fetchErr,
 		"PortalVersion": config.GetVersion(),
-		"ServerVersion": GetServerVersion(h.apiURL),
+		"ServerVersion": CachedServerVersion(h.apiURL),
 	}
 
 	if err := h.templates.ExecuteTemplate(w, "users.html", data); err != nil {
@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/bobmcallan/vire-portal/internal/config"
@@ -11,8 +14,9 @@ import (
 
 // VersionHandler handles version information requests.
 type VersionHandler struct {
-	logger *common.Logger
-	apiURL string
+	logger        *common.Logger
+	apiURL        string
+	catalogInfoFn func() (string, int)
 }
 
 // NewVersionHandler creates a new version handler.
@@ -25,8 +29,15 @@ func (h *VersionHandler) SetAPIURL(apiURL string) {
 	h.apiURL = apiURL
 }
 
+// SetCatalogInfoFn sets the function returning the MCP catalog hash and tool count.
+func (h *VersionHandler) SetCatalogInfoFn(fn func() (string, int)) {
+	h.catalogInfoFn = fn
+}
+
 // ServeHTTP handles GET /api/version.
-// Returns portal version fields alongside server version fields.
+// Returns portal version fields alongside server version fields. When a
+// catalog info function is set, catalog_hash and catalog_tool_count describe
+// the MCP tool set; all values are strings to keep the response a flat map.
 func (h *VersionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if !RequireMethod(w, r, "GET") {
 		return
@@ -44,31 +55,143 @@ func (h *VersionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		resp[k] = v
 	}
 
+	if h.catalogInfoFn != nil {
+		hash, count := h.catalogInfoFn()
+		resp["catalog_hash"] = hash
+		resp["catalog_tool_count"] = strconv.Itoa(count)
+	}
+
 	WriteJSON(w, http.StatusOK, resp)
 }
 
+// serverVersionTimeout bounds one fetch of vire-server's /api/version, and
+// GetServerVersion's total time across both of its attempts.
+const serverVersionTimeout = 2 * time.Second
+
+// serverVersionRetryDelay is how long GetServerVersion waits before its one
+// retry, long enough to ride out a vire-server restart.
+const serverVersionRetryDelay = 250 * time.Millisecond
+
 // GetServerVersion fetches the version from the vire-server API.
-// Returns the version string on success, or "unavailable" on any error.
+// A failed fetch is retried once after serverVersionRetryDelay, with both
+// attempts sharing serverVersionTimeout. Returns the version string on
+// success, or "unavailable" if both attempts fail.
 func GetServerVersion(apiURL string) string {
-	fields := fetchServerVersionFields(apiURL)
-	if v, ok := fields["version"]; ok {
-		return v
+	if apiURL == "" {
+		return "unavailable"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serverVersionTimeout)
+	defer cancel()
+
+	version := fetchServerVersionFieldsContext(ctx, apiURL)["version"]
+	if version != "unavailable" {
+		return version
+	}
+
+	timer := time.NewTimer(serverVersionRetryDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return "unavailable"
+	}
+	return fetchServerVersionFieldsContext(ctx, apiURL)["version"]
+}
+
+// serverVersionTTL is how long a fetched server version is served before it
+// is refreshed in the background.
+const serverVersionTTL = 30 * time.Second
+
+// serverVersionColdWait bounds how long a page render waits for the very
+// first fetch of a server version before rendering without it.
+const serverVersionColdWait = 250 * time.Millisecond
+
+// serverVersionPending is shown until the first fetch completes.
+const serverVersionPending = "pending"
+
+// serverVersionEntry is the cached server version for one API URL.
+type serverVersionEntry struct {
+	version    string
+	fetchedAt  time.Time
+	refreshing bool
+	loaded     chan struct{} // closed when the first fetch completes
+}
+
+var serverVersionCache = struct {
+	sync.Mutex
+	entries map[string]*serverVersionEntry
+}{entries: make(map[string]*serverVersionEntry)}
+
+// CachedServerVersion returns the vire-server version for page rendering
+// without blocking on the upstream call. Versions are cached per API URL for
+// serverVersionTTL and refreshed in the background once stale. Only the first
+// call for an API URL waits, for at most serverVersionColdWait, and renders
+// "pending" if the fetch has not finished; "unavailable" is returned only once
+// a fetch has failed.
+func CachedServerVersion(apiURL string) string {
+	serverVersionCache.Lock()
+	e, ok := serverVersionCache.entries[apiURL]
+	if !ok {
+		e = &serverVersionEntry{loaded: make(chan struct{})}
+		serverVersionCache.entries[apiURL] = e
+	}
+	if !e.refreshing && time.Since(e.fetchedAt) >= serverVersionTTL {
+		e.refreshing = true
+		go refreshServerVersion(apiURL, e)
+	}
+	serverVersionCache.Unlock()
+
+	timer := time.NewTimer(serverVersionColdWait)
+	defer timer.Stop()
+	select {
+	case <-e.loaded:
+	case <-timer.C:
+	}
+
+	serverVersionCache.Lock()
+	defer serverVersionCache.Unlock()
+	if e.fetchedAt.IsZero() {
+		return serverVersionPending
+	}
+	return e.version
+}
+
+// refreshServerVersion fetches the server version and stores it in e.
+func refreshServerVersion(apiURL string, e *serverVersionEntry) {
+	version := GetServerVersion(apiURL)
+
+	serverVersionCache.Lock()
+	defer serverVersionCache.Unlock()
+	first := e.fetchedAt.IsZero()
+	e.version = version
+	e.fetchedAt = time.Now()
+	e.refreshing = false
+	if first {
+		close(e.loaded)
 	}
-	return "unavailable"
 }
 
 // fetchServerVersionFields fetches version fields from the vire-server API.
 // Returns a map of field names to values, or an empty map on error.
 func fetchServerVersionFields(apiURL string) map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), serverVersionTimeout)
+	defer cancel()
+	return fetchServerVersionFieldsContext(ctx, apiURL)
+}
+
+// fetchServerVersionFieldsContext is fetchServerVersionFields bounded by ctx
+// instead of its own timeout.
+func fetchServerVersionFieldsContext(ctx context.Context, apiURL string) map[string]string {
 	if apiURL == "" {
 		return map[string]string{"version": "unavailable"}
 	}
 
-	client := &http.Client{
-		Timeout: 2 * time.Second,
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"/api/version", nil)
+	if err != nil {
+		return map[string]string{"version": "unavailable"}
 	}
-
-	resp, err := client.Get(apiURL + "/api/version")
+	// Only apiURL's host is allowed, so a redirect cannot take the probe elsewhere
+	resp, err := config.NewHostAllowList(apiURL, nil).Client(0).Do(req)
 	if err != nil {
 		return map[string]string{"version": "unavailable"}
 	}
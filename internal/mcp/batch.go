@@ -0,0 +1,147 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// batchToolName is the name of the batch meta-tool.
+const batchToolName = "batch"
+
+// maxBatchCalls caps how many sub-calls one batch may contain.
+const maxBatchCalls = 20
+
+// batchCall is one requested sub-call in a batch.
+type batchCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// batchCallResult is the outcome of one sub-call. Result holds the tool's
+// JSON response (or its text as a JSON string); Error holds the message of a
+// failed sub-call.
+type batchCallResult struct {
+	Tool    string          `json:"tool"`
+	IsError bool            `json:"is_error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// BatchTool returns the mcp.Tool definition for batch.
+func BatchTool() mcp.Tool {
+	return mcp.NewTool(batchToolName,
+		mcp.WithDescription(fmt.Sprintf("Run several tool calls in one request (up to %d). Calls run concurrently and results are returned in the same order. A failing call is marked with is_error without failing the batch.", maxBatchCalls)),
+		mcp.WithArray("calls",
+			mcp.Description("Tool calls to run, each {\"tool\": name, \"arguments\": {...}}."),
+			mcp.Required(),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"tool":      map[string]any{"type": "string", "description": "Tool name"},
+					"arguments": map[string]any{"type": "object", "description": "Tool arguments"},
+				},
+				"required": []string{"tool"},
+			}),
+		),
+	)
+}
+
+// BatchToolHandler returns a handler that dispatches each sub-call to the tool
+// currently registered on srv, so catalog refreshes are picked up. Sub-calls
+// share the caller's context; upstream concurrency is bounded by the proxy's
+// in-flight limit.
+func BatchToolHandler(srv *server.MCPServer) server.ToolHandlerFunc {
+	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		raw, ok := r.GetArguments()["calls"]
+		if !ok {
+			return errorResult("Error: calls is required"), nil
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return errorResult("Error: invalid calls"), nil
+		}
+		var calls []batchCall
+		if err := json.Unmarshal(data, &calls); err != nil {
+			return errorResult("Error: calls must be an array of {tool, arguments} objects"), nil
+		}
+		if len(calls) == 0 {
+			return errorResult("Error: calls must not be empty"), nil
+		}
+		if len(calls) > maxBatchCalls {
+			return errorResult(fmt.Sprintf("Error: too many calls (%d); maximum is %d", len(calls), maxBatchCalls)), nil
+		}
+
+		results := make([]batchCallResult, len(calls))
+		var wg sync.WaitGroup
+		for i, call := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				results[i] = runBatchCall(ctx, srv, call)
+			}()
+		}
+		wg.Wait()
+
+		out, err := json.Marshal(map[string]interface{}{"results": results})
+		if err != nil {
+			return errorResult("failed to marshal batch result"), nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{mcp.NewTextContent(string(out))},
+		}, nil
+	}
+}
+
+// runBatchCall executes one sub-call and converts its outcome to a
+// batchCallResult. Unknown tools, nested batches and handler errors become
+// error entries.
+func runBatchCall(ctx context.Context, srv *server.MCPServer, call batchCall) batchCallResult {
+	res := batchCallResult{Tool: call.Tool}
+	fail := func(msg string) batchCallResult {
+		res.IsError = true
+		res.Error = msg
+		return res
+	}
+
+	if call.Tool == batchToolName {
+		return fail("Error: batch calls cannot be nested")
+	}
+	tool := srv.GetTool(call.Tool)
+	if tool == nil {
+		return fail(fmt.Sprintf("Error: unknown tool %q", call.Tool))
+	}
+
+	var req mcp.CallToolRequest
+	req.Params.Name = call.Tool
+	req.Params.Arguments = call.Arguments
+	result, err := tool.Handler(ctx, req)
+	if err != nil {
+		return fail(fmt.Sprintf("Error: %v", err))
+	}
+	if result == nil {
+		return fail("Error: empty result")
+	}
+
+	var texts []string
+	for _, c := range result.Content {
+		if tc, ok := c.(mcp.TextContent); ok {
+			texts = append(texts, tc.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	if result.IsError {
+		return fail(text)
+	}
+	if json.Valid([]byte(text)) {
+		res.Result = json.RawMessage(text)
+	} else {
+		res.Result, _ = json.Marshal(text)
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func newBatchTestServer(t *testing.T, upstream http.HandlerFunc) *mcpserver.MCPServer {
+	t.Helper()
+	mockServer := httptest.NewServer(upstream)
+	t.Cleanup(mockServer.Close)
+
+	ct := CatalogTool{
+		Name:   "get_quote",
+		Method: "GET",
+		Path:   "/api/market/quote/{ticker}",
+		Params: []CatalogParam{
+			{Name: "ticker", Type: "string", Required: true, In: "path"},
+		},
+	}
+
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+	s.AddTool(BatchTool(), BatchToolHandler(s))
+	return s
+}
+
+func decodeBatchResults(t *testing.T, text string) []batchCallResult {
+	t.Helper()
+	var out struct {
+		Results []batchCallResult `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(text), &out); err != nil {
+		t.Fatalf("failed to unmarshal batch result: %v (%s)", err, text)
+	}
+	return out.Results
+}
+
+func TestBatchTool_MixedSuccessAndFailure(t *testing.T) {
+	s := newBatchTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/BAD.AU") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"ticker not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ticker":"BHP.AU","price":45.1}`))
+	})
+
+	result := callTool(t, s, "batch", map[string]interface{}{
+		"calls": []interface{}{
+			map[string]interface{}{"tool": "get_quote", "arguments": map[string]interface{}{"ticker": "BHP.AU"}},
+			map[string]interface{}{"tool": "get_quote", "arguments": map[string]interface{}{"ticker": "BAD.AU"}},
+			map[string]interface{}{"tool": "no_such_tool"},
+		},
+	})
+
+	if result.IsError {
+		t.Fatalf("expected batch to succeed despite failing sub-calls, got: %s", extractText(t, result.Content[0]))
+	}
+	results := decodeBatchResults(t, extractText(t, result.Content[0]))
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+
+	if results[0].IsError || !strings.Contains(string(results[0].Result), `"price":45.1`) {
+		t.Errorf("expected first call to succeed with quote, got %+v", results[0])
+	}
+	if !results[1].IsError || !strings.Contains(results[1].Error, "ticker not found") {
+		t.Errorf("expected second call to fail with upstream error, got %+v", results[1])
+	}
+	if !results[2].IsError || !strings.Contains(results[2].Error, "unknown tool") {
+		t.Errorf("expected third call to fail as unknown tool, got %+v", results[2])
+	}
+}
+
+func TestBatchTool_RejectsNestedBatch(t *testing.T) {
+	s := newBatchTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	result := callTool(t, s, "batch", map[string]interface{}{
+		"calls": []interface{}{
+			map[string]interface{}{"tool": "batch", "arguments": map[string]interface{}{"calls": []interface{}{}}},
+		},
+	})
+
+	results := decodeBatchResults(t, extractText(t, result.Content[0]))
+	if len(results) != 1 || !results[0].IsError || !strings.Contains(results[0].Error, "nested") {
+		t.Errorf("expected nested batch to be rejected, got %+v", results)
+	}
+}
+
+func TestBatchTool_TooManyCalls(t *testing.T) {
+	s := newBatchTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	calls := make([]interface{}, maxBatchCalls+1)
+	for i := range calls {
+		calls[i] = map[string]interface{}{"tool": "get_quote"}
+	}
+	result := callTool(t, s, "batch", map[string]interface{}{"calls": calls})
+
+	if !result.IsError {
+		t.Fatal("expected error for too many calls")
+	}
+	if text := extractText(t, result.Content[0]); !strings.Contains(text, "too many calls") {
+		t.Errorf("expected too many calls error, got: %s", text)
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// minMaxResults is the smallest max_results a caller may ask for.
+const minMaxResults = 1
+
+// maxResultsParam is the portal-side argument that caps how many candidates a
+// screening tool returns. It has no In location, so it is never forwarded to
+// vire-server.
+var maxResultsParam = CatalogParam{
+	Name:        "max_results",
+	Type:        "number",
+	Description: "Maximum number of candidates to return, highest score first. Omit to return all.",
+	Minimum:     func() *float64 { v := float64(minMaxResults); return &v }(),
+}
+
+// limitCandidates sorts the candidates in body by score, highest first, and
+// when max is positive keeps the top max, adding a "note" saying how many
+// were dropped. Bodies that are not candidate lists, or are already in score
+// order within max, are returned unchanged. A truncated top-level array is
+// wrapped as {"candidates": [...]} so the note has somewhere to go.
+func limitCandidates(body []byte, max int) []byte {
+	var obj map[string]json.RawMessage
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		if err := json.Unmarshal(body, &obj); err != nil || obj["candidates"] == nil {
+			return body
+		}
+		if err := json.Unmarshal(obj["candidates"], &items); err != nil {
+			return body
+		}
+	}
+
+	scores := make([]float64, len(items))
+	for i, item := range items {
+		var c struct {
+			Score float64 `json:"score"`
+		}
+		_ = json.Unmarshal(item, &c)
+		scores[i] = c.Score
+	}
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+
+	reordered := false
+	sorted := make([]json.RawMessage, len(items))
+	for i := range sorted {
+		sorted[i] = items[idx[i]]
+		reordered = reordered || idx[i] != i
+	}
+	truncated := max > 0 && len(sorted) > max
+	if !truncated && !reordered {
+		return body
+	}
+	if truncated {
+		sorted = sorted[:max]
+	}
+
+	if obj == nil {
+		if !truncated {
+			out, err := json.Marshal(sorted)
+			if err != nil {
+				return body
+			}
+			return out
+		}
+		obj = map[string]json.RawMessage{}
+	}
+	obj["candidates"], _ = json.Marshal(sorted)
+	if truncated {
+		obj["note"], _ = json.Marshal(fmt.Sprintf("showing top %d of %d (by score)", max, len(items)))
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func TestLimitCandidates_SortsThenTruncates(t *testing.T) {
+	body := []byte(`{"exchange":"AU","candidates":[
+		{"ticker":"AAA","score":0.2},
+		{"ticker":"BBB","score":0.9},
+		{"ticker":"CCC","score":0.5},
+		{"ticker":"DDD","score":0.7}]}`)
+
+	var got struct {
+		Exchange   string `json:"exchange"`
+		Note       string `json:"note"`
+		Candidates []struct {
+			Ticker string `json:"ticker"`
+		} `json:"candidates"`
+	}
+	if err := json.Unmarshal(limitCandidates(body, 2), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if len(got.Candidates) != 2 || got.Candidates[0].Ticker != "BBB" || got.Candidates[1].Ticker != "DDD" {
+		t.Errorf("expected top two by score [BBB DDD], got %+v", got.Candidates)
+	}
+	if got.Note != "showing top 2 of 4 (by score)" {
+		t.Errorf("unexpected note: %q", got.Note)
+	}
+	if got.Exchange != "AU" {
+		t.Errorf("expected other fields preserved, got exchange %q", got.Exchange)
+	}
+}
+
+func TestLimitCandidates_WrapsTopLevelArray(t *testing.T) {
+	body := []byte(`[{"ticker":"AAA","score":0.1},{"ticker":"BBB","score":0.8}]`)
+
+	out := string(limitCandidates(body, 1))
+	if !strings.Contains(out, `"candidates":[{"ticker":"BBB","score":0.8}]`) {
+		t.Errorf("expected wrapped top candidate, got %s", out)
+	}
+	if !strings.Contains(out, "showing top 1 of 2 (by score)") {
+		t.Errorf("expected note, got %s", out)
+	}
+}
+
+func TestLimitCandidates_SortsWithoutTruncating(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body string
+		max  int
+		want string
+	}{
+		"no limit":     {`[{"score":1},{"score":2}]`, 0, `[{"score":2},{"score":1}]`},
+		"within limit": {`{"candidates":[{"score":1},{"score":3}],"exchange":"AU"}`, 5, `{"candidates":[{"score":3},{"score":1}],"exchange":"AU"}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := string(limitCandidates([]byte(tc.body), tc.max)); got != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestLimitCandidates_Unchanged(t *testing.T) {
+	for name, tc := range map[string]struct {
+		body string
+		max  int
+	}{
+		"already sorted": {`[{"score":2},{"score":1}]`, 0},
+		"within limit":   {`{"candidates":[{"score":1}]}`, 5},
+		"no candidates":  {`{"holdings":[1,2,3]}`, 1},
+		"not json":       {"ticker,score\nAAA,1\n", 1},
+		"bad candidates": {`{"candidates":"none"}`, 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := string(limitCandidates([]byte(tc.body), tc.max)); got != tc.body {
+				t.Errorf("expected body unchanged, got %s", got)
+			}
+		})
+	}
+}
+
+func TestGenericHandler_MaxResults(t *testing.T) {
+	var receivedQuery string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[{"ticker":"AAA","score":0.3},{"ticker":"BBB","score":0.6},{"ticker":"CCC","score":0.1}]}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{Name: "screen_stocks", Method: "GET", Path: "/api/screen", Response: ResponseCandidates}
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	if _, ok := listTools(t, s)[0].InputSchema.Properties["max_results"]; !ok {
+		t.Error("expected max_results in screen_stocks schema")
+	}
+
+	result := callTool(t, s, "screen_stocks", map[string]interface{}{"max_results": 1})
+	if result.IsError {
+		t.Fatalf("expected success, got: %s", extractText(t, result.Content[0]))
+	}
+	if receivedQuery != "" {
+		t.Errorf("expected max_results not to be forwarded, got query %q", receivedQuery)
+	}
+	text := extractText(t, result.Content[0])
+	if !strings.Contains(text, `"ticker":"BBB"`) || strings.Contains(text, `"ticker":"AAA"`) {
+		t.Errorf("expected only the top candidate, got: %s", text)
+	}
+	if !strings.Contains(text, "showing top 1 of 3 (by score)") {
+		t.Errorf("expected truncation note, got: %s", text)
+	}
+
+	result = callTool(t, s, "screen_stocks", map[string]interface{}{"max_results": 0})
+	if !result.IsError {
+		t.Error("expected error for max_results below 1")
+	}
+}
+
+func TestBuildMCPTool_MaxResultsOnlyOnCandidateTools(t *testing.T) {
+	tool := BuildMCPTool(CatalogTool{Name: "get_version", Method: "GET", Path: "/api/version"})
+	if _, ok := tool.InputSchema.Properties["max_results"]; ok {
+		t.Error("expected no max_results on non-candidate tool")
+	}
+	// The response field, not the tool name, marks a candidate tool
+	tool = BuildMCPTool(CatalogTool{Name: "screen_stocks", Method: "GET", Path: "/api/screen"})
+	if _, ok := tool.InputSchema.Properties["max_results"]; ok {
+		t.Error("expected no max_results without response \"candidates\"")
+	}
+	tool = BuildMCPTool(CatalogTool{Name: "rank_ideas", Method: "GET", Path: "/api/ideas", Response: ResponseCandidates})
+	if _, ok := tool.InputSchema.Properties["max_results"]; !ok {
+		t.Error("expected max_results on a tool with response \"candidates\"")
+	}
+}
@@ -2,11 +2,21 @@ package mcp
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/cache"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -15,6 +25,12 @@ import (
 // maxCatalogSize is the maximum allowed size for a catalog response (1MB).
 const maxCatalogSize = 1 << 20
 
+// catalogPath is the vire-server endpoint serving the tool catalog.
+const catalogPath = "/api/mcp/tools"
+
+// maxToolCacheEntries caps the response cache of each cached tool.
+const maxToolCacheEntries = 500
+
 // allowedMethods is the whitelist of HTTP methods for catalog tools.
 var allowedMethods = map[string]bool{
 	"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true,
@@ -27,6 +43,55 @@ type CatalogTool struct {
 	Method      string         `json:"method"`
 	Path        string         `json:"path"`
 	Params      []CatalogParam `json:"params"`
+
+	// Deprecated tools stay registered but their description carries a
+	// deprecation notice (see DeprecationNotice).
+	Deprecated        bool   `json:"deprecated,omitempty"`
+	DeprecatedMessage string `json:"deprecated_message,omitempty"`
+
+	// CacheTTLSeconds opts a GET tool into response caching for that many
+	// seconds. Zero (the default) disables caching.
+	CacheTTLSeconds int `json:"cache_ttl_seconds,omitempty"`
+
+	// TimeoutSeconds overrides the proxy's per-call deadline for this tool
+	// (mcp.tool_timeout_seconds). Zero uses the proxy default; at most
+	// MaxToolTimeoutSeconds.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+
+	// Response names the shape of the tool's response, for tools whose
+	// results the portal post-processes: ResponseCandidates or
+	// ResponseHolding. Empty or unknown values are passed through untouched.
+	Response string `json:"response,omitempty"`
+
+	// Progress opts a long-running tool (e.g. funnel_screen) into progress
+	// notifications for clients that send a progress token.
+	Progress bool `json:"progress,omitempty"`
+}
+
+// ResponseCandidates marks a tool whose response is a list of scored
+// candidates, either as a top-level array or under a "candidates" key. Such
+// tools are sorted by score and get the portal-side max_results argument.
+const ResponseCandidates = "candidates"
+
+// ResponseHolding marks a tool whose response is a single holding with its
+// trades, either bare or under a "holding" key. Such tools get the
+// portal-side format argument for a CSV trade history.
+const ResponseHolding = "holding"
+
+// MaxToolTimeoutSeconds is the longest per-call deadline a catalog tool may
+// ask for. It is also the proxy HTTP client's hard timeout.
+const MaxToolTimeoutSeconds = 300
+
+// DeprecationNotice returns the notice prefixed to a deprecated tool's
+// description, or "" if the tool is not deprecated.
+func (ct CatalogTool) DeprecationNotice() string {
+	if !ct.Deprecated {
+		return ""
+	}
+	if msg := strings.TrimSpace(ct.DeprecatedMessage); msg != "" {
+		return "DEPRECATED: " + msg
+	}
+	return "DEPRECATED: this tool may be removed in a future release."
 }
 
 // CatalogParam describes one parameter for a catalog tool.
@@ -37,12 +102,105 @@ type CatalogParam struct {
 	Required    bool   `json:"required"`
 	In          string `json:"in"`           // path, query, body
 	DefaultFrom string `json:"default_from"` // e.g. "user_config.default_portfolio"
+	// Default is a literal value sent when the argument is absent and
+	// DefaultFrom is not set.
+	Default interface{} `json:"default,omitempty"`
+	// Enum optionally restricts the value (or each array item) to a fixed set.
+	Enum []string `json:"enum,omitempty"`
+	// Minimum and Maximum optionally bound number values (inclusive).
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+	// Pattern optionally constrains string values (or each array item) to a regular expression.
+	Pattern string         `json:"pattern,omitempty"`
+	pattern *regexp.Regexp // Pattern compiled by ValidateCatalog
+	// ArrayFormat controls how an array param with In "query" is encoded:
+	// "repeat" (default) sends tickers=a&tickers=b, "comma" sends tickers=a,b.
+	ArrayFormat string `json:"array_format,omitempty"`
+}
+
+// setQueryArray encodes an array query param as repeated keys, or as one
+// comma-joined value when param.ArrayFormat is "comma". Empty items are skipped.
+func setQueryArray(q url.Values, param CatalogParam, items []interface{}) {
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if v := fmt.Sprint(item); item != nil && v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	if param.ArrayFormat == "comma" {
+		q.Set(param.Name, strings.Join(values, ","))
+		return
+	}
+	q[param.Name] = values
+}
+
+// validateParamValue checks val (or each item of an array val) against the
+// param's enum, minimum/maximum and pattern constraints. Numbers passed as
+// strings are parsed before the bounds check. Nil and empty values pass;
+// required checks happen separately.
+func validateParamValue(param CatalogParam, val interface{}) error {
+	if val == nil {
+		return nil
+	}
+	items, ok := val.([]interface{})
+	if !ok {
+		items = []interface{}{val}
+	}
+	for _, item := range items {
+		v := fmt.Sprint(item)
+		if v == "" {
+			continue
+		}
+		if len(param.Enum) > 0 && !slices.Contains(param.Enum, v) {
+			return fmt.Errorf("invalid %s %q; valid values: %s", param.Name, v, strings.Join(param.Enum, ", "))
+		}
+		if param.Minimum != nil || param.Maximum != nil {
+			n, ok := paramNumber(item)
+			if !ok {
+				return fmt.Errorf("invalid %s %q; must be a number", param.Name, v)
+			}
+			if param.Minimum != nil && n < *param.Minimum {
+				return fmt.Errorf("invalid %s %s; must be at least %s", param.Name, formatBound(n), formatBound(*param.Minimum))
+			}
+			if param.Maximum != nil && n > *param.Maximum {
+				return fmt.Errorf("invalid %s %s; must be at most %s", param.Name, formatBound(n), formatBound(*param.Maximum))
+			}
+		}
+		if s, ok := item.(string); ok && param.pattern != nil {
+			if !param.pattern.MatchString(s) {
+				return fmt.Errorf("invalid %s %q; must match pattern %s", param.Name, s, param.Pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// paramNumber returns item as a number, parsing numeric strings.
+func paramNumber(item interface{}) (float64, bool) {
+	switch n := item.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// formatBound renders a number without trailing zeros (100, not 100.000000).
+func formatBound(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
 }
 
 // FetchCatalog fetches the tool catalog from vire-server.
 // Returns nil, nil if the server is unreachable (non-fatal at startup).
 func (p *MCPProxy) FetchCatalog(ctx context.Context) ([]CatalogTool, error) {
-	body, err := p.get(ctx, "/api/mcp/tools")
+	body, err := p.get(ctx, catalogPath)
 	if err != nil {
 		return nil, err
 	}
@@ -56,57 +214,192 @@ func (p *MCPProxy) FetchCatalog(ctx context.Context) ([]CatalogTool, error) {
 	return tools, nil
 }
 
+// CatalogHash returns a hex SHA-256 of the catalog's tool definitions.
+// Tools are hashed in name order so the hash only changes when the exposed
+// tool set changes, not when vire-server reorders the catalog.
+// Returns "" for an empty catalog.
+func CatalogHash(catalog []CatalogTool) string {
+	if len(catalog) == 0 {
+		return ""
+	}
+	sorted := make([]CatalogTool, len(catalog))
+	copy(sorted, catalog)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
 // ValidateCatalogTool validates a single catalog tool entry.
 func ValidateCatalogTool(ct CatalogTool) error {
+	_, err := validateCatalogTool(ct)
+	return err
+}
+
+// validateCatalogTool validates ct and returns it with its param patterns
+// compiled, so tool calls never compile a pattern.
+func validateCatalogTool(ct CatalogTool) (CatalogTool, error) {
 	if ct.Name == "" {
-		return fmt.Errorf("tool has empty name")
+		return ct, fmt.Errorf("tool has empty name")
 	}
 	if ct.Method == "" {
-		return fmt.Errorf("tool %q has empty method", ct.Name)
+		return ct, fmt.Errorf("tool %q has empty method", ct.Name)
 	}
 	if !allowedMethods[strings.ToUpper(ct.Method)] {
-		return fmt.Errorf("tool %q has unsupported method %q", ct.Name, ct.Method)
+		return ct, fmt.Errorf("tool %q has unsupported method %q", ct.Name, ct.Method)
 	}
 	if ct.Path == "" {
-		return fmt.Errorf("tool %q has empty path", ct.Name)
+		return ct, fmt.Errorf("tool %q has empty path", ct.Name)
 	}
 	if !strings.HasPrefix(ct.Path, "/api/") {
-		return fmt.Errorf("tool %q has invalid path %q (must start with /api/)", ct.Name, ct.Path)
+		return ct, fmt.Errorf("tool %q has invalid path %q (must start with /api/)", ct.Name, ct.Path)
 	}
 	if strings.Contains(ct.Path, "..") {
-		return fmt.Errorf("tool %q has invalid path %q (contains ..)", ct.Name, ct.Path)
+		return ct, fmt.Errorf("tool %q has invalid path %q (contains ..)", ct.Name, ct.Path)
+	}
+	if err := validatePathParams(ct); err != nil {
+		return ct, err
+	}
+	if ct.TimeoutSeconds < 0 || ct.TimeoutSeconds > MaxToolTimeoutSeconds {
+		return ct, fmt.Errorf("tool %q has timeout_seconds %d outside 0-%d", ct.Name, ct.TimeoutSeconds, MaxToolTimeoutSeconds)
+	}
+	// Copy params so compiled patterns never write through to the caller's catalog
+	ct.Params = slices.Clone(ct.Params)
+	for i, p := range ct.Params {
+		switch p.ArrayFormat {
+		case "", "repeat", "comma":
+		default:
+			return ct, fmt.Errorf("tool %q param %q has unsupported array_format %q", ct.Name, p.Name, p.ArrayFormat)
+		}
+		if p.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return ct, fmt.Errorf("tool %q param %q has invalid pattern: %v", ct.Name, p.Name, err)
+		}
+		ct.Params[i].pattern = re
+	}
+	return ct, nil
+}
+
+// pathPlaceholder matches a {name} placeholder in a catalog path template.
+var pathPlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// validatePathParams checks that every {placeholder} in the tool's path has a
+// param with In "path", and that every path param appears in the path.
+func validatePathParams(ct CatalogTool) error {
+	matches := pathPlaceholder.FindAllStringSubmatch(ct.Path, -1)
+	placeholders := map[string]bool{}
+	for _, m := range matches {
+		if m[1] == "" {
+			return fmt.Errorf("tool %q has empty placeholder in path %q", ct.Name, ct.Path)
+		}
+		placeholders[m[1]] = true
+	}
+	if rest := pathPlaceholder.ReplaceAllString(ct.Path, ""); strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("tool %q has unbalanced braces in path %q", ct.Name, ct.Path)
+	}
+
+	pathParams := map[string]bool{}
+	for _, p := range ct.Params {
+		if p.In != "path" {
+			continue
+		}
+		if !placeholders[p.Name] {
+			return fmt.Errorf("tool %q path param %q has no {%s} placeholder in path %q", ct.Name, p.Name, p.Name, ct.Path)
+		}
+		pathParams[p.Name] = true
+	}
+	for _, m := range matches {
+		if !pathParams[m[1]] {
+			return fmt.Errorf("tool %q path %q has placeholder {%s} with no matching path param", ct.Name, ct.Path, m[1])
+		}
 	}
 	return nil
 }
 
-// ValidateCatalog filters and validates catalog entries, logging warnings for invalid or duplicate tools.
-func ValidateCatalog(catalog []CatalogTool, logger *common.Logger) []CatalogTool {
+// RejectedTool is a catalog entry ValidateCatalog dropped and why.
+type RejectedTool struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ValidateCatalog filters and validates catalog entries, logging warnings
+// for invalid or duplicate tools. A tool is a duplicate if an earlier tool
+// has the same name, or the same method and path under another name; the
+// first one wins. It returns the surviving tools and the dropped entries
+// with their reasons, both in catalog order.
+func ValidateCatalog(catalog []CatalogTool, logger *common.Logger) ([]CatalogTool, []RejectedTool) {
 	seen := make(map[string]bool, len(catalog))
+	routes := make(map[string]string, len(catalog)) // "METHOD path" -> tool name
 	valid := make([]CatalogTool, 0, len(catalog))
+	var rejected []RejectedTool
 	for _, ct := range catalog {
-		if err := ValidateCatalogTool(ct); err != nil {
+		ct, err := validateCatalogTool(ct)
+		if err != nil {
 			logger.Warn().Str("error", err.Error()).Msg("skipping invalid catalog tool")
+			rejected = append(rejected, RejectedTool{Name: ct.Name, Reason: err.Error()})
 			continue
 		}
 		if seen[ct.Name] {
 			logger.Warn().Str("name", ct.Name).Msg("skipping duplicate catalog tool")
+			rejected = append(rejected, RejectedTool{Name: ct.Name, Reason: fmt.Sprintf("duplicate tool name %q", ct.Name)})
+			continue
+		}
+		route := strings.ToUpper(ct.Method) + " " + ct.Path
+		if first, ok := routes[route]; ok {
+			logger.Warn().Str("name", ct.Name).Str("route", route).Str("first", first).Msg("skipping catalog tool with duplicate method and path")
+			rejected = append(rejected, RejectedTool{Name: ct.Name, Reason: fmt.Sprintf("duplicate route %s (already served by %q)", route, first)})
 			continue
 		}
 		seen[ct.Name] = true
+		routes[route] = ct.Name
+		if ct.Deprecated {
+			logger.Warn().Str("name", ct.Name).Str("message", ct.DeprecatedMessage).Msg("catalog tool is deprecated")
+		}
 		valid = append(valid, ct)
 	}
-	return valid
+	return valid, rejected
+}
+
+// withCompiledPatterns compiles the patterns of a tool that did not come
+// through ValidateCatalog. Invalid patterns are left unenforced.
+func withCompiledPatterns(ct CatalogTool) CatalogTool {
+	params := slices.Clone(ct.Params)
+	for i, p := range params {
+		if p.Pattern != "" && p.pattern == nil {
+			params[i].pattern, _ = regexp.Compile(p.Pattern)
+		}
+	}
+	ct.Params = params
+	return ct
 }
 
 // BuildMCPTool converts a CatalogTool into an mcp.Tool with the appropriate schema.
+// Deprecated tools get their deprecation notice ahead of the description.
 func BuildMCPTool(ct CatalogTool) mcp.Tool {
-	opts := []mcp.ToolOption{mcp.WithDescription(ct.Description)}
+	desc := ct.Description
+	if notice := ct.DeprecationNotice(); notice != "" {
+		desc = strings.TrimSpace(notice + " " + desc)
+	}
+	opts := []mcp.ToolOption{mcp.WithDescription(desc)}
 	for _, p := range ct.Params {
 		if p.In == "path" || p.In == "query" || p.In == "body" {
 			opt := buildParamOption(p)
 			opts = append(opts, opt)
 		}
 	}
+	if ct.Response == ResponseCandidates {
+		opts = append(opts, buildParamOption(maxResultsParam))
+	}
+	if ct.Response == ResponseHolding {
+		opts = append(opts, buildParamOption(formatParam))
+	}
 	return mcp.NewTool(ct.Name, opts...)
 }
 
@@ -119,32 +412,68 @@ func buildParamOption(p CatalogParam) mcp.ToolOption {
 	if p.Required {
 		opts = append(opts, mcp.Required())
 	}
+	if p.Default != nil {
+		opts = append(opts, func(schema map[string]any) {
+			schema["default"] = p.Default
+		})
+	}
 
 	switch p.Type {
 	case "number":
+		if p.Minimum != nil {
+			opts = append(opts, mcp.Min(*p.Minimum))
+		}
+		if p.Maximum != nil {
+			opts = append(opts, mcp.Max(*p.Maximum))
+		}
 		return mcp.WithNumber(p.Name, opts...)
 	case "boolean":
 		return mcp.WithBoolean(p.Name, opts...)
 	case "array":
-		opts = append([]mcp.PropertyOption{mcp.WithStringItems()}, opts...)
+		var itemOpts []mcp.PropertyOption
+		if len(p.Enum) > 0 {
+			itemOpts = append(itemOpts, mcp.Enum(p.Enum...))
+		}
+		if p.Pattern != "" {
+			itemOpts = append(itemOpts, mcp.Pattern(p.Pattern))
+		}
+		opts = append([]mcp.PropertyOption{mcp.WithStringItems(itemOpts...)}, opts...)
 		return mcp.WithArray(p.Name, opts...)
 	default:
 		// string, object, or unknown — all passed as string
+		if len(p.Enum) > 0 {
+			opts = append(opts, mcp.Enum(p.Enum...))
+		}
+		if p.Pattern != "" {
+			opts = append(opts, mcp.Pattern(p.Pattern))
+		}
 		return mcp.WithString(p.Name, opts...)
 	}
 }
 
 // GenericToolHandler creates a handler that routes an MCP tool call to
 // the appropriate vire-server REST endpoint based on a CatalogTool definition.
+// GET tools with CacheTTLSeconds set get their own response cache. Each call
+// runs under the tool's TimeoutSeconds, or the proxy default when unset.
 func GenericToolHandler(p *MCPProxy, ct CatalogTool) server.ToolHandlerFunc {
+	ct = withCompiledPatterns(ct)
+	var respCache *cache.ResponseCache
+	if ct.CacheTTLSeconds > 0 && strings.EqualFold(ct.Method, http.MethodGet) {
+		respCache = cache.New(time.Duration(ct.CacheTTLSeconds)*time.Second, maxToolCacheEntries)
+	}
 	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Resolve path, query, and body params
+		// Resolve path, query, and body params. logPath mirrors path with
+		// secret values redacted.
 		path := ct.Path
+		logPath := ct.Path
 		bodyParams := map[string]interface{}{}
 		queryParams := url.Values{}
 
 		for _, param := range ct.Params {
 			val := resolveParamValue(ctx, p, r, param)
+			if err := validateParamValue(param, val); err != nil {
+				return errorResult(fmt.Sprintf("Error: %v", err)), nil
+			}
 			switch param.In {
 			case "path":
 				strVal := fmt.Sprint(val)
@@ -155,8 +484,11 @@ func GenericToolHandler(p *MCPProxy, ct CatalogTool) server.ToolHandlerFunc {
 					continue
 				}
 				path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(strVal))
+				logPath = strings.ReplaceAll(logPath, "{"+param.Name+"}", url.PathEscape(redactValue(param.Name, strVal)))
 			case "query":
-				if val != nil {
+				if items, ok := val.([]interface{}); ok {
+					setQueryArray(queryParams, param, items)
+				} else if val != nil {
 					strVal := fmt.Sprint(val)
 					if strVal != "" {
 						queryParams.Set(param.Name, strVal)
@@ -169,35 +501,113 @@ func GenericToolHandler(p *MCPProxy, ct CatalogTool) server.ToolHandlerFunc {
 			}
 		}
 
+		var maxResults int
+		if ct.Response == ResponseCandidates {
+			if err := validateParamValue(maxResultsParam, r.GetArguments()[maxResultsParam.Name]); err != nil {
+				return errorResult(fmt.Sprintf("Error: %v", err)), nil
+			}
+			maxResults = r.GetInt(maxResultsParam.Name, 0)
+		}
+
+		var tradesCSV bool
+		if ct.Response == ResponseHolding {
+			if err := validateParamValue(formatParam, r.GetArguments()[formatParam.Name]); err != nil {
+				return errorResult(fmt.Sprintf("Error: %v", err)), nil
+			}
+			tradesCSV = r.GetString(formatParam.Name, "") == "csv"
+		}
+
 		if len(queryParams) > 0 {
 			path += "?" + queryParams.Encode()
+			logPath += "?" + queryParams.Encode()
+		}
+
+		p.logger.Debug().
+			Str("tool", ct.Name).
+			Str("method", strings.ToUpper(ct.Method)).
+			Str("path", redactPath(logPath)).
+			Str("args", redactedArgs(r.GetArguments())).
+			Msg("tool call")
+
+		timeout := p.toolTimeout(ct)
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// Clients that sent a progress token hear from long calls while
+		// vire-server is still working, not only once it has answered.
+		var progress *progressReporter
+		if ct.Progress {
+			progress = newProgressReporter(ctx, r, p.progressInterval)
 		}
+		finishProgress := progress.track(ctx, ct.Name)
 
 		// Execute HTTP request based on method
 		var respBody []byte
+		var contentType string
 		var err error
 		switch strings.ToUpper(ct.Method) {
 		case "GET":
-			respBody, err = p.get(ctx, path)
-		case "POST":
-			respBody, err = p.post(ctx, path, bodyOrNil(bodyParams))
-		case "PUT":
-			respBody, err = p.put(ctx, path, bodyOrNil(bodyParams))
-		case "PATCH":
-			respBody, err = p.patch(ctx, path, bodyOrNil(bodyParams))
+			respBody, contentType, err = p.cachedGet(ctx, respCache, path)
+		case "POST", "PUT", "PATCH":
+			respBody, contentType, err = p.do(ctx, strings.ToUpper(ct.Method), path, bodyOrNil(bodyParams))
 		case "DELETE":
-			respBody, err = p.del(ctx, path)
+			respBody, contentType, err = p.do(ctx, http.MethodDelete, path, nil)
 		default:
-			return errorResult(fmt.Sprintf("Error: unsupported method %s", ct.Method)), nil
+			err = fmt.Errorf("unsupported method %s", ct.Method)
 		}
+		finishProgress(respBody, err)
 
 		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errorResult(fmt.Sprintf("Error: %s timed out after %s", ct.Name, timeout)), nil
+			}
 			return errorResult(fmt.Sprintf("Error: %v", err)), nil
 		}
-		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(respBody))}}, nil
+		if ct.Response == ResponseCandidates {
+			respBody = limitCandidates(respBody, maxResults)
+		}
+		respBody = neutralizeSentiment(respBody, p.neutral)
+		if tradesCSV {
+			h, err := decodeHolding(respBody)
+			if err != nil {
+				return errorResult(fmt.Sprintf("Error: cannot export trades as CSV: %v", err)), nil
+			}
+			return contentResult(ct.Name, []byte(formatTradeHistoryCSV(h)), "text/csv"), nil
+		}
+		return contentResult(ct.Name, respBody, contentType), nil
 	}
 }
 
+// cachedGet serves a GET from c when a fresh entry exists, otherwise calls
+// vire-server and caches a successful response. Keys include the user ID so
+// cached data never crosses users, and the user's timezone so a timezone
+// change is not answered with timestamps in the old zone. A nil cache always
+// calls vire-server.
+// It returns the body and its Content-Type, which is cached alongside it.
+func (p *MCPProxy) cachedGet(ctx context.Context, c *cache.ResponseCache, path string) ([]byte, string, error) {
+	if c == nil {
+		return p.do(ctx, http.MethodGet, path, nil)
+	}
+	var userID string
+	if uc, ok := GetUserContext(ctx); ok {
+		userID = uc.UserID
+	}
+	key := cache.MakeVariantKey(userID, http.MethodGet, path, p.userTimezone(ctx))
+	if hit, ok := c.Get(key); ok {
+		p.logger.Debug().Str("path", redactPath(path)).Msg("tool cache hit")
+		return hit.Body, hit.Headers.Get("Content-Type"), nil
+	}
+	body, contentType, err := p.do(ctx, http.MethodGet, path, nil)
+	if err == nil {
+		headers := http.Header{}
+		if contentType != "" {
+			headers.Set("Content-Type", contentType)
+		}
+		c.Set(key, &cache.CachedResponse{StatusCode: http.StatusOK, Headers: headers, Body: body})
+	}
+	return body, contentType, err
+}
+
 // resolveParamValue extracts a parameter value from the MCP request,
 // falling back to defaults from config when default_from is set.
 func resolveParamValue(ctx context.Context, p *MCPProxy, r mcp.CallToolRequest, param CatalogParam) interface{} {
@@ -222,7 +632,7 @@ func resolveParamValue(ctx context.Context, p *MCPProxy, r mcp.CallToolRequest,
 		return resolveDefaultValue(ctx, p, param.DefaultFrom)
 	}
 
-	return nil
+	return param.Default
 }
 
 // resolveDefaultValue resolves a default value from the portal config.
@@ -236,23 +646,22 @@ func resolveDefaultValue(ctx context.Context, p *MCPProxy, defaultFrom string) i
 }
 
 // resolveDefaultPortfolio resolves the default portfolio using a 3-tier strategy:
-// 1. First portfolio from X-Vire-Portfolios header (config)
-// 2. API fallback: GET /api/portfolios/default from vire-server
+// 1. The user's selected portfolio (UserPreferences)
+// 2. First configured portfolio (user.portfolios)
+// 3. API fallback: GET /api/portfolios/default from vire-server
 // Returns empty string if no default can be resolved.
 func resolveDefaultPortfolio(ctx context.Context, p *MCPProxy) string {
-	// Tier 1: Check config headers
-	headers := p.UserHeaders()
-	portfolios := headers.Get("X-Vire-Portfolios")
-	if portfolios != "" {
-		for i := 0; i < len(portfolios); i++ {
-			if portfolios[i] == ',' {
-				return portfolios[:i]
-			}
-		}
-		return portfolios
+	// Tier 1: Portfolio selected in the web UI
+	if preferred := p.preferredPortfolio(ctx); preferred != "" {
+		return preferred
+	}
+
+	// Tier 2: First configured portfolio
+	if p.defaultPortfolio != "" {
+		return p.defaultPortfolio
 	}
 
-	// Tier 2: API fallback
+	// Tier 3: API fallback
 	body, err := p.get(ctx, "/api/portfolios/default")
 	if err != nil {
 		return ""
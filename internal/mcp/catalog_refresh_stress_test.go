@@ -401,6 +401,123 @@ func TestRefreshCatalog_ServerReturns503ThenRecovers(t *testing.T) {
 	}
 }
 
+// TestCatalogStatus_ReportsCountAndFetchTime verifies CatalogStatus tracks the
+// tool count and advances the fetch time on each successful refresh.
+func TestCatalogStatus_ReportsCountAndFetchTime(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	count, first := h.CatalogStatus()
+	if count != 1 {
+		t.Errorf("expected 1 catalog tool, got %d", count)
+	}
+	if first.IsZero() {
+		t.Fatal("expected non-zero fetch time after initial load")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+	if _, second := h.CatalogStatus(); !second.After(first) {
+		t.Errorf("expected fetch time to advance, got %v then %v", first, second)
+	}
+}
+
+// TestCatalogVersion_HashFollowsRefresh verifies the catalog hash is stable
+// across refreshes of an unchanged catalog and changes when the tool set does.
+func TestCatalogVersion_HashFollowsRefresh(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	hash, count := h.CatalogVersion()
+	if hash == "" || count != 1 {
+		t.Fatalf("expected hash and 1 tool after initial load, got %q, %d", hash, count)
+	}
+
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+	if same, _ := h.CatalogVersion(); same != hash {
+		t.Errorf("expected unchanged catalog to keep hash %s, got %s", hash, same)
+	}
+
+	ctrl.CatalogJSON.Store(`[{"name":"tool_a","description":"Tool A","method":"GET","path":"/api/a","params":[]},{"name":"tool_b","description":"Tool B","method":"GET","path":"/api/b","params":[]}]`)
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+	changed, count := h.CatalogVersion()
+	if changed == hash {
+		t.Error("expected hash to change when catalog changed")
+	}
+	if count != 2 {
+		t.Errorf("expected 2 tools after refresh, got %d", count)
+	}
+}
+
+// TestTool_LooksUpValidatedCatalog verifies Tool finds catalog tools by name
+// and follows refreshes.
+func TestTool_LooksUpValidatedCatalog(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	if _, ok := h.Tool("tool_b"); ok {
+		t.Fatal("expected tool_b to be unknown before refresh")
+	}
+
+	ctrl.CatalogJSON.Store(`[{"name":"tool_a","description":"Tool A","method":"GET","path":"/api/a","params":[]},{"name":"tool_b","description":"Tool B","method":"POST","path":"/api/b","params":[]}]`)
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+	ct, ok := h.Tool("tool_b")
+	if !ok || ct.Method != "POST" || ct.Path != "/api/b" {
+		t.Errorf("expected tool_b POST /api/b, got %+v (found %v)", ct, ok)
+	}
+}
+
+func TestCatalogReport_ListsRejections(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	ctrl.CatalogJSON.Store(`[
+		{"name":"tool_a","description":"Tool A","method":"GET","path":"/api/a","params":[]},
+		{"name":"tool_a","description":"Tool A again","method":"GET","path":"/api/a2","params":[]},
+		{"name":"tool_bad","description":"Bad path","method":"GET","path":"/etc/passwd","params":[]}
+	]`)
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+
+	report := h.CatalogReport()
+	if len(report.Tools) != 1 || report.Tools[0].Path != "/api/a" {
+		t.Errorf("expected only the first tool_a to survive, got %+v", report.Tools)
+	}
+	if report.Source != ctrl.URL()+"/api/mcp/tools" || report.FetchedAt.IsZero() {
+		t.Errorf("expected source and fetch time, got %q %v", report.Source, report.FetchedAt)
+	}
+	if len(report.Rejected) != 2 {
+		t.Fatalf("expected 2 rejections, got %+v", report.Rejected)
+	}
+	if r := report.Rejected[0]; r.Name != "tool_a" || !strings.Contains(r.Reason, "duplicate") {
+		t.Errorf("expected duplicate tool_a rejection, got %+v", r)
+	}
+	if r := report.Rejected[1]; r.Name != "tool_bad" || !strings.Contains(r.Reason, "invalid path") {
+		t.Errorf("expected invalid path rejection for tool_bad, got %+v", r)
+	}
+}
+
 // =============================================================================
 // 5. Security — Malicious Server Responses
 // =============================================================================
@@ -634,6 +751,40 @@ func TestRefreshCatalog_GetVersionOverride(t *testing.T) {
 	t.Logf("Catalog after refresh with get_version: %d tools", len(catalog))
 }
 
+// TestRefreshCatalog_KeepsLocalTools verifies the portal's local tools are
+// registered at startup and survive a refresh, and that the local get_version
+// replaces the catalog's.
+func TestRefreshCatalog_KeepsLocalTools(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+	ctrl.CatalogJSON.Store(`[
+		{"name":"get_version","description":"Server-only version","method":"GET","path":"/api/version","params":[]},
+		{"name":"other_tool","description":"Other","method":"GET","path":"/api/other","params":[]}
+	]`)
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	check := func(when string) {
+		t.Helper()
+		registered := h.mcpSrv.ListTools()
+		for _, local := range h.localTools() {
+			if _, ok := registered[local.Tool.Name]; !ok {
+				t.Errorf("%s: local tool %s not registered", when, local.Tool.Name)
+			}
+		}
+		if v := registered["get_version"]; v == nil || v.Tool.Description != VersionTool().Description {
+			t.Errorf("%s: expected the portal's get_version to replace the catalog's", when)
+		}
+	}
+
+	check("startup")
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+	check("after refresh")
+}
+
 // TestRefreshCatalog_PreservesOriginalOnError verifies that a failed refresh
 // does not corrupt the existing catalog.
 func TestRefreshCatalog_PreservesOriginalOnError(t *testing.T) {
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// contentResult turns a vire-server response into a tool result based on its
+// Content-Type. JSON, and responses without a Content-Type, are returned as
+// plain text as before. Other text (CSV, plain text, XML, a chart URL) is
+// returned as text whose _meta.mimeType names the real type, so clients don't
+// mistake it for JSON. Images, audio, video and other binary bodies can't be
+// returned as text and become a tool error describing what came back.
+func contentResult(tool string, body []byte, contentType string) *mcp.CallToolResult {
+	if contentType == "" {
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(body))}}
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	if isJSONMediaType(mediaType) {
+		return &mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(string(body))}}
+	}
+	if isTextMediaType(mediaType) || (!isBinaryMediaType(mediaType) && looksLikeText(body)) {
+		text := mcp.NewTextContent(string(body))
+		text.Meta = mcp.NewMetaFromMap(map[string]any{"mimeType": mediaType})
+		return &mcp.CallToolResult{Content: []mcp.Content{text}}
+	}
+	return errorResult(fmt.Sprintf("Error: %s returned %s content (%d bytes), which cannot be shown as text", tool, mediaType, len(body)))
+}
+
+// isJSONMediaType reports whether mediaType is application/json or a +json type.
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isTextMediaType reports whether mediaType is textual without being JSON.
+func isTextMediaType(mediaType string) bool {
+	switch mediaType {
+	case "application/xml", "application/csv", "application/yaml", "application/x-yaml", "application/x-ndjson", "application/javascript":
+		return true
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// isBinaryMediaType reports whether mediaType is always binary, whatever the
+// body looks like.
+func isBinaryMediaType(mediaType string) bool {
+	switch mediaType {
+	case "application/octet-stream", "application/pdf", "application/zip", "application/gzip":
+		return true
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/", "font/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeText reports whether body is valid UTF-8 with no NUL bytes, for
+// media types not listed above.
+func looksLikeText(body []byte) bool {
+	return utf8.Valid(body) && !bytes.ContainsRune(body, 0)
+}
@@ -0,0 +1,138 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// contentMimeType returns the _meta.mimeType of an MCP content block, "" when absent.
+func contentMimeType(t *testing.T, content interface{}) string {
+	t.Helper()
+	contentJSON, _ := json.Marshal(content)
+	var c struct {
+		Meta struct {
+			MimeType string `json:"mimeType"`
+		} `json:"_meta"`
+	}
+	json.Unmarshal(contentJSON, &c)
+	return c.Meta.MimeType
+}
+
+func TestGenericHandler_ContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantError   bool
+		wantText    string
+		wantMime    string
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        []byte(`{"ticker":"BHP.AU"}`),
+			wantText:    `{"ticker":"BHP.AU"}`,
+		},
+		{
+			name:     "no content type",
+			body:     []byte(`{"ticker":"BHP.AU"}`),
+			wantText: `{"ticker":"BHP.AU"}`,
+		},
+		{
+			name:        "csv",
+			contentType: "text/csv",
+			body:        []byte("ticker,close\nBHP.AU,45.50\n"),
+			wantText:    "ticker,close\nBHP.AU,45.50\n",
+			wantMime:    "text/csv",
+		},
+		{
+			name:        "plain text chart URL",
+			contentType: "text/plain; charset=utf-8",
+			body:        []byte("https://charts.example.com/abc.png"),
+			wantText:    "https://charts.example.com/abc.png",
+			wantMime:    "text/plain",
+		},
+		{
+			name:        "png",
+			contentType: "image/png",
+			body:        []byte("\x89PNG\r\n\x1a\n\x00\x00"),
+			wantError:   true,
+			wantText:    "image/png content (10 bytes)",
+		},
+		{
+			name:        "unknown binary",
+			contentType: "application/x-parquet",
+			body:        []byte{0x50, 0x41, 0x52, 0x31, 0x00, 0xff},
+			wantError:   true,
+			wantText:    "application/x-parquet",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				} else {
+					// Stop net/http sniffing a type for the body
+					w.Header()["Content-Type"] = nil
+				}
+				w.Write(tt.body)
+			}))
+			defer mockServer.Close()
+
+			ct := CatalogTool{Name: "get_report", Method: "GET", Path: "/api/report"}
+			s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+			p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+			s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+			result := callTool(t, s, "get_report", map[string]interface{}{})
+
+			if result.IsError != tt.wantError {
+				t.Fatalf("expected IsError=%v, got %v", tt.wantError, result.IsError)
+			}
+			text := extractText(t, result.Content[0])
+			if tt.wantError {
+				if !strings.Contains(text, tt.wantText) {
+					t.Errorf("expected error mentioning %q, got: %s", tt.wantText, text)
+				}
+				return
+			}
+			if text != tt.wantText {
+				t.Errorf("expected body passed through, got: %q", text)
+			}
+			if got := contentMimeType(t, result.Content[0]); got != tt.wantMime {
+				t.Errorf("expected mimeType %q, got %q", tt.wantMime, got)
+			}
+		})
+	}
+}
+
+func TestGenericHandler_CachedContentType(t *testing.T) {
+	calls := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("ticker,close\n"))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{Name: "get_report", Method: "GET", Path: "/api/report", CacheTTLSeconds: 60}
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	callTool(t, s, "get_report", map[string]interface{}{})
+	result := callTool(t, s, "get_report", map[string]interface{}{})
+
+	if calls != 1 {
+		t.Fatalf("expected the second call to be served from cache, got %d upstream calls", calls)
+	}
+	if got := contentMimeType(t, result.Content[0]); got != "text/csv" {
+		t.Errorf("expected cached mimeType text/csv, got %q", got)
+	}
+}
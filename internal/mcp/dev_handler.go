@@ -76,7 +76,7 @@ func (dh *DevHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Inject user context and delegate to main handler
 	ctx := WithUserContext(r.Context(), UserContext{UserID: userID})
 	r = r.WithContext(ctx)
-	dh.handler.streamable.ServeHTTP(w, r)
+	dh.handler.serveStreamable(w, r)
 }
 
 // GenerateEndpoint generates an encrypted MCP endpoint URL for a user.
@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// holdingMatch is one holding that matched a find_holding query.
+type holdingMatch struct {
+	Portfolio string          `json:"portfolio"`
+	Ticker    string          `json:"ticker"`
+	Position  json.RawMessage `json:"position"`
+}
+
+// findHoldingResult is the find_holding tool response.
+type findHoldingResult struct {
+	Query    string         `json:"query"`
+	Matches  []holdingMatch `json:"matches"`
+	Searched []string       `json:"searched"`
+	Skipped  []string       `json:"skipped,omitempty"`
+}
+
+// matchTicker reports whether query identifies ticker, ignoring case and an
+// exchange suffix present on only one side: "BHP" matches "BHP.AU" and
+// "SKS.AU" matches "SKS", but "BHP.AU" does not match "BHP.US".
+func matchTicker(query, ticker string) bool {
+	q := strings.ToUpper(strings.TrimSpace(query))
+	t := strings.ToUpper(strings.TrimSpace(ticker))
+	if q == "" || t == "" {
+		return false
+	}
+	if q == t {
+		return true
+	}
+	return hasExchangeSuffix(t, q) || hasExchangeSuffix(q, t)
+}
+
+// hasExchangeSuffix reports whether full is base followed by a single
+// ".EXCHANGE" suffix.
+func hasExchangeSuffix(full, base string) bool {
+	suffix, ok := strings.CutPrefix(full, base+".")
+	return ok && suffix != "" && !strings.Contains(suffix, ".")
+}
+
+// maxTickerSuggestions caps how many near-miss tickers suggestTickers returns.
+const maxTickerSuggestions = 3
+
+// suggestTickers returns the held tickers closest to query, nearest first,
+// for when matchTicker finds nothing. Exchange suffixes are ignored when
+// comparing. A ticker is close when one base is a prefix of the other or the
+// edit distance is at most a third of the query's length (minimum 1).
+func suggestTickers(query string, held []string) []string {
+	q := tickerBase(query)
+	if q == "" {
+		return nil
+	}
+	limit := max(1, len(q)/3)
+
+	type candidate struct {
+		ticker string
+		dist   int
+	}
+	var candidates []candidate
+	seen := map[string]bool{}
+	for _, ticker := range held {
+		t := tickerBase(ticker)
+		if t == "" || seen[ticker] {
+			continue
+		}
+		seen[ticker] = true
+		dist := levenshtein(q, t)
+		if dist > limit && !strings.HasPrefix(t, q) && !strings.HasPrefix(q, t) {
+			continue
+		}
+		candidates = append(candidates, candidate{ticker, dist})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].ticker < candidates[j].ticker
+	})
+
+	var out []string
+	for _, c := range candidates {
+		if len(out) == maxTickerSuggestions {
+			break
+		}
+		out = append(out, c.ticker)
+	}
+	return out
+}
+
+// tickerBase upper-cases ticker and strips any ".EXCHANGE" suffix.
+func tickerBase(ticker string) string {
+	base, _, _ := strings.Cut(strings.ToUpper(strings.TrimSpace(ticker)), ".")
+	return base
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// FindHoldingTool returns the mcp.Tool definition for find_holding.
+func FindHoldingTool() mcp.Tool {
+	return mcp.NewTool("find_holding",
+		mcp.WithDescription("Find which of the user's portfolios hold a ticker. Accepts a loose ticker with or without an exchange suffix (e.g. BHP or BHP.AU) and returns each matching portfolio with the position. When nothing matches, the error suggests the closest tickers held."),
+		mcp.WithString("ticker",
+			mcp.Description("Ticker to search for. The exchange suffix is optional."),
+			mcp.Required(),
+		),
+	)
+}
+
+// FindHoldingToolHandler returns a handler that searches every portfolio for
+// holdings matching the requested ticker. Portfolios that fail to load are
+// listed in "skipped" rather than failing the whole search. When nothing
+// matches but a held ticker is close, the result is an error suggesting it.
+func FindHoldingToolHandler(proxy *MCPProxy) server.ToolHandlerFunc {
+	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		query := strings.TrimSpace(r.GetString("ticker", ""))
+		if query == "" {
+			return errorResult("Error: ticker is required"), nil
+		}
+
+		body, err := proxy.get(ctx, "/api/portfolios")
+		if err != nil {
+			return errorResult(fmt.Sprintf("Error: failed to list portfolios: %v", err)), nil
+		}
+		var list struct {
+			Portfolios []struct {
+				Name string `json:"name"`
+			} `json:"portfolios"`
+		}
+		if err := json.Unmarshal(body, &list); err != nil {
+			return errorResult("Error: invalid portfolio list response"), nil
+		}
+
+		result := findHoldingResult{Query: query, Matches: []holdingMatch{}, Searched: []string{}}
+		var held []string
+		for _, p := range list.Portfolios {
+			if p.Name == "" {
+				continue
+			}
+			pBody, err := proxy.get(ctx, "/api/portfolios/"+url.PathEscape(p.Name))
+			if err != nil {
+				result.Skipped = append(result.Skipped, p.Name)
+				continue
+			}
+			var portfolio struct {
+				Holdings []json.RawMessage `json:"holdings"`
+			}
+			if err := json.Unmarshal(pBody, &portfolio); err != nil {
+				result.Skipped = append(result.Skipped, p.Name)
+				continue
+			}
+			result.Searched = append(result.Searched, p.Name)
+
+			for _, raw := range portfolio.Holdings {
+				var h struct {
+					Ticker string `json:"ticker"`
+				}
+				if json.Unmarshal(raw, &h) != nil {
+					continue
+				}
+				if !matchTicker(query, h.Ticker) {
+					held = append(held, h.Ticker)
+					continue
+				}
+				result.Matches = append(result.Matches, holdingMatch{
+					Portfolio: p.Name,
+					Ticker:    h.Ticker,
+					Position:  raw,
+				})
+			}
+		}
+
+		if len(result.Matches) == 0 {
+			if suggestions := suggestTickers(query, held); len(suggestions) > 0 {
+				return errorResult(fmt.Sprintf("Error: no holding matches %s. Did you mean: %s?", query, strings.Join(suggestions, ", "))), nil
+			}
+		}
+
+		out, err := json.Marshal(result)
+		if err != nil {
+			return errorResult("failed to marshal find_holding result"), nil
+		}
+		out = neutralizeSentiment(out, proxy.neutral)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{mcp.NewTextContent(string(out))},
+		}, nil
+	}
+}
@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMatchTicker(t *testing.T) {
+	tests := []struct {
+		query  string
+		ticker string
+		want   bool
+	}{
+		{"BHP", "BHP.AU", true},
+		{"bhp", "BHP.AU", true},
+		{"SKS.AU", "SKS", true},
+		{"CBA.AU", "CBA.AU", true},
+		{"BRK.B", "BRK.B.US", true},
+		{"BHP.AU", "BHP.US", false},
+		{"BH", "BHP.AU", false},
+		{"BHP", "BHPX", false},
+		{"", "BHP", false},
+	}
+	for _, tt := range tests {
+		if got := matchTicker(tt.query, tt.ticker); got != tt.want {
+			t.Errorf("matchTicker(%q, %q) = %v, want %v", tt.query, tt.ticker, got, tt.want)
+		}
+	}
+}
+
+// newHoldingsServer serves two portfolios: Growth holds BHP.AU and CBA.AU,
+// SMSF holds CBA and VAS.AU.
+func newHoldingsServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/portfolios":
+			w.Write([]byte(`{"portfolios":[{"name":"Growth"},{"name":"SMSF"}],"default":"Growth"}`))
+		case "/api/portfolios/Growth":
+			w.Write([]byte(`{"holdings":[{"ticker":"BHP.AU","units":100},{"ticker":"CBA.AU","units":10}]}`))
+		case "/api/portfolios/SMSF":
+			w.Write([]byte(`{"holdings":[{"ticker":"CBA","units":5},{"ticker":"VAS.AU","units":20}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func callFindHolding(t *testing.T, proxy *MCPProxy, ticker string) findHoldingResult {
+	t.Helper()
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"ticker": ticker}
+
+	result, err := FindHoldingToolHandler(proxy)(t.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+
+	var resp findHoldingResult
+	text := result.Content[0].(mcpgo.TextContent).Text
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp
+}
+
+func TestFindHoldingToolHandler_MatchesExchangeSuffix(t *testing.T) {
+	srv := newHoldingsServer()
+	defer srv.Close()
+
+	resp := callFindHolding(t, NewMCPProxy(srv.URL, testLogger(), testConfig()), "BHP")
+
+	if len(resp.Matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(resp.Matches))
+	}
+	m := resp.Matches[0]
+	if m.Portfolio != "Growth" || m.Ticker != "BHP.AU" {
+		t.Errorf("expected BHP.AU in Growth, got %s in %s", m.Ticker, m.Portfolio)
+	}
+	var pos struct {
+		Units float64 `json:"units"`
+	}
+	if err := json.Unmarshal(m.Position, &pos); err != nil || pos.Units != 100 {
+		t.Errorf("expected position with 100 units, got %s", m.Position)
+	}
+}
+
+func TestFindHoldingToolHandler_MatchesAcrossPortfolios(t *testing.T) {
+	srv := newHoldingsServer()
+	defer srv.Close()
+
+	resp := callFindHolding(t, NewMCPProxy(srv.URL, testLogger(), testConfig()), "CBA")
+
+	if len(resp.Matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(resp.Matches))
+	}
+	if resp.Matches[0].Portfolio != "Growth" || resp.Matches[0].Ticker != "CBA.AU" {
+		t.Errorf("expected CBA.AU in Growth, got %+v", resp.Matches[0])
+	}
+	if resp.Matches[1].Portfolio != "SMSF" || resp.Matches[1].Ticker != "CBA" {
+		t.Errorf("expected CBA in SMSF, got %+v", resp.Matches[1])
+	}
+	if len(resp.Searched) != 2 {
+		t.Errorf("expected 2 portfolios searched, got %v", resp.Searched)
+	}
+}
+
+func TestFindHoldingToolHandler_RequiresTicker(t *testing.T) {
+	handler := FindHoldingToolHandler(NewMCPProxy(mockAPIServer.URL, testLogger(), testConfig()))
+
+	result, err := handler(t.Context(), mcpgo.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error result when ticker is missing")
+	}
+}
+
+func TestFindHoldingTool_SurvivesRefresh(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	if h.mcpSrv.GetTool("find_holding") == nil {
+		t.Fatal("expected find_holding to be registered at startup")
+	}
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+	if h.mcpSrv.GetTool("find_holding") == nil {
+		t.Error("expected find_holding to survive catalog refresh")
+	}
+}
+
+func TestSuggestTickers(t *testing.T) {
+	held := []string{"BHP.AU", "CBA.AU", "CBA", "VAS.AU", "RIO.AU"}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"BHPX", []string{"BHP.AU"}},
+		{"bhpx.au", []string{"BHP.AU"}},
+		{"CBB", []string{"CBA", "CBA.AU"}},
+		{"VA", []string{"VAS.AU"}},
+		{"ZZZZ", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := suggestTickers(tt.query, held)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("suggestTickers(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFindHoldingToolHandler_SuggestsClosestTicker(t *testing.T) {
+	srv := newHoldingsServer()
+	defer srv.Close()
+
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"ticker": "BHPX"}
+	result, err := FindHoldingToolHandler(NewMCPProxy(srv.URL, testLogger(), testConfig()))(t.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error result for unmatched ticker")
+	}
+	if text := result.Content[0].(mcpgo.TextContent).Text; !strings.Contains(text, "Did you mean: BHP.AU?") {
+		t.Errorf("expected BHP.AU suggestion, got: %s", text)
+	}
+}
+
+func TestFindHoldingToolHandler_NoMatchNoSuggestion(t *testing.T) {
+	srv := newHoldingsServer()
+	defer srv.Close()
+
+	resp := callFindHolding(t, NewMCPProxy(srv.URL, testLogger(), testConfig()), "XYZW")
+	if len(resp.Matches) != 0 {
+		t.Errorf("expected no matches, got %+v", resp.Matches)
+	}
+}
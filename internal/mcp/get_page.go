@@ -49,7 +49,8 @@ func GetPageTool() mcp.Tool {
 }
 
 // GetPageToolHandler returns a handler that fetches a rendered portal page via loopback HTTP.
-func GetPageToolHandler(portalBaseURL string, jwtSecret []byte) server.ToolHandlerFunc {
+// The minted loopback JWT is sent in the cookie named cookieName.
+func GetPageToolHandler(portalBaseURL string, jwtSecret []byte, cookieName string) server.ToolHandlerFunc {
 	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		page := r.GetString("page", "")
 		path, ok := allowedPages[page]
@@ -71,7 +72,7 @@ func GetPageToolHandler(portalBaseURL string, jwtSecret []byte) server.ToolHandl
 		if err != nil {
 			return errorResult(fmt.Sprintf("Error: failed to build request: %v", err)), nil
 		}
-		req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+		req.AddCookie(&http.Cookie{Name: cookieName, Value: token})
 
 		client := &http.Client{Timeout: loopbackTimeout}
 		resp, err := client.Do(req)
@@ -21,7 +21,7 @@ import (
 // =============================================================================
 
 func TestGetPage_StressWhitelistBypass(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	attacks := []struct {
@@ -200,7 +200,7 @@ func TestGetPage_StressLargeResponse(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -239,7 +239,7 @@ func TestGetPage_StressSlowDripResponse(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	// Use a context with a short timeout to keep test fast
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -267,7 +267,7 @@ func TestGetPage_StressSlowDripResponse(t *testing.T) {
 // =============================================================================
 
 func TestGetPage_StressNoUserContext(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 
 	req := mcpgo.CallToolRequest{}
 	req.Params.Arguments = map[string]interface{}{"page": "dashboard"}
@@ -288,7 +288,7 @@ func TestGetPage_StressNoUserContext(t *testing.T) {
 func TestGetPage_StressEmptyUserID(t *testing.T) {
 	// UserContext with empty UserID — should still work (sub will be "")
 	// The handler checks for UserContext presence, not UserID content
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: ""})
 
 	req := mcpgo.CallToolRequest{}
@@ -322,7 +322,7 @@ func TestGetPage_StressCookieContainsValidJWT(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, secret)
+	handler := GetPageToolHandler(srv.URL, secret, "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -356,7 +356,7 @@ func TestGetPage_StressNoExtraCookiesLeaked(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -428,7 +428,7 @@ func TestGetPage_StressConcurrentRequests(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	var wg sync.WaitGroup
@@ -475,7 +475,7 @@ func TestGetPage_StressConcurrentRequests(t *testing.T) {
 
 func TestGetPage_StressErrorDoesNotLeakInternalURL(t *testing.T) {
 	// When portal is unreachable, error should not expose the full loopback URL
-	handler := GetPageToolHandler("http://127.0.0.1:19999", []byte("secret"))
+	handler := GetPageToolHandler("http://127.0.0.1:19999", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -508,7 +508,7 @@ func TestGetPage_StressNon200DoesNotLeakSensitiveBody(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -542,7 +542,7 @@ func TestGetPage_StressBaseURLNotFromUserInput(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	// Even if the page param contains URL-like content, it should be rejected
@@ -577,7 +577,7 @@ func TestGetPage_StressChunkedResponseCapped(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -602,7 +602,7 @@ func TestGetPage_StressChunkedResponseCapped(t *testing.T) {
 // =============================================================================
 
 func TestGetPage_StressMissingPageArgument(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	// No arguments at all
@@ -619,7 +619,7 @@ func TestGetPage_StressMissingPageArgument(t *testing.T) {
 }
 
 func TestGetPage_StressNonStringPageArgument(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	nonStrings := []interface{}{
@@ -659,7 +659,7 @@ func TestGetPage_StressLimitReaderExactBoundary(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -694,7 +694,7 @@ func TestGetPage_StressRequestMethodIsGET(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -724,7 +724,7 @@ func TestGetPage_StressTruncatedResponseNotDetected(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -766,7 +766,7 @@ func TestGetPage_StressRedirectFollowed(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -795,7 +795,7 @@ func TestGetPage_StressEmptyResponse(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "alice"})
 
 	req := mcpgo.CallToolRequest{}
@@ -44,7 +44,7 @@ func TestGetPageToolHandler_ValidPage(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("test-secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("test-secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -64,7 +64,7 @@ func TestGetPageToolHandler_ValidPage(t *testing.T) {
 }
 
 func TestGetPageToolHandler_InvalidPage(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -80,7 +80,7 @@ func TestGetPageToolHandler_InvalidPage(t *testing.T) {
 }
 
 func TestGetPageToolHandler_EmptyPage(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -96,7 +96,7 @@ func TestGetPageToolHandler_EmptyPage(t *testing.T) {
 }
 
 func TestGetPageToolHandler_NoUserContext(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 
 	req := mcpgo.CallToolRequest{}
 	req.Params.Arguments = map[string]interface{}{"page": "dashboard"}
@@ -111,7 +111,7 @@ func TestGetPageToolHandler_NoUserContext(t *testing.T) {
 }
 
 func TestGetPageToolHandler_PortalUnavailable(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -133,7 +133,7 @@ func TestGetPageToolHandler_NonOKResponse(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -229,7 +229,7 @@ func TestGetPageToolHandler_AllPages(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	for page := range allowedPages {
@@ -247,7 +247,7 @@ func TestGetPageToolHandler_AllPages(t *testing.T) {
 }
 
 func TestGetPageToolHandler_TraversalAttempt(t *testing.T) {
-	handler := GetPageToolHandler("http://localhost:1", []byte("secret"))
+	handler := GetPageToolHandler("http://localhost:1", []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	attacks := []string{"../admin", "admin/users", "/admin"}
@@ -276,7 +276,7 @@ func TestGetPageToolHandler_CookieSet(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	handler := GetPageToolHandler(srv.URL, []byte("secret"))
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "vire_session")
 	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
 
 	req := mcpgo.CallToolRequest{}
@@ -298,3 +298,32 @@ func TestGetPageToolHandler_CookieSet(t *testing.T) {
 		t.Errorf("expected JWT cookie with 3 parts, got %d", len(parts))
 	}
 }
+
+func TestGetPageToolHandler_ConfiguredCookieName(t *testing.T) {
+	var receivedCookie string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("portal_sid")
+		if err == nil {
+			receivedCookie = cookie.Value
+		}
+		w.Write([]byte("<html>ok</html>"))
+	}))
+	defer srv.Close()
+
+	handler := GetPageToolHandler(srv.URL, []byte("secret"), "portal_sid")
+	ctx := WithUserContext(context.Background(), UserContext{UserID: "user123"})
+
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"page": "dashboard"}
+
+	result, err := handler(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+	if receivedCookie == "" {
+		t.Error("expected portal_sid cookie to be set")
+	}
+}
@@ -6,14 +6,18 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
 )
 
@@ -24,28 +28,90 @@ type Handler struct {
 	logger        *common.Logger
 	catalog       []CatalogTool
 	jwtSecret     []byte
+	cookieName    string
 	portalBaseURL string
 	mcpSrv        *mcpserver.MCPServer // for SetTools() during refresh
 	proxy         *MCPProxy            // for FetchCatalog() during refresh
-	catalogMu     sync.RWMutex         // protects catalog field
+	rejected      []RejectedTool       // catalog entries dropped by the last validation
+	catalogMu     sync.RWMutex         // protects catalog, rejected, catalogAt, catalogHash and openAPI
+	catalogAt     time.Time            // last successful catalog fetch
+	catalogHash   string               // CatalogHash of catalog, computed at registration
+	openAPI       []byte               // BuildOpenAPI of catalog, regenerated on refresh
 	stopWatch     chan struct{}        // closed to stop version watcher
+	maxMessage    int64                // cap on inbound JSON-RPC message bodies
 }
 
 // catalogRetryDelay is the delay between retry attempts.
 const catalogRetryDelay = 2 * time.Second
 
+// maxCatalogRetryAfter caps how long a 429 Retry-After can stretch the
+// startup catalog backoff.
+const maxCatalogRetryAfter = 30 * time.Second
+
+// catalogBackoff returns the delay before the next catalog fetch attempt.
+// A rate-limited fetch waits for vire-server's Retry-After, up to
+// maxCatalogRetryAfter; anything else uses catalogRetryDelay.
+func catalogBackoff(err error) time.Duration {
+	var rl *RateLimitError
+	if errors.As(err, &rl) && rl.RetryAfter > catalogRetryDelay {
+		return min(rl.RetryAfter, maxCatalogRetryAfter)
+	}
+	return catalogRetryDelay
+}
+
+// catalogUnavailableInstructions is returned as the server instructions in
+// the initialize response while no catalog tools are registered, so clients
+// can tell the user why the tool list is nearly empty.
+const catalogUnavailableInstructions = "The Vire tool catalog is currently unavailable (vire-server could not be reached or returned no tools). " +
+	"The portal retries automatically; until then only the local tools are available. " +
+	"Call portal_status to check the connection."
+
 // versionPollInterval is how often the version watcher polls vire-server.
 const versionPollInterval = 30 * time.Second
 
+// HandlerOption customises NewHandler.
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	httpClient *http.Client
+	userLookup func(userID string) (*client.UserProfile, error)
+}
+
+// WithHTTPClient makes the handler send every vire-server request, including
+// the startup catalog fetch, through c instead of its pooled client.
+func WithHTTPClient(c *http.Client) HandlerOption {
+	return func(o *handlerOptions) { o.httpClient = c }
+}
+
+// WithUserLookup makes the handler read users' saved preferences, the
+// portfolio and timezone selected in the web UI, from their vire-server
+// profile.
+func WithUserLookup(fn func(userID string) (*client.UserProfile, error)) HandlerOption {
+	return func(o *handlerOptions) { o.userLookup = fn }
+}
+
 // NewHandler creates a new MCP handler with dynamic tool registration from vire-server.
-func NewHandler(cfg *config.Config, logger *common.Logger) *Handler {
+func NewHandler(cfg *config.Config, logger *common.Logger, opts ...HandlerOption) *Handler {
+	var o handlerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	hooks := &mcpserver.Hooks{}
 	mcpSrv := mcpserver.NewMCPServer(
 		"vire-portal",
 		"1.0.0",
 		mcpserver.WithToolCapabilities(true),
+		mcpserver.WithHooks(hooks),
 	)
 
 	proxy := NewMCPProxy(cfg.API.URL, logger, cfg)
+	if o.httpClient != nil {
+		proxy.httpClient = o.httpClient
+	}
+	if o.userLookup != nil {
+		proxy.preferences = NewUserPreferences(o.userLookup)
+	}
 
 	// Fetch tool catalog from vire-server with retry (non-fatal if unreachable)
 	maxAttempts := cfg.MCP.CatalogRetries
@@ -68,12 +134,14 @@ func NewHandler(cfg *config.Config, logger *common.Logger) *Handler {
 			Str("api_url", cfg.API.URL).
 			Msg("failed to fetch tool catalog, retrying")
 		if attempt < maxAttempts {
-			time.Sleep(catalogRetryDelay)
+			time.Sleep(catalogBackoff(fetchErr))
 		}
 	}
 
 	var validated []CatalogTool
+	var rejected []RejectedTool
 	var toolCount int
+	var catalogAt time.Time
 	if fetchErr != nil {
 		logger.Warn().
 			Int("attempts", maxAttempts).
@@ -81,17 +149,11 @@ func NewHandler(cfg *config.Config, logger *common.Logger) *Handler {
 			Str("api_url", cfg.API.URL).
 			Msg("failed to fetch tool catalog after retries, starting with 0 tools")
 	} else {
-		validated = ValidateCatalog(catalog, logger)
+		validated, rejected = ValidateCatalog(catalog, logger)
 		toolCount = RegisterToolsFromCatalog(mcpSrv, proxy, validated)
+		catalogAt = time.Now()
 	}
 
-	// Override get_version with combined handler that includes both
-	// vire-portal and vire-server version info.
-	mcpSrv.AddTool(VersionTool(), VersionToolHandler(proxy))
-
-	// Register portal_get_page local tool
-	mcpSrv.AddTool(GetPageTool(), GetPageToolHandler(cfg.BaseURL(), []byte(cfg.Auth.JWTSecret)))
-
 	streamable := mcpserver.NewStreamableHTTPServer(mcpSrv,
 		mcpserver.WithStateLess(true),
 	)
@@ -105,12 +167,26 @@ func NewHandler(cfg *config.Config, logger *common.Logger) *Handler {
 		streamable:    streamable,
 		logger:        logger,
 		catalog:       validated,
+		rejected:      rejected,
+		catalogAt:     catalogAt,
+		catalogHash:   CatalogHash(validated),
+		openAPI:       catalogOpenAPI(validated, logger),
 		jwtSecret:     []byte(cfg.Auth.JWTSecret),
+		cookieName:    cfg.Auth.CookieName(),
 		portalBaseURL: cfg.BaseURL(),
 		mcpSrv:        mcpSrv,
 		proxy:         proxy,
 		stopWatch:     make(chan struct{}),
+		maxMessage:    cfg.MCP.MessageLimit(),
 	}
+
+	// Local tools are registered after the catalog so get_version replaces
+	// vire-server's catalog entry.
+	mcpSrv.AddTools(h.localTools()...)
+
+	// Explain an empty catalog in the initialize response
+	hooks.AddAfterInitialize(h.addCatalogInstructions)
+
 	go h.watchServerVersion()
 	return h
 }
@@ -124,6 +200,101 @@ func (h *Handler) Catalog() []CatalogTool {
 	return result
 }
 
+// Tool returns the validated catalog tool with the given name.
+func (h *Handler) Tool(name string) (CatalogTool, bool) {
+	h.catalogMu.RLock()
+	defer h.catalogMu.RUnlock()
+	for _, ct := range h.catalog {
+		if ct.Name == name {
+			return ct, true
+		}
+	}
+	return CatalogTool{}, false
+}
+
+// CatalogStatus returns the number of validated catalog tools and the time of
+// the last successful catalog fetch (zero if the catalog has never loaded).
+func (h *Handler) CatalogStatus() (int, time.Time) {
+	h.catalogMu.RLock()
+	defer h.catalogMu.RUnlock()
+	return len(h.catalog), h.catalogAt
+}
+
+// CatalogReport describes the catalog currently served, for debugging
+// catalog issues: where it was fetched from, when, the validated tools, and
+// the entries validation dropped.
+type CatalogReport struct {
+	Source    string
+	FetchedAt time.Time // zero if the catalog has never loaded
+	Tools     []CatalogTool
+	Rejected  []RejectedTool
+}
+
+// CatalogReport returns a snapshot of the served catalog and its rejections.
+func (h *Handler) CatalogReport() CatalogReport {
+	h.catalogMu.RLock()
+	defer h.catalogMu.RUnlock()
+	tools := make([]CatalogTool, len(h.catalog))
+	copy(tools, h.catalog)
+	return CatalogReport{
+		Source:    h.proxy.serverURL + catalogPath,
+		FetchedAt: h.catalogAt,
+		Tools:     tools,
+		Rejected:  slices.Clone(h.rejected),
+	}
+}
+
+// addCatalogInstructions sets catalogUnavailableInstructions on the
+// initialize result while the catalog has no tools. The catalog is checked
+// per request, so the note disappears once a refresh succeeds.
+func (h *Handler) addCatalogInstructions(_ context.Context, _ any, _ *mcp.InitializeRequest, result *mcp.InitializeResult) {
+	if count, _ := h.CatalogStatus(); count == 0 && result != nil {
+		result.Instructions = catalogUnavailableInstructions
+	}
+}
+
+// SetPortfolioPreference records the portfolio userID selected in the web UI,
+// once it has been saved to their profile. It becomes that user's default
+// portfolio for MCP tool calls. An empty name clears the preference.
+func (h *Handler) SetPortfolioPreference(userID, name string) {
+	h.proxy.preferences.SetPortfolio(userID, name)
+}
+
+// SetTimezonePreference records the display timezone userID chose in the
+// web UI, once it has been saved to their profile. It is sent as
+// X-Vire-Timezone on that user's tool calls instead of the configured
+// user.timezone. An empty timezone clears the preference.
+func (h *Handler) SetTimezonePreference(userID, timezone string) {
+	h.proxy.preferences.SetTimezone(userID, timezone)
+}
+
+// CatalogVersion returns the hash of the validated catalog and its tool count,
+// so clients can detect when the exposed tool set changed between deploys.
+func (h *Handler) CatalogVersion() (string, int) {
+	h.catalogMu.RLock()
+	defer h.catalogMu.RUnlock()
+	return h.catalogHash, len(h.catalog)
+}
+
+// OpenAPI returns the cached OpenAPI document for the validated catalog,
+// or nil if it could not be generated.
+func (h *Handler) OpenAPI() []byte {
+	h.catalogMu.RLock()
+	defer h.catalogMu.RUnlock()
+	return h.openAPI
+}
+
+// catalogOpenAPI builds the OpenAPI document for catalog, logging and
+// returning nil on failure.
+func catalogOpenAPI(catalog []CatalogTool, logger *common.Logger) []byte {
+	doc, err := BuildOpenAPI(catalog, config.GetVersion())
+	if err != nil {
+		logger.Warn().Str("error", err.Error()).Msg("failed to build OpenAPI document from catalog")
+		return nil
+	}
+	return doc
+}
+
 // RefreshCatalog fetches the current tool catalog from vire-server, validates it,
 // atomically replaces all registered tools via SetTools(), and updates the catalog.
 // Returns the count of validated tools (excluding get_version) or an error.
@@ -136,35 +307,55 @@ func (h *Handler) RefreshCatalog() (int, error) {
 		return 0, fmt.Errorf("fetch catalog: %w", err)
 	}
 
-	validated := ValidateCatalog(catalog, h.logger)
+	validated, rejected := ValidateCatalog(catalog, h.logger)
 
-	tools := make([]mcpserver.ServerTool, 0, len(validated)+1)
+	local := h.localTools()
+	tools := make([]mcpserver.ServerTool, 0, len(validated)+len(local))
 	for _, ct := range validated {
 		tools = append(tools, mcpserver.ServerTool{
 			Tool:    BuildMCPTool(ct),
 			Handler: GenericToolHandler(h.proxy, ct),
 		})
 	}
-	// Always include combined version tool
-	tools = append(tools, mcpserver.ServerTool{
-		Tool:    VersionTool(),
-		Handler: VersionToolHandler(h.proxy),
-	})
-	// Always include portal_get_page local tool
-	tools = append(tools, mcpserver.ServerTool{
-		Tool:    GetPageTool(),
-		Handler: GetPageToolHandler(h.portalBaseURL, h.jwtSecret),
-	})
+	tools = append(tools, local...)
 
 	h.mcpSrv.SetTools(tools...)
 
+	hash := CatalogHash(validated)
+	doc := catalogOpenAPI(validated, h.logger)
+
 	h.catalogMu.Lock()
 	h.catalog = validated
+	h.rejected = rejected
+	h.catalogAt = time.Now()
+	h.catalogHash = hash
+	h.openAPI = doc
 	h.catalogMu.Unlock()
 
 	return len(validated), nil
 }
 
+// localTools returns the tools the portal implements itself. They are
+// registered alongside the catalog tools at startup and on every
+// RefreshCatalog, after the catalog tools so they take precedence.
+func (h *Handler) localTools() []mcpserver.ServerTool {
+	return []mcpserver.ServerTool{
+		// Combined get_version with vire-portal and vire-server version info
+		{Tool: VersionTool(), Handler: VersionToolHandler(h.proxy)},
+		{Tool: GetPageTool(), Handler: GetPageToolHandler(h.portalBaseURL, h.jwtSecret, h.sessionCookieName())},
+		// Searches all portfolios
+		{Tool: FindHoldingTool(), Handler: FindHoldingToolHandler(h.proxy)},
+		// Optional weekly/monthly downsampling
+		{Tool: PortfolioHistoryTool(), Handler: PortfolioHistoryToolHandler(h.proxy)},
+		// Multi-ticker quote comparison table
+		{Tool: GetQuotesTool(), Handler: GetQuotesToolHandler(h.proxy)},
+		// Diagnostics, reads the catalog status
+		{Tool: PortalStatusTool(), Handler: PortalStatusToolHandler(h.proxy, h.CatalogStatus)},
+		// Runs several registered tools in one call
+		{Tool: BatchTool(), Handler: BatchToolHandler(h.mcpSrv)},
+	}
+}
+
 // watchServerVersion polls vire-server's /api/version every versionPollInterval.
 // When the build field changes, it triggers a catalog refresh.
 func (h *Handler) watchServerVersion() {
@@ -274,6 +465,19 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.serveStreamable(w, r)
+}
+
+// serveStreamable passes an authenticated request to the MCP server once
+// its body is within the message size limit.
+func (h *Handler) serveStreamable(w http.ResponseWriter, r *http.Request) {
+	if !readLimitedMessage(w, r, h.maxMessage) {
+		h.logger.Warn().
+			Int64("limit_bytes", h.maxMessage).
+			Int64("content_length", r.ContentLength).
+			Msg("rejected oversized MCP message")
+		return
+	}
 	h.streamable.ServeHTTP(w, r)
 }
 
@@ -288,7 +492,16 @@ func sanitizeHost(host string) string {
 	return host
 }
 
-// withUserContext extracts user identity from Bearer token or vire_session cookie,
+// sessionCookieName returns the configured session cookie name,
+// defaulting to config.DefaultSessionCookieName.
+func (h *Handler) sessionCookieName() string {
+	if h.cookieName == "" {
+		return config.DefaultSessionCookieName
+	}
+	return h.cookieName
+}
+
+// withUserContext extracts user identity from Bearer token or the session cookie,
 // validates the JWT (signature + expiry), and attaches UserContext to the request context.
 // Bearer token takes priority (Claude CLI/Desktop), cookie is fallback (web dashboard).
 // If anything fails, the original request is returned unchanged.
@@ -304,7 +517,7 @@ func (h *Handler) withUserContext(r *http.Request) *http.Request {
 	}
 
 	// Fall back to cookie (web dashboard)
-	cookie, err := r.Cookie("vire_session")
+	cookie, err := r.Cookie(h.sessionCookieName())
 	if err != nil || cookie.Value == "" {
 		return r
 	}
@@ -44,6 +44,24 @@ func TestWithUserContext_ValidCookie(t *testing.T) {
 	}
 }
 
+func TestWithUserContext_ConfiguredCookieName(t *testing.T) {
+	jwt := buildTestJWT("user42")
+	h := &Handler{cookieName: "portal_sid"}
+
+	req := httptest.NewRequest("GET", "/mcp", nil)
+	req.AddCookie(&http.Cookie{Name: "portal_sid", Value: jwt})
+	uc, ok := GetUserContext(h.withUserContext(req).Context())
+	if !ok || uc.UserID != "user42" {
+		t.Errorf("expected UserID user42 from configured cookie, got ok=%v uc=%+v", ok, uc)
+	}
+
+	req = httptest.NewRequest("GET", "/mcp", nil)
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: jwt})
+	if _, ok := GetUserContext(h.withUserContext(req).Context()); ok {
+		t.Error("expected default cookie name to be ignored when a custom name is configured")
+	}
+}
+
 func TestWithUserContext_NoCookie(t *testing.T) {
 	req := httptest.NewRequest("GET", "/mcp", nil)
 
@@ -655,3 +673,59 @@ func TestRefreshCatalog_ConcurrentAccess(t *testing.T) {
 
 	wg.Wait()
 }
+
+// --- X-Vire-User-ID forwarding ---
+
+func TestServeHTTP_ForwardsUserIDOnlyWhenAuthenticated(t *testing.T) {
+	var mu sync.Mutex
+	seen := map[string][]string{} // path -> X-Vire-User-ID values
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen[r.URL.Path] = append(seen[r.URL.Path], r.Header.Get("X-Vire-User-ID"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/mcp/tools":
+			w.Write([]byte(`[{"name":"get_a","description":"A","method":"GET","path":"/api/a","params":[]}]`))
+		default:
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer upstream.Close()
+
+	cfg := testConfig()
+	cfg.API.URL = upstream.URL
+	cfg.MCP.CatalogRetries = 1
+	h := NewHandler(cfg, testLogger())
+	defer h.Close()
+
+	call := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_a","arguments":{}}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := call("Bearer " + buildTestJWT("alice")); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for authenticated call, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := call(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for anonymous call, got %d", w.Code)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := seen["/api/a"]; len(got) != 1 || got[0] != "alice" {
+		t.Errorf("expected one tool call forwarding X-Vire-User-ID alice, got %q", got)
+	}
+	for _, id := range seen["/api/mcp/tools"] {
+		if id != "" {
+			t.Errorf("expected no X-Vire-User-ID on the anonymous catalog fetch, got %q", id)
+		}
+	}
+}
@@ -17,23 +17,22 @@ func errorResult(message string) *mcp.CallToolResult {
 	}
 }
 
-// resolvePortfolio resolves the portfolio name from the request or the server default.
+// resolvePortfolio resolves the portfolio name from the request, the user's
+// selected portfolio, the configured default or the server default.
 func resolvePortfolio(ctx context.Context, p *MCPProxy, request mcp.CallToolRequest) string {
 	name := request.GetString("portfolio_name", "")
 	if name != "" {
 		return name
 	}
 
-	// Check if config has a default portfolio
-	headers := p.UserHeaders()
-	if portfolios := headers.Get("X-Vire-Portfolios"); portfolios != "" {
-		// Use the first configured portfolio as default
-		for i := 0; i < len(portfolios); i++ {
-			if portfolios[i] == ',' {
-				return portfolios[:i]
-			}
-		}
-		return portfolios
+	// Use the portfolio the user selected in the web UI
+	if preferred := p.preferredPortfolio(ctx); preferred != "" {
+		return preferred
+	}
+
+	// Use the first configured portfolio as default
+	if p.defaultPortfolio != "" {
+		return p.defaultPortfolio
 	}
 
 	// Ask the server for the default
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// historyDateLayout is the date format of timeline data points. Longer
+// timestamps (RFC 3339) are truncated to this length before parsing.
+const historyDateLayout = "2006-01-02"
+
+// downsampleToWeekly reduces a daily series to one point per ISO week.
+func downsampleToWeekly(points []json.RawMessage) []json.RawMessage {
+	return downsample(points, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	})
+}
+
+// downsampleToMonthly reduces a daily series to one point per calendar month.
+func downsampleToMonthly(points []json.RawMessage) []json.RawMessage {
+	return downsample(points, func(t time.Time) string {
+		return t.Format("2006-01")
+	})
+}
+
+// downsample keeps the last point of each period, where period maps a
+// point's date to its period key. Points must be in date order. The final
+// period is represented by its last point like any other, even when it is
+// incomplete, so the series' last point appears exactly once.
+// If any point lacks a parseable "date", points are returned unchanged.
+func downsample(points []json.RawMessage, period func(time.Time) string) []json.RawMessage {
+	if len(points) == 0 {
+		return points
+	}
+	keys := make([]string, len(points))
+	for i, raw := range points {
+		var p struct {
+			Date string `json:"date"`
+		}
+		if json.Unmarshal(raw, &p) != nil || len(p.Date) < len(historyDateLayout) {
+			return points
+		}
+		t, err := time.Parse(historyDateLayout, p.Date[:len(historyDateLayout)])
+		if err != nil {
+			return points
+		}
+		keys[i] = period(t)
+	}
+
+	out := make([]json.RawMessage, 0, len(points)/5+1)
+	for i := range points {
+		if i == len(points)-1 || keys[i+1] != keys[i] {
+			out = append(out, points[i])
+		}
+	}
+	return out
+}
+
+// PortfolioHistoryTool returns the mcp.Tool definition for portfolio_history.
+func PortfolioHistoryTool() mcp.Tool {
+	return mcp.NewTool("portfolio_history",
+		mcp.WithDescription("Get the daily value history (timeline) for a portfolio. Set downsample to weekly or monthly to return one point per period (the last day of each period) and reduce the row count."),
+		mcp.WithString("portfolio_name",
+			mcp.Description("Portfolio name. Defaults to the user's default portfolio."),
+		),
+		mcp.WithString("downsample",
+			mcp.Description("Optional period to downsample the daily series to: weekly or monthly."),
+			mcp.Enum("weekly", "monthly"),
+		),
+	)
+}
+
+// PortfolioHistoryToolHandler returns a handler that fetches the portfolio
+// timeline from vire-server and optionally downsamples its data points.
+func PortfolioHistoryToolHandler(proxy *MCPProxy) server.ToolHandlerFunc {
+	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mode := r.GetString("downsample", "")
+		var sampler func([]json.RawMessage) []json.RawMessage
+		switch mode {
+		case "":
+		case "weekly":
+			sampler = downsampleToWeekly
+		case "monthly":
+			sampler = downsampleToMonthly
+		default:
+			return errorResult(fmt.Sprintf("Error: %q is not a valid downsample. Choose weekly or monthly", mode)), nil
+		}
+
+		name := resolvePortfolio(ctx, proxy, r)
+		if name == "" {
+			return errorResult("Error: no portfolio specified and no default portfolio found"), nil
+		}
+
+		body, err := proxy.get(ctx, "/api/portfolios/"+url.PathEscape(name)+"/timeline")
+		if err != nil {
+			return errorResult(fmt.Sprintf("Error: failed to load history: %v", err)), nil
+		}
+		if sampler == nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{mcp.NewTextContent(string(body))},
+			}, nil
+		}
+
+		var timeline map[string]json.RawMessage
+		if err := json.Unmarshal(body, &timeline); err != nil {
+			return errorResult("Error: invalid history response"), nil
+		}
+		var points []json.RawMessage
+		if raw, ok := timeline["data_points"]; ok {
+			if err := json.Unmarshal(raw, &points); err != nil {
+				return errorResult("Error: invalid history data points"), nil
+			}
+		}
+		sampled, err := json.Marshal(sampler(points))
+		if err != nil {
+			return errorResult("failed to marshal history"), nil
+		}
+		timeline["data_points"] = sampled
+		timeline["downsample"], _ = json.Marshal(mode)
+
+		out, err := json.Marshal(timeline)
+		if err != nil {
+			return errorResult("failed to marshal history"), nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{mcp.NewTextContent(string(out))},
+		}, nil
+	}
+}
@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+// dailyPoints builds n consecutive daily timeline points starting at start.
+func dailyPoints(start time.Time, n int) []json.RawMessage {
+	points := make([]json.RawMessage, n)
+	for i := range points {
+		d := start.AddDate(0, 0, i).Format("2006-01-02")
+		points[i] = json.RawMessage(fmt.Sprintf(`{"date":%q,"portfolio_value":%d}`, d, 1000+i))
+	}
+	return points
+}
+
+func pointDate(t *testing.T, raw json.RawMessage) string {
+	t.Helper()
+	var p struct {
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(raw, &p); err != nil {
+		t.Fatalf("failed to unmarshal point: %v", err)
+	}
+	return p.Date
+}
+
+func TestDownsampleToWeekly_NinetyDays(t *testing.T) {
+	// Monday 2026-01-05 + 90 days: 12 full ISO weeks and a partial 13th.
+	points := dailyPoints(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 90)
+
+	weekly := downsampleToWeekly(points)
+
+	if len(weekly) != 13 {
+		t.Fatalf("expected 13 weekly points, got %d", len(weekly))
+	}
+	if got := pointDate(t, weekly[0]); got != "2026-01-11" {
+		t.Errorf("expected first week to close on Sunday 2026-01-11, got %s", got)
+	}
+	last := pointDate(t, points[len(points)-1])
+	count := 0
+	for _, p := range weekly {
+		if pointDate(t, p) == last {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected final point %s exactly once, got %d", last, count)
+	}
+}
+
+func TestDownsampleToMonthly_NinetyDays(t *testing.T) {
+	points := dailyPoints(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), 90)
+
+	monthly := downsampleToMonthly(points)
+
+	want := []string{"2026-01-31", "2026-02-28", "2026-03-31"}
+	if len(monthly) != len(want) {
+		t.Fatalf("expected %d monthly points, got %d", len(want), len(monthly))
+	}
+	for i, w := range want {
+		if got := pointDate(t, monthly[i]); got != w {
+			t.Errorf("point %d: expected %s, got %s", i, w, got)
+		}
+	}
+}
+
+func TestDownsample_UnparseableDatesUnchanged(t *testing.T) {
+	points := []json.RawMessage{
+		json.RawMessage(`{"date":"2026-01-01"}`),
+		json.RawMessage(`{"date":"not-a-date"}`),
+	}
+	if got := downsampleToWeekly(points); len(got) != 2 {
+		t.Errorf("expected series returned unchanged, got %d points", len(got))
+	}
+}
+
+func TestPortfolioHistoryToolHandler_Downsamples(t *testing.T) {
+	points := dailyPoints(time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), 90)
+	timeline, _ := json.Marshal(map[string]interface{}{"data_points": points, "currency": "AUD"})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/portfolios/Growth/timeline" {
+			w.Write(timeline)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"portfolio_name": "Growth", "downsample": "weekly"}
+
+	result, err := PortfolioHistoryToolHandler(NewMCPProxy(srv.URL, testLogger(), testConfig()))(t.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %v", result.Content)
+	}
+
+	var resp struct {
+		DataPoints []json.RawMessage `json:"data_points"`
+		Currency   string            `json:"currency"`
+		Downsample string            `json:"downsample"`
+	}
+	text := result.Content[0].(mcpgo.TextContent).Text
+	if err := json.Unmarshal([]byte(text), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.DataPoints) != 13 {
+		t.Errorf("expected 13 weekly points, got %d", len(resp.DataPoints))
+	}
+	if resp.Currency != "AUD" {
+		t.Errorf("expected other timeline fields kept, got currency %q", resp.Currency)
+	}
+	if resp.Downsample != "weekly" {
+		t.Errorf("expected downsample weekly, got %q", resp.Downsample)
+	}
+}
+
+func TestPortfolioHistoryToolHandler_RejectsUnknownDownsample(t *testing.T) {
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"portfolio_name": "Growth", "downsample": "hourly"}
+
+	result, err := PortfolioHistoryToolHandler(NewMCPProxy(mockAPIServer.URL, testLogger(), testConfig()))(t.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error result for unknown downsample")
+	}
+}
@@ -1,19 +1,24 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/client"
 	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 	mcpgo "github.com/mark3labs/mcp-go/mcp"
@@ -520,6 +525,52 @@ func TestValidateCatalogTool_PathTraversal(t *testing.T) {
 	}
 }
 
+func TestValidateCatalogTool_TimeoutSeconds(t *testing.T) {
+	for _, secs := range []int{0, 3, MaxToolTimeoutSeconds} {
+		ct := CatalogTool{Name: "test", Method: "GET", Path: "/api/test", TimeoutSeconds: secs}
+		if err := ValidateCatalogTool(ct); err != nil {
+			t.Errorf("timeout_seconds %d: unexpected error: %v", secs, err)
+		}
+	}
+	for _, secs := range []int{-1, MaxToolTimeoutSeconds + 1} {
+		ct := CatalogTool{Name: "test", Method: "GET", Path: "/api/test", TimeoutSeconds: secs}
+		if err := ValidateCatalogTool(ct); err == nil {
+			t.Errorf("timeout_seconds %d: expected error", secs)
+		}
+	}
+}
+
+func TestValidateCatalogTool_InvalidParamPattern(t *testing.T) {
+	ct := CatalogTool{Name: "test", Method: "GET", Path: "/api/test", Params: []CatalogParam{
+		{Name: "ticker", Type: "string", In: "query", Pattern: "[A-Z"},
+	}}
+	if err := ValidateCatalogTool(ct); err == nil {
+		t.Error("expected error for invalid param pattern")
+	}
+}
+
+func TestValidateCatalog_CompilesParamPatterns(t *testing.T) {
+	catalog := []CatalogTool{
+		{Name: "bad", Method: "GET", Path: "/api/bad", Params: []CatalogParam{
+			{Name: "ticker", Type: "string", In: "query", Pattern: "[A-Z"},
+		}},
+		{Name: "good", Method: "GET", Path: "/api/good", Params: []CatalogParam{
+			{Name: "ticker", Type: "string", In: "query", Pattern: `^[A-Z]+$`},
+		}},
+	}
+	valid, rejected := ValidateCatalog(catalog, testLogger())
+
+	if len(rejected) != 1 || rejected[0].Name != "bad" || !strings.Contains(rejected[0].Reason, "invalid pattern") {
+		t.Fatalf("expected bad tool rejected for its pattern, got %+v", rejected)
+	}
+	if len(valid) != 1 || valid[0].Params[0].pattern == nil {
+		t.Fatalf("expected good tool with a compiled pattern, got %+v", valid)
+	}
+	if catalog[1].Params[0].pattern != nil {
+		t.Error("expected the input catalog to be left untouched")
+	}
+}
+
 func TestValidateCatalogTool_AllValidMethods(t *testing.T) {
 	for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
 		ct := CatalogTool{Name: "test_" + method, Method: method, Path: "/api/test"}
@@ -536,10 +587,40 @@ func TestValidateCatalog_FiltersDuplicates(t *testing.T) {
 		{Name: "tool_a", Method: "POST", Path: "/api/a2"}, // duplicate name
 	}
 
-	valid := ValidateCatalog(catalog, testLogger())
+	valid, rejected := ValidateCatalog(catalog, testLogger())
 	if len(valid) != 2 {
 		t.Errorf("expected 2 valid tools (1 duplicate removed), got %d", len(valid))
 	}
+	if len(rejected) != 1 || rejected[0].Name != "tool_a" || rejected[0].Reason != `duplicate tool name "tool_a"` {
+		t.Errorf("expected the second tool_a rejected as a duplicate, got %+v", rejected)
+	}
+}
+
+func TestValidateCatalog_FiltersDuplicateRoutes(t *testing.T) {
+	catalog := []CatalogTool{
+		{Name: "get_version", Method: "GET", Path: "/api/version"},
+		{Name: "server_version", Method: "get", Path: "/api/version"}, // same route, different name
+		{Name: "set_version", Method: "POST", Path: "/api/version"},   // same path, different method
+		{Name: "get_version", Method: "GET", Path: "/api/v2/version"}, // duplicate name
+	}
+
+	var buf bytes.Buffer
+	valid, rejected := ValidateCatalog(catalog, common.NewDedicatedLoggerWithOutput("warn", &buf))
+	if len(valid) != 2 || valid[0].Name != "get_version" || valid[1].Name != "set_version" {
+		t.Fatalf("expected get_version and set_version to survive, got %+v", valid)
+	}
+	if len(rejected) != 2 {
+		t.Fatalf("expected 2 rejections, got %+v", rejected)
+	}
+	if r := rejected[0]; r.Name != "server_version" || r.Reason != `duplicate route GET /api/version (already served by "get_version")` {
+		t.Errorf("expected server_version rejected as a duplicate route, got %+v", r)
+	}
+	if r := rejected[1]; r.Name != "get_version" || !strings.Contains(r.Reason, "duplicate tool name") {
+		t.Errorf("expected second get_version rejected as a duplicate name, got %+v", r)
+	}
+	if !strings.Contains(buf.String(), "skipping catalog tool with duplicate method and path") {
+		t.Errorf("expected duplicate route to be logged, got: %s", buf.String())
+	}
 }
 
 func TestValidateCatalog_FiltersInvalid(t *testing.T) {
@@ -549,19 +630,120 @@ func TestValidateCatalog_FiltersInvalid(t *testing.T) {
 		{Name: "bad_path", Method: "GET", Path: "/evil/path"}, // no /api/ prefix
 	}
 
-	valid := ValidateCatalog(catalog, testLogger())
+	valid, rejected := ValidateCatalog(catalog, testLogger())
 	if len(valid) != 1 {
 		t.Errorf("expected 1 valid tool, got %d", len(valid))
 	}
 	if valid[0].Name != "good_tool" {
 		t.Errorf("expected surviving tool to be good_tool, got %q", valid[0].Name)
 	}
+	if len(rejected) != 2 {
+		t.Fatalf("expected 2 rejected tools, got %+v", rejected)
+	}
+	if rejected[0].Name != "" || !strings.Contains(rejected[0].Reason, "empty name") {
+		t.Errorf("expected empty-name rejection, got %+v", rejected[0])
+	}
+	if rejected[1].Name != "bad_path" || !strings.Contains(rejected[1].Reason, "must start with /api/") {
+		t.Errorf("expected bad_path rejection, got %+v", rejected[1])
+	}
+}
+
+func TestValidateCatalogTool_PathPlaceholderMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		ct   CatalogTool
+	}{
+		{"placeholder without param", CatalogTool{Name: "t", Method: "GET", Path: "/api/items/{item_id}"}},
+		{"placeholder with query param", CatalogTool{Name: "t", Method: "GET", Path: "/api/items/{item_id}",
+			Params: []CatalogParam{{Name: "item_id", Type: "string", In: "query"}}}},
+		{"path param without placeholder", CatalogTool{Name: "t", Method: "GET", Path: "/api/items",
+			Params: []CatalogParam{{Name: "item_id", Type: "string", In: "path"}}}},
+		{"empty placeholder", CatalogTool{Name: "t", Method: "GET", Path: "/api/items/{}"}},
+		{"unbalanced brace", CatalogTool{Name: "t", Method: "GET", Path: "/api/items/{item_id",
+			Params: []CatalogParam{{Name: "item_id", Type: "string", In: "path"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCatalogTool(tt.ct); err == nil {
+				t.Error("expected error for path placeholder mismatch")
+			}
+		})
+	}
+
+	ok := CatalogTool{Name: "t", Method: "GET", Path: "/api/portfolios/{portfolio_name}/items/{item_id}",
+		Params: []CatalogParam{
+			{Name: "portfolio_name", Type: "string", In: "path"},
+			{Name: "item_id", Type: "string", In: "path"},
+			{Name: "limit", Type: "number", In: "query"},
+		}}
+	if err := ValidateCatalogTool(ok); err != nil {
+		t.Errorf("expected matching placeholders to validate, got %v", err)
+	}
+}
+
+func TestValidateCatalog_FiltersPathPlaceholderMismatch(t *testing.T) {
+	catalog := []CatalogTool{
+		{Name: "get_item", Method: "GET", Path: "/api/items/{item_id}"},
+		{Name: "list_items", Method: "GET", Path: "/api/items"},
+	}
+
+	var buf bytes.Buffer
+	valid, rejected := ValidateCatalog(catalog, common.NewDedicatedLoggerWithOutput("warn", &buf))
+	if len(valid) != 1 || valid[0].Name != "list_items" {
+		t.Fatalf("expected only list_items to survive, got %+v", valid)
+	}
+	if len(rejected) != 1 || rejected[0].Name != "get_item" || !strings.Contains(rejected[0].Reason, "{item_id}") {
+		t.Errorf("expected get_item rejected naming {item_id}, got %+v", rejected)
+	}
+	logs := buf.String()
+	if !strings.Contains(logs, "skipping invalid catalog tool") || !strings.Contains(logs, "{item_id}") {
+		t.Errorf("expected logged reason naming {item_id}, got: %s", logs)
+	}
 }
 
 func TestValidateCatalog_EmptyInput(t *testing.T) {
-	valid := ValidateCatalog([]CatalogTool{}, testLogger())
-	if len(valid) != 0 {
-		t.Errorf("expected 0 valid tools from empty input, got %d", len(valid))
+	valid, rejected := ValidateCatalog([]CatalogTool{}, testLogger())
+	if len(valid) != 0 || len(rejected) != 0 {
+		t.Errorf("expected no tools from empty input, got %d valid and %d rejected", len(valid), len(rejected))
+	}
+}
+
+func TestCatalogHash_StableAndOrderIndependent(t *testing.T) {
+	a := []CatalogTool{
+		{Name: "tool_a", Method: "GET", Path: "/api/a"},
+		{Name: "tool_b", Method: "POST", Path: "/api/b"},
+	}
+	b := []CatalogTool{a[1], a[0]}
+
+	hash := CatalogHash(a)
+	if len(hash) != 64 {
+		t.Fatalf("expected 64-char hex hash, got %q", hash)
+	}
+	if CatalogHash(a) != hash {
+		t.Error("expected hash to be stable across calls")
+	}
+	if CatalogHash(b) != hash {
+		t.Error("expected hash to ignore catalog order")
+	}
+	if a[0].Name != "tool_a" {
+		t.Error("expected CatalogHash not to reorder its input")
+	}
+}
+
+func TestCatalogHash_ChangesWithCatalog(t *testing.T) {
+	base := []CatalogTool{{Name: "tool_a", Method: "GET", Path: "/api/a"}}
+	hash := CatalogHash(base)
+
+	changed := []CatalogTool{{Name: "tool_a", Method: "GET", Path: "/api/a", Description: "new"}}
+	if CatalogHash(changed) == hash {
+		t.Error("expected hash to change when a tool definition changes")
+	}
+	added := append([]CatalogTool{}, base[0], CatalogTool{Name: "tool_b", Method: "GET", Path: "/api/b"})
+	if CatalogHash(added) == hash {
+		t.Error("expected hash to change when a tool is added")
+	}
+	if CatalogHash(nil) != "" {
+		t.Error("expected empty hash for empty catalog")
 	}
 }
 
@@ -732,6 +914,50 @@ func TestBuildMCPTool_NoParams(t *testing.T) {
 	}
 }
 
+func TestBuildMCPTool_DeprecatedNotice(t *testing.T) {
+	ct := CatalogTool{
+		Name:              "get_portfolio_v1",
+		Description:       "Get a portfolio.",
+		Method:            "GET",
+		Path:              "/api/portfolios/{name}",
+		Deprecated:        true,
+		DeprecatedMessage: "use get_portfolio instead.",
+	}
+
+	tool := BuildMCPTool(ct)
+
+	want := "DEPRECATED: use get_portfolio instead. Get a portfolio."
+	if tool.Description != want {
+		t.Errorf("expected description %q, got %q", want, tool.Description)
+	}
+
+	ct.DeprecatedMessage = ""
+	if got := BuildMCPTool(ct).Description; !strings.HasPrefix(got, "DEPRECATED: ") || !strings.HasSuffix(got, "Get a portfolio.") {
+		t.Errorf("expected default deprecation notice, got %q", got)
+	}
+}
+
+func TestValidateCatalog_KeepsDeprecated(t *testing.T) {
+	var catalog []CatalogTool
+	if err := json.Unmarshal([]byte(`[
+		{"name":"old_tool","description":"Old","method":"GET","path":"/api/old","deprecated":true,"deprecated_message":"use new_tool"},
+		{"name":"new_tool","description":"New","method":"GET","path":"/api/new"}
+	]`), &catalog); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	valid, rejected := ValidateCatalog(catalog, testLogger())
+	if len(valid) != 2 || len(rejected) != 0 {
+		t.Fatalf("expected deprecated tool kept, got %d tools and %+v rejected", len(valid), rejected)
+	}
+	if !valid[0].Deprecated || valid[0].DeprecatedMessage != "use new_tool" {
+		t.Errorf("expected deprecation fields parsed, got %+v", valid[0])
+	}
+	if valid[1].Deprecated {
+		t.Error("expected new_tool not deprecated")
+	}
+}
+
 func TestBuildMCPTool_StringParam(t *testing.T) {
 	ct := CatalogTool{
 		Name:        "get_quote",
@@ -832,6 +1058,63 @@ func TestBuildMCPTool_ArrayParam(t *testing.T) {
 	}
 }
 
+func TestBuildMCPTool_EnumParam(t *testing.T) {
+	ct := CatalogTool{
+		Name:   "get_history",
+		Method: "GET",
+		Path:   "/api/history",
+		Params: []CatalogParam{
+			{Name: "granularity", Type: "string", In: "query", Enum: []string{"daily", "weekly", "monthly"}},
+		},
+	}
+
+	tool := BuildMCPTool(ct)
+
+	propMap, ok := tool.InputSchema.Properties["granularity"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map for granularity property, got %T", tool.InputSchema.Properties["granularity"])
+	}
+	enum, ok := propMap["enum"].([]string)
+	if !ok {
+		t.Fatalf("expected []string enum, got %T", propMap["enum"])
+	}
+	if strings.Join(enum, ",") != "daily,weekly,monthly" {
+		t.Errorf("expected enum daily,weekly,monthly, got %v", enum)
+	}
+
+	data, err := json.Marshal(tool.InputSchema)
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+	if !strings.Contains(string(data), `"enum":["daily","weekly","monthly"]`) {
+		t.Errorf("expected enum in JSON schema, got %s", data)
+	}
+}
+
+func TestBuildMCPTool_BoundsAndPattern(t *testing.T) {
+	minLimit, maxLimit := 1.0, 100.0
+	ct := CatalogTool{
+		Name:   "screen_stocks",
+		Method: "GET",
+		Path:   "/api/screen",
+		Params: []CatalogParam{
+			{Name: "limit", Type: "number", In: "query", Minimum: &minLimit, Maximum: &maxLimit},
+			{Name: "ticker", Type: "string", In: "query", Pattern: `^[A-Z0-9]+\.[A-Z]{2,3}$`},
+		},
+	}
+
+	tool := BuildMCPTool(ct)
+
+	limitProp := tool.InputSchema.Properties["limit"].(map[string]interface{})
+	if limitProp["minimum"] != 1.0 || limitProp["maximum"] != 100.0 {
+		t.Errorf("expected minimum 1 and maximum 100, got %v and %v", limitProp["minimum"], limitProp["maximum"])
+	}
+	tickerProp := tool.InputSchema.Properties["ticker"].(map[string]interface{})
+	if tickerProp["pattern"] != `^[A-Z0-9]+\.[A-Z]{2,3}$` {
+		t.Errorf("expected ticker pattern, got %v", tickerProp["pattern"])
+	}
+}
+
 func TestBuildMCPTool_BooleanParam(t *testing.T) {
 	ct := CatalogTool{
 		Name:        "test_tool",
@@ -1234,6 +1517,59 @@ func TestGenericHandler_GET_MultipleQueryParams(t *testing.T) {
 	}
 }
 
+func TestGenericHandler_GET_ArrayQueryParam(t *testing.T) {
+	var received url.Values
+	var receivedRaw string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query()
+		receivedRaw = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"quotes":[]}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{
+		Name:   "get_quotes",
+		Method: "GET",
+		Path:   "/api/market/quotes",
+		Params: []CatalogParam{
+			{Name: "tickers", Type: "array", In: "query"},
+			{Name: "fields", Type: "array", In: "query", ArrayFormat: "comma"},
+		},
+	}
+
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	result := callTool(t, s, "get_quotes", map[string]interface{}{
+		"tickers": []interface{}{"BHP.AU", "CBA.AU"},
+		"fields":  []interface{}{"price", "volume"},
+	})
+
+	if result.IsError {
+		t.Fatalf("expected non-error result, got: %s", extractText(t, result.Content[0]))
+	}
+	if got := received["tickers"]; len(got) != 2 || got[0] != "BHP.AU" || got[1] != "CBA.AU" {
+		t.Errorf("expected repeated tickers keys [BHP.AU CBA.AU], got %v (raw %q)", got, receivedRaw)
+	}
+	if !strings.Contains(receivedRaw, "tickers=BHP.AU&tickers=CBA.AU") {
+		t.Errorf("expected repeated-key encoding, got %q", receivedRaw)
+	}
+	if got := received.Get("fields"); got != "price,volume" {
+		t.Errorf("expected comma-joined fields, got %q", got)
+	}
+}
+
+func TestValidateCatalogTool_InvalidArrayFormat(t *testing.T) {
+	ct := CatalogTool{Name: "test", Method: "GET", Path: "/api/test", Params: []CatalogParam{
+		{Name: "tickers", Type: "array", In: "query", ArrayFormat: "pipes"},
+	}}
+	if err := ValidateCatalogTool(ct); err == nil {
+		t.Error("expected error for unsupported array_format")
+	}
+}
+
 func TestGenericHandler_POST_BodyParams(t *testing.T) {
 	var receivedMethod string
 	var receivedPath string
@@ -1428,6 +1764,150 @@ func TestGenericHandler_MissingRequiredPathParam(t *testing.T) {
 	}
 }
 
+func TestGenericHandler_RejectsValueOutsideEnum(t *testing.T) {
+	called := false
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{
+		Name:   "get_history",
+		Method: "GET",
+		Path:   "/api/history",
+		Params: []CatalogParam{
+			{Name: "granularity", Type: "string", In: "query", Enum: []string{"daily", "weekly", "monthly"}},
+		},
+	}
+
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	result := callTool(t, s, "get_history", map[string]interface{}{"granularity": "hourly"})
+
+	if !result.IsError {
+		t.Fatal("expected error result for out-of-enum value")
+	}
+	if called {
+		t.Error("expected request not to be proxied")
+	}
+	text := extractText(t, result.Content[0])
+	if !strings.Contains(text, `"hourly"`) || !strings.Contains(text, "daily, weekly, monthly") {
+		t.Errorf("expected error to name value and list valid values, got: %s", text)
+	}
+
+	result = callTool(t, s, "get_history", map[string]interface{}{"granularity": "weekly"})
+	if result.IsError {
+		t.Errorf("expected valid enum value to succeed, got: %s", extractText(t, result.Content[0]))
+	}
+	if !called {
+		t.Error("expected valid enum value to be proxied")
+	}
+}
+
+func TestGenericHandler_RejectsLimitOutOfRange(t *testing.T) {
+	called := false
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer mockServer.Close()
+
+	minLimit, maxLimit := 1.0, 100.0
+	ct := CatalogTool{
+		Name:   "screen_stocks",
+		Method: "GET",
+		Path:   "/api/screen",
+		Params: []CatalogParam{
+			{Name: "limit", Type: "number", In: "query", Minimum: &minLimit, Maximum: &maxLimit},
+		},
+	}
+
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	result := callTool(t, s, "screen_stocks", map[string]interface{}{"limit": 500})
+	if !result.IsError {
+		t.Fatal("expected error result for limit above maximum")
+	}
+	if text := extractText(t, result.Content[0]); !strings.Contains(text, "invalid limit 500; must be at most 100") {
+		t.Errorf("expected descriptive maximum error, got: %s", text)
+	}
+
+	result = callTool(t, s, "screen_stocks", map[string]interface{}{"limit": 0})
+	if !result.IsError {
+		t.Fatal("expected error result for limit below minimum")
+	}
+	if text := extractText(t, result.Content[0]); !strings.Contains(text, "must be at least 1") {
+		t.Errorf("expected descriptive minimum error, got: %s", text)
+	}
+	if called {
+		t.Error("expected out-of-range calls not to be proxied")
+	}
+
+	// Numbers passed as strings are bounded too
+	result = callTool(t, s, "screen_stocks", map[string]interface{}{"limit": "500"})
+	if text := extractText(t, result.Content[0]); !result.IsError || !strings.Contains(text, "must be at most 100") {
+		t.Errorf("expected string limit above maximum rejected, got: %s", text)
+	}
+	result = callTool(t, s, "screen_stocks", map[string]interface{}{"limit": "lots"})
+	if text := extractText(t, result.Content[0]); !result.IsError || !strings.Contains(text, `invalid limit "lots"; must be a number`) {
+		t.Errorf("expected non-numeric limit rejected, got: %s", text)
+	}
+	if called {
+		t.Error("expected out-of-range calls not to be proxied")
+	}
+
+	result = callTool(t, s, "screen_stocks", map[string]interface{}{"limit": 25})
+	if result.IsError || !called {
+		t.Error("expected in-range limit to be proxied")
+	}
+}
+
+func TestGenericHandler_RejectsTickerFailingPattern(t *testing.T) {
+	called := false
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{
+		Name:   "get_quote",
+		Method: "GET",
+		Path:   "/api/market/quote/{ticker}",
+		Params: []CatalogParam{
+			{Name: "ticker", Type: "string", Required: true, In: "path", Pattern: `^[A-Z0-9]+\.[A-Z]{2,3}$`},
+		},
+	}
+
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	result := callTool(t, s, "get_quote", map[string]interface{}{"ticker": "bhp au"})
+	if !result.IsError {
+		t.Fatal("expected error result for ticker failing pattern")
+	}
+	if called {
+		t.Error("expected invalid ticker not to be proxied")
+	}
+	if text := extractText(t, result.Content[0]); !strings.Contains(text, `invalid ticker "bhp au"; must match pattern`) {
+		t.Errorf("expected descriptive pattern error, got: %s", text)
+	}
+
+	result = callTool(t, s, "get_quote", map[string]interface{}{"ticker": "BHP.AU"})
+	if result.IsError || !called {
+		t.Error("expected valid ticker to be proxied")
+	}
+}
+
 func TestGenericHandler_ServerError(t *testing.T) {
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -1531,33 +2011,224 @@ func TestGenericHandler_DefaultFrom_ExplicitOverridesDefault(t *testing.T) {
 	}
 }
 
-func TestGenericHandler_DefaultFrom_NoConfig(t *testing.T) {
+func TestGenericHandler_LiteralDefault(t *testing.T) {
+	var receivedQuery string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer mockServer.Close()
+
 	ct := CatalogTool{
-		Name:   "get_portfolio",
+		Name:   "get_history",
 		Method: "GET",
-		Path:   "/api/portfolios/{portfolio_name}",
+		Path:   "/api/history",
 		Params: []CatalogParam{
-			{Name: "portfolio_name", Type: "string", Required: false, In: "path",
-				DefaultFrom: "user_config.default_portfolio"},
+			{Name: "granularity", Type: "string", In: "query", Default: "monthly"},
+			{Name: "limit", Type: "number", In: "query", Default: 25.0},
 		},
 	}
 
-	cfg := config.NewDefaultConfig()
-	// No portfolios configured, no server to fall back to
+	tool := BuildMCPTool(ct)
+	if prop := tool.InputSchema.Properties["granularity"].(map[string]interface{}); prop["default"] != "monthly" {
+		t.Errorf("expected schema default 'monthly', got %v", prop["default"])
+	}
+	if prop := tool.InputSchema.Properties["limit"].(map[string]interface{}); prop["default"] != 25.0 {
+		t.Errorf("expected schema default 25, got %v", prop["default"])
+	}
 
 	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
-	p := NewMCPProxy("http://127.0.0.1:1", testLogger(), cfg)
-	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
-
-	// No portfolio_name, no default — should get error (unreplaced path param)
-	result := callTool(t, s, "get_portfolio", map[string]interface{}{})
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(tool, GenericToolHandler(p, ct))
 
-	// The handler should return an error or handle gracefully
-	// Either way, it should not panic
-	if result == nil {
-		t.Fatal("expected non-nil result")
+	// Omitted params send their defaults
+	result := callTool(t, s, "get_history", map[string]interface{}{})
+	if result.IsError {
+		t.Fatalf("expected non-error result, got: %s", extractText(t, result.Content[0]))
+	}
+	if receivedQuery != "granularity=monthly&limit=25" {
+		t.Errorf("expected defaults in query, got %q", receivedQuery)
 	}
-}
+
+	// Explicit arguments override defaults
+	result = callTool(t, s, "get_history", map[string]interface{}{"granularity": "daily", "limit": 5})
+	if result.IsError {
+		t.Fatalf("expected non-error result, got: %s", extractText(t, result.Content[0]))
+	}
+	if receivedQuery != "granularity=daily&limit=5" {
+		t.Errorf("expected explicit values in query, got %q", receivedQuery)
+	}
+}
+
+func TestGenericHandler_CacheTTL(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"call":%d,"user":%q}`, n, r.Header.Get("X-Vire-User-ID"))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{
+		Name:            "get_quote",
+		Method:          "GET",
+		Path:            "/api/market/quote/{ticker}",
+		CacheTTLSeconds: 1,
+		Params: []CatalogParam{
+			{Name: "ticker", Type: "string", Required: true, In: "path"},
+		},
+	}
+	handler := GenericToolHandler(NewMCPProxy(mockServer.URL, testLogger(), testConfig()), ct)
+
+	call := func(userID, ticker string) string {
+		t.Helper()
+		var req mcpgo.CallToolRequest
+		req.Params.Name = ct.Name
+		req.Params.Arguments = map[string]interface{}{"ticker": ticker}
+		ctx := WithUserContext(context.Background(), UserContext{UserID: userID})
+		result, err := handler(ctx, req)
+		if err != nil || result.IsError {
+			t.Fatalf("unexpected error: %v %+v", err, result)
+		}
+		return extractText(t, result.Content[0])
+	}
+
+	first := call("alice", "BHP.AU")
+	if got := call("alice", "BHP.AU"); got != first {
+		t.Errorf("expected cache hit within TTL, got %s then %s", first, got)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 upstream call within TTL, got %d", calls)
+	}
+
+	// A different user or a different path is a separate entry
+	if got := call("bob", "BHP.AU"); !strings.Contains(got, `"user":"bob"`) {
+		t.Errorf("expected bob's own response, got %s", got)
+	}
+	call("alice", "CBA.AU")
+	if calls != 3 {
+		t.Errorf("expected 3 upstream calls, got %d", calls)
+	}
+
+	// After the TTL the entry expires and vire-server is called again
+	time.Sleep(1100 * time.Millisecond)
+	if got := call("alice", "BHP.AU"); got == first {
+		t.Errorf("expected cache miss after TTL, got cached %s", got)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 upstream calls after expiry, got %d", calls)
+	}
+}
+
+func TestGenericHandler_CacheKeyedByTimezone(t *testing.T) {
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Vire-Timezone"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"timezone":%q}`, r.Header.Get("X-Vire-Timezone"))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{Name: "get_report", Method: "GET", Path: "/api/report", CacheTTLSeconds: 60}
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	handler := GenericToolHandler(p, ct)
+
+	call := func() string {
+		t.Helper()
+		var req mcpgo.CallToolRequest
+		req.Params.Name = ct.Name
+		result, err := handler(WithUserContext(context.Background(), UserContext{UserID: "alice"}), req)
+		if err != nil || result.IsError {
+			t.Fatalf("unexpected error: %v %+v", err, result)
+		}
+		return extractText(t, result.Content[0])
+	}
+
+	call()
+	p.preferences.SetTimezone("alice", "America/New_York")
+	if got := call(); !strings.Contains(got, "America/New_York") {
+		t.Errorf("expected a response rendered for the new timezone, got %s", got)
+	}
+	call()
+	if len(received) != 2 {
+		t.Errorf("expected 2 upstream calls (one per timezone), got %d: %q", len(received), received)
+	}
+}
+
+func TestGenericHandler_TimeoutSeconds(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer mockServer.Close()
+
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	p.callTimeout = 100 * time.Millisecond
+
+	call := func(ct CatalogTool) *mcpgo.CallToolResult {
+		t.Helper()
+		var req mcpgo.CallToolRequest
+		req.Params.Name = ct.Name
+		result, err := GenericToolHandler(p, ct)(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	// Same upstream latency: the tool with its own generous timeout succeeds
+	slow := call(CatalogTool{Name: "funnel_screen", Method: "GET", Path: "/api/screen", TimeoutSeconds: 1})
+	if slow.IsError {
+		t.Errorf("expected tool with timeout_seconds to succeed, got %s", extractText(t, slow.Content[0]))
+	}
+
+	// ...while a tool on the default timeout fails
+	fast := call(CatalogTool{Name: "get_version", Method: "GET", Path: "/api/version"})
+	if !fast.IsError {
+		t.Fatal("expected default-timeout tool to fail")
+	}
+	if text := extractText(t, fast.Content[0]); !strings.Contains(text, "get_version timed out after 100ms") {
+		t.Errorf("expected timeout error, got %s", text)
+	}
+}
+
+func TestGenericHandler_DefaultFrom_NoConfig(t *testing.T) {
+	ct := CatalogTool{
+		Name:   "get_portfolio",
+		Method: "GET",
+		Path:   "/api/portfolios/{portfolio_name}",
+		Params: []CatalogParam{
+			{Name: "portfolio_name", Type: "string", Required: false, In: "path",
+				DefaultFrom: "user_config.default_portfolio"},
+		},
+	}
+
+	cfg := config.NewDefaultConfig()
+	// No portfolios configured, no server to fall back to
+
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy("http://127.0.0.1:1", testLogger(), cfg)
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	// No portfolio_name, no default — should get error (unreplaced path param)
+	result := callTool(t, s, "get_portfolio", map[string]interface{}{})
+
+	// The handler should return an error or handle gracefully
+	// Either way, it should not panic
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+}
 
 func TestGenericHandler_DefaultFrom_APIFallback(t *testing.T) {
 	// Mock server: returns default portfolio via API, handles the resulting tool call
@@ -1618,6 +2289,60 @@ func TestNewHandler_CatalogUnavailable(t *testing.T) {
 	}
 }
 
+// initializeInstructions sends an MCP initialize request through h and
+// returns the instructions from the result.
+func initializeInstructions(t *testing.T, h *Handler) string {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0.0"}}}`,
+	))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+buildTestJWT("test-user"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for initialize, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Result struct {
+			Instructions string `json:"instructions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal initialize response: %v (%s)", err, rec.Body.String())
+	}
+	return resp.Result.Instructions
+}
+
+func TestNewHandler_CatalogUnavailable_InitializeInstructions(t *testing.T) {
+	cfg := testConfig() // mock server returns 503
+	h := NewHandler(cfg, testLogger())
+	defer h.Close()
+
+	got := initializeInstructions(t, h)
+	if got != catalogUnavailableInstructions {
+		t.Errorf("expected catalog unavailable instructions, got %q", got)
+	}
+}
+
+func TestNewHandler_CatalogLoaded_NoInstructions(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"name":"list_portfolios","description":"List","method":"GET","path":"/api/portfolios","params":[]}]`))
+	}))
+	defer mockServer.Close()
+
+	cfg := testConfig()
+	cfg.API.URL = mockServer.URL
+	h := NewHandler(cfg, testLogger())
+	defer h.Close()
+
+	if got := initializeInstructions(t, h); got != "" {
+		t.Errorf("expected no instructions with a loaded catalog, got %q", got)
+	}
+}
+
 func TestNewHandler_CatalogRetry_SucceedsOnSecondAttempt(t *testing.T) {
 	var attempts int
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1798,6 +2523,25 @@ func TestProxy_ForwardsUserContextHeaders(t *testing.T) {
 	}
 }
 
+func TestProxy_ForwardsRequestID(t *testing.T) {
+	var received string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("X-Request-ID")
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer mockServer.Close()
+
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+
+	ctx := common.WithRequestID(t.Context(), "req-123")
+	if _, err := p.get(ctx, "/api/version"); err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	if received != "req-123" {
+		t.Errorf("expected X-Request-ID req-123 forwarded, got %q", received)
+	}
+}
+
 func TestProxy_NoUserContextHeaders_WhenMissing(t *testing.T) {
 	var receivedHeaders http.Header
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -2155,6 +2899,33 @@ func TestNewMCPProxy_UserHeaders_DisplayCurrency(t *testing.T) {
 	}
 }
 
+func TestMCPProxy_TimezoneHeader(t *testing.T) {
+	var received []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("X-Vire-Timezone"))
+		w.Write([]byte(`{}`))
+	}))
+	defer mockServer.Close()
+
+	cfg := testConfig()
+	cfg.User.Timezone = "America/New_York"
+	p := NewMCPProxy(mockServer.URL, testLogger(), cfg)
+	p.preferences.SetTimezone("user-2", "Australia/Perth")
+
+	p.get(t.Context(), "/api/version")
+	p.get(WithUserContext(t.Context(), UserContext{UserID: "user-1"}), "/api/version")
+	p.get(WithUserContext(t.Context(), UserContext{UserID: "user-2"}), "/api/version")
+
+	want := []string{"America/New_York", "America/New_York", "Australia/Perth"}
+	if strings.Join(received, ",") != strings.Join(want, ",") {
+		t.Errorf("expected X-Vire-Timezone %v, got %v", want, received)
+	}
+
+	if got := NewMCPProxy(mockServer.URL, testLogger(), config.NewDefaultConfig()).UserHeaders().Get("X-Vire-Timezone"); got != config.DefaultTimezone {
+		t.Errorf("expected default X-Vire-Timezone %q, got %q", config.DefaultTimezone, got)
+	}
+}
+
 func TestNewMCPProxy_UserHeaders_EmptyConfig(t *testing.T) {
 	cfg := config.NewDefaultConfig()
 
@@ -2285,6 +3056,150 @@ func TestMCPProxy_Get_ErrorResponse(t *testing.T) {
 	}
 }
 
+func TestMCPProxy_Get_RateLimited(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"too many requests"}`))
+	}))
+	defer mockServer.Close()
+
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+
+	_, err := p.get(t.Context(), "/api/mcp/tools")
+	var rl *RateLimitError
+	if !errors.As(err, &rl) {
+		t.Fatalf("expected *RateLimitError, got %v", err)
+	}
+	if rl.RetryAfter != 2*time.Second {
+		t.Errorf("expected RetryAfter 2s, got %v", rl.RetryAfter)
+	}
+	if err.Error() != "rate limited, retry after 2s" {
+		t.Errorf("unexpected error message: %q", err.Error())
+	}
+	if d := catalogBackoff(err); d != 2*time.Second {
+		t.Errorf("expected catalog backoff 2s, got %v", d)
+	}
+}
+
+func TestGenericHandler_LogsArgsWithSecretsRedacted(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer mockServer.Close()
+
+	var buf strings.Builder
+	logger := common.NewDedicatedLoggerWithOutput("debug", &buf)
+	p := NewMCPProxy(mockServer.URL, logger, testConfig())
+
+	ct := CatalogTool{
+		Name:   "set_strategy",
+		Method: "PUT",
+		Path:   "/api/portfolios/{portfolio_name}/strategy",
+		Params: []CatalogParam{
+			{Name: "portfolio_name", Type: "string", In: "path"},
+			{Name: "api_key", Type: "string", In: "query"},
+			{Name: "strategy_json", Type: "string", In: "body"},
+		},
+	}
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{
+		"portfolio_name": "SMSF",
+		"api_key":        "sk-very-secret",
+		"strategy_json":  `{"risk":"low"}`,
+	}
+
+	result, err := GenericToolHandler(p, ct)(t.Context(), req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %v", err, result)
+	}
+
+	logged := buf.String()
+	if strings.Contains(logged, "sk-very-secret") {
+		t.Errorf("secret api_key leaked into logs: %s", logged)
+	}
+	if !strings.Contains(logged, redactedValue) {
+		t.Errorf("expected redaction marker in logs: %s", logged)
+	}
+	if !strings.Contains(logged, "strategy_json") || !strings.Contains(logged, "risk") {
+		t.Errorf("expected strategy_json argument logged: %s", logged)
+	}
+	if !strings.Contains(logged, "set_strategy") || !strings.Contains(logged, "/api/portfolios/SMSF/strategy") {
+		t.Errorf("expected tool name and resolved path logged: %s", logged)
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	for _, name := range []string{"api_key", "navexa_key", "token", "access_token", "password", "X-Vire-Navexa-Key", "client_secret"} {
+		if !isSecretName(name) {
+			t.Errorf("expected %q to be secret", name)
+		}
+	}
+	for _, name := range []string{"strategy_json", "ticker", "portfolio_name", "keyword", "monkey_count"} {
+		if isSecretName(name) {
+			t.Errorf("expected %q not to be secret", name)
+		}
+	}
+}
+
+func TestGenericHandler_RateLimitedResult(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer mockServer.Close()
+
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	handler := GenericToolHandler(p, CatalogTool{Name: "list_portfolios", Method: "GET", Path: "/api/portfolios"})
+
+	result, err := handler(t.Context(), mcpgo.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error result for 429")
+	}
+	if text := result.Content[0].(mcpgo.TextContent).Text; text != "Error: rate limited, retry after 2s" {
+		t.Errorf("unexpected result text: %q", text)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"2", 2 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCatalogBackoff(t *testing.T) {
+	if d := catalogBackoff(fmt.Errorf("server returned 503")); d != catalogRetryDelay {
+		t.Errorf("expected default delay for non-429, got %v", d)
+	}
+	if d := catalogBackoff(&RateLimitError{}); d != catalogRetryDelay {
+		t.Errorf("expected default delay without Retry-After, got %v", d)
+	}
+	if d := catalogBackoff(&RateLimitError{RetryAfter: 5 * time.Second}); d != 5*time.Second {
+		t.Errorf("expected Retry-After delay 5s, got %v", d)
+	}
+	if d := catalogBackoff(&RateLimitError{RetryAfter: time.Hour}); d != maxCatalogRetryAfter {
+		t.Errorf("expected delay capped at %v, got %v", maxCatalogRetryAfter, d)
+	}
+}
+
 func TestMCPProxy_Put(t *testing.T) {
 	var receivedMethod string
 
@@ -2441,6 +3356,196 @@ func TestMCPProxy_ContextCancellation_CancelsInFlightRequest(t *testing.T) {
 	}
 }
 
+// --- Connection Pooling Tests ---
+
+func TestMCPProxy_ReusesConnections(t *testing.T) {
+	var mu sync.Mutex
+	conns := 0
+	mockServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	mockServer.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	mockServer.Start()
+	defer mockServer.Close()
+
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	for i := 0; i < 5; i++ {
+		if _, err := p.get(t.Context(), "/api/version"); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if conns != 1 {
+		t.Errorf("expected 5 sequential requests to share 1 connection, got %d", conns)
+	}
+}
+
+func TestNewProxyTransport_AppliesPoolSettings(t *testing.T) {
+	tr := newProxyTransport(config.MCPConfig{MaxIdleConnsPerHost: 7, IdleConnTimeoutSeconds: 15})
+	if tr.MaxIdleConnsPerHost != 7 {
+		t.Errorf("expected MaxIdleConnsPerHost 7, got %d", tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != 15*time.Second {
+		t.Errorf("expected IdleConnTimeout 15s, got %v", tr.IdleConnTimeout)
+	}
+
+	tr = newProxyTransport(config.MCPConfig{})
+	if tr.MaxIdleConnsPerHost != config.DefaultMCPMaxIdleConnsPerHost {
+		t.Errorf("expected default MaxIdleConnsPerHost, got %d", tr.MaxIdleConnsPerHost)
+	}
+}
+
+func TestMCPProxy_RefusesNonAllowListedHost(t *testing.T) {
+	hit := false
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer other.Close()
+
+	// The upstream redirects to a host that is not allow-listed.
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer upstream.Close()
+
+	p := NewMCPProxy(upstream.URL, testLogger(), testConfig())
+	_, err := p.get(context.Background(), "/api/portfolios")
+	if err == nil || !strings.Contains(err.Error(), "not in the allow-list") {
+		t.Fatalf("expected allow-list refusal, got %v", err)
+	}
+	if hit {
+		t.Error("expected non-allow-listed host not to be contacted")
+	}
+
+	cfg := testConfig()
+	cfg.API.AllowedHosts = []string{strings.TrimPrefix(other.URL, "http://")}
+	p = NewMCPProxy(upstream.URL, testLogger(), cfg)
+	if _, err := p.get(context.Background(), "/api/portfolios"); err != nil {
+		t.Fatalf("expected allow-listed host to be reachable, got %v", err)
+	}
+	if !hit {
+		t.Error("expected allow-listed host to be contacted")
+	}
+}
+
+// --- Concurrency Limit Tests ---
+
+func TestMCPProxy_MaxInflight_CapEnforced(t *testing.T) {
+	var mu sync.Mutex
+	inflight, peak := 0, 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inflight++
+		if inflight > peak {
+			peak = inflight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inflight--
+		mu.Unlock()
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer mockServer.Close()
+
+	cfg := testConfig()
+	cfg.MCP.MaxInflight = 2
+	p := NewMCPProxy(mockServer.URL, testLogger(), cfg)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.get(t.Context(), "/api/version"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent upstream requests, saw %d", peak)
+	}
+	if peak < 2 {
+		t.Errorf("expected requests to run concurrently up to the cap, saw %d", peak)
+	}
+}
+
+func TestMCPProxy_MaxInflight_QueueTimeoutReturnsBusy(t *testing.T) {
+	unblock := make(chan struct{})
+	received := make(chan struct{}, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer mockServer.Close()
+	defer close(unblock)
+
+	cfg := testConfig()
+	cfg.MCP.MaxInflight = 1
+	p := NewMCPProxy(mockServer.URL, testLogger(), cfg)
+	p.queueTimeout = 50 * time.Millisecond
+
+	go p.get(t.Context(), "/api/version")
+	<-received
+
+	_, err := p.get(t.Context(), "/api/version")
+	if !errors.Is(err, ErrServerBusy) {
+		t.Fatalf("expected ErrServerBusy, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "server busy") {
+		t.Errorf("expected clear busy message, got %q", err.Error())
+	}
+}
+
+func TestMCPProxy_MaxInflight_QueuedRequestRespectsContext(t *testing.T) {
+	unblock := make(chan struct{})
+	received := make(chan struct{}, 1)
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		<-unblock
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer mockServer.Close()
+	defer close(unblock)
+
+	cfg := testConfig()
+	cfg.MCP.MaxInflight = 1
+	p := NewMCPProxy(mockServer.URL, testLogger(), cfg)
+
+	go p.get(t.Context(), "/api/version")
+	<-received
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.post(ctx, "/api/test", map[string]string{"k": "v"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error while queued, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("queued request did not honour its context deadline")
+	}
+}
+
 // --- resolvePortfolio Tests (used by default_from resolution) ---
 
 func TestResolvePortfolio_ExplicitParam(t *testing.T) {
@@ -2547,6 +3652,126 @@ func TestResolvePortfolio_EmptyEverywhere(t *testing.T) {
 	}
 }
 
+func TestResolvePortfolio_PreferenceOverridesConfig(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/portfolios" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"portfolios":[{"name":"SMSF"},{"name":"Personal"}],"default":"SMSF"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockServer.Close()
+
+	cfg := config.NewDefaultConfig()
+	cfg.User.Portfolios = []string{"SMSF", "Personal"}
+	p := NewMCPProxy(mockServer.URL, testLogger(), cfg)
+	p.preferences.SetPortfolio("user-1", "Personal")
+
+	req := mcpgo.CallToolRequest{
+		Params: mcpgo.CallToolParams{
+			Name:      "test_tool",
+			Arguments: map[string]interface{}{},
+		},
+	}
+
+	ctx := WithUserContext(t.Context(), UserContext{UserID: "user-1"})
+	if result := resolvePortfolio(ctx, p, req); result != "Personal" {
+		t.Errorf("expected 'Personal' from preference, got %q", result)
+	}
+	if result := resolveDefaultPortfolio(ctx, p); result != "Personal" {
+		t.Errorf("expected 'Personal' from preference for default_from, got %q", result)
+	}
+
+	// Other users keep the config-first default
+	other := WithUserContext(t.Context(), UserContext{UserID: "user-2"})
+	if result := resolvePortfolio(other, p, req); result != "SMSF" {
+		t.Errorf("expected 'SMSF' for user without preference, got %q", result)
+	}
+}
+
+func TestResolvePortfolio_PreferenceNotRevalidated(t *testing.T) {
+	var paths []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockServer.Close()
+
+	p := NewMCPProxy(mockServer.URL, testLogger(), config.NewDefaultConfig())
+	p.preferences.SetPortfolio("user-1", "Personal")
+
+	req := mcpgo.CallToolRequest{
+		Params: mcpgo.CallToolParams{
+			Name:      "test_tool",
+			Arguments: map[string]interface{}{},
+		},
+	}
+
+	ctx := WithUserContext(t.Context(), UserContext{UserID: "user-1"})
+	if result := resolvePortfolio(ctx, p, req); result != "Personal" {
+		t.Errorf("expected 'Personal' from preference, got %q", result)
+	}
+	if len(paths) != 0 {
+		t.Errorf("expected no vire-server requests to resolve a saved preference, got %v", paths)
+	}
+}
+
+func TestUserPreferences_SetGetClear(t *testing.T) {
+	prefs := NewUserPreferences(nil)
+	if got := prefs.Portfolio("user-1"); got != "" {
+		t.Errorf("expected no preference, got %q", got)
+	}
+	prefs.SetPortfolio("user-1", "Personal")
+	prefs.SetTimezone("user-1", "Australia/Perth")
+	if got := prefs.Portfolio("user-1"); got != "Personal" {
+		t.Errorf("expected 'Personal', got %q", got)
+	}
+	prefs.SetPortfolio("user-1", "")
+	if got := prefs.Portfolio("user-1"); got != "" {
+		t.Errorf("expected preference to be cleared, got %q", got)
+	}
+	if got := prefs.Timezone("user-1"); got != "Australia/Perth" {
+		t.Errorf("expected timezone kept when the portfolio is cleared, got %q", got)
+	}
+}
+
+func TestUserPreferences_ReadsProfile(t *testing.T) {
+	stored := client.UserProfile{DefaultPortfolio: "Personal", Timezone: "Australia/Perth"}
+	lookups := 0
+	prefs := NewUserPreferences(func(userID string) (*client.UserProfile, error) {
+		lookups++
+		if userID != "user-1" {
+			return nil, errors.New("user not found")
+		}
+		profile := stored
+		return &profile, nil
+	})
+	clock := common.NewFakeClock(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC))
+	prefs.clock = clock
+
+	if got := prefs.Portfolio("user-1"); got != "Personal" {
+		t.Errorf("expected 'Personal' from profile, got %q", got)
+	}
+	stored.DefaultPortfolio = "SMSF"
+	if got, tz := prefs.Portfolio("user-1"), prefs.Timezone("user-1"); got != "Personal" || tz != "Australia/Perth" || lookups != 1 {
+		t.Errorf("expected cached 'Personal'/'Australia/Perth' after 1 lookup, got %q/%q after %d", got, tz, lookups)
+	}
+	prefs.SetPortfolio("user-1", "SMSF")
+	if got := prefs.Portfolio("user-1"); got != "SMSF" || lookups != 1 {
+		t.Errorf("expected 'SMSF' from Set without a lookup, got %q after %d", got, lookups)
+	}
+
+	stored.Timezone = "America/New_York"
+	clock.Advance(profilePreferenceTTL)
+	if got := prefs.Timezone("user-1"); got != "America/New_York" || lookups != 2 {
+		t.Errorf("expected 'America/New_York' re-read after TTL, got %q after %d lookups", got, lookups)
+	}
+	if got := prefs.Portfolio("user-2"); got != "" {
+		t.Errorf("expected no preference when the profile lookup fails, got %q", got)
+	}
+}
+
 // --- Integration Test: Full Catalog -> Registration -> Tool Call ---
 
 func TestIntegration_CatalogToToolCall(t *testing.T) {
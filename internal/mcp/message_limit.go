@@ -0,0 +1,55 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// readLimitedMessage buffers r's JSON-RPC body, capped at limit bytes. A
+// batch is one body, so the cap covers the whole batch. When the body is
+// too large (by Content-Length or once read) it writes a JSON-RPC error with
+// a null id and returns false; otherwise r.Body is replaced by the buffered
+// bytes for the MCP server to read. Requests without a body (GET streams,
+// DELETE) pass through.
+func readLimitedMessage(w http.ResponseWriter, r *http.Request, limit int64) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.Method != http.MethodPost {
+		return true
+	}
+	if r.ContentLength > limit {
+		writeMessageTooLarge(w, limit)
+		return false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeMessageTooLarge(w, limit)
+		} else {
+			writeJSONRPCError(w, http.StatusBadRequest, mcp.PARSE_ERROR, "failed to read request body")
+		}
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return true
+}
+
+// writeMessageTooLarge writes the 413 JSON-RPC error for an oversized message.
+func writeMessageTooLarge(w http.ResponseWriter, limit int64) {
+	writeJSONRPCError(w, http.StatusRequestEntityTooLarge, mcp.INVALID_REQUEST,
+		fmt.Sprintf("message too large: JSON-RPC messages are limited to %d bytes", limit))
+}
+
+// writeJSONRPCError writes a JSON-RPC error response with a null id, for
+// failures before the request could be parsed.
+func writeJSONRPCError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(mcp.NewJSONRPCError(mcp.RequestId{}, code, message, nil))
+}
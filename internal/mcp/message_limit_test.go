@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newLimitedHandler returns a handler capping messages at limit bytes.
+func newLimitedHandler(t *testing.T, limit int64) *Handler {
+	t.Helper()
+	cfg := testConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.MCP.MaxMessageBytes = limit
+	h := NewHandler(cfg, testLogger())
+	t.Cleanup(h.Close)
+	return h
+}
+
+func postMCP(h http.Handler, body io.Reader, contentLength int64) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/mcp", body)
+	req.ContentLength = contentLength
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+buildTestJWT("user-1"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func assertMessageTooLarge(t *testing.T, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Error   struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected a JSON-RPC error body, got %q", w.Body.String())
+	}
+	if resp.JSONRPC != "2.0" || string(resp.ID) != "null" {
+		t.Errorf("expected jsonrpc 2.0 with null id, got %q / %s", resp.JSONRPC, resp.ID)
+	}
+	if resp.Error.Code != -32600 || !strings.Contains(resp.Error.Message, "too large") {
+		t.Errorf("unexpected error: %+v", resp.Error)
+	}
+}
+
+func TestMCPMessageLimit_DeclaredLengthOverLimit(t *testing.T) {
+	h := newLimitedHandler(t, 1024)
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"pad":"` + strings.Repeat("x", 2048) + `"}}`
+	assertMessageTooLarge(t, postMCP(h, strings.NewReader(body), int64(len(body))))
+}
+
+func TestMCPMessageLimit_ChunkedBodyOverLimit(t *testing.T) {
+	h := newLimitedHandler(t, 1024)
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"pad":"` + strings.Repeat("x", 2048) + `"}}`
+	// Unknown length: the limit is enforced while reading.
+	assertMessageTooLarge(t, postMCP(h, io.MultiReader(strings.NewReader(body)), -1))
+}
+
+func TestMCPMessageLimit_BatchCountsAsOneMessage(t *testing.T) {
+	h := newLimitedHandler(t, 1024)
+	msg := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	batch := "[" + strings.Repeat(msg+",", 40) + msg + "]"
+	if len(msg) >= 1024 || len(batch) <= 1024 {
+		t.Fatalf("test setup: want small messages in a batch over the limit")
+	}
+	assertMessageTooLarge(t, postMCP(h, strings.NewReader(batch), int64(len(batch))))
+}
+
+func TestMCPMessageLimit_UnderLimitReachesServer(t *testing.T) {
+	h := newLimitedHandler(t, 4096)
+	body := `{"jsonrpc":"2.0","id":7,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+	w := postMCP(h, strings.NewReader(body), int64(len(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"id":7`) || !strings.Contains(w.Body.String(), "serverInfo") {
+		t.Errorf("expected initialize result, got %s", w.Body.String())
+	}
+}
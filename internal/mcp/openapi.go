@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"encoding/json"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// openAPIVersion is the OpenAPI specification version of BuildOpenAPI's output.
+const openAPIVersion = "3.0.3"
+
+// BuildOpenAPI returns a minimal OpenAPI 3 document describing the
+// vire-server endpoints behind the catalog tools: one operation per tool,
+// keyed by path and method, with operationId set to the tool name. Path
+// and query params become parameters and body params a JSON request body,
+// using the same schemas BuildMCPTool gives MCP clients. Portal-side
+// params, which are never forwarded, are left out. When two tools share a
+// path and method, the first by name wins.
+func BuildOpenAPI(catalog []CatalogTool, version string) ([]byte, error) {
+	sorted := make([]CatalogTool, len(catalog))
+	copy(sorted, catalog)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	paths := map[string]map[string]any{}
+	for _, ct := range sorted {
+		method := strings.ToLower(ct.Method)
+		if paths[ct.Path] == nil {
+			paths[ct.Path] = map[string]any{}
+		}
+		if _, taken := paths[ct.Path][method]; taken {
+			continue
+		}
+		paths[ct.Path][method] = openAPIOperation(ct)
+	}
+
+	return json.Marshal(map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "Vire API",
+			"description": "vire-server endpoints exposed as MCP tools by vire-portal, generated from the tool catalog.",
+			"version":     version,
+		},
+		"paths": paths,
+	})
+}
+
+// openAPIOperation builds the OpenAPI operation object for one tool.
+func openAPIOperation(ct CatalogTool) map[string]any {
+	op := map[string]any{
+		"operationId": ct.Name,
+		"summary":     ct.Description,
+		"responses": map[string]any{
+			"default": map[string]any{"description": "vire-server response"},
+		},
+	}
+	if ct.Deprecated {
+		op["deprecated"] = true
+		if notice := ct.DeprecationNotice(); notice != "" {
+			op["description"] = notice
+		}
+	}
+
+	parameters := []map[string]any{}
+	bodyProps := map[string]any{}
+	var bodyRequired []string
+	for _, p := range ct.Params {
+		switch p.In {
+		case "path", "query":
+			schema := paramSchema(p)
+			delete(schema, "description")
+			param := map[string]any{
+				"name":     p.Name,
+				"in":       p.In,
+				"required": p.Required || p.In == "path", // OpenAPI requires path params
+				"schema":   schema,
+			}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			if p.In == "query" && p.Type == "array" {
+				param["style"] = "form"
+				param["explode"] = p.ArrayFormat != "comma"
+			}
+			parameters = append(parameters, param)
+		case "body":
+			bodyProps[p.Name] = paramSchema(p)
+			if p.Required {
+				bodyRequired = append(bodyRequired, p.Name)
+			}
+		}
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	if len(bodyProps) > 0 {
+		schema := map[string]any{"type": "object", "properties": bodyProps}
+		if len(bodyRequired) > 0 {
+			schema["required"] = bodyRequired
+		}
+		op["requestBody"] = map[string]any{
+			"required": len(bodyRequired) > 0,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schema},
+			},
+		}
+	}
+	return op
+}
+
+// paramSchema returns the JSON Schema buildParamOption gives p, so the
+// OpenAPI document and the MCP tool schemas cannot drift apart.
+func paramSchema(p CatalogParam) map[string]any {
+	tool := mcp.NewTool("schema", buildParamOption(p))
+	schema, _ := tool.InputSchema.Properties[p.Name].(map[string]any)
+	return maps.Clone(schema)
+}
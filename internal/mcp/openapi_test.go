@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type openAPIDoc struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths map[string]map[string]struct {
+		OperationID string `json:"operationId"`
+		Deprecated  bool   `json:"deprecated"`
+		Parameters  []struct {
+			Name     string         `json:"name"`
+			In       string         `json:"in"`
+			Required bool           `json:"required"`
+			Schema   map[string]any `json:"schema"`
+		} `json:"parameters"`
+		RequestBody *struct {
+			Content map[string]struct {
+				Schema struct {
+					Properties map[string]map[string]any `json:"properties"`
+					Required   []string                  `json:"required"`
+				} `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+	} `json:"paths"`
+}
+
+func parseOpenAPI(t *testing.T, data []byte) openAPIDoc {
+	t.Helper()
+	var doc openAPIDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid OpenAPI JSON: %v", err)
+	}
+	return doc
+}
+
+func TestBuildOpenAPI_SampleCatalog(t *testing.T) {
+	catalog := parseSampleCatalog(t)
+	data, err := BuildOpenAPI(catalog, "1.2.3")
+	if err != nil {
+		t.Fatalf("BuildOpenAPI: %v", err)
+	}
+	doc := parseOpenAPI(t, data)
+	if !strings.HasPrefix(doc.OpenAPI, "3.") || doc.Info.Version != "1.2.3" {
+		t.Errorf("unexpected header: openapi=%q version=%q", doc.OpenAPI, doc.Info.Version)
+	}
+
+	for _, ct := range catalog {
+		op, ok := doc.Paths[ct.Path][strings.ToLower(ct.Method)]
+		if !ok {
+			t.Errorf("%s: no %s operation at %s", ct.Name, ct.Method, ct.Path)
+			continue
+		}
+		if op.OperationID != ct.Name {
+			t.Errorf("%s: operationId = %q", ct.Name, op.OperationID)
+		}
+
+		var wantParams, gotParams, wantBody, gotBody []string
+		for _, p := range ct.Params {
+			switch p.In {
+			case "path", "query":
+				wantParams = append(wantParams, p.In+":"+p.Name)
+			case "body":
+				wantBody = append(wantBody, p.Name)
+			}
+		}
+		for _, p := range op.Parameters {
+			gotParams = append(gotParams, p.In+":"+p.Name)
+			if p.In == "path" && !p.Required {
+				t.Errorf("%s: path param %s must be required", ct.Name, p.Name)
+			}
+			if p.Schema["type"] == nil {
+				t.Errorf("%s: param %s has no schema type", ct.Name, p.Name)
+			}
+		}
+		if op.RequestBody != nil {
+			for name := range op.RequestBody.Content["application/json"].Schema.Properties {
+				gotBody = append(gotBody, name)
+			}
+		}
+		sort.Strings(gotBody)
+		sort.Strings(wantBody)
+		if strings.Join(gotParams, ",") != strings.Join(wantParams, ",") {
+			t.Errorf("%s: parameters = %v, want %v", ct.Name, gotParams, wantParams)
+		}
+		if strings.Join(gotBody, ",") != strings.Join(wantBody, ",") {
+			t.Errorf("%s: body properties = %v, want %v", ct.Name, gotBody, wantBody)
+		}
+	}
+
+	diag := doc.Paths["/api/diagnostics"]["get"]
+	for _, p := range diag.Parameters {
+		if p.Name == "limit" && p.Schema["type"] != "number" {
+			t.Errorf("expected limit schema type number, got %v", p.Schema["type"])
+		}
+	}
+	body := doc.Paths["/api/portfolios/{portfolio_name}/strategy"]["put"].RequestBody
+	if body == nil || strings.Join(body.Content["application/json"].Schema.Required, ",") != "strategy_json" {
+		t.Errorf("expected strategy_json required in request body, got %+v", body)
+	}
+}
+
+func TestBuildOpenAPI_SkipsPortalSideParams(t *testing.T) {
+	ct := CatalogTool{
+		Name: "screen_stocks", Method: "GET", Path: "/api/screen",
+		Params: []CatalogParam{{Name: "sector", Type: "string", In: "query"}, maxResultsParam},
+	}
+	data, err := BuildOpenAPI([]CatalogTool{ct}, "dev")
+	if err != nil {
+		t.Fatalf("BuildOpenAPI: %v", err)
+	}
+	op := parseOpenAPI(t, data).Paths["/api/screen"]["get"]
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "sector" {
+		t.Errorf("expected only the forwarded sector param, got %+v", op.Parameters)
+	}
+}
+
+// TestOpenAPI_RegeneratedOnRefresh verifies the cached document follows
+// catalog refreshes.
+func TestOpenAPI_RegeneratedOnRefresh(t *testing.T) {
+	ctrl := newMockServer()
+	defer ctrl.Close()
+
+	h := newTestHandler(t, ctrl)
+	defer h.Close()
+
+	if _, ok := parseOpenAPI(t, h.OpenAPI()).Paths["/api/a"]["get"]; !ok {
+		t.Fatal("expected tool_a in the initial document")
+	}
+
+	ctrl.CatalogJSON.Store(`[{"name":"tool_b","description":"Tool B","method":"POST","path":"/api/b","params":[]}]`)
+	if _, err := h.RefreshCatalog(); err != nil {
+		t.Fatalf("RefreshCatalog failed: %v", err)
+	}
+	doc := parseOpenAPI(t, h.OpenAPI())
+	if _, ok := doc.Paths["/api/a"]; ok {
+		t.Error("expected tool_a removed after refresh")
+	}
+	if op, ok := doc.Paths["/api/b"]["post"]; !ok || op.OperationID != "tool_b" {
+		t.Error("expected tool_b POST /api/b after refresh")
+	}
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/client"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// profilePreferenceTTL is how long a user's stored preferences are cached
+// before they are re-read from their vire-server profile.
+const profilePreferenceTTL = time.Minute
+
+// UserPreferences caches the preferences each user saved from the web UI,
+// which are stored on their vire-server user profile:
+//   - default_portfolio (POST /api/preferences/portfolio), consulted by
+//     resolvePortfolio before the configured default, so a portfolio picked
+//     in the dashboard is also the MCP default.
+//   - timezone (POST /api/preferences/timezone), sent by applyUserHeaders as
+//     X-Vire-Timezone in place of the configured user.timezone.
+//
+// Both are validated when they are saved. Entries are re-read after
+// profilePreferenceTTL, so a change saved through another portal instance is
+// picked up.
+type UserPreferences struct {
+	mu     sync.Mutex
+	lookup func(userID string) (*client.UserProfile, error) // nil: only Set values are known
+	clock  common.Clock
+	byUser map[string]cachedPreferences
+	sets   uint64 // bumped by every Set, so a lookup racing a Set is not stored
+}
+
+type cachedPreferences struct {
+	portfolio string
+	timezone  string
+	loaded    time.Time
+}
+
+// NewUserPreferences creates a preference store that reads users' profiles
+// through lookup.
+func NewUserPreferences(lookup func(userID string) (*client.UserProfile, error)) *UserPreferences {
+	return &UserPreferences{
+		lookup: lookup,
+		clock:  common.SystemClock,
+		byUser: make(map[string]cachedPreferences),
+	}
+}
+
+// SetPortfolio caches userID's selected portfolio after it has been saved to
+// their profile. An empty name clears it.
+func (p *UserPreferences) SetPortfolio(userID, name string) {
+	p.set(userID, func(prefs *cachedPreferences) { prefs.portfolio = name })
+}
+
+// SetTimezone caches userID's timezone after it has been saved to their
+// profile. An empty timezone clears it.
+func (p *UserPreferences) SetTimezone(userID, timezone string) {
+	p.set(userID, func(prefs *cachedPreferences) { prefs.timezone = timezone })
+}
+
+// set applies update to userID's entry, loading it first so the preference
+// not being changed is kept.
+func (p *UserPreferences) set(userID string, update func(*cachedPreferences)) {
+	prefs := p.get(userID)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if current, ok := p.byUser[userID]; ok {
+		prefs = current
+	}
+	update(&prefs)
+	p.byUser[userID] = prefs
+	p.sets++
+}
+
+// Portfolio returns userID's selected portfolio, or "" if none is set.
+func (p *UserPreferences) Portfolio(userID string) string {
+	return p.get(userID).portfolio
+}
+
+// Timezone returns userID's timezone, or "" if none is set.
+func (p *UserPreferences) Timezone(userID string) string {
+	return p.get(userID).timezone
+}
+
+// get returns userID's cached preferences, re-reading their profile once the
+// entry is older than profilePreferenceTTL. A failed read keeps the last
+// known values until the next refresh. The lock is not held during the
+// lookup, so a slow vire-server only delays the user being refreshed.
+func (p *UserPreferences) get(userID string) cachedPreferences {
+	p.mu.Lock()
+	now := p.clock.Now()
+	cached, ok := p.byUser[userID]
+	if p.lookup == nil || (ok && now.Sub(cached.loaded) < profilePreferenceTTL) {
+		p.mu.Unlock()
+		return cached
+	}
+	sets := p.sets
+	p.mu.Unlock()
+
+	fresh := cached
+	if profile, err := p.lookup(userID); err == nil && profile != nil {
+		fresh.portfolio = profile.DefaultPortfolio
+		fresh.timezone = profile.Timezone
+	}
+	fresh.loaded = now
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sets != sets {
+		if current, ok := p.byUser[userID]; ok {
+			return current
+		}
+		return fresh
+	}
+	p.byUser[userID] = fresh
+	return fresh
+}
+
+// preferredPortfolio returns the calling user's selected portfolio, or "" if
+// none is set. The selection was checked against the user's portfolios when
+// it was saved, so it is not re-validated here.
+func (p *MCPProxy) preferredPortfolio(ctx context.Context) string {
+	uc, ok := GetUserContext(ctx)
+	if !ok || uc.UserID == "" {
+		return ""
+	}
+	return p.preferences.Portfolio(uc.UserID)
+}
@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultProgressInterval is how often a tool call still waiting on
+// vire-server sends a progress notification.
+const defaultProgressInterval = 5 * time.Second
+
+// progressReporter sends notifications/progress for one tool call. A nil
+// reporter (the client sent no progress token) reports nothing.
+type progressReporter struct {
+	srv      *server.MCPServer
+	token    mcp.ProgressToken
+	interval time.Duration
+
+	mu       sync.Mutex
+	progress float64
+}
+
+// newProgressReporter returns a reporter for r, or nil when the client did
+// not ask for progress or the call is not being served by an MCP server.
+func newProgressReporter(ctx context.Context, r mcp.CallToolRequest, interval time.Duration) *progressReporter {
+	if r.Params.Meta == nil || r.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	srv := server.ServerFromContext(ctx)
+	if srv == nil {
+		return nil
+	}
+	return &progressReporter{srv: srv, token: r.Params.Meta.ProgressToken, interval: interval}
+}
+
+// step advances progress by one and sends message. Delivery failures are
+// ignored: progress is advisory.
+func (pr *progressReporter) step(ctx context.Context, message string) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	params := map[string]any{
+		"progressToken": pr.token,
+		"progress":      pr.progress,
+		"message":       message,
+	}
+	_ = pr.srv.SendNotificationToClient(ctx, "notifications/progress", params)
+	pr.progress++
+}
+
+// track reports tool as running now and every interval while vire-server
+// works on it. The returned finish stops the updates and reports how the
+// call ended; call it with the upstream response body and error once the
+// request returns. ctx is the call's deadline context.
+func (pr *progressReporter) track(ctx context.Context, tool string) (finish func(body []byte, err error)) {
+	if pr == nil {
+		return func([]byte, error) {}
+	}
+	start := time.Now()
+	pr.step(ctx, tool+" running...")
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(pr.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pr.step(ctx, fmt.Sprintf("%s still running (%s)", tool, time.Since(start).Round(time.Second)))
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return func(body []byte, err error) {
+		close(stop)
+		wg.Wait()
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			pr.step(ctx, tool+" timed out")
+		case err != nil:
+			pr.step(ctx, tool+" failed")
+		default:
+			pr.reportStages(ctx, body)
+			pr.step(ctx, tool+" complete")
+		}
+	}
+}
+
+// reportStages sends one notification per stage listed under "stages" in
+// body, e.g. "momentum: 120 -> 30". vire-server returns the stages with the
+// final response, so they arrive together just before "complete".
+func (pr *progressReporter) reportStages(ctx context.Context, body []byte) {
+	var resp struct {
+		Stages []struct {
+			Name        string `json:"name"`
+			InputCount  int    `json:"input_count"`
+			OutputCount int    `json:"output_count"`
+		} `json:"stages"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return
+	}
+	for _, s := range resp.Stages {
+		pr.step(ctx, fmt.Sprintf("%s: %d -> %d", s.Name, s.InputCount, s.OutputCount))
+	}
+}
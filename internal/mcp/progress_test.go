@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// progressSession is a ClientSession that buffers notifications sent to it.
+type progressSession struct {
+	ch chan mcpgo.JSONRPCNotification
+}
+
+func (s *progressSession) Initialize()                                           {}
+func (s *progressSession) Initialized() bool                                     { return true }
+func (s *progressSession) NotificationChannel() chan<- mcpgo.JSONRPCNotification { return s.ch }
+func (s *progressSession) SessionID() string                                     { return "progress-test" }
+
+// callToolWithProgress calls name with a progress token on a session and
+// returns the progress messages the client received.
+func callToolWithProgress(t *testing.T, s *mcpserver.MCPServer, name string) []string {
+	t.Helper()
+
+	session := &progressSession{ch: make(chan mcpgo.JSONRPCNotification, 16)}
+	if err := s.RegisterSession(t.Context(), session); err != nil {
+		t.Fatalf("register session: %v", err)
+	}
+	ctx := s.WithContext(t.Context(), session)
+
+	msg := json.RawMessage(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"` + name + `","arguments":{},"_meta":{"progressToken":"tok-1"}}}`)
+	if _, ok := s.HandleMessage(ctx, msg).(mcpgo.JSONRPCResponse); !ok {
+		t.Fatal("expected JSONRPCResponse")
+	}
+	close(session.ch)
+
+	var messages []string
+	for n := range session.ch {
+		if n.Method != "notifications/progress" {
+			continue
+		}
+		if tok := n.Params.AdditionalFields["progressToken"]; tok != "tok-1" {
+			t.Errorf("expected progressToken tok-1, got %v", tok)
+		}
+		msg, _ := n.Params.AdditionalFields["message"].(string)
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+// progressServer serves funnel_screen, a progress-enabled tool backed by
+// handler, and returns the MCP server and its proxy.
+func progressServer(t *testing.T, handler http.HandlerFunc) (*mcpserver.MCPServer, *MCPProxy) {
+	t.Helper()
+	mockServer := httptest.NewServer(handler)
+	t.Cleanup(mockServer.Close)
+
+	ct := CatalogTool{Name: "funnel_screen", Method: "POST", Path: "/api/screen/funnel", Progress: true}
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+	return s, p
+}
+
+func TestGenericHandler_ReportsFunnelStages(t *testing.T) {
+	s, _ := progressServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"stages":[
+				{"name":"universe","input_count":500,"output_count":120,"duration_ms":900},
+				{"name":"momentum","input_count":120,"output_count":30,"duration_ms":1400},
+				{"name":"quality","input_count":30,"output_count":10,"duration_ms":600}
+			],
+			"candidates":[]}`))
+	})
+
+	got := callToolWithProgress(t, s, "funnel_screen")
+	want := []string{
+		"funnel_screen running...",
+		"universe: 500 -> 120",
+		"momentum: 120 -> 30",
+		"quality: 30 -> 10",
+		"funnel_screen complete",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("progress messages = %q, want %q", got, want)
+	}
+}
+
+func TestGenericHandler_ReportsProgressWhileInFlight(t *testing.T) {
+	s, p := progressServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(120 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[]}`))
+	})
+	p.progressInterval = 25 * time.Millisecond
+
+	got := callToolWithProgress(t, s, "funnel_screen")
+	if len(got) < 4 {
+		t.Fatalf("expected start, at least two in-flight updates and completion, got %q", got)
+	}
+	if got[0] != "funnel_screen running..." {
+		t.Errorf("expected first message %q, got %q", "funnel_screen running...", got[0])
+	}
+	for _, msg := range got[1 : len(got)-1] {
+		if !strings.HasPrefix(msg, "funnel_screen still running") {
+			t.Errorf("expected in-flight update, got %q", msg)
+		}
+	}
+	if last := got[len(got)-1]; last != "funnel_screen complete" {
+		t.Errorf("expected last message %q, got %q", "funnel_screen complete", last)
+	}
+}
+
+func TestGenericHandler_ProgressReportsFailure(t *testing.T) {
+	s, _ := progressServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":"screen failed","stages":[{"name":"universe","input_count":500,"output_count":0}]}`))
+	})
+
+	got := callToolWithProgress(t, s, "funnel_screen")
+	want := []string{"funnel_screen running...", "funnel_screen failed"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("progress messages = %q, want %q", got, want)
+	}
+}
+
+func TestGenericHandler_ProgressReportsTimeout(t *testing.T) {
+	s, p := progressServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+	p.callTimeout = 50 * time.Millisecond
+
+	got := callToolWithProgress(t, s, "funnel_screen")
+	want := []string{"funnel_screen running...", "funnel_screen timed out"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("progress messages = %q, want %q", got, want)
+	}
+}
+
+func TestGenericHandler_ProgressOnlyForOptedInTools(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version":"1.0.0"}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{Name: "get_version", Method: "GET", Path: "/api/version"}
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	if got := callToolWithProgress(t, s, "get_version"); len(got) != 0 {
+		t.Errorf("expected no progress for a tool without \"progress\": true, got %q", got)
+	}
+}
+
+func TestGenericHandler_NoProgressToken_NoProgress(t *testing.T) {
+	s, _ := progressServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[]}`))
+	})
+
+	session := &progressSession{ch: make(chan mcpgo.JSONRPCNotification, 16)}
+	if err := s.RegisterSession(t.Context(), session); err != nil {
+		t.Fatalf("register session: %v", err)
+	}
+	msg := json.RawMessage(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"funnel_screen","arguments":{}}}`)
+	if _, ok := s.HandleMessage(s.WithContext(t.Context(), session), msg).(mcpgo.JSONRPCResponse); !ok {
+		t.Fatal("expected JSONRPCResponse")
+	}
+	close(session.ch)
+	for n := range session.ch {
+		if n.Method == "notifications/progress" {
+			t.Errorf("expected no progress without a progress token, got %v", n.Params.AdditionalFields)
+		}
+	}
+}
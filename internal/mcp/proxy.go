@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,12 +20,56 @@ import (
 // maxResponseSize caps the proxy response body to prevent OOM from unexpectedly large responses.
 const maxResponseSize = 50 << 20 // 50MB
 
+// ErrServerBusy is returned when a proxy request cannot get an upstream
+// slot within the queue timeout.
+var ErrServerBusy = errors.New("server busy: too many concurrent requests to vire-server, try again shortly")
+
+// RateLimitError is returned when vire-server responds 429 Too Many Requests.
+// RetryAfter is parsed from the Retry-After header and is zero when absent.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return "rate limited, retry later"
+	}
+	secs := int((e.RetryAfter + time.Second - 1) / time.Second)
+	return fmt.Sprintf("rate limited, retry after %ds", secs)
+}
+
+// parseRetryAfter parses a Retry-After header value, either delay-seconds or
+// an HTTP date. Returns zero for missing, invalid or past values.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
 // MCPProxy connects MCP tool calls to the REST API on vire-server.
 type MCPProxy struct {
-	serverURL   string
-	httpClient  *http.Client
-	logger      *common.Logger
-	userHeaders http.Header
+	serverURL        string
+	httpClient       *http.Client
+	logger           *common.Logger
+	userHeaders      http.Header
+	defaultPortfolio string                  // cfg.User.DefaultPortfolio(), "" when unset
+	preferences      *UserPreferences        // per-user portfolio and timezone saved in the web UI, see WithUserLookup
+	neutral          *common.NeutralLanguage // rewrites sentiment and impact text, see neutralizeSentiment
+	inflight         chan struct{}           // semaphore capping concurrent upstream requests
+	queueTimeout     time.Duration
+	callTimeout      time.Duration // default catalog tool deadline, see toolTimeout
+	progressInterval time.Duration // how often in-flight tool calls report progress
 }
 
 // NewMCPProxy creates a new MCP proxy targeting the given vire-server URL.
@@ -35,19 +82,110 @@ func NewMCPProxy(serverURL string, logger *common.Logger, cfg *config.Config) *M
 	if cfg.User.DisplayCurrency != "" {
 		headers.Set("X-Vire-Display-Currency", cfg.User.DisplayCurrency)
 	}
+	headers.Set("X-Vire-Timezone", cfg.User.TimezoneOrDefault())
 	// Portal version headers for server compatibility checks
 	headers.Set("X-Vire-Portal-Version", config.GetVersion())
 	headers.Set("X-Vire-Portal-Build", config.GetBuild())
 	headers.Set("X-Vire-Portal-Commit", config.GetGitCommit())
 
+	maxInflight := cfg.MCP.MaxInflight
+	if maxInflight <= 0 {
+		maxInflight = config.DefaultMCPMaxInflight
+	}
+	queueTimeout := cfg.MCP.QueueTimeoutSeconds
+	if queueTimeout <= 0 {
+		queueTimeout = config.DefaultMCPQueueTimeoutSeconds
+	}
+	callTimeout := cfg.MCP.ToolTimeoutSeconds
+	if callTimeout <= 0 {
+		callTimeout = config.DefaultMCPToolTimeoutSeconds
+	}
+	callTimeout = min(callTimeout, MaxToolTimeoutSeconds)
+	neutralTerms := cfg.MCP.NeutralTerms
+	if neutralTerms == nil {
+		neutralTerms = common.DefaultNeutralTerms
+	}
+
 	return &MCPProxy{
 		serverURL: serverURL,
 		httpClient: &http.Client{
-			Timeout: 300 * time.Second,
+			Timeout:   MaxToolTimeoutSeconds * time.Second,
+			Transport: config.NewHostAllowList(serverURL, cfg.API.AllowedHosts).RoundTripper(newProxyTransport(cfg.MCP)),
 		},
-		logger:      logger,
-		userHeaders: headers,
+		logger:           logger,
+		userHeaders:      headers,
+		defaultPortfolio: cfg.User.DefaultPortfolio(),
+		preferences:      NewUserPreferences(nil),
+		neutral:          common.NewNeutralLanguage(neutralTerms),
+		inflight:         make(chan struct{}, maxInflight),
+		queueTimeout:     time.Duration(queueTimeout) * time.Second,
+		callTimeout:      time.Duration(callTimeout) * time.Second,
+		progressInterval: defaultProgressInterval,
+	}
+}
+
+// toolTimeout returns the per-call deadline for ct: its own TimeoutSeconds
+// when set, otherwise the configured mcp.tool_timeout_seconds.
+func (p *MCPProxy) toolTimeout(ct CatalogTool) time.Duration {
+	if ct.TimeoutSeconds > 0 {
+		return time.Duration(ct.TimeoutSeconds) * time.Second
+	}
+	return p.callTimeout
+}
+
+// newProxyTransport builds the pooled transport shared by all proxy requests,
+// so calls to vire-server reuse keep-alive connections instead of dialing
+// each time. Non-positive settings fall back to the defaults.
+func newProxyTransport(cfg config.MCPConfig) *http.Transport {
+	idlePerHost := cfg.MaxIdleConnsPerHost
+	if idlePerHost <= 0 {
+		idlePerHost = config.DefaultMCPMaxIdleConnsPerHost
+	}
+	idleTimeout := cfg.IdleConnTimeoutSeconds
+	if idleTimeout <= 0 {
+		idleTimeout = config.DefaultMCPIdleConnTimeoutSeconds
+	}
+	dialTimeout := cfg.DialTimeoutSeconds
+	if dialTimeout <= 0 {
+		dialTimeout = config.DefaultMCPDialTimeoutSeconds
 	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{
+		Timeout:   time.Duration(dialTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	t.MaxIdleConns = idlePerHost
+	t.MaxIdleConnsPerHost = idlePerHost
+	t.IdleConnTimeout = time.Duration(idleTimeout) * time.Second
+	return t
+}
+
+// acquire waits for an upstream request slot. It returns ErrServerBusy if no
+// slot frees up within the queue timeout, or the context error if ctx ends
+// first. The returned func releases the slot.
+func (p *MCPProxy) acquire(ctx context.Context, method, path string) (func(), error) {
+	select {
+	case p.inflight <- struct{}{}:
+		return p.release, nil
+	default:
+	}
+
+	timer := time.NewTimer(p.queueTimeout)
+	defer timer.Stop()
+	select {
+	case p.inflight <- struct{}{}:
+		return p.release, nil
+	case <-timer.C:
+		p.logger.Warn().Str("method", method).Str("path", redactPath(path)).Int("max_inflight", cap(p.inflight)).Msg("proxy request rejected: queue timeout")
+		return nil, ErrServerBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *MCPProxy) release() {
+	<-p.inflight
 }
 
 // UserHeaders returns the configured X-Vire-* headers for testing.
@@ -75,137 +213,118 @@ func (p *MCPProxy) applyUserHeaders(req *http.Request) {
 			req.Header.Set(key, v)
 		}
 	}
+	if id := common.RequestIDFromContext(req.Context()); id != "" {
+		req.Header.Set(common.RequestIDHeader, id)
+	}
 	// Per-request user context headers
 	if uc, ok := GetUserContext(req.Context()); ok {
 		if uc.UserID != "" {
 			req.Header.Set("X-Vire-User-ID", sanitizeHeaderValue(uc.UserID))
 		}
 	}
+	req.Header.Set("X-Vire-Timezone", sanitizeHeaderValue(p.userTimezone(req.Context())))
 }
 
-// get performs a GET request to the given path on vire-server.
-func (p *MCPProxy) get(ctx context.Context, path string) ([]byte, error) {
-	p.logger.Debug().Str("method", "GET").Str("path", path).Msg("proxy request")
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.serverURL+path, nil)
-	if err != nil {
-		return nil, err
-	}
-	p.applyUserHeaders(req)
-
-	start := time.Now()
-	resp, err := p.httpClient.Do(req)
-	duration := time.Since(start)
-	if err != nil {
-		p.logger.Error().Str("method", "GET").Str("path", path).Int64("duration_ms", duration.Milliseconds()).Str("error", err.Error()).Msg("proxy request failed")
-		return nil, fmt.Errorf("server request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	p.logger.Debug().Int("status", resp.StatusCode).Int64("duration_ms", duration.Milliseconds()).Msg("proxy response")
-
-	if resp.StatusCode >= 400 {
-		return nil, parseErrorResponse(resp.StatusCode, body)
+// userTimezone returns the X-Vire-Timezone sent for the calling user: the
+// timezone they saved in the web UI, else the configured user.timezone.
+func (p *MCPProxy) userTimezone(ctx context.Context) string {
+	if uc, ok := GetUserContext(ctx); ok && uc.UserID != "" {
+		if tz := p.preferences.Timezone(uc.UserID); tz != "" {
+			return tz
+		}
 	}
+	return p.userHeaders.Get("X-Vire-Timezone")
+}
 
-	return body, nil
+// get performs a GET request to the given path on vire-server.
+func (p *MCPProxy) get(ctx context.Context, path string) ([]byte, error) {
+	body, _, err := p.do(ctx, http.MethodGet, path, nil)
+	return body, err
 }
 
 // post performs a POST request with a JSON body to the given path on vire-server.
 func (p *MCPProxy) post(ctx context.Context, path string, data interface{}) ([]byte, error) {
-	return p.doJSON(ctx, http.MethodPost, path, data)
+	body, _, err := p.do(ctx, http.MethodPost, path, data)
+	return body, err
 }
 
 // put performs a PUT request with a JSON body to the given path on vire-server.
 func (p *MCPProxy) put(ctx context.Context, path string, data interface{}) ([]byte, error) {
-	return p.doJSON(ctx, http.MethodPut, path, data)
+	body, _, err := p.do(ctx, http.MethodPut, path, data)
+	return body, err
 }
 
 // patch performs a PATCH request with a JSON body to the given path on vire-server.
 func (p *MCPProxy) patch(ctx context.Context, path string, data interface{}) ([]byte, error) {
-	return p.doJSON(ctx, http.MethodPatch, path, data)
+	body, _, err := p.do(ctx, http.MethodPatch, path, data)
+	return body, err
 }
 
 // del performs a DELETE request to the given path on vire-server.
 func (p *MCPProxy) del(ctx context.Context, path string) ([]byte, error) {
-	p.logger.Debug().Str("method", "DELETE").Str("path", path).Msg("proxy request")
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, p.serverURL+path, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	p.applyUserHeaders(req)
-
-	start := time.Now()
-	resp, err := p.httpClient.Do(req)
-	duration := time.Since(start)
-	if err != nil {
-		p.logger.Error().Str("method", "DELETE").Str("path", path).Int64("duration_ms", duration.Milliseconds()).Str("error", err.Error()).Msg("proxy request failed")
-		return nil, fmt.Errorf("server request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	p.logger.Debug().Int("status", resp.StatusCode).Int64("duration_ms", duration.Milliseconds()).Msg("proxy response")
-
-	if resp.StatusCode >= 400 {
-		return nil, parseErrorResponse(resp.StatusCode, body)
-	}
-
-	return body, nil
+	body, _, err := p.do(ctx, http.MethodDelete, path, nil)
+	return body, err
 }
 
-// doJSON performs an HTTP request with JSON body.
-func (p *MCPProxy) doJSON(ctx context.Context, method, path string, data interface{}) ([]byte, error) {
-	p.logger.Debug().Str("method", method).Str("path", path).Msg("proxy request")
+// do performs an HTTP request to vire-server, with data (when non-nil) as
+// the JSON body. It returns the response body and its Content-Type.
+func (p *MCPProxy) do(ctx context.Context, method, path string, data interface{}) ([]byte, string, error) {
+	p.logger.Debug().Str("method", method).Str("path", redactPath(path)).Msg("proxy request")
 
 	var bodyReader io.Reader
 	if data != nil {
 		jsonData, err := json.Marshal(data)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request: %w", err)
+			return nil, "", fmt.Errorf("failed to marshal request: %w", err)
 		}
 		bodyReader = bytes.NewReader(jsonData)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, p.serverURL+path, bodyReader)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	if method != http.MethodGet {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	req.Header.Set("Content-Type", "application/json")
 	p.applyUserHeaders(req)
 
+	release, err := p.acquire(ctx, method, path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+
 	start := time.Now()
 	resp, err := p.httpClient.Do(req)
 	duration := time.Since(start)
 	if err != nil {
-		p.logger.Error().Str("method", method).Str("path", path).Int64("duration_ms", duration.Milliseconds()).Str("error", err.Error()).Msg("proxy request failed")
-		return nil, fmt.Errorf("server request failed: %w", err)
+		p.logger.Error().Str("method", method).Str("path", redactPath(path)).Int64("duration_ms", duration.Milliseconds()).Str("error", redactError(err)).Msg("proxy request failed")
+		return nil, "", fmt.Errorf("server request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
 	}
 
 	p.logger.Debug().Int("status", resp.StatusCode).Int64("duration_ms", duration.Milliseconds()).Msg("proxy response")
 
 	if resp.StatusCode >= 400 {
-		return nil, parseErrorResponse(resp.StatusCode, body)
+		return nil, "", responseError(resp, body)
 	}
 
-	return body, nil
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+// responseError converts an upstream error response into an error. A 429
+// becomes a *RateLimitError carrying the Retry-After delay.
+func responseError(resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
+	return parseErrorResponse(resp.StatusCode, body)
 }
 
 // parseErrorResponse extracts a meaningful error message from an HTTP error response.
@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxQuoteTickers caps how many tickers one get_quotes call may compare.
+const maxQuoteTickers = 20
+
+// formatQuotes renders quotes as one markdown table for side-by-side
+// comparison. Each row carries its own status: "stale" when the quote is
+// older than common.FreshnessRealTimeQuote (or has no timestamp), "fresh"
+// otherwise. Nil quotes are skipped.
+func formatQuotes(quotes []*models.RealTimeQuote) string {
+	var b strings.Builder
+	b.WriteString("| Ticker | Price | Change | Change % | Volume | Status |\n")
+	b.WriteString("|--------|------:|-------:|---------:|-------:|--------|\n")
+	for _, q := range quotes {
+		if q == nil {
+			continue
+		}
+		status := "fresh"
+		if !common.IsFresh(q.Timestamp, common.FreshnessRealTimeQuote) {
+			status = "stale"
+		}
+		fmt.Fprintf(&b, "| %s | %.2f | %+.2f | %s | %d | %s |\n",
+			q.Code, q.Close, q.Change, common.FormatSignedPct(q.ChangePct), q.Volume, status)
+	}
+	return b.String()
+}
+
+// GetQuotesTool returns the mcp.Tool definition for get_quotes.
+func GetQuotesTool() mcp.Tool {
+	return mcp.NewTool("get_quotes",
+		mcp.WithDescription(fmt.Sprintf("Compare real-time quotes for several tickers (up to %d) in one table: price, change, change %%, volume, and whether each quote is fresh or stale.", maxQuoteTickers)),
+		mcp.WithArray("tickers",
+			mcp.Description("Tickers to quote, with exchange suffix (e.g. BHP.AU, AAPL.US)."),
+			mcp.Required(),
+			mcp.WithStringItems(),
+		),
+	)
+}
+
+// GetQuotesToolHandler returns a handler that fetches a quote per ticker from
+// vire-server and renders them with formatQuotes. Tickers whose quote fails
+// to load are listed under the table rather than failing the whole call.
+func GetQuotesToolHandler(proxy *MCPProxy) server.ToolHandlerFunc {
+	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var tickers []string
+		seen := map[string]bool{}
+		for _, t := range r.GetStringSlice("tickers", nil) {
+			t = strings.ToUpper(strings.TrimSpace(t))
+			if t == "" || seen[t] {
+				continue
+			}
+			seen[t] = true
+			tickers = append(tickers, t)
+		}
+		if len(tickers) == 0 {
+			return errorResult("Error: tickers is required"), nil
+		}
+		if len(tickers) > maxQuoteTickers {
+			return errorResult(fmt.Sprintf("Error: too many tickers (%d); maximum is %d", len(tickers), maxQuoteTickers)), nil
+		}
+
+		var quotes []*models.RealTimeQuote
+		var unavailable []string
+		for _, ticker := range tickers {
+			body, err := proxy.get(ctx, "/api/market/quote/"+url.PathEscape(ticker))
+			if err != nil {
+				unavailable = append(unavailable, ticker)
+				continue
+			}
+			var q models.RealTimeQuote
+			if err := json.Unmarshal(body, &q); err != nil {
+				unavailable = append(unavailable, ticker)
+				continue
+			}
+			if q.Code == "" {
+				q.Code = ticker
+			}
+			quotes = append(quotes, &q)
+		}
+		if len(quotes) == 0 {
+			return errorResult(fmt.Sprintf("Error: no quotes available for %s", strings.Join(unavailable, ", "))), nil
+		}
+
+		text := formatQuotes(quotes)
+		if len(unavailable) > 0 {
+			text += "\nUnavailable: " + strings.Join(unavailable, ", ") + "\n"
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{mcp.NewTextContent(text)},
+		}, nil
+	}
+}
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestFormatQuotes_FlagsStaleRows(t *testing.T) {
+	now := time.Now()
+	quotes := []*models.RealTimeQuote{
+		{Code: "BHP.AU", Close: 45.1, Change: 0.35, ChangePct: 0.78, Volume: 1234567, Timestamp: now.Add(-time.Minute)},
+		{Code: "CBA.AU", Close: 120, Change: -1, ChangePct: -0.83, Volume: 900, Timestamp: now.Add(-time.Hour)},
+		nil,
+		{Code: "NEW.AU", Close: 1.5},
+	}
+
+	lines := strings.Split(strings.TrimSpace(formatQuotes(quotes)), "\n")
+	want := []string{
+		"| Ticker | Price | Change | Change % | Volume | Status |",
+		"|--------|------:|-------:|---------:|-------:|--------|",
+		"| BHP.AU | 45.10 | +0.35 | +0.78% | 1234567 | fresh |",
+		"| CBA.AU | 120.00 | -1.00 | -0.83% | 900 | stale |",
+		"| NEW.AU | 1.50 | +0.00 | +0.00% | 0 | stale |",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected table:\n%s\nwant:\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGetQuotesToolHandler(t *testing.T) {
+	fresh := time.Now().UTC().Format(time.RFC3339)
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/market/quote/BHP.AU":
+			fmt.Fprintf(w, `{"code":"BHP.AU","close":45.1,"change":0.35,"change_p":0.78,"volume":100,"timestamp":%q}`, fresh)
+		case "/api/market/quote/CBA.AU":
+			w.Write([]byte(`{"close":120,"timestamp":"2026-01-02T00:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	req := mcpgo.CallToolRequest{}
+	req.Params.Arguments = map[string]interface{}{"tickers": []interface{}{"bhp.au", "CBA.AU", "BHP.AU", "BAD.AU"}}
+	result, err := GetQuotesToolHandler(NewMCPProxy(srv.URL, testLogger(), testConfig()))(t.Context(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %s", result.Content[0].(mcpgo.TextContent).Text)
+	}
+	text := result.Content[0].(mcpgo.TextContent).Text
+	for _, want := range []string{"| BHP.AU | 45.10 | +0.35 | +0.78% | 100 | fresh |", "| CBA.AU | 120.00 |", "| stale |", "Unavailable: BAD.AU"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in output:\n%s", want, text)
+		}
+	}
+	if len(paths) != 3 {
+		t.Errorf("expected duplicate tickers fetched once, got %v", paths)
+	}
+}
+
+func TestGetQuotesToolHandler_RequiresTickers(t *testing.T) {
+	result, err := GetQuotesToolHandler(NewMCPProxy(mockAPIServer.URL, testLogger(), testConfig()))(t.Context(), mcpgo.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Error("expected error result without tickers")
+	}
+}
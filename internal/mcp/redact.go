@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// redactedValue replaces logged secret values.
+const redactedValue = "[REDACTED]"
+
+// isSecretName reports whether a parameter or header name looks like it
+// carries a secret: *_key / *-key (including X-Vire-*-Key headers), key,
+// or anything containing token, password or secret.
+func isSecretName(name string) bool {
+	n := strings.ToLower(name)
+	return n == "key" ||
+		strings.HasSuffix(n, "_key") ||
+		strings.HasSuffix(n, "-key") ||
+		strings.Contains(n, "token") ||
+		strings.Contains(n, "password") ||
+		strings.Contains(n, "secret")
+}
+
+// redactValue returns value, or redactedValue if name is secret.
+func redactValue(name, value string) string {
+	if isSecretName(name) {
+		return redactedValue
+	}
+	return value
+}
+
+// redactedArgs renders tool-call arguments as JSON for logging, with secret
+// arguments redacted.
+func redactedArgs(args map[string]any) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+	safe := make(map[string]any, len(args))
+	for k, v := range args {
+		if isSecretName(k) {
+			safe[k] = redactedValue
+		} else {
+			safe[k] = v
+		}
+	}
+	out, err := json.Marshal(safe)
+	if err != nil {
+		return "{}"
+	}
+	return string(out)
+}
+
+// redactPath redacts secret query parameter values in a request path.
+func redactPath(path string) string {
+	base, rawQuery, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return base + "?" + redactedValue
+	}
+	changed := false
+	for k := range q {
+		if isSecretName(k) {
+			q.Set(k, redactedValue)
+			changed = true
+		}
+	}
+	if !changed {
+		return path
+	}
+	return base + "?" + q.Encode()
+}
+
+// redactError renders err for logging, redacting secret query values in
+// the URL of a transport error.
+func redactError(err error) string {
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		return ue.Op + " " + redactPath(ue.URL) + ": " + ue.Err.Error()
+	}
+	return err.Error()
+}
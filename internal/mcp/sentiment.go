@@ -0,0 +1,87 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+)
+
+// sentimentFields are the JSON keys whose string values are sentiment or
+// impact text (models.NewsIntelligence, HoldingReview.NewsImpact,
+// SnipeBuy.NewsSentiment, NewsItem.Sentiment, ...). Their values are passed
+// through the proxy's NeutralLanguage wherever they appear in a response.
+var sentimentFields = map[string]bool{
+	"overall_sentiment": true,
+	"news_sentiment":    true,
+	"sentiment":         true,
+	"impact_week":       true,
+	"impact_month":      true,
+	"impact_year":       true,
+	"news_impact":       true,
+	"impact":            true,
+}
+
+// neutralizeSentiment rewrites the sentimentFields values in a JSON body with
+// n, at any depth. Bodies that are not JSON, have no sentiment fields, or
+// need no rewriting are returned unchanged.
+func neutralizeSentiment(body []byte, n *common.NeutralLanguage) []byte {
+	if n == nil || !hasSentimentField(body) {
+		return body
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	if !neutralizeValue(v, n) {
+		return body
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return body
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// hasSentimentField is a cheap pre-check so most responses skip decoding.
+func hasSentimentField(body []byte) bool {
+	for key := range sentimentFields {
+		if bytes.Contains(body, []byte(`"`+key+`"`)) {
+			return true
+		}
+	}
+	return false
+}
+
+// neutralizeValue rewrites sentiment fields in v in place and reports
+// whether anything changed.
+func neutralizeValue(v any, n *common.NeutralLanguage) bool {
+	changed := false
+	switch val := v.(type) {
+	case map[string]any:
+		for key, child := range val {
+			if s, ok := child.(string); ok && sentimentFields[key] {
+				if out := n.Replace(s); out != s {
+					val[key] = out
+					changed = true
+				}
+				continue
+			}
+			if neutralizeValue(child, n) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range val {
+			if neutralizeValue(child, n) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestNeutralizeSentiment(t *testing.T) {
+	n := common.NewNeutralLanguage(common.DefaultNeutralTerms)
+
+	body := []byte(`{"summary":"Analysts turned bullish","news_intelligence":{"overall_sentiment":"Bullish","impact_week":"bearish short term","impact_year":"neutral"},"holdings":[{"news_impact":"BULLISH","price":1.10}]}`)
+	out := neutralizeSentiment(body, n)
+
+	var got struct {
+		Summary string `json:"summary"`
+		News    struct {
+			OverallSentiment string `json:"overall_sentiment"`
+			ImpactWeek       string `json:"impact_week"`
+			ImpactYear       string `json:"impact_year"`
+		} `json:"news_intelligence"`
+		Holdings []struct {
+			NewsImpact string      `json:"news_impact"`
+			Price      json.Number `json:"price"`
+		} `json:"holdings"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.News.OverallSentiment != "Positive" || got.News.ImpactWeek != "negative short term" || got.News.ImpactYear != "neutral" {
+		t.Errorf("unexpected news intelligence: %+v", got.News)
+	}
+	if got.Holdings[0].NewsImpact != "POSITIVE" || got.Holdings[0].Price != "1.10" {
+		t.Errorf("unexpected holding: %+v", got.Holdings[0])
+	}
+	if got.Summary != "Analysts turned bullish" {
+		t.Errorf("expected non-sentiment fields untouched, got %q", got.Summary)
+	}
+
+	for _, unchanged := range [][]byte{
+		[]byte(`{"overall_sentiment":"neutral"}`),
+		[]byte(`{"close":185.5}`),
+		[]byte(`not json "sentiment"`),
+	} {
+		if out := neutralizeSentiment(unchanged, n); string(out) != string(unchanged) {
+			t.Errorf("expected %s unchanged, got %s", unchanged, out)
+		}
+	}
+}
+
+func TestGenericHandler_StockDataSentimentNeutralized(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ticker":"BHP.AU","news_intelligence":{"summary":"Iron ore demand steady","overall_sentiment":"Bullish","impact_week":"Bearish","impact_month":"bullish","impact_year":"mixed"}}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{Name: "get_stock_data", Method: "GET", Path: "/api/market/stocks/BHP.AU"}
+	var req mcpgo.CallToolRequest
+	req.Params.Name = ct.Name
+	result, err := GenericToolHandler(NewMCPProxy(mockServer.URL, testLogger(), testConfig()), ct)(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %s", extractText(t, result.Content[0]))
+	}
+
+	var data struct {
+		News struct {
+			OverallSentiment string `json:"overall_sentiment"`
+			ImpactWeek       string `json:"impact_week"`
+			ImpactMonth      string `json:"impact_month"`
+			ImpactYear       string `json:"impact_year"`
+		} `json:"news_intelligence"`
+	}
+	if err := json.Unmarshal([]byte(extractText(t, result.Content[0])), &data); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if data.News.OverallSentiment != "Positive" {
+		t.Errorf("expected Bullish overall sentiment rendered as Positive, got %q", data.News.OverallSentiment)
+	}
+	if data.News.ImpactWeek != "Negative" || data.News.ImpactMonth != "positive" || data.News.ImpactYear != "mixed" {
+		t.Errorf("unexpected impact fields: %+v", data.News)
+	}
+}
+
+func TestNewMCPProxy_NeutralTermsFromConfig(t *testing.T) {
+	body := []byte(`{"news_intelligence":{"overall_sentiment":"Bullish","impact_week":"bearish"}}`)
+	tests := []struct {
+		name  string
+		terms map[string]string
+		want  string
+	}{
+		{"defaults", nil, `{"news_intelligence":{"impact_week":"negative","overall_sentiment":"Positive"}}`},
+		{"custom overrides defaults", map[string]string{"bullish": "upbeat"}, `{"news_intelligence":{"impact_week":"bearish","overall_sentiment":"Upbeat"}}`},
+		{"empty disables", map[string]string{}, string(body)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.MCP.NeutralTerms = tt.terms
+			p := NewMCPProxy("http://localhost:4242", testLogger(), cfg)
+			if got := string(neutralizeSentiment(body, p.neutral)); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// portalStatus is the portal_status tool response.
+type portalStatus struct {
+	Portal   versionInfo    `json:"portal"`
+	Upstream upstreamStatus `json:"upstream"`
+	Catalog  catalogStatus  `json:"catalog"`
+	User     string         `json:"user,omitempty"`
+}
+
+// upstreamStatus reports whether vire-server answered the health check.
+type upstreamStatus struct {
+	URL       string `json:"url"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// catalogStatus reports the loaded tool catalog.
+type catalogStatus struct {
+	Tools    int        `json:"tools"`
+	LoadedAt *time.Time `json:"loaded_at,omitempty"`
+}
+
+// PortalStatusTool returns the mcp.Tool definition for portal_status.
+func PortalStatusTool() mcp.Tool {
+	return mcp.NewTool("portal_status",
+		mcp.WithDescription("Diagnose the Vire connection: portal version, whether vire-server is reachable, how many catalog tools are loaded, and the authenticated user. Always available, even when the tool catalog failed to load."),
+	)
+}
+
+// PortalStatusToolHandler returns a handler that reports portal and upstream
+// status. catalogStatusFn supplies the current catalog tool count and load time
+// (see Handler.CatalogStatus). It never fails: an unreachable vire-server is
+// reported in the result.
+func PortalStatusToolHandler(proxy *MCPProxy, catalogStatusFn func() (int, time.Time)) server.ToolHandlerFunc {
+	return func(ctx context.Context, r mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		result := portalStatus{
+			Portal: versionInfo{
+				Version: common.GetVersion(),
+				Build:   common.GetBuild(),
+				Commit:  common.GetGitCommit(),
+			},
+			Upstream: upstreamStatus{URL: proxy.ServerURL(), Reachable: true},
+		}
+
+		if _, err := proxy.get(ctx, "/api/health"); err != nil {
+			result.Upstream.Reachable = false
+			result.Upstream.Error = redactError(err)
+		}
+
+		count, loadedAt := catalogStatusFn()
+		result.Catalog.Tools = count
+		if !loadedAt.IsZero() {
+			result.Catalog.LoadedAt = &loadedAt
+		}
+
+		if uc, ok := GetUserContext(ctx); ok {
+			result.User = uc.UserID
+		}
+
+		out, err := json.Marshal(result)
+		if err != nil {
+			return errorResult("failed to marshal portal status"), nil
+		}
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{mcp.NewTextContent(string(out))},
+		}, nil
+	}
+}
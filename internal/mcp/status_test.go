@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPortalStatus_AvailableWhenCatalogFetchFails(t *testing.T) {
+	cfg := testConfig()
+	cfg.API.URL = mockAPIServer.URL // Returns 503, so FetchCatalog fails
+	cfg.MCP.CatalogRetries = 1
+
+	h := NewHandler(cfg, testLogger())
+	defer h.Close()
+
+	if len(h.Catalog()) != 0 {
+		t.Fatalf("expected empty catalog, got %d tools", len(h.Catalog()))
+	}
+	if h.mcpSrv.GetTool("portal_status") == nil {
+		t.Fatal("expected portal_status to be registered without a catalog")
+	}
+
+	result := callTool(t, h.mcpSrv, "portal_status", map[string]interface{}{})
+	if result.IsError {
+		t.Fatalf("unexpected tool error: %s", extractText(t, result.Content[0]))
+	}
+
+	var status portalStatus
+	if err := json.Unmarshal([]byte(extractText(t, result.Content[0])), &status); err != nil {
+		t.Fatalf("failed to unmarshal status: %v", err)
+	}
+	if status.Catalog.Tools != 0 || status.Catalog.LoadedAt != nil {
+		t.Errorf("expected empty, never-loaded catalog, got %+v", status.Catalog)
+	}
+	if status.Upstream.Reachable || status.Upstream.Error == "" {
+		t.Errorf("expected unreachable upstream with error, got %+v", status.Upstream)
+	}
+	if status.Portal.Version == "" {
+		t.Error("expected portal version")
+	}
+}
+
+func TestPortalStatus_ReportsUserAndCatalog(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	loadedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	handler := PortalStatusToolHandler(NewMCPProxy(srv.URL, testLogger(), testConfig()), func() (int, time.Time) {
+		return 42, loadedAt
+	})
+
+	ctx := WithUserContext(context.Background(), UserContext{UserID: "user-123"})
+	result, err := handler(ctx, mcpgo.CallToolRequest{})
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %+v", err, result)
+	}
+
+	var status portalStatus
+	if err := json.Unmarshal([]byte(extractText(t, result.Content[0])), &status); err != nil {
+		t.Fatalf("failed to unmarshal status: %v", err)
+	}
+	if status.User != "user-123" {
+		t.Errorf("expected user-123, got %q", status.User)
+	}
+	if !status.Upstream.Reachable || status.Upstream.URL != srv.URL {
+		t.Errorf("expected reachable upstream at %s, got %+v", srv.URL, status.Upstream)
+	}
+	if status.Catalog.Tools != 42 || status.Catalog.LoadedAt == nil || !status.Catalog.LoadedAt.Equal(loadedAt) {
+		t.Errorf("expected 42 tools loaded at %v, got %+v", loadedAt, status.Catalog)
+	}
+}
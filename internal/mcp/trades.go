@@ -0,0 +1,76 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+)
+
+// formatParam is the portal-side argument that asks a ResponseHolding tool
+// for its trade history as CSV. It has no In location, so it is never forwarded to
+// vire-server.
+var formatParam = CatalogParam{
+	Name:        "format",
+	Type:        "string",
+	Description: `Response format. "csv" returns the holding's trade history as CSV (date, type, units, price, value) for spreadsheets.`,
+	Enum:        []string{"json", "csv"},
+}
+
+// tradeDateLayouts are the date formats Navexa trades arrive in.
+var tradeDateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02", "02/01/2006"}
+
+// decodeHolding reads a holding from a ResponseHolding tool response.
+func decodeHolding(body []byte) (models.Holding, error) {
+	var wrapped struct {
+		Holding *models.Holding `json:"holding"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.Holding != nil {
+		return *wrapped.Holding, nil
+	}
+	var h models.Holding
+	if err := json.Unmarshal(body, &h); err != nil {
+		return models.Holding{}, err
+	}
+	if h.Ticker == "" {
+		return models.Holding{}, fmt.Errorf("response has no holding")
+	}
+	return h, nil
+}
+
+// formatTradeHistoryCSV renders h's trades as CSV with a header row. Dates
+// are ISO 8601 (YYYY-MM-DD) and numbers are unformatted so spreadsheets
+// read them as values.
+func formatTradeHistoryCSV(h models.Holding) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"date", "type", "units", "price", "value"})
+	for _, t := range h.Trades {
+		if t == nil {
+			continue
+		}
+		_ = w.Write([]string{isoTradeDate(t.Date), t.Type, formatRaw(t.Units), formatRaw(t.Price), formatRaw(t.Value)})
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// isoTradeDate returns date as YYYY-MM-DD, or unchanged if it is in no
+// known layout.
+func isoTradeDate(date string) string {
+	for _, layout := range tradeDateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format("2006-01-02")
+		}
+	}
+	return date
+}
+
+// formatRaw formats v with no grouping or currency symbol.
+func formatRaw(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
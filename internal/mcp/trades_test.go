@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+func TestFormatTradeHistoryCSV(t *testing.T) {
+	h := models.Holding{
+		Ticker: "BHP",
+		Trades: []*models.NavexaTrade{
+			{Type: "buy", Date: "2024-03-05T00:00:00Z", Units: 1000, Price: 45.5, Value: 45500},
+			{Type: "sell", Date: "17/09/2025", Units: 250.5, Price: 1234.567, Value: 309258.0335},
+		},
+	}
+
+	got := formatTradeHistoryCSV(h)
+	want := "date,type,units,price,value\n" +
+		"2024-03-05,buy,1000,45.5,45500\n" +
+		"2025-09-17,sell,250.5,1234.567,309258.0335\n"
+	if got != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatTradeHistoryCSV_NoTrades(t *testing.T) {
+	if got := formatTradeHistoryCSV(models.Holding{Ticker: "BHP"}); got != "date,type,units,price,value\n" {
+		t.Errorf("expected header only, got %q", got)
+	}
+}
+
+func TestGenericHandler_StockFormatCSV(t *testing.T) {
+	var receivedQuery string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"holding":{"ticker":"BHP","trades":[{"type":"buy","date":"2024-03-05","units":10,"price":40,"value":400}]}}`))
+	}))
+	defer mockServer.Close()
+
+	ct := CatalogTool{Name: "get_portfolio_stock", Method: "GET", Path: "/api/stock", Response: ResponseHolding}
+	s := mcpserver.NewMCPServer("test", "1.0.0", mcpserver.WithToolCapabilities(true))
+	p := NewMCPProxy(mockServer.URL, testLogger(), testConfig())
+	s.AddTool(BuildMCPTool(ct), GenericToolHandler(p, ct))
+
+	result := callTool(t, s, "get_portfolio_stock", map[string]interface{}{"format": "csv"})
+	if result.IsError {
+		t.Fatalf("expected success, got: %s", extractText(t, result.Content[0]))
+	}
+	if receivedQuery != "" {
+		t.Errorf("expected format not to be forwarded, got query %q", receivedQuery)
+	}
+	text := extractText(t, result.Content[0])
+	if text != "date,type,units,price,value\n2024-03-05,buy,10,40,400\n" {
+		t.Errorf("unexpected CSV: %q", text)
+	}
+
+	result = callTool(t, s, "get_portfolio_stock", map[string]interface{}{})
+	if text := extractText(t, result.Content[0]); !strings.Contains(text, `"holding"`) {
+		t.Errorf("expected JSON passthrough without format, got: %s", text)
+	}
+
+	result = callTool(t, s, "get_portfolio_stock", map[string]interface{}{"format": "xlsx"})
+	if !result.IsError {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestBuildMCPTool_FormatOnlyOnHoldingTools(t *testing.T) {
+	tool := BuildMCPTool(CatalogTool{Name: "get_portfolio_stock", Method: "GET", Path: "/api/stock"})
+	if _, ok := tool.InputSchema.Properties["format"]; ok {
+		t.Error("expected no format without response \"holding\"")
+	}
+	tool = BuildMCPTool(CatalogTool{Name: "get_position", Method: "GET", Path: "/api/position", Response: ResponseHolding})
+	if _, ok := tool.InputSchema.Properties["format"]; !ok {
+		t.Error("expected format on a tool with response \"holding\"")
+	}
+}
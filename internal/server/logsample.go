@@ -0,0 +1,61 @@
+package server
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
+)
+
+// logSampler decides which request logs to write for paths matched by
+// logging.sample rules. Each rule keeps its own counter, so the first of
+// every Every matching successful requests is logged. Non-2xx responses are
+// always logged. A nil sampler logs everything.
+type logSampler struct {
+	rules []*logSampleRule
+}
+
+type logSampleRule struct {
+	path   string
+	prefix bool
+	every  uint64
+	count  atomic.Uint64
+}
+
+// newLogSampler builds a sampler from the config rules, or returns nil when
+// there are none. Rules with an empty path or negative rate are skipped
+// (Validate reports them).
+func newLogSampler(rules []config.LogSampleRule) *logSampler {
+	var ls logSampler
+	for _, r := range rules {
+		path := strings.TrimSpace(r.Path)
+		if path == "" || r.Every < 0 {
+			continue
+		}
+		rule := &logSampleRule{every: uint64(r.Every)}
+		rule.path, rule.prefix = strings.CutSuffix(path, "*")
+		ls.rules = append(ls.rules, rule)
+	}
+	if len(ls.rules) == 0 {
+		return nil
+	}
+	return &ls
+}
+
+// allow reports whether a request for path that finished with status should
+// be logged. The first matching rule applies.
+func (ls *logSampler) allow(path string, status int) bool {
+	if ls == nil || status < 200 || status >= 300 {
+		return true
+	}
+	for _, r := range ls.rules {
+		if path != r.path && !(r.prefix && strings.HasPrefix(path, r.path)) {
+			continue
+		}
+		if r.every == 0 {
+			return false
+		}
+		return (r.count.Add(1)-1)%r.every == 0
+	}
+	return true
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/handlers"
+)
+
+// logTailPath is the SSE endpoint streaming lines appended to the log file.
+const logTailPath = "/api/admin/logs/tail"
+
+// logTailPollInterval is how often the tail checks the log file for new data.
+var logTailPollInterval = 500 * time.Millisecond
+
+// logTailHeartbeat is how often an SSE comment is sent on an idle stream so
+// proxies don't close it.
+const logTailHeartbeat = 15 * time.Second
+
+// maxLogTailLine caps a buffered partial line; longer lines are sent in pieces.
+const maxLogTailLine = 64 << 10
+
+// registerLogTailRoutes mounts GET /api/admin/logs/tail behind the admin
+// token. Without file logging (logging.outputs lacks "file") the path
+// returns 404 rather than falling through to the vire-server proxy.
+func (s *Server) registerLogTailRoutes(mux *http.ServeMux) {
+	path := s.app.Config.Logging.FileOutputPath()
+	if path == "" {
+		mux.Handle(logTailPath, http.NotFoundHandler())
+		return
+	}
+	mux.Handle("GET "+logTailPath, s.adminTokenMiddleware(s.app.Config.Server.AdminToken, s.handleLogTail(path)))
+}
+
+// handleLogTail streams lines appended to the log file at path as SSE
+// "data:" events, starting from the current end of the file. The stream
+// follows the file across rotation and truncation and ends when the client
+// disconnects.
+func (s *Server) handleLogTail(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			handlers.WriteError(w, http.StatusInternalServerError, "streaming not supported")
+			return
+		}
+
+		tail := newLogTail(path)
+		defer tail.Close()
+		if err := tail.seekEnd(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn().Str("path", path).Str("error", err.Error()).Msg("log tail: failed to open log file")
+		}
+
+		// The stream outlives the server's write timeout
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		poll := time.NewTicker(logTailPollInterval)
+		defer poll.Stop()
+		heartbeat := time.NewTicker(logTailHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := io.WriteString(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-poll.C:
+				lines, err := tail.next()
+				if err != nil {
+					s.logger.Warn().Str("path", path).Str("error", err.Error()).Msg("log tail: read failed")
+				}
+				if len(lines) == 0 {
+					continue
+				}
+				for _, line := range lines {
+					if _, err := fmt.Fprintf(w, "data: %s\n\n", line); err != nil {
+						return
+					}
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// logTail reads complete lines appended to a file. It reopens the path when
+// it points at a different file (rename or symlink rotation) and rewinds
+// when the file shrinks (truncation).
+type logTail struct {
+	path    string
+	f       *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+}
+
+func newLogTail(path string) *logTail {
+	return &logTail{path: path}
+}
+
+// seekEnd opens the file positioned at its end, so only lines written from
+// now on are returned.
+func (t *logTail) seekEnd() error {
+	if err := t.open(); err != nil {
+		return err
+	}
+	off, err := t.f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	t.offset = off
+	return nil
+}
+
+// open (re)opens the file at path from the start.
+func (t *logTail) open() error {
+	t.Close()
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	t.f, t.info, t.offset, t.partial = f, info, 0, nil
+	return nil
+}
+
+// next returns the complete lines appended since the last call. Lines left
+// in a file that was rotated away are returned before following the new file.
+func (t *logTail) next() ([]string, error) {
+	if t.f == nil {
+		// Not created yet, or gone mid-rotation: follow it from the start
+		if err := t.open(); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	lines, err := t.read()
+	if err != nil {
+		return lines, err
+	}
+
+	info, err := os.Stat(t.path)
+	switch {
+	case err != nil:
+		// Renamed away and not yet recreated; retry on the next poll
+		lines = t.flushPartial(lines)
+		t.Close()
+	case !os.SameFile(info, t.info):
+		lines = t.flushPartial(lines)
+		if err := t.open(); err != nil {
+			return lines, err
+		}
+		more, err := t.read()
+		return append(lines, more...), err
+	case info.Size() < t.offset:
+		if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+			return lines, err
+		}
+		t.offset, t.partial = 0, nil
+		more, err := t.read()
+		return append(lines, more...), err
+	}
+	return lines, nil
+}
+
+// read consumes everything available from the open file and splits it into
+// complete lines, keeping any trailing partial line for the next read.
+func (t *logTail) read() ([]string, error) {
+	buf := make([]byte, 32<<10)
+	var lines []string
+	for {
+		n, err := t.f.Read(buf)
+		if n > 0 {
+			t.offset += int64(n)
+			t.partial = append(t.partial, buf[:n]...)
+			for {
+				i := bytes.IndexByte(t.partial, '\n')
+				if i < 0 {
+					break
+				}
+				lines = append(lines, strings.TrimRight(string(t.partial[:i]), "\r"))
+				t.partial = t.partial[i+1:]
+			}
+			if len(t.partial) > maxLogTailLine {
+				lines = append(lines, string(t.partial))
+				t.partial = nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+// flushPartial appends an unterminated last line of a rotated-away file.
+func (t *logTail) flushPartial(lines []string) []string {
+	if len(t.partial) > 0 {
+		lines = append(lines, strings.TrimRight(string(t.partial), "\r"))
+		t.partial = nil
+	}
+	return lines
+}
+
+// Close releases the open file, if any.
+func (t *logTail) Close() {
+	if t.f != nil {
+		t.f.Close()
+		t.f = nil
+	}
+}
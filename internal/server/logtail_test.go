@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
+)
+
+func appendLog(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("open log: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatalf("write log: %v", err)
+	}
+}
+
+func nextLines(t *testing.T, tail *logTail) []string {
+	t.Helper()
+	lines, err := tail.next()
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	return lines
+}
+
+func TestLogTail_StreamsAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portal.log")
+	appendLog(t, path, "before tail\n")
+
+	tail := newLogTail(path)
+	defer tail.Close()
+	if err := tail.seekEnd(); err != nil {
+		t.Fatalf("seekEnd: %v", err)
+	}
+
+	if got := nextLines(t, tail); len(got) != 0 {
+		t.Errorf("expected existing lines to be skipped, got %v", got)
+	}
+
+	appendLog(t, path, "first\nsecond\r\npart")
+	if got, want := nextLines(t, tail), []string{"first", "second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	appendLog(t, path, "ial\n")
+	if got, want := nextLines(t, tail), []string{"partial"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected partial line to be joined, got %v", got)
+	}
+}
+
+func TestLogTail_FollowsRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "portal.log")
+	appendLog(t, path, "")
+
+	tail := newLogTail(path)
+	defer tail.Close()
+	if err := tail.seekEnd(); err != nil {
+		t.Fatalf("seekEnd: %v", err)
+	}
+
+	// Lines written just before the rename are still delivered
+	appendLog(t, path, "old-1\n")
+	if err := os.Rename(path, filepath.Join(dir, "portal.log.1")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if got, want := nextLines(t, tail), []string{"old-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v before the new file exists, got %v", want, got)
+	}
+
+	appendLog(t, path, "new-1\nnew-2\n")
+	if got, want := nextLines(t, tail), []string{"new-1", "new-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v from the new file, got %v", want, got)
+	}
+
+	// Replaced in one step (e.g. a symlink swapped to a fresh file)
+	appendLog(t, path, "tail-of-old\n")
+	if err := os.Rename(path, filepath.Join(dir, "portal.log.2")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	appendLog(t, path, "newer\n")
+	if got, want := nextLines(t, tail), []string{"tail-of-old", "newer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v across the swap, got %v", want, got)
+	}
+}
+
+func TestLogTail_FollowsTruncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portal.log")
+	appendLog(t, path, "a long line that will be truncated away\n")
+
+	tail := newLogTail(path)
+	defer tail.Close()
+	if err := tail.seekEnd(); err != nil {
+		t.Fatalf("seekEnd: %v", err)
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	appendLog(t, path, "fresh\n")
+	if got, want := nextLines(t, tail), []string{"fresh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v after truncation, got %v", want, got)
+	}
+}
+
+func TestRoutes_LogTail(t *testing.T) {
+	t.Run("not found without file logging", func(t *testing.T) {
+		cfg := config.NewDefaultConfig()
+		cfg.MCP.CatalogRetries = 0
+		cfg.Logging.Outputs = []string{"console"}
+		cfg.Server.AdminToken = "admin-s3cret"
+		srv := New(newTestAppWithConfig(t, cfg))
+
+		req := httptest.NewRequest("GET", logTailPath, nil)
+		req.Header.Set("Authorization", "Bearer admin-s3cret")
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 without file logging, got %d", w.Code)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "portal.log")
+	appendLog(t, path, "existing\n")
+
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.Logging.Outputs = []string{"file"}
+	cfg.Logging.FilePath = path
+	cfg.Server.AdminToken = "admin-s3cret"
+	srv := New(newTestAppWithConfig(t, cfg))
+
+	t.Run("forbidden without token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", logTailPath, nil)
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected 403 without token, got %d", w.Code)
+		}
+	})
+
+	t.Run("streams appended lines", func(t *testing.T) {
+		orig := logTailPollInterval
+		logTailPollInterval = 10 * time.Millisecond
+		defer func() { logTailPollInterval = orig }()
+
+		ts := httptest.NewServer(srv.Handler())
+		defer ts.Close()
+
+		req, _ := http.NewRequestWithContext(t.Context(), "GET", ts.URL+logTailPath, nil)
+		req.Header.Set("Authorization", "Bearer admin-s3cret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected 200, got %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("expected text/event-stream, got %q", ct)
+		}
+
+		appendLog(t, path, "hello from the log\n")
+
+		got := make(chan string, 1)
+		go func() {
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+					got <- line
+					return
+				}
+			}
+		}()
+		select {
+		case line := <-got:
+			if line != "hello from the log" {
+				t.Errorf("expected the appended line only, got %q", line)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the appended line")
+		}
+	})
+}
@@ -1,206 +1,556 @@
 package server
 
 import (
-	"context"
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
+	"github.com/bobmcallan/vire-portal/internal/handlers"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 	"github.com/google/uuid"
 )
 
-// contextKey is the type for context keys used in middleware.
-type contextKey string
-
-const correlationIDKey contextKey = "correlation_id"
+// maxRequestIDLen bounds client-supplied request IDs so they can be safely
+// echoed in headers and logs.
+const maxRequestIDLen = 128
 
 // withMiddleware wraps the router with the middleware chain.
 func (s *Server) withMiddleware(handler http.Handler) http.Handler {
 	// Applied in reverse order (last applied = first executed)
-	handler = s.recoveryMiddleware(handler)
-	handler = s.maxBodySizeMiddleware(1 << 20)(handler) // 1MB limit
-	handler = s.csrfMiddleware(handler)
-	handler = s.corsMiddleware(handler)
-	handler = s.securityHeadersMiddleware(handler)
+	handler = s.maxBodySizeMiddleware(s.app.Config.Server.MaxBodyBytes)(handler)
+	handler = s.csrfMiddleware(s.app.Config.Auth.CookieName())(handler)
+	handler = s.corsMiddleware(s.app.Config.CORS)(handler)
+	handler = s.maintenanceMiddleware(handler)
+	handler = s.trailingSlashMiddleware(s.app.Config.Server.RedirectTrailingSlash)(handler)
+	handler = s.basePathMiddleware(s.app.Config.BasePath())(handler)
+	handler = s.securityHeadersMiddleware(s.app.Config.Security)(handler)
 	handler = s.loggingMiddleware(handler)
 	handler = s.correlationIDMiddleware(handler)
+	handler = s.recoveryMiddleware(handler) // outermost, so a panic in any middleware is recovered
 	return handler
 }
 
-// correlationIDMiddleware extracts or generates a correlation ID for request tracking.
+// correlationIDMiddleware extracts or generates a request ID for request
+// tracking. An incoming X-Request-ID (or X-Correlation-ID) is reused when it
+// is a safe token; otherwise a UUID is generated. The ID is stored in the
+// request context (see common.RequestIDFromContext) and echoed in both
+// response headers.
 func (s *Server) correlationIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		correlationID := r.Header.Get("X-Request-ID")
+		correlationID := r.Header.Get(common.RequestIDHeader)
 		if correlationID == "" {
 			correlationID = r.Header.Get("X-Correlation-ID")
 		}
-		if correlationID == "" {
+		if !validRequestID(correlationID) {
 			correlationID = uuid.New().String()
 		}
 
+		w.Header().Set(common.RequestIDHeader, correlationID)
 		w.Header().Set("X-Correlation-ID", correlationID)
 
-		ctx := context.WithValue(r.Context(), correlationIDKey, correlationID)
+		ctx := common.WithRequestID(r.Context(), correlationID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// loggingMiddleware logs HTTP requests and responses.
+// validRequestID reports whether id is a non-empty, bounded token of
+// letters, digits and "-_.:" that is safe to echo in headers and logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// loggingMiddleware logs HTTP requests and responses, thinned by the
+// logging.sample rules. A request that panics is logged as the 500 that
+// recoveryMiddleware will send, and the panic is passed on.
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
 		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		defer func() {
+			if err := recover(); err != nil {
+				if err != http.ErrAbortHandler && !rw.wroteHeader {
+					rw.statusCode = http.StatusInternalServerError
+				}
+				s.logRequest(r, rw, start)
+				panic(err)
+			}
+		}()
 		next.ServeHTTP(rw, r)
+		s.logRequest(r, rw, start)
+	})
+}
 
-		durationMs := time.Since(start).Milliseconds()
-		correlationID, _ := r.Context().Value(correlationIDKey).(string)
+// logRequest writes the access log line for r unless it is sampled out.
+func (s *Server) logRequest(r *http.Request, rw *responseWriter, start time.Time) {
+	if !s.logSampler.allow(r.URL.Path, rw.statusCode) {
+		return
+	}
 
-		event := s.logger.Debug()
-		if rw.statusCode >= 500 {
-			event = s.logger.Error()
-		} else if rw.statusCode >= 400 {
-			event = s.logger.Warn()
-		}
+	durationMs := time.Since(start).Milliseconds()
+	correlationID := common.RequestIDFromContext(r.Context())
 
-		event.
-			Str("correlation_id", correlationID).
-			Str("method", r.Method).
-			Str("path", r.URL.Path).
-			Int("status", rw.statusCode).
-			Int64("duration_ms", durationMs).
-			Int("bytes", rw.bytesWritten).
-			Str("remote", r.RemoteAddr).
-			Msg("HTTP request")
-	})
-}
+	event := s.logger.Debug()
+	if rw.statusCode >= 500 {
+		event = s.logger.Error()
+	} else if rw.statusCode >= 400 {
+		event = s.logger.Warn()
+	}
 
-// corsMiddleware handles CORS headers.
-func (s *Server) corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	event.
+		Str("correlation_id", correlationID).
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Int("status", rw.statusCode).
+		Int64("duration_ms", durationMs).
+		Int("bytes", rw.bytesWritten).
+		Str("remote", r.RemoteAddr).
+		Msg("HTTP request")
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// corsMiddleware handles CORS for the /mcp and /api/ endpoints using the
+// configured origin allow-list. Allowed origins are reflected back (or "*" is
+// sent when the list contains "*" and credentials are disabled); requests
+// from other origins get no CORS headers, and their preflights are rejected
+// with 403. Other paths pass through untouched.
+func (s *Server) corsMiddleware(cfg config.CORSConfig) func(http.Handler) http.Handler {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	if methods == "" {
+		methods = "GET, POST, PUT, DELETE, OPTIONS"
+	}
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	if headers == "" {
+		headers = "Content-Type, Authorization"
+	}
+	wildcard := false
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" && !cfg.AllowCredentials {
+			wildcard = true
 		}
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/mcp" && !strings.HasPrefix(r.URL.Path, "/api/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			origin := r.Header.Get("Origin")
+			if !wildcard {
+				w.Header().Add("Vary", "Origin")
+			}
+			allowed := true
+			switch {
+			case wildcard:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin != "" && originAllowed(origin, cfg.AllowedOrigins):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			default:
+				allowed = false
+			}
+
+			// Only CORS preflights are answered here; a plain OPTIONS
+			// falls through so handlers can advertise their Allow header.
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if !allowed && origin != "" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				if allowed {
+					w.Header().Set("Access-Control-Allow-Methods", methods)
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+					if cfg.MaxAgeSeconds > 0 {
+						w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAgeSeconds))
+					}
+				}
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originAllowed reports whether origin matches an entry in allowed. Entries
+// match exactly (case-insensitive) or, for "scheme://*.domain" entries, any
+// subdomain of domain over the same scheme. "*" entries never match here.
+func originAllowed(origin string, allowed []string) bool {
+	origin = strings.ToLower(origin)
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" || a == "*" {
+			continue
+		}
+		if a == origin {
+			return true
+		}
+		scheme, host, ok := strings.Cut(a, "://*.")
+		if !ok {
+			continue
+		}
+		prefix := scheme + "://"
+		if !strings.HasPrefix(origin, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(origin, prefix)
+		if strings.HasSuffix(rest, "."+host) && !strings.ContainsAny(strings.TrimSuffix(rest, "."+host), "/@") {
+			return true
+		}
+	}
+	return false
 }
 
-// recoveryMiddleware recovers from panics and returns 500 error.
+// recoveryMiddleware recovers from panics anywhere below it in the chain,
+// including the other middleware. It runs outside correlationIDMiddleware,
+// so the request ID is read from the response header that middleware set
+// (when it got that far). The panic and its stack are logged with the
+// request ID; the client gets a generic 500 JSON error, or nothing more if
+// the response had already started. http.ErrAbortHandler is re-panicked so
+// net/http aborts the response as intended.
 func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 		defer func() {
-			if err := recover(); err != nil {
-				correlationID, _ := r.Context().Value(correlationIDKey).(string)
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
 
-				s.logger.Error().
-					Str("correlation_id", correlationID).
-					Str("error", fmt.Sprintf("%v", err)).
-					Str("path", r.URL.Path).
-					Msg("panic recovered")
+			s.logger.Error().
+				Str("correlation_id", rw.Header().Get(common.RequestIDHeader)).
+				Str("error", fmt.Sprintf("%v", err)).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Str("stack", string(debug.Stack())).
+				Msg("panic recovered")
 
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			if !rw.wroteHeader {
+				handlers.WriteErrorCode(rw, http.StatusInternalServerError, handlers.ErrCodeInternal, "internal server error")
 			}
 		}()
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rw, r)
 	})
 }
 
-// securityHeadersMiddleware sets standard security headers on all responses.
-func (s *Server) securityHeadersMiddleware(next http.Handler) http.Handler {
+// maintenancePage is served to browsers while maintenance mode is enabled.
+// It only depends on /static/, which stays reachable during maintenance.
+const maintenancePage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<link rel="stylesheet" href="/static/css/portal.css">
+<title>VIRE — Maintenance</title>
+</head>
+<body>
+<main class="landing">
+<div class="landing-inner">
+<div class="landing-hero">
+<h1 class="landing-title">VIRE</h1>
+<p class="landing-tagline">VIRE is undergoing scheduled maintenance. Please check back in a few minutes.</p>
+</div>
+</div>
+</main>
+</body>
+</html>
+`
+
+// basePathMiddleware mounts the portal under basePath (e.g. "/vire"). The
+// prefix is stripped before routing and recorded for templates (see
+// handlers.BasePath), and root-relative redirect Locations are prefixed.
+// Requests outside the prefix get 404; the bare prefix redirects to
+// basePath + "/". An empty basePath disables the middleware.
+func (s *Server) basePathMiddleware(basePath string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if basePath == "" {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == basePath {
+				target := basePath + "/"
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, http.StatusMovedPermanently)
+				return
+			}
+			rest, ok := strings.CutPrefix(r.URL.Path, basePath)
+			if !ok || !strings.HasPrefix(rest, "/") {
+				http.NotFound(w, r)
+				return
+			}
+
+			r2 := handlers.WithBasePath(r, basePath)
+			r2.URL = new(url.URL)
+			*r2.URL = *r.URL
+			r2.URL.Path = rest
+			r2.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, basePath)
+			next.ServeHTTP(&basePathWriter{ResponseWriter: w, basePath: basePath}, r2)
+		})
+	}
+}
+
+// basePathWriter prefixes root-relative Location headers with basePath so
+// handler redirects ("/dashboard") stay under the mount. It delegates
+// Flush for SSE streaming.
+type basePathWriter struct {
+	http.ResponseWriter
+	basePath    string
+	wroteHeader bool
+}
+
+func (bw *basePathWriter) WriteHeader(code int) {
+	if !bw.wroteHeader {
+		bw.wroteHeader = true
+		if loc := bw.Header().Get("Location"); strings.HasPrefix(loc, "/") && !strings.HasPrefix(loc, "//") {
+			bw.Header().Set("Location", bw.basePath+loc)
+		}
+	}
+	bw.ResponseWriter.WriteHeader(code)
+}
+
+func (bw *basePathWriter) Write(b []byte) (int, error) {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	return bw.ResponseWriter.Write(b)
+}
+
+func (bw *basePathWriter) Flush() {
+	if f, ok := bw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (bw *basePathWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
+
+// trailingSlashMiddleware 301-redirects GET and HEAD requests for "/path/"
+// to the canonical "/path", keeping the query string. The root and paths
+// under /static/ and /debug/pprof/ (whose index must end in a slash) are left
+// alone, as are other methods (a redirect would drop the body). Disabled when
+// enabled is false.
+func (s *Server) trailingSlashMiddleware(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) ||
+				path == "/" || !strings.HasSuffix(path, "/") || strings.HasPrefix(path, "/static/") ||
+				strings.HasPrefix(path, pprofPrefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			target := "/" + strings.Trim(path, "/")
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		})
+	}
+}
+
+// adminTokenMiddleware only lets through requests with an
+// "Authorization: Bearer <token>" header matching token; others get 403.
+// An empty token rejects every request.
+func (s *Server) adminTokenMiddleware(token string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "DENY")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-		w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; font-src 'self' https://fonts.gstatic.com; script-src 'self' 'unsafe-inline' 'unsafe-eval' https://cdn.jsdelivr.net; connect-src 'self' https://cdn.jsdelivr.net")
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			s.logger.Warn().Str("path", r.URL.Path).Str("remote_addr", r.RemoteAddr).Msg("admin token rejected")
+			handlers.WriteError(w, http.StatusForbidden, "Forbidden")
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-// maxBodySizeMiddleware limits the size of request bodies.
-// The MCP endpoint gets a larger limit (10MB) for JSON-RPC payloads.
+// maintenanceMiddleware returns 503 while maintenance mode is enabled:
+// a JSON {"status":"maintenance"} for /api/* and /mcp, and a maintenance
+// page otherwise. /api/health (for load balancers) and /static/ (for the
+// page's stylesheet) pass through.
+func (s *Server) maintenanceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if !s.maintenance.Load() || path == "/api/health" || strings.HasPrefix(path, "/static/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", "60")
+		w.Header().Set("Cache-Control", "no-store")
+		if strings.HasPrefix(path, "/api/") || path == "/mcp" || strings.HasPrefix(path, "/mcp/") {
+			handlers.WriteJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "maintenance"})
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(maintenancePage))
+	})
+}
+
+// securityHeadersMiddleware sets standard security headers on all responses.
+// The Content-Security-Policy comes from the security config and is sent
+// report-only when csp_report_only is set.
+func (s *Server) securityHeadersMiddleware(cfg config.SecurityConfig) func(http.Handler) http.Handler {
+	csp := cfg.CSP()
+	cspHeader := cfg.CSPHeader()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("X-XSS-Protection", "1; mode=block")
+			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			w.Header().Set(cspHeader, csp)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// maxBodySizeMiddleware limits the size of POST, PUT and PATCH request bodies.
+// Requests whose declared Content-Length exceeds the limit are rejected with a
+// 413 JSON error; other bodies are wrapped in http.MaxBytesReader so handlers
+// can report 413 when reading fails. A non-positive maxBytes uses the default.
+// /mcp and /mcp/* are left to the MCP handlers' own message limit.
 func (s *Server) maxBodySizeMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	if maxBytes <= 0 {
+		maxBytes = config.DefaultMaxBodyBytes
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			// The MCP handlers apply mcp.max_message_bytes themselves and
+			// answer with a JSON-RPC error rather than this REST one.
+			if r.URL.Path == "/mcp" || strings.HasPrefix(r.URL.Path, "/mcp/") {
+				next.ServeHTTP(w, r)
+				return
+			}
 			if r.Body != nil {
-				limit := maxBytes
-				if r.URL.Path == "/mcp" {
-					limit = 10 << 20 // 10MB for MCP
+				if r.ContentLength > maxBytes {
+					handlers.WriteBodyTooLarge(w, maxBytes)
+					return
 				}
-				r.Body = http.MaxBytesReader(w, r.Body, limit)
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// csrfMiddleware provides CSRF protection for server-rendered forms.
+// csrfMiddleware provides CSRF protection for server-rendered forms and for
+// API calls authenticated by the session cookie.
 // Safe methods (GET, HEAD, OPTIONS) are allowed without a token.
-// API routes (/api/) are skipped (they use Bearer tokens).
+// API routes (/api/) are skipped unless the request is authenticated by the
+// session cookie (named sessionCookie) rather than a Bearer token, since only
+// then can a cross-site request ride on the browser's credentials.
 // Unsafe methods require a matching _csrf cookie and X-CSRF-Token header.
 // A _csrf cookie is set on GET responses for JavaScript to read.
-func (s *Server) csrfMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip safe methods
-		if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
-			// Set CSRF cookie on GET for JS to read
-			if r.Method == "GET" {
-				cookie, err := r.Cookie("_csrf")
-				if err != nil || cookie.Value == "" {
-					token := generateCSRFToken()
-					http.SetCookie(w, &http.Cookie{
-						Name:     "_csrf",
-						Value:    token,
-						Path:     "/",
-						HttpOnly: false, // JS needs to read it
-						SameSite: http.SameSiteStrictMode,
-					})
+func (s *Server) csrfMiddleware(sessionCookie string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Skip safe methods
+			if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
+				// Set CSRF cookie on GET for JS to read
+				if r.Method == "GET" {
+					cookie, err := r.Cookie("_csrf")
+					if err != nil || cookie.Value == "" {
+						token := generateCSRFToken()
+						http.SetCookie(w, &http.Cookie{
+							Name:     "_csrf",
+							Value:    token,
+							Path:     "/",
+							HttpOnly: false, // JS needs to read it
+							SameSite: http.SameSiteStrictMode,
+						})
+					}
 				}
+				next.ServeHTTP(w, r)
+				return
 			}
-			next.ServeHTTP(w, r)
-			return
-		}
 
-		// Skip API routes (Bearer tokens), MCP endpoint (JSON-RPC),
-		// and OAuth endpoints (called by external OAuth clients)
-		if strings.HasPrefix(r.URL.Path, "/api/") ||
-			strings.HasPrefix(r.URL.Path, "/mcp") ||
-			r.URL.Path == "/authorize" ||
-			r.URL.Path == "/register" ||
-			r.URL.Path == "/token" {
-			next.ServeHTTP(w, r)
-			return
-		}
+			// Skip API calls not authenticated by the session cookie, the MCP
+			// endpoint (JSON-RPC), and OAuth endpoints (called by external
+			// OAuth clients)
+			if (strings.HasPrefix(r.URL.Path, "/api/") && !cookieAuthenticated(r, sessionCookie)) ||
+				strings.HasPrefix(r.URL.Path, "/mcp") ||
+				r.URL.Path == "/authorize" ||
+				r.URL.Path == "/register" ||
+				r.URL.Path == "/token" {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		// Validate CSRF token for unsafe methods
-		cookie, err := r.Cookie("_csrf")
-		if err != nil || cookie.Value == "" {
-			http.Error(w, "Forbidden: missing CSRF token", http.StatusForbidden)
-			return
-		}
+			// Validate CSRF token for unsafe methods
+			cookie, err := r.Cookie("_csrf")
+			if err != nil || cookie.Value == "" {
+				http.Error(w, "Forbidden: missing CSRF token", http.StatusForbidden)
+				return
+			}
 
-		// Accept CSRF token from header (AJAX) or form field (HTML forms)
-		token := r.Header.Get("X-CSRF-Token")
-		if token == "" {
-			token = r.FormValue("_csrf")
-		}
-		if token == "" || token != cookie.Value {
-			http.Error(w, "Forbidden: invalid CSRF token", http.StatusForbidden)
-			return
-		}
+			// Accept CSRF token from header (AJAX) or form field (HTML forms)
+			token := r.Header.Get("X-CSRF-Token")
+			if token == "" {
+				token = r.FormValue("_csrf")
+			}
+			if token == "" || token != cookie.Value {
+				http.Error(w, "Forbidden: invalid CSRF token", http.StatusForbidden)
+				return
+			}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// cookieAuthenticated reports whether r is authenticated by the session
+// cookie named sessionCookie rather than a Bearer token, i.e. whether a
+// cross-site request could ride on credentials the browser sends itself.
+func cookieAuthenticated(r *http.Request, sessionCookie string) bool {
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return false
+	}
+	cookie, err := r.Cookie(sessionCookie)
+	return err == nil && cookie.Value != ""
 }
 
 // generateCSRFToken creates a random token for CSRF protection.
@@ -220,14 +570,17 @@ type responseWriter struct {
 	http.ResponseWriter
 	statusCode   int
 	bytesWritten int
+	wroteHeader  bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
+	rw.wroteHeader = true
 	rw.ResponseWriter.WriteHeader(code)
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
 	n, err := rw.ResponseWriter.Write(b)
 	rw.bytesWritten += n
 	return n, err
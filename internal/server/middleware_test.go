@@ -1,12 +1,17 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/bobmcallan/vire-portal/internal/config"
+	"github.com/bobmcallan/vire-portal/internal/handlers"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
@@ -21,8 +26,8 @@ func TestCorrelationIDMiddleware_GeneratesID(t *testing.T) {
 	s := newTestServer()
 
 	handler := s.correlationIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id, ok := r.Context().Value(correlationIDKey).(string)
-		if !ok || id == "" {
+		id := common.RequestIDFromContext(r.Context())
+		if id == "" {
 			t.Error("expected correlation ID in context")
 		}
 		w.WriteHeader(http.StatusOK)
@@ -43,7 +48,7 @@ func TestCorrelationIDMiddleware_UsesProvidedID(t *testing.T) {
 	s := newTestServer()
 
 	handler := s.correlationIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id, _ := r.Context().Value(correlationIDKey).(string)
+		id := common.RequestIDFromContext(r.Context())
 		if id != "test-request-id" {
 			t.Errorf("expected test-request-id, got %s", id)
 		}
@@ -65,7 +70,7 @@ func TestCorrelationIDMiddleware_UsesCorrelationIDHeader(t *testing.T) {
 	s := newTestServer()
 
 	handler := s.correlationIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		id, _ := r.Context().Value(correlationIDKey).(string)
+		id := common.RequestIDFromContext(r.Context())
 		if id != "existing-correlation-id" {
 			t.Errorf("expected existing-correlation-id, got %s", id)
 		}
@@ -79,16 +84,67 @@ func TestCorrelationIDMiddleware_UsesCorrelationIDHeader(t *testing.T) {
 	handler.ServeHTTP(w, req)
 }
 
+func TestCorrelationIDMiddleware_EchoesRequestIDHeader(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.correlationIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Generated when absent
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+	generated := w.Header().Get("X-Request-ID")
+	if generated == "" {
+		t.Fatal("expected generated X-Request-ID header")
+	}
+	if w.Header().Get("X-Correlation-ID") != generated {
+		t.Errorf("expected X-Correlation-ID to match X-Request-ID %q, got %q", generated, w.Header().Get("X-Correlation-ID"))
+	}
+
+	// Passed through when present
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "trace-abc.123")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Request-ID"); got != "trace-abc.123" {
+		t.Errorf("expected X-Request-ID trace-abc.123, got %q", got)
+	}
+}
+
+func TestCorrelationIDMiddleware_ReplacesUnsafeID(t *testing.T) {
+	s := newTestServer()
+
+	var seen string
+	handler := s.correlationIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = common.RequestIDFromContext(r.Context())
+	}))
+
+	for _, bad := range []string{"has space", "<script>", strings.Repeat("a", maxRequestIDLen+1)} {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Request-ID", bad)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if seen == bad || seen == "" {
+			t.Errorf("expected unsafe ID %q to be replaced, got %q", bad, seen)
+		}
+		if w.Header().Get("X-Request-ID") != seen {
+			t.Errorf("expected echoed ID to match context ID %q", seen)
+		}
+	}
+}
+
 // --- CORS Middleware ---
 
 func TestCORSMiddleware_SetsHeaders(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.corsMiddleware(config.NewDefaultConfig().CORS)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
-	req := httptest.NewRequest("GET", "/test", nil)
+	req := httptest.NewRequest("GET", "/api/test", nil)
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -104,11 +160,12 @@ func TestCORSMiddleware_SetsHeaders(t *testing.T) {
 func TestCORSMiddleware_HandlesPreflight(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.corsMiddleware(config.NewDefaultConfig().CORS)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("next handler should not be called for OPTIONS")
 	}))
 
-	req := httptest.NewRequest("OPTIONS", "/test", nil)
+	req := httptest.NewRequest("OPTIONS", "/api/test", nil)
+	req.Header.Set("Access-Control-Request-Method", "POST")
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -118,6 +175,165 @@ func TestCORSMiddleware_HandlesPreflight(t *testing.T) {
 	}
 }
 
+func TestCORSMiddleware_PlainOPTIONSReachesHandler(t *testing.T) {
+	s := newTestServer()
+
+	called := false
+	handler := s.corsMiddleware(config.NewDefaultConfig().CORS)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest("OPTIONS", "/api/health", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if !called {
+		t.Fatal("expected non-preflight OPTIONS to reach the handler")
+	}
+	if w.Header().Get("Allow") == "" {
+		t.Error("expected handler's Allow header on response")
+	}
+}
+
+func restrictedCORS() config.CORSConfig {
+	cfg := config.NewDefaultConfig().CORS
+	cfg.AllowedOrigins = []string{"https://app.example.com", "https://*.vire.dev"}
+	cfg.AllowCredentials = true
+	return cfg
+}
+
+func TestCORSMiddleware_ReflectsAllowedOrigins(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.corsMiddleware(restrictedCORS())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, origin := range []string{"https://app.example.com", "https://portal.vire.dev", "https://a.b.vire.dev"} {
+		req := httptest.NewRequest("GET", "/api/portfolios", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+			t.Errorf("expected origin %s to be reflected, got %q", origin, got)
+		}
+		if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+			t.Errorf("expected credentials header for %s", origin)
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Errorf("expected Vary: Origin for %s, got %q", origin, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestCORSMiddleware_RejectsUnlistedOrigins(t *testing.T) {
+	s := newTestServer()
+
+	called := false
+	handler := s.corsMiddleware(restrictedCORS())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	origins := []string{
+		"https://evil.com",
+		"http://app.example.com",          // scheme mismatch
+		"https://vire.dev",                // wildcard does not match apex
+		"https://vire.dev.evil.com",       // suffix trick
+		"https://evil.com/.vire.dev",      // path trick
+		"https://app.example.com.evil.io", // prefix trick
+	}
+	for _, origin := range origins {
+		req := httptest.NewRequest("OPTIONS", "/mcp", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected preflight from %s to be rejected with 403, got %d", origin, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("expected no allow-origin for %s, got %q", origin, got)
+		}
+	}
+	if called {
+		t.Error("next handler should not be called for preflight")
+	}
+}
+
+func TestCORSMiddleware_PreflightAllowedOrigin(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.corsMiddleware(restrictedCORS())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called for OPTIONS")
+	}))
+
+	req := httptest.NewRequest("OPTIONS", "/mcp", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for allowed preflight, got %d", w.Code)
+	}
+	if !strings.Contains(w.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("expected POST in allowed methods, got %q", w.Header().Get("Access-Control-Allow-Methods"))
+	}
+	if w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("expected max age 600, got %q", w.Header().Get("Access-Control-Max-Age"))
+	}
+}
+
+func TestCORSMiddleware_NeverWildcardWithCredentials(t *testing.T) {
+	s := newTestServer()
+
+	cfg := config.NewDefaultConfig().CORS
+	cfg.AllowCredentials = true // allowed_origins is still ["*"]
+	handler := s.corsMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/api/portfolios", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no allow-origin when credentials are enabled with *, got %q", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("expected no credentials header for unlisted origin")
+	}
+}
+
+func TestCORSMiddleware_SkipsNonAPIPaths(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.corsMiddleware(config.NewDefaultConfig().CORS)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("expected no CORS headers on page routes, got %q", got)
+	}
+}
+
 // --- Recovery Middleware ---
 
 func TestRecoveryMiddleware_CatchesPanic(t *testing.T) {
@@ -135,6 +351,51 @@ func TestRecoveryMiddleware_CatchesPanic(t *testing.T) {
 	if w.Code != http.StatusInternalServerError {
 		t.Errorf("expected status 500 after panic, got %d", w.Code)
 	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON error, got Content-Type %q", ct)
+	}
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected JSON body, got %q", w.Body.String())
+	}
+	if resp["status"] != "error" || resp["code"] != handlers.ErrCodeInternal {
+		t.Errorf("unexpected error body: %v", resp)
+	}
+	if strings.Contains(w.Body.String(), "test panic") || strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("panic details leaked to client: %s", w.Body.String())
+	}
+}
+
+func TestRecoveryMiddleware_ResponseAlreadyStarted(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.recoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("late panic")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if w.Code != http.StatusAccepted || w.Body.String() != "partial" {
+		t.Errorf("expected the started response untouched, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryMiddleware_RepanicsAbortHandler(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.recoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler re-panicked, got %v", err)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
 }
 
 func TestRecoveryMiddleware_PassesThrough(t *testing.T) {
@@ -154,6 +415,34 @@ func TestRecoveryMiddleware_PassesThrough(t *testing.T) {
 	}
 }
 
+func TestRecoveryMiddleware_OutsideCorrelationAndLogging(t *testing.T) {
+	var logs bytes.Buffer
+	s := &Server{logger: common.NewDedicatedLoggerWithOutput("debug", &logs)}
+
+	handler := s.recoveryMiddleware(s.correlationIDMiddleware(s.loggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("test panic")
+	}))))
+
+	req := httptest.NewRequest("GET", "/panic", nil)
+	req.Header.Set("X-Request-ID", "req-panic-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 after panic, got %d", w.Code)
+	}
+	if got := w.Header().Get("X-Request-ID"); got != "req-panic-1" {
+		t.Errorf("expected request ID on the 500, got %q", got)
+	}
+	out := logs.String()
+	if !strings.Contains(out, "panic recovered") || !strings.Contains(out, "req-panic-1") {
+		t.Errorf("expected panic logged with the request ID, got %s", out)
+	}
+	if !strings.Contains(out, "HTTP request") || !strings.Contains(out, "500") {
+		t.Errorf("expected the panicking request in the access log as a 500, got %s", out)
+	}
+}
+
 // --- Logging Middleware ---
 
 func TestLoggingMiddleware_CapturesStatusCode(t *testing.T) {
@@ -174,6 +463,53 @@ func TestLoggingMiddleware_CapturesStatusCode(t *testing.T) {
 	}
 }
 
+func TestLogSampler_OneInTen(t *testing.T) {
+	ls := newLogSampler([]config.LogSampleRule{{Path: "/api/health", Every: 10}})
+
+	logged := 0
+	for i := 0; i < 100; i++ {
+		if ls.allow("/api/health", http.StatusOK) {
+			logged++
+		}
+	}
+	if logged != 10 {
+		t.Errorf("expected 10 of 100 matching requests logged, got %d", logged)
+	}
+
+	for i := 0; i < 20; i++ {
+		if !ls.allow("/api/health", http.StatusServiceUnavailable) {
+			t.Fatal("expected non-2xx responses to always be logged")
+		}
+	}
+	if !ls.allow("/dashboard", http.StatusOK) {
+		t.Error("expected unmatched paths to always be logged")
+	}
+}
+
+func TestLogSampler_PrefixAndErrorsOnly(t *testing.T) {
+	ls := newLogSampler([]config.LogSampleRule{{Path: "/mcp*", Every: 0}})
+
+	if ls.allow("/mcp", http.StatusOK) || ls.allow("/mcp/abc123", http.StatusAccepted) {
+		t.Error("expected successful /mcp requests to be dropped with every = 0")
+	}
+	if !ls.allow("/mcp", http.StatusUnauthorized) {
+		t.Error("expected failed /mcp requests to be logged")
+	}
+	if !ls.allow("/api/mcp", http.StatusOK) {
+		t.Error("expected prefix rules to match from the start of the path")
+	}
+}
+
+func TestLogSampler_NoRules(t *testing.T) {
+	if ls := newLogSampler(nil); ls != nil {
+		t.Fatal("expected nil sampler without rules")
+	}
+	var ls *logSampler
+	if !ls.allow("/api/health", http.StatusOK) {
+		t.Error("expected a nil sampler to log everything")
+	}
+}
+
 // --- responseWriter ---
 
 func TestResponseWriter_CapturesBytes(t *testing.T) {
@@ -200,7 +536,7 @@ func TestResponseWriter_CapturesBytes(t *testing.T) {
 func TestSecurityHeadersMiddleware_SetsAllHeaders(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.securityHeadersMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.securityHeadersMiddleware(config.SecurityConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -242,7 +578,7 @@ func TestSecurityHeadersMiddleware_SetsAllHeaders(t *testing.T) {
 func TestSecurityHeadersMiddleware_PassesThroughResponse(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.securityHeadersMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.securityHeadersMiddleware(config.SecurityConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusTeapot)
 		w.Write([]byte("I'm a teapot"))
 	}))
@@ -257,8 +593,97 @@ func TestSecurityHeadersMiddleware_PassesThroughResponse(t *testing.T) {
 	}
 }
 
+func TestSecurityHeadersMiddleware_DefaultCSPAllowsAlpineCDN(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.securityHeadersMiddleware(config.SecurityConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	csp := w.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "https://cdn.jsdelivr.net") {
+		t.Errorf("expected CSP to allow the Alpine CDN host, got %q", csp)
+	}
+	if !strings.Contains(csp, "style-src 'self' 'unsafe-inline'") {
+		t.Errorf("expected CSP to allow inline styles for x-cloak, got %q", csp)
+	}
+	if w.Header().Get("Content-Security-Policy-Report-Only") != "" {
+		t.Error("expected no report-only CSP by default")
+	}
+}
+
+func TestSecurityHeadersMiddleware_ConfiguredReportOnlyCSP(t *testing.T) {
+	s := newTestServer()
+	cfg := config.SecurityConfig{
+		ContentSecurityPolicy: "default-src 'self'; script-src 'self' https://cdn.example.com",
+		CSPReportOnly:         true,
+	}
+
+	handler := s.securityHeadersMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if got := w.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("expected no enforcing CSP in report-only mode, got %q", got)
+	}
+	if got := w.Header().Get("Content-Security-Policy-Report-Only"); got != cfg.ContentSecurityPolicy {
+		t.Errorf("expected configured report-only CSP, got %q", got)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" || w.Header().Get("X-Frame-Options") != "DENY" {
+		t.Error("expected the other security headers in report-only mode")
+	}
+}
+
 // --- Max Body Size Middleware ---
 
+func TestTrailingSlashMiddleware_Redirects(t *testing.T) {
+	s := newTestServer()
+	handler := s.trailingSlashMiddleware(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method, path string
+		wantCode     int
+		wantLocation string
+	}{
+		{"GET", "/settings/", http.StatusMovedPermanently, "/settings"},
+		{"GET", "/dashboard/?tab=1", http.StatusMovedPermanently, "/dashboard?tab=1"},
+		{"HEAD", "/help//", http.StatusMovedPermanently, "/help"},
+		{"GET", "/", http.StatusOK, ""},
+		{"GET", "/settings", http.StatusOK, ""},
+		{"GET", "/static/css/", http.StatusOK, ""},
+		{"POST", "/api/feedback/", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.wantCode || w.Header().Get("Location") != tt.wantLocation {
+			t.Errorf("%s %s: expected %d %q, got %d %q", tt.method, tt.path, tt.wantCode, tt.wantLocation, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestTrailingSlashMiddleware_Disabled(t *testing.T) {
+	s := newTestServer()
+	handler := s.trailingSlashMiddleware(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/settings/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected pass-through when disabled, got %d", w.Code)
+	}
+}
+
 func TestMaxBodySizeMiddleware_AllowsSmallBody(t *testing.T) {
 	s := newTestServer()
 
@@ -321,12 +746,79 @@ func TestMaxBodySizeMiddleware_AllowsGETWithNoBody(t *testing.T) {
 	}
 }
 
+func TestMaxBodySizeMiddleware_Returns413JSON(t *testing.T) {
+	s := newTestServer()
+
+	called := false
+	handler := s.maxBodySizeMiddleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{"POST", "PUT", "PATCH"} {
+		called = false
+		req := httptest.NewRequest(method, "/test", strings.NewReader(strings.Repeat("x", 100)))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: expected status 413, got %d", method, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s: expected JSON content type, got %q", method, ct)
+		}
+		if !strings.Contains(w.Body.String(), `"status":"error"`) {
+			t.Errorf("%s: expected JSON error body, got %s", method, w.Body.String())
+		}
+		if called {
+			t.Errorf("%s: expected handler not to be called for oversized body", method)
+		}
+	}
+}
+
+func TestMaxBodySizeMiddleware_StreamedBodyReturnsMaxBytesError(t *testing.T) {
+	s := newTestServer()
+
+	var readErr error
+	handler := s.maxBodySizeMiddleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	// Unknown length (chunked) bodies are only caught while reading.
+	req := httptest.NewRequest("POST", "/test", strings.NewReader(strings.Repeat("x", 100)))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Errorf("expected *http.MaxBytesError, got %v", readErr)
+	}
+}
+
+func TestMaxBodySizeMiddleware_IgnoresNonWriteMethods(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.maxBodySizeMiddleware(10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("DELETE", "/test", strings.NewReader(strings.Repeat("x", 100)))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status 200 for DELETE, got %d", w.Code)
+	}
+}
+
 // --- CSRF Middleware ---
 
 func TestCSRFMiddleware_AllowsGETWithoutToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -343,7 +835,7 @@ func TestCSRFMiddleware_AllowsGETWithoutToken(t *testing.T) {
 func TestCSRFMiddleware_AllowsHEADWithoutToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -360,7 +852,7 @@ func TestCSRFMiddleware_AllowsHEADWithoutToken(t *testing.T) {
 func TestCSRFMiddleware_AllowsOPTIONSWithoutToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -377,7 +869,7 @@ func TestCSRFMiddleware_AllowsOPTIONSWithoutToken(t *testing.T) {
 func TestCSRFMiddleware_RejectsPOSTWithoutToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called without CSRF token")
 	}))
 
@@ -394,7 +886,7 @@ func TestCSRFMiddleware_RejectsPOSTWithoutToken(t *testing.T) {
 func TestCSRFMiddleware_RejectsPUTWithoutToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called without CSRF token")
 	}))
 
@@ -411,7 +903,7 @@ func TestCSRFMiddleware_RejectsPUTWithoutToken(t *testing.T) {
 func TestCSRFMiddleware_RejectsDELETEWithoutToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called without CSRF token")
 	}))
 
@@ -428,7 +920,7 @@ func TestCSRFMiddleware_RejectsDELETEWithoutToken(t *testing.T) {
 func TestCSRFMiddleware_AllowsPOSTWithMatchingToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -448,7 +940,7 @@ func TestCSRFMiddleware_AllowsPOSTWithMatchingToken(t *testing.T) {
 func TestCSRFMiddleware_RejectsMismatchedToken(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called with mismatched CSRF token")
 	}))
 
@@ -467,7 +959,7 @@ func TestCSRFMiddleware_RejectsMismatchedToken(t *testing.T) {
 func TestCSRFMiddleware_SkipsAPIRoutes(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -485,7 +977,7 @@ func TestCSRFMiddleware_SkipsAPIRoutes(t *testing.T) {
 func TestCSRFMiddleware_SetsCookieOnGET(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -519,7 +1011,7 @@ func TestCSRFMiddleware_SetsCookieOnGET(t *testing.T) {
 func TestCSP_AllowsSelfScripts(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.securityHeadersMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.securityHeadersMiddleware(config.SecurityConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -561,12 +1053,11 @@ func TestCSP_AllowsSelfScripts(t *testing.T) {
 // --- Stress Tests: CSRF Bypass for API Routes ---
 
 func TestCSRFMiddleware_SkipsLoginRoute(t *testing.T) {
-	// POST /api/auth/login is under /api/ so CSRF is skipped.
-	// This is by design since /api/ routes use Bearer tokens.
-	// Verify this works correctly.
+	// POST /api/auth/login without a session cookie carries no ambient
+	// credentials, so CSRF is skipped.
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -576,17 +1067,80 @@ func TestCSRFMiddleware_SkipsLoginRoute(t *testing.T) {
 	handler.ServeHTTP(w, req)
 
 	if w.Code == http.StatusForbidden {
-		t.Error("POST /api/auth/login should not be blocked by CSRF — it's under /api/")
+		t.Error("POST /api/auth/login without a session should not be blocked by CSRF")
 	}
 	if w.Code != http.StatusOK {
 		t.Errorf("expected 200, got %d", w.Code)
 	}
 }
 
+func TestCSRFMiddleware_CookieAuthenticatedAPIRequiresToken(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, path := range []string{"/api/preferences/portfolio", "/api/settings/test-key", "/api/portfolios/SMSF/sync", "/api/portfolios/SMSF/strategy"} {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{}`))
+		req.AddCookie(&http.Cookie{Name: "vire_session", Value: "session-jwt"})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 for a cookie-authenticated call without a CSRF token, got %d", path, w.Code)
+		}
+
+		req = httptest.NewRequest("POST", path, strings.NewReader(`{}`))
+		req.AddCookie(&http.Cookie{Name: "vire_session", Value: "session-jwt"})
+		req.AddCookie(&http.Cookie{Name: "_csrf", Value: "csrf-token"})
+		req.Header.Set("X-CSRF-Token", "csrf-token")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200 with a matching CSRF token, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestCSRFMiddleware_SkipsBearerAuthenticatedAPI(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("PUT", "/api/portfolios/SMSF/strategy", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", "Bearer api-token")
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: "session-jwt"})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a Bearer-authenticated API call, got %d", w.Code)
+	}
+}
+
+func TestCSRFMiddleware_ConfiguredSessionCookie(t *testing.T) {
+	s := newTestServer()
+
+	handler := s.csrfMiddleware("portal_sid")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/api/preferences/timezone", strings.NewReader(`{}`))
+	req.AddCookie(&http.Cookie{Name: "portal_sid", Value: "session-jwt"})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a call authenticated by the configured cookie, got %d", w.Code)
+	}
+}
+
 func TestCSRFMiddleware_SkipsMCPRoute(t *testing.T) {
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
@@ -604,7 +1158,7 @@ func TestCSRFMiddleware_DoesNotSkipNonAPIPost(t *testing.T) {
 	// A form POST to a non-API, non-MCP route MUST require CSRF.
 	s := newTestServer()
 
-	handler := s.csrfMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	handler := s.csrfMiddleware(config.DefaultSessionCookieName)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		t.Error("handler should not be called without CSRF token for non-API POST")
 	}))
 
@@ -652,7 +1206,7 @@ func TestCorrelationIDMiddleware_SanitizesInput(t *testing.T) {
 
 // --- Stress Tests: Max Body Size ---
 
-func TestMaxBodySizeMiddleware_MCPGetsLargerLimit(t *testing.T) {
+func TestMaxBodySizeMiddleware_MCPLeftToMCPHandler(t *testing.T) {
 	s := newTestServer()
 
 	var readErr error
@@ -661,15 +1215,18 @@ func TestMaxBodySizeMiddleware_MCPGetsLargerLimit(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	}))
 
-	// 2MB body to /mcp should succeed (limit is 10MB)
+	// 2MB body to /mcp passes the middleware; the MCP handler applies
+	// mcp.max_message_bytes and answers with a JSON-RPC error.
 	largeBody := strings.Repeat("x", 2<<20)
-	req := httptest.NewRequest("POST", "/mcp", strings.NewReader(largeBody))
-	w := httptest.NewRecorder()
+	for _, path := range []string{"/mcp", "/mcp/dev-uid"} {
+		req := httptest.NewRequest("POST", path, strings.NewReader(largeBody))
+		w := httptest.NewRecorder()
 
-	handler.ServeHTTP(w, req)
+		handler.ServeHTTP(w, req)
 
-	if readErr != nil {
-		t.Errorf("2MB body to /mcp should be allowed (10MB limit), got error: %v", readErr)
+		if readErr != nil {
+			t.Errorf("2MB body to %s should pass the middleware, got error: %v", path, readErr)
+		}
 	}
 }
 
@@ -689,7 +1246,10 @@ func TestMaxBodySizeMiddleware_NonMCPRejectsLargeBody(t *testing.T) {
 
 	handler.ServeHTTP(w, req)
 
-	if readErr == nil {
-		t.Error("2KB body to non-MCP route should be rejected (1KB limit)")
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("2KB body to non-MCP route should be rejected with 413 (1KB limit), got %d", w.Code)
+	}
+	if readErr != nil {
+		t.Errorf("handler should not run for a declared oversized body, got read error: %v", readErr)
 	}
 }
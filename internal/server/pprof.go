@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// pprofPrefix is where the net/http/pprof handlers are mounted.
+const pprofPrefix = "/debug/pprof/"
+
+// registerPprofRoutes mounts net/http/pprof under /debug/pprof/ behind the
+// admin token. When server.pprof is off the prefix returns 404 rather than
+// falling through to the landing page.
+func (s *Server) registerPprofRoutes(mux *http.ServeMux) {
+	if !s.app.Config.Server.Pprof {
+		mux.Handle(pprofPrefix, http.NotFoundHandler())
+		return
+	}
+
+	pprofMux := http.NewServeMux()
+	pprofMux.HandleFunc(pprofPrefix, pprof.Index)
+	pprofMux.HandleFunc(pprofPrefix+"cmdline", pprof.Cmdline)
+	pprofMux.HandleFunc(pprofPrefix+"profile", pprof.Profile)
+	pprofMux.HandleFunc(pprofPrefix+"symbol", pprof.Symbol)
+	pprofMux.HandleFunc(pprofPrefix+"trace", pprof.Trace)
+	mux.Handle(pprofPrefix, s.adminTokenMiddleware(s.app.Config.Server.AdminToken, pprofMux))
+}
@@ -149,6 +149,90 @@ func TestAPIProxy_StressSuite(t *testing.T) {
 		}
 	})
 
+	t.Run("UserIDHeader", func(t *testing.T) {
+		var received []string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r.Header.Get("X-Vire-User-ID"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"ok"}`))
+		}))
+		defer backend.Close()
+
+		application.Config.API.URL = backend.URL
+		srv := New(application)
+
+		// Authenticated: the session's user is forwarded, not the client's claim
+		req := httptest.NewRequest("POST", "/api/portfolios/test/sync-all", nil)
+		req.Header.Set("X-Vire-User-ID", "mallory")
+		req.AddCookie(&http.Cookie{Name: "vire_session", Value: createTestJWT("alice", application.Config.Auth.JWTSecret)})
+		addCSRF(req)
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+		// Anonymous: a spoofed header is dropped
+		req = httptest.NewRequest("POST", "/api/portfolios/test/sync-all", nil)
+		req.Header.Set("X-Vire-User-ID", "alice")
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(received) != 2 || received[0] != "alice" || received[1] != "" {
+			t.Errorf("expected X-Vire-User-ID [alice, \"\"], got %q", received)
+		}
+	})
+
+	t.Run("TimezoneHeader", func(t *testing.T) {
+		var received []string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r.Header.Get("X-Vire-Timezone"))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"ok"}`))
+		}))
+		defer backend.Close()
+
+		application.Config.API.URL = backend.URL
+		application.Config.User.Timezone = "America/New_York"
+		defer func() { application.Config.User.Timezone = "" }()
+		srv := New(application)
+
+		// Configured zone, overriding a client-supplied header
+		req := httptest.NewRequest("POST", "/api/portfolios/test/sync-all", nil)
+		req.Header.Set("X-Vire-Timezone", "Europe/London")
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+		// The user's vire_timezone cookie wins
+		req = httptest.NewRequest("POST", "/api/portfolios/test/sync-all", nil)
+		req.AddCookie(&http.Cookie{Name: "vire_timezone", Value: "Australia/Sydney"})
+		srv.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(received) != 2 || received[0] != "America/New_York" || received[1] != "Australia/Sydney" {
+			t.Errorf("expected X-Vire-Timezone [America/New_York, Australia/Sydney], got %q", received)
+		}
+	})
+
+	t.Run("RedirectToNonAllowListedHost", func(t *testing.T) {
+		hit := false
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hit = true
+		}))
+		defer other.Close()
+
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, other.URL+r.URL.Path, http.StatusTemporaryRedirect)
+		}))
+		defer backend.Close()
+
+		application.Config.API.URL = backend.URL
+		srv := New(application)
+
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/portfolios", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected 503 for a redirect off the allow-list, got %d", w.Code)
+		}
+		if hit {
+			t.Error("expected non-allow-listed host not to be contacted")
+		}
+	})
+
 	t.Run("ResponseHeaderInjection", func(t *testing.T) {
 		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Set-Cookie", "vire_session=evil-token; Path=/; HttpOnly")
@@ -384,6 +468,42 @@ func TestAPIProxy_StressSuite(t *testing.T) {
 		}
 	})
 
+	t.Run("CacheKeyedByTimezone", func(t *testing.T) {
+		var received []string
+		backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = append(received, r.Header.Get("X-Vire-Timezone"))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"timezone":"` + r.Header.Get("X-Vire-Timezone") + `"}`))
+		}))
+		defer backend.Close()
+
+		application.Config.API.URL = backend.URL
+		token := createTestJWT("timezone-cache-user", application.Config.Auth.JWTSecret)
+		srv := New(application)
+
+		get := func(timezone string) string {
+			req := httptest.NewRequest("GET", "/api/portfolios", nil)
+			req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+			if timezone != "" {
+				req.AddCookie(&http.Cookie{Name: "vire_timezone", Value: timezone})
+			}
+			w := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(w, req)
+			return w.Body.String()
+		}
+
+		get("")
+		// A new timezone is not answered from the entry cached for the old one
+		if body := get("America/New_York"); !strings.Contains(body, "America/New_York") {
+			t.Errorf("expected a response rendered for America/New_York, got %s", body)
+		}
+		get("America/New_York")
+		if len(received) != 2 {
+			t.Errorf("expected 2 backend hits (one per timezone), got %d: %q", len(received), received)
+		}
+	})
+
 	t.Run("WriteInvalidatesCache", func(t *testing.T) {
 		var backendHits int
 		var mu sync.Mutex
@@ -424,6 +544,7 @@ func TestAPIProxy_StressSuite(t *testing.T) {
 		// PUT to /api/portfolios — invalidates cached entries containing this path
 		req2 := httptest.NewRequest("PUT", "/api/portfolios", strings.NewReader(`{"name":"SMSF"}`))
 		req2.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+		addCSRF(req2)
 		req2.Header.Set("Content-Type", "application/json")
 		w2 := httptest.NewRecorder()
 		srv.Handler().ServeHTTP(w2, req2)
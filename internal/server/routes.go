@@ -8,6 +8,7 @@ import (
 
 	"github.com/bobmcallan/vire-portal/internal/cache"
 	"github.com/bobmcallan/vire-portal/internal/handlers"
+	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
 // setupRoutes configures all HTTP routes.
@@ -37,6 +38,7 @@ func (s *Server) setupRoutes() *http.ServeMux {
 	mux.HandleFunc("GET /m/{portfolio...}", s.app.MobileDashboardHandler.ServeHTTP)
 	mux.HandleFunc("GET /strategy", s.app.StrategyHandler.ServeHTTP)
 	mux.HandleFunc("GET /cash", s.app.CashHandler.ServeHTTP)
+	mux.HandleFunc("GET /diagnostics", s.app.DiagnosticsHandler.ServeHTTP)
 	mux.HandleFunc("GET /mcp-info", s.app.MCPPageHandler.ServeHTTP)
 	mux.HandleFunc("GET /help", s.app.PageHandler.ServeHelpPage())
 	mux.HandleFunc("GET /changelog", s.app.PageHandler.ServeChangelogPage())
@@ -47,15 +49,17 @@ func (s *Server) setupRoutes() *http.ServeMux {
 
 	// Static files (CSS, JS, images)
 	mux.HandleFunc("/static/", s.app.PageHandler.StaticFileHandler)
+	mux.HandleFunc("GET /favicon.ico", s.app.PageHandler.FaviconHandler)
+	mux.HandleFunc("GET /manifest.webmanifest", s.app.PageHandler.ManifestHandler)
 
-	// MCP endpoint (JSON-RPC over HTTP)
+	// MCP endpoint (JSON-RPC over HTTP, SSE-streamed responses; no write timeout)
 	if s.app.MCPHandler != nil {
-		mux.Handle("/mcp", s.app.MCPHandler)
+		mux.Handle("/mcp", streaming(s.app.MCPHandler))
 	}
 	// Dev-mode MCP endpoint with encrypted UID authentication
 	// Pattern: /mcp/{encrypted_uid}
 	if s.app.MCPDevHandler != nil {
-		mux.Handle("/mcp/", s.app.MCPDevHandler)
+		mux.Handle("/mcp/", streaming(s.app.MCPDevHandler))
 	}
 
 	// Profile page
@@ -78,9 +82,31 @@ func (s *Server) setupRoutes() *http.ServeMux {
 	// API routes
 	mux.HandleFunc("/api/health", s.app.HealthHandler.ServeHTTP)
 	mux.HandleFunc("/api/server-health", s.app.ServerHealthHandler.ServeHTTP)
+	mux.HandleFunc("GET /api/health/deep", s.app.DeepHealthHandler.ServeHTTP)
 	mux.HandleFunc("/api/version", s.app.VersionHandler.ServeHTTP)
+	mux.HandleFunc("GET /api/tools/openapi.json", s.app.ToolsHandler.HandleOpenAPI)
+	mux.HandleFunc("GET /api/tools/{name}", s.app.ToolsHandler.HandleTool)
+	mux.HandleFunc("GET /api/dashboard/summary", s.app.DashboardHandler.HandleSummary)
+	mux.HandleFunc("GET /api/portfolios/{name}/strategy", s.app.StrategyHandler.HandleGetStrategy)
+	mux.HandleFunc("PUT /api/portfolios/{name}/strategy", s.app.StrategyHandler.HandlePutStrategy)
+	mux.HandleFunc("GET /api/portfolios/{name}/growth.png", s.app.GrowthChartHandler.HandleGrowthPNG)
+	mux.HandleFunc("GET /api/portfolios/{name}/allocation", s.app.AllocationHandler.HandleAllocation)
+	mux.HandleFunc("POST /api/portfolios/{name}/sync", s.app.SyncHandler.HandleSync)
+	mux.HandleFunc("POST /api/settings/test-key", s.app.ProfileHandler.HandleTestKey)
+	mux.HandleFunc("POST /api/preferences/portfolio", s.app.PreferencesHandler.HandlePortfolio)
+	mux.HandleFunc("POST /api/preferences/locale", s.app.PreferencesHandler.HandleLocale)
+	mux.HandleFunc("POST /api/preferences/timezone", s.app.PreferencesHandler.HandleTimezone)
 	mux.HandleFunc("POST /api/shutdown", s.handleShutdown)
 
+	// Profiling (server.pprof, admin token only)
+	s.registerPprofRoutes(mux)
+
+	// Log file tail (file logging only, admin token only)
+	s.registerLogTailRoutes(mux)
+
+	// Served MCP catalog and validation rejections (admin token only)
+	mux.Handle("GET /api/admin/catalog", s.adminTokenMiddleware(s.app.Config.Server.AdminToken, http.HandlerFunc(s.app.ToolsHandler.HandleAdminCatalog)))
+
 	// Proxy unmatched API routes to vire-server
 	mux.HandleFunc("/api/", s.handleAPIProxy)
 
@@ -128,13 +154,16 @@ func (s *Server) handleAPIProxy(w http.ResponseWriter, r *http.Request) {
 
 	// Extract user ID for cache keying
 	var userID string
-	if loggedIn, claims := handlers.IsLoggedIn(r, []byte(s.app.Config.Auth.JWTSecret)); loggedIn && claims != nil {
+	if loggedIn, claims := s.app.SessionCookie.IsLoggedIn(r, []byte(s.app.Config.Auth.JWTSecret)); loggedIn && claims != nil {
 		userID = claims.Sub
 	}
 
-	// Check cache for GET requests (key includes query string)
+	// The user's display timezone, from the vire_timezone cookie or user.timezone.
+	timezone := handlers.RequestTimezone(r, s.app.Config.User.TimezoneOrDefault())
+
+	// Check cache for GET requests (key includes query string and timezone)
+	cacheKey := cache.MakeVariantKey(userID, r.Method, r.URL.RequestURI(), timezone)
 	if r.Method == http.MethodGet && userID != "" {
-		cacheKey := cache.MakeKey(userID, r.Method, r.URL.RequestURI())
 		if cached, ok := s.cache.Get(cacheKey); ok {
 			for key, values := range cached.Headers {
 				for _, value := range values {
@@ -164,13 +193,22 @@ func (s *Server) handleAPIProxy(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Inject X-Vire-User-ID from session cookie for authenticated API calls
+	// Forward the request ID (possibly generated here) for cross-service tracing
+	if id := common.RequestIDFromContext(r.Context()); id != "" {
+		proxyReq.Header.Set(common.RequestIDHeader, id)
+	}
+
+	// Inject X-Vire-User-ID from session cookie for authenticated API calls.
+	// A client-supplied value is never forwarded, so anonymous requests
+	// cannot act as another user.
+	proxyReq.Header.Del("X-Vire-User-ID")
 	if userID != "" {
 		proxyReq.Header.Set("X-Vire-User-ID", userID)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(proxyReq)
+	proxyReq.Header.Set("X-Vire-Timezone", timezone)
+
+	resp, err := s.apiClient.Do(proxyReq)
 	if err != nil {
 		s.logger.Warn().Err(err).Str("path", r.URL.Path).Msg("API proxy request failed")
 		http.Error(w, `{"error":"API server unavailable"}`, http.StatusServiceUnavailable)
@@ -188,7 +226,6 @@ func (s *Server) handleAPIProxy(w http.ResponseWriter, r *http.Request) {
 
 	// Cache successful GET responses (skip oversized bodies)
 	if r.Method == http.MethodGet && userID != "" && resp.StatusCode >= 200 && resp.StatusCode < 300 && len(body) <= maxCacheableBody {
-		cacheKey := cache.MakeKey(userID, r.Method, r.URL.RequestURI())
 		headerCopy := make(http.Header)
 		for key, values := range resp.Header {
 			headerCopy[key] = append([]string(nil), values...)
@@ -208,6 +245,9 @@ func (s *Server) handleAPIProxy(w http.ResponseWriter, r *http.Request) {
 	// Invalidate cache on write operations
 	if r.Method != http.MethodGet && userID != "" {
 		s.cache.InvalidatePrefix(r.URL.Path)
+		if strings.HasPrefix(r.URL.Path, "/api/portfolios/") {
+			s.app.DashboardHandler.InvalidateSummary(userID)
+		}
 	}
 
 	for key, values := range resp.Header {
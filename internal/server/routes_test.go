@@ -5,10 +5,12 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -41,6 +43,13 @@ func createTestJWT(userID, secret string) string {
 	return sigInput + "." + signature
 }
 
+// addCSRF adds a matching _csrf cookie and X-CSRF-Token header, as the
+// portal's pages send on cookie-authenticated API calls.
+func addCSRF(req *http.Request) {
+	req.AddCookie(&http.Cookie{Name: "_csrf", Value: "test-csrf-token"})
+	req.Header.Set("X-CSRF-Token", "test-csrf-token")
+}
+
 func newTestApp(t *testing.T) *app.App {
 	t.Helper()
 
@@ -494,6 +503,165 @@ func TestRoutes_ChangelogPage(t *testing.T) {
 	}
 }
 
+func TestRoutes_ReadOnlyEndpoints_HEADAndOPTIONS(t *testing.T) {
+	application := newTestApp(t)
+	ts := httptest.NewServer(New(application).Handler())
+	defer ts.Close()
+
+	for _, path := range []string{"/api/health", "/api/version"} {
+		req, _ := http.NewRequest(http.MethodHead, ts.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("HEAD %s: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("HEAD %s: expected 200, got %d", path, resp.StatusCode)
+		}
+		if len(body) != 0 {
+			t.Errorf("HEAD %s: expected no body, got %q", path, body)
+		}
+	}
+
+	for _, path := range []string{"/api/health", "/api/version", "/api/server-health"} {
+		req, _ := http.NewRequest(http.MethodOptions, ts.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("OPTIONS %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("OPTIONS %s: expected 204, got %d", path, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Allow"); got != "GET, HEAD, OPTIONS" {
+			t.Errorf("OPTIONS %s: expected Allow 'GET, HEAD, OPTIONS', got %q", path, got)
+		}
+	}
+}
+
+// --- Maintenance Mode Tests ---
+
+func TestRoutes_MaintenanceMode(t *testing.T) {
+	application := newTestApp(t)
+	srv := New(application)
+	srv.SetMaintenance(true)
+
+	// Health still passes for the load balancer
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /api/health 200 during maintenance, got %d", w.Code)
+	}
+
+	// Browser pages get the maintenance page
+	testToken := createTestJWT("test-user-123", application.Config.Auth.JWTSecret)
+	req := httptest.NewRequest("GET", "/dashboard", nil)
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: testToken})
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /dashboard 503 during maintenance, got %d", w.Code)
+	}
+	if !strings.Contains(w.Header().Get("Content-Type"), "text/html") || !strings.Contains(w.Body.String(), "maintenance") {
+		t.Errorf("expected HTML maintenance page, got %q: %s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	// API and MCP clients get JSON
+	for _, path := range []string{"/api/portfolios", "/mcp"} {
+		w = httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected %s 503 during maintenance, got %d", path, w.Code)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["status"] != "maintenance" {
+			t.Errorf("expected %s JSON status maintenance, got %s", path, w.Body.String())
+		}
+	}
+
+	// Disabling restores normal routing
+	srv.SetMaintenance(false)
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /dashboard 200 after maintenance, got %d", w.Code)
+	}
+}
+
+func TestRoutes_MaintenanceModeFromConfig(t *testing.T) {
+	application := newTestApp(t)
+	application.Config.Server.Maintenance = true
+	srv := New(application)
+
+	if !srv.Maintenance() {
+		t.Fatal("expected maintenance mode enabled from config")
+	}
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 during maintenance, got %d", w.Code)
+	}
+}
+
+func TestRoutes_BasePath(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.Server.BasePath = "/vire"
+	application := newTestAppWithConfig(t, cfg)
+	srv := New(application)
+	token := createTestJWT("test-user-123", application.Config.Auth.JWTSecret)
+
+	get := func(path string, loggedIn bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if loggedIn {
+			req.AddCookie(&http.Cookie{Name: "vire_session", Value: token})
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/vire/dashboard", true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for /vire/dashboard, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `href="/vire/dashboard"`) {
+		t.Error("expected dashboard link to include the base path")
+	}
+	if !strings.Contains(body, `href="/vire/static/css/portal.css"`) {
+		t.Error("expected static assets to include the base path")
+	}
+	if strings.Contains(body, `href="/dashboard"`) {
+		t.Error("expected no unprefixed dashboard link")
+	}
+
+	w = get("/vire/mcp-info", true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for /vire/mcp-info, got %d", w.Code)
+	}
+	if want := application.Config.BaseURL() + "/mcp"; !strings.HasSuffix(want, "/vire/mcp") || !strings.Contains(w.Body.String(), want) {
+		t.Errorf("expected MCP endpoint %s on /mcp-info", want)
+	}
+
+	// Handler redirects stay under the mount
+	w = get("/vire/dashboard", false)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/vire/" {
+		t.Errorf("expected redirect to /vire/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	if w = get("/vire/api/health", false); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for /vire/api/health, got %d", w.Code)
+	}
+	if w = get("/dashboard", true); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 outside the base path, got %d", w.Code)
+	}
+	if w = get("/vire", false); w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/vire/" {
+		t.Errorf("expected bare base path to redirect to /vire/, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+}
+
 // --- Dashboard Route Tests ---
 
 func TestRoutes_DashboardPage(t *testing.T) {
@@ -679,6 +847,7 @@ func TestRoutes_LogoutEndpoint(t *testing.T) {
 
 	req := httptest.NewRequest("POST", "/api/auth/logout", nil)
 	req.AddCookie(&http.Cookie{Name: "vire_session", Value: "some-token"})
+	addCSRF(req)
 	w := httptest.NewRecorder()
 
 	srv.Handler().ServeHTTP(w, req)
@@ -823,3 +992,231 @@ func searchString(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRoutes_Pprof(t *testing.T) {
+	get := func(srv *Server, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		srv := New(newTestApp(t))
+		if w := get(srv, "/debug/pprof/", ""); w.Code != http.StatusNotFound {
+			t.Errorf("expected 404 when pprof is disabled, got %d", w.Code)
+		}
+	})
+
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.Server.Pprof = true
+	cfg.Server.AdminToken = "admin-s3cret"
+	srv := New(newTestAppWithConfig(t, cfg))
+
+	t.Run("forbidden without token", func(t *testing.T) {
+		for _, token := range []string{"", "wrong"} {
+			if w := get(srv, "/debug/pprof/", token); w.Code != http.StatusForbidden {
+				t.Errorf("expected 403 with token %q, got %d", token, w.Code)
+			}
+		}
+		if w := get(srv, "/debug/pprof/heap", ""); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403 for heap profile without token, got %d", w.Code)
+		}
+	})
+
+	t.Run("serves with token", func(t *testing.T) {
+		w := get(srv, "/debug/pprof/", "admin-s3cret")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200 for pprof index, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "goroutine") {
+			t.Error("expected pprof index to list the goroutine profile")
+		}
+		if w := get(srv, "/debug/pprof/cmdline", "admin-s3cret"); w.Code != http.StatusOK {
+			t.Errorf("expected 200 for cmdline, got %d", w.Code)
+		}
+	})
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestRoutes_InjectedUpstream(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	upstream := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		seen = append(seen, r.URL.Host+r.URL.Path)
+		mu.Unlock()
+		body := `{}`
+		switch r.URL.Path {
+		case "/api/mcp/tools":
+			body = `[{"name":"get_quote","description":"Quote","method":"GET","path":"/api/market/quote/{ticker}","params":[{"name":"ticker","type":"string","in":"path","required":true}]}]`
+		case "/api/portfolios":
+			body = `{"portfolios":[{"name":"SMSF"}]}`
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    r,
+		}, nil
+	})}
+
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 1
+	cfg.API.URL = "http://unused.invalid"
+	application, err := app.New(cfg, common.NewSilentLogger(),
+		app.WithHTTPClient(upstream),
+		app.WithUpstreamURL("http://vire.test"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	t.Cleanup(func() { application.Close() })
+
+	if cfg.API.URL != "http://unused.invalid" {
+		t.Errorf("expected caller's config untouched, got API.URL %q", cfg.API.URL)
+	}
+	if application.Config.API.URL != "http://vire.test" {
+		t.Errorf("expected app API.URL http://vire.test, got %q", application.Config.API.URL)
+	}
+	if catalog := application.MCPHandler.Catalog(); len(catalog) != 1 || catalog[0].Name != "get_quote" {
+		t.Errorf("expected get_quote catalog from injected upstream, got %+v", catalog)
+	}
+
+	srv := New(application)
+	req := httptest.NewRequest("POST", "/api/preferences/portfolio", strings.NewReader(`{"name":"SMSF"}`))
+	req.AddCookie(&http.Cookie{Name: "vire_session", Value: createTestJWT("user-1", application.Config.Auth.JWTSecret)})
+	addCSRF(req)
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected preference saved via injected upstream, got %d: %s", w.Code, w.Body.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, want := range []string{"vire.test/api/mcp/tools", "vire.test/api/portfolios"} {
+		found := false
+		for _, s := range seen {
+			found = found || s == want
+		}
+		if !found {
+			t.Errorf("expected upstream request %s, saw %v", want, seen)
+		}
+	}
+}
+
+func TestRoutes_FaviconAndManifest(t *testing.T) {
+	application := newTestApp(t)
+	srv := New(application)
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/favicon.ico", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /favicon.ico 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "image/") {
+		t.Errorf("expected image content type for /favicon.ico, got %q", ct)
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/manifest.webmanifest", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/manifest+json" {
+		t.Errorf("expected manifest 200 application/manifest+json, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestRoutes_ToolsAPI(t *testing.T) {
+	application := newTestApp(t)
+	srv := New(application)
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/tools/openapi.json", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"openapi"`) {
+		t.Errorf("expected OpenAPI document, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/tools/get_quote", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a tool missing from the empty catalog, got %d", w.Code)
+	}
+}
+
+func TestRoutes_AdminCatalog(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.Server.AdminToken = "admin-s3cret"
+	srv := New(newTestAppWithConfig(t, cfg))
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/admin/catalog", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if w := get(token); w.Code != http.StatusForbidden {
+			t.Errorf("expected 403 with token %q, got %d", token, w.Code)
+		}
+	}
+
+	w := get("admin-s3cret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with admin token, got %d: %s", w.Code, w.Body.String())
+	}
+	var report struct {
+		Source    string            `json:"source"`
+		ToolCount int               `json:"tool_count"`
+		Tools     []json.RawMessage `json:"tools"`
+		Rejected  []json.RawMessage `json:"rejected"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !strings.HasSuffix(report.Source, "/api/mcp/tools") || report.ToolCount != 0 || report.Rejected == nil {
+		t.Errorf("expected empty catalog report, got %s", w.Body.String())
+	}
+}
+
+func TestMiddlewareChain_RecoversPanics(t *testing.T) {
+	application := newTestApp(t)
+	srv := New(application)
+
+	handler := srv.withMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["boom"]++ // nil map write
+	}))
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/boom", nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("request %d: expected 500, got %d", i, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"code":"INTERNAL_ERROR"`) || strings.Contains(w.Body.String(), "nil map") {
+			t.Errorf("request %d: expected generic JSON error, got %s", i, w.Body.String())
+		}
+		if w.Header().Get("X-Request-ID") == "" || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("request %d: expected request ID and security headers on the 500", i)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected server to keep serving after a panic, got %d", w.Code)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/bobmcallan/vire-portal/internal/app"
 	"github.com/bobmcallan/vire-portal/internal/cache"
+	"github.com/bobmcallan/vire-portal/internal/config"
 	common "github.com/bobmcallan/vire-portal/internal/vire/common"
 )
 
@@ -18,7 +20,10 @@ type Server struct {
 	server       *http.Server
 	logger       *common.Logger
 	cache        *cache.ResponseCache
+	apiClient    *http.Client // API proxy client, limited to the upstream host allow-list
 	shutdownChan chan struct{}
+	maintenance  atomic.Bool
+	logSampler   *logSampler
 }
 
 // SetShutdownChannel sets the channel that will be signaled when HTTP shutdown is requested.
@@ -26,28 +31,65 @@ func (s *Server) SetShutdownChannel(ch chan struct{}) {
 	s.shutdownChan = ch
 }
 
+// SetMaintenance enables or disables maintenance mode at runtime.
+func (s *Server) SetMaintenance(enabled bool) {
+	if s.maintenance.Swap(enabled) != enabled {
+		s.logger.Info().Bool("maintenance", enabled).Msg("maintenance mode changed")
+	}
+}
+
+// Maintenance reports whether maintenance mode is enabled.
+func (s *Server) Maintenance() bool {
+	return s.maintenance.Load()
+}
+
 // New creates a new HTTP server with the given app.
 func New(application *app.App) *Server {
 	s := &Server{
-		app:    application,
-		logger: application.Logger,
-		cache:  cache.New(30*time.Second, 1000),
+		app:        application,
+		logger:     application.Logger,
+		cache:      cache.New(30*time.Second, 1000),
+		apiClient:  config.NewHostAllowList(application.Config.API.URL, application.Config.API.AllowedHosts).Client(30 * time.Second),
+		logSampler: newLogSampler(application.Config.Logging.Sample),
 	}
 
+	s.maintenance.Store(application.Config.Server.Maintenance)
 	s.router = s.setupRoutes()
 
-	addr := fmt.Sprintf("%s:%d", application.Config.Server.Host, application.Config.Server.Port)
+	cfg := application.Config.Server
+	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	s.server = &http.Server{
-		Addr:         addr,
-		Handler:      s.withMiddleware(s.router),
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 300 * time.Second, // 5 min: MCP tools (generate_report, etc.) can take minutes
-		IdleTimeout:  120 * time.Second,
+		Addr:              addr,
+		Handler:           s.withMiddleware(s.router),
+		ReadHeaderTimeout: timeoutOrDefault(cfg.ReadHeaderTimeoutSeconds, config.DefaultReadHeaderTimeoutSeconds),
+		ReadTimeout:       timeoutOrDefault(cfg.ReadTimeoutSeconds, config.DefaultReadTimeoutSeconds),
+		WriteTimeout:      timeoutOrDefault(cfg.WriteTimeoutSeconds, config.DefaultWriteTimeoutSeconds),
+		IdleTimeout:       timeoutOrDefault(cfg.IdleTimeoutSeconds, config.DefaultIdleTimeoutSeconds),
 	}
 
 	return s
 }
 
+// timeoutOrDefault converts a timeout in seconds to a duration, using def
+// when seconds is not positive.
+func timeoutOrDefault(seconds, def int) time.Duration {
+	if seconds <= 0 {
+		seconds = def
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// streaming exempts a long-lived streaming handler (SSE, MCP tool calls)
+// from the server's WriteTimeout by clearing the connection's write
+// deadline before the handler runs.
+func streaming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ErrNotSupported (e.g. under httptest) just leaves the deadline alone.
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		next.ServeHTTP(w, r)
+	})
+}
+
 // Start starts the HTTP server.
 func (s *Server) Start() error {
 	s.logger.Info().
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/config"
+)
+
+func TestNew_TimeoutDefaults(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.Server.ReadHeaderTimeoutSeconds = 0 // unset falls back to the default
+	cfg.Server.WriteTimeoutSeconds = 42
+	srv := New(newTestAppWithConfig(t, cfg))
+
+	if got := srv.server.ReadHeaderTimeout; got != config.DefaultReadHeaderTimeoutSeconds*time.Second {
+		t.Errorf("ReadHeaderTimeout = %v, want default", got)
+	}
+	if got := srv.server.WriteTimeout; got != 42*time.Second {
+		t.Errorf("WriteTimeout = %v, want 42s", got)
+	}
+	if srv.server.ReadTimeout == 0 || srv.server.IdleTimeout == 0 {
+		t.Error("expected non-zero read and idle timeouts")
+	}
+}
+
+func TestServer_ReadHeaderTimeoutCutsOffSlowClient(t *testing.T) {
+	cfg := config.NewDefaultConfig()
+	cfg.MCP.CatalogRetries = 0
+	cfg.Server.ReadHeaderTimeoutSeconds = 1
+	srv := New(newTestAppWithConfig(t, cfg))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.server.Serve(ln)
+	t.Cleanup(func() { srv.server.Close() })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Send part of the headers and then stall, slowloris style.
+	if _, err := conn.Write([]byte("GET /api/health HTTP/1.1\r\nHost: portal\r\n")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	start := time.Now()
+	_, err = io.ReadAll(conn)
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatal("expected the server to close the stalled connection before the client deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("connection closed after %v, expected about the 1s header timeout", elapsed)
+	}
+}
+
+func TestStreaming_ExemptFromWriteTimeout(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/slow", slow)
+	mux.Handle("/stream", streaming(slow))
+
+	ts := httptest.NewUnstartedServer(mux)
+	ts.Config.WriteTimeout = 100 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	if resp, err := http.Get(ts.URL + "/slow"); err == nil {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr == nil && string(body) == "done" {
+			t.Error("expected the write timeout to cut off a slow non-streaming response")
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/stream")
+	if err != nil {
+		t.Fatalf("streaming request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "done" {
+		t.Errorf("expected streaming handler to finish past the write timeout, got %q, %v", body, err)
+	}
+}
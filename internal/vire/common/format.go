@@ -4,6 +4,7 @@ package common
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/bobmcallan/vire-portal/internal/vire/models"
 )
@@ -98,6 +99,24 @@ func FormatMoneyWithCurrency(v float64, currency string) string {
 	return fmt.Sprintf("%s%s.%02d", sym, s, cents)
 }
 
+// ConvertCurrency converts v between AUD and USD using an AUDUSD rate
+// (US dollars per Australian dollar, as stored in Portfolio.FXRate).
+// Same-currency, unsupported pairs, or a non-positive rate return v unchanged.
+func ConvertCurrency(v float64, from, to string, audusd float64) float64 {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to || audusd <= 0 {
+		return v
+	}
+	switch {
+	case from == "USD" && to == "AUD":
+		return v / audusd
+	case from == "AUD" && to == "USD":
+		return v * audusd
+	default:
+		return v
+	}
+}
+
 // FormatSignedMoneyWithCurrency formats a currency amount with +/- prefix.
 func FormatSignedMoneyWithCurrency(v float64, currency string) string {
 	if v >= 0 {
@@ -114,6 +133,58 @@ func FormatMarketCap(v float64) string {
 	return fmt.Sprintf("$%.2fM", v/1e6)
 }
 
+// timestampLayout renders a timestamp with its zone abbreviation, e.g.
+// "1 Mar 2026 21:00 AEDT".
+const timestampLayout = "2 Jan 2006 15:04 MST"
+
+// LoadTimezone returns the location for an IANA zone name such as
+// "Australia/Sydney". An empty or unknown name yields UTC.
+func LoadTimezone(name string) *time.Location {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// FormatTimestamp formats t in loc, suffixed with the zone abbreviation
+// (AEST, EDT, UTC, ...). A nil loc means UTC and a zero t returns "".
+func FormatTimestamp(t time.Time, loc *time.Location) string {
+	if t.IsZero() {
+		return ""
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(timestampLayout)
+}
+
+// FormatDuration formats d as a whole number of its largest unit, e.g.
+// "3 days", "1 hour" or "45 minutes". Durations under a minute are
+// "less than a minute".
+func FormatDuration(d time.Duration) string {
+	unit := func(n int64, name string) string {
+		if n == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%d %ss", n, name)
+	}
+	switch {
+	case d >= 24*time.Hour:
+		return unit(int64(d/(24*time.Hour)), "day")
+	case d >= time.Hour:
+		return unit(int64(d/time.Hour), "hour")
+	case d >= time.Minute:
+		return unit(int64(d/time.Minute), "minute")
+	default:
+		return "less than a minute"
+	}
+}
+
 // IsETF determines if a holding is an ETF based on fundamentals or name
 func IsETF(hr *models.HoldingReview) bool {
 	if hr.Fundamentals != nil && hr.Fundamentals.IsETF {
@@ -2,6 +2,9 @@ package common
 
 import (
 	"testing"
+	"time"
+
+	"github.com/bobmcallan/vire-portal/internal/vire/models"
 )
 
 func TestFormatMoney_ExistingBehavior(t *testing.T) {
@@ -85,3 +88,72 @@ func TestFormatSignedMoneyWithCurrency(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		from, to string
+		rate     float64
+		want     float64
+	}{
+		{"USD to AUD", 65, "USD", "AUD", 0.65, 100},
+		{"AUD to USD", 100, "AUD", "USD", 0.65, 65},
+		{"same currency", 100, "AUD", "aud", 0.65, 100},
+		{"zero rate", 100, "USD", "AUD", 0, 100},
+		{"unsupported pair", 100, "GBP", "AUD", 0.65, 100},
+	}
+
+	for _, tt := range tests {
+		got := ConvertCurrency(tt.value, tt.from, tt.to, tt.rate)
+		if diff := got - tt.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: ConvertCurrency(%.2f, %s, %s, %.2f) = %v, want %v", tt.name, tt.value, tt.from, tt.to, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTimestamp_ReviewDateInUserTimezone(t *testing.T) {
+	review := models.PortfolioReview{ReviewDate: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}
+	tests := []struct {
+		timezone string
+		want     string
+	}{
+		{"America/New_York", "1 Mar 2026 05:00 EST"},
+		{"Australia/Sydney", "1 Mar 2026 21:00 AEDT"},
+		{"", "1 Mar 2026 10:00 UTC"},
+		{"Not/AZone", "1 Mar 2026 10:00 UTC"},
+	}
+	for _, tt := range tests {
+		if got := FormatTimestamp(review.ReviewDate, LoadTimezone(tt.timezone)); got != tt.want {
+			t.Errorf("FormatTimestamp(%q) = %q, want %q", tt.timezone, got, tt.want)
+		}
+	}
+
+	winter := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	if got := FormatTimestamp(winter, LoadTimezone("Australia/Sydney")); got != "1 Jul 2026 10:00 AEST" {
+		t.Errorf("expected AEST outside daylight saving, got %q", got)
+	}
+	if got := FormatTimestamp(time.Time{}, time.UTC); got != "" {
+		t.Errorf("expected zero time to format as empty, got %q", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{30 * time.Second, "less than a minute"},
+		{time.Minute, "1 minute"},
+		{45*time.Minute + 30*time.Second, "45 minutes"},
+		{time.Hour, "1 hour"},
+		{5*time.Hour + 59*time.Minute, "5 hours"},
+		{24 * time.Hour, "1 day"},
+		{3*24*time.Hour + 23*time.Hour, "3 days"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
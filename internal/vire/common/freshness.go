@@ -30,8 +30,14 @@ const (
 
 // IsFresh returns true if the given timestamp is within the TTL
 func IsFresh(updated time.Time, ttl time.Duration) bool {
+	return IsFreshAt(SystemClock, updated, ttl)
+}
+
+// IsFreshAt is IsFresh measured against clock. A timestamp exactly ttl old
+// is stale.
+func IsFreshAt(clock Clock, updated time.Time, ttl time.Duration) bool {
 	if updated.IsZero() {
 		return false
 	}
-	return time.Since(updated) < ttl
+	return clock.Now().Sub(updated) < ttl
 }
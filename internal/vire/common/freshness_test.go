@@ -0,0 +1,36 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsFreshAt_Boundary(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(updated)
+
+	clock.Advance(FreshnessPortfolio - time.Nanosecond)
+	if !IsFreshAt(clock, updated, FreshnessPortfolio) {
+		t.Error("expected fresh one nanosecond before the TTL")
+	}
+
+	clock.Advance(time.Nanosecond)
+	if IsFreshAt(clock, updated, FreshnessPortfolio) {
+		t.Error("expected stale exactly at the TTL")
+	}
+}
+
+func TestIsFreshAt_ZeroTimeIsStale(t *testing.T) {
+	if IsFreshAt(NewFakeClock(time.Now()), time.Time{}, time.Hour) {
+		t.Error("expected zero timestamp to be stale")
+	}
+}
+
+func TestIsFresh_UsesSystemClock(t *testing.T) {
+	if !IsFresh(time.Now(), time.Minute) {
+		t.Error("expected just-updated timestamp to be fresh")
+	}
+	if IsFresh(time.Now().Add(-2*time.Minute), time.Minute) {
+		t.Error("expected old timestamp to be stale")
+	}
+}
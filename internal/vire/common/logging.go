@@ -151,6 +151,58 @@ func NewLoggerWithOutput(level string, w io.Writer) *Logger {
 	return &Logger{ILogger: arborLogger}
 }
 
+// NewDedicatedLogger creates a logger that writes only to its own outputs,
+// independent of the globally-registered writers used by the app logger.
+// Supports "console" (stderr) and "file" outputs. Used for the audit log.
+func NewDedicatedLogger(cfg LoggingConfig) *Logger {
+	level := cfg.Level
+	if level == "" {
+		level = "info"
+	}
+
+	var ws []writers.IWriter
+	for _, out := range cfg.Outputs {
+		switch out {
+		case "console":
+			ws = append(ws, writers.ConsoleWriter(models.WriterConfiguration{
+				Type:       models.LogWriterTypeConsole,
+				Writer:     os.Stderr,
+				TimeFormat: "2006-01-02T15:04:05Z07:00",
+			}))
+		case "file":
+			maxSize := int64(cfg.MaxSizeMB) * 1024 * 1024
+			if maxSize <= 0 {
+				maxSize = 500 * 1024 // 500KB default
+			}
+			maxBackups := cfg.MaxBackups
+			if maxBackups <= 0 {
+				maxBackups = 20
+			}
+			ws = append(ws, writers.FileWriter(models.WriterConfiguration{
+				Type:       models.LogWriterTypeFile,
+				FileName:   cfg.FilePath,
+				MaxSize:    maxSize,
+				MaxBackups: maxBackups,
+				TimeFormat: "2006-01-02T15:04:05Z07:00",
+			}))
+		}
+	}
+	if len(ws) == 0 {
+		ws = append(ws, &discardWriter{})
+	}
+
+	arborLogger := arbor.NewLogger().WithWriters(ws).WithLevelFromString(level)
+	return &Logger{ILogger: arborLogger}
+}
+
+// NewDedicatedLoggerWithOutput creates a dedicated logger writing text lines to w.
+// Unlike NewLoggerWithOutput, nothing is registered globally.
+func NewDedicatedLoggerWithOutput(level string, w io.Writer) *Logger {
+	adapter := &writerAdapter{out: w, level: log.TraceLevel}
+	arborLogger := arbor.NewLogger().WithWriters([]writers.IWriter{adapter}).WithLevelFromString(level)
+	return &Logger{ILogger: arborLogger}
+}
+
 // NewDefaultLogger creates a logger with default settings
 func NewDefaultLogger() *Logger {
 	return NewLogger("info")
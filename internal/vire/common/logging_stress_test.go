@@ -12,7 +12,7 @@ import (
 // Tests arbor's memory writer thread safety under contention.
 
 func TestStress_ConcurrentCorrelationIDs(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 
 	var wg sync.WaitGroup
 	goroutines := 50
@@ -30,6 +30,10 @@ func TestStress_ConcurrentCorrelationIDs(t *testing.T) {
 					Int("entry", j).
 					Str("data", fmt.Sprintf("payload-%d-%d", id, j)).
 					Msg("stress test entry")
+				// arbor feeds the memory store through a 1000-entry async
+				// buffer that silently drops on overflow; pace the writers
+				// so entries are not lost before they can be queried.
+				time.Sleep(100 * time.Microsecond)
 			}
 		}(i)
 	}
@@ -64,7 +68,7 @@ func TestStress_ConcurrentCorrelationIDs(t *testing.T) {
 
 // Concurrent reads AND writes simultaneously
 func TestStress_ConcurrentReadWrite(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 
 	var wg sync.WaitGroup
 	done := make(chan struct{})
@@ -113,7 +117,7 @@ func TestStress_ConcurrentReadWrite(t *testing.T) {
 // What happens after 10,000 log entries in memory writer?
 
 func TestStress_MemoryPressure10k(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 
 	// Write 10,000 entries
 	for i := 0; i < 10000; i++ {
@@ -149,7 +153,7 @@ func TestStress_MemoryPressure10k(t *testing.T) {
 // Can you create 100 correlated loggers from the same parent without issues?
 
 func TestStress_100CorrelatedLoggers(t *testing.T) {
-	parent := NewLogger("info")
+	parent := newTestLogger(t, "info")
 
 	loggers := make([]*Logger, 100)
 	for i := 0; i < 100; i++ {
@@ -194,7 +198,7 @@ func TestStress_100CorrelatedLoggers(t *testing.T) {
 
 // Concurrent logger creation from same parent
 func TestStress_ConcurrentLoggerCreation(t *testing.T) {
-	parent := NewLogger("info")
+	parent := newTestLogger(t, "info")
 
 	var wg sync.WaitGroup
 	loggers := make([]*Logger, 100)
@@ -270,7 +274,7 @@ func TestStress_LoggingWorksWithoutMemoryWriter(t *testing.T) {
 // Note: arbor's async logStoreWriter may buffer, so we need to account for timing.
 
 func TestStress_NoLogLoss_SequentialWrites(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 	corrID := "loss-check-seq"
 	correlated := logger.WithCorrelationId(corrID)
 
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -15,9 +16,21 @@ import (
 // enforces this since arbor's ILogEvent has no .Time() method. If the conversion is
 // missing, the build fails.
 
+// newTestLogger is NewLogger with its file output under t.TempDir(), so test
+// runs leave no log files in the package directory.
+func newTestLogger(t *testing.T, level string) *Logger {
+	t.Helper()
+	return NewLoggerFromConfig(LoggingConfig{
+		Level:    level,
+		Outputs:  []string{"console", "file"},
+		FilePath: filepath.Join(t.TempDir(), "vire.log"),
+	})
+}
+
 // --- Test 1: Logger creation ---
 
 func TestNewLogger_ReturnsNonNil(t *testing.T) {
+	t.Chdir(t.TempDir()) // NewLogger writes logs/vire.log relative to the working directory
 	logger := NewLogger("info")
 	if logger == nil {
 		t.Fatal("NewLogger returned nil")
@@ -26,7 +39,7 @@ func TestNewLogger_ReturnsNonNil(t *testing.T) {
 
 func TestNewLogger_FluentAPI(t *testing.T) {
 	// Must not panic — proves the fluent chain works with arbor
-	logger := NewLogger("error")
+	logger := newTestLogger(t, "error")
 	logger.Info().Str("key", "value").Msg("test message")
 	logger.Warn().Int("count", 42).Msg("warning")
 	logger.Error().Err(nil).Msg("error message")
@@ -44,7 +57,27 @@ func TestNewLoggerWithOutput_WritesToProvidedWriter(t *testing.T) {
 	}
 }
 
+func TestNewDedicatedLoggerWithOutput_IsolatedFromGlobalWriters(t *testing.T) {
+	var appBuf, auditBuf bytes.Buffer
+	appLogger := NewLoggerWithOutput("info", &appBuf)
+	auditLogger := NewDedicatedLoggerWithOutput("info", &auditBuf)
+
+	auditLogger.Info().Str("field", "navexa_key").Msg("audit entry")
+	appLogger.Info().Msg("app entry")
+
+	if !strings.Contains(auditBuf.String(), "audit entry") || !strings.Contains(auditBuf.String(), "field=navexa_key") {
+		t.Errorf("expected audit entry in dedicated output, got %q", auditBuf.String())
+	}
+	if strings.Contains(auditBuf.String(), "app entry") {
+		t.Error("app log entry leaked into dedicated output")
+	}
+	if strings.Contains(appBuf.String(), "audit entry") {
+		t.Error("audit entry leaked into app log output")
+	}
+}
+
 func TestNewDefaultLogger_ReturnsNonNil(t *testing.T) {
+	t.Chdir(t.TempDir())
 	logger := NewDefaultLogger()
 	if logger == nil {
 		t.Fatal("NewDefaultLogger returned nil")
@@ -98,7 +131,7 @@ func TestNewLogger_DoesNotWriteToStdout(t *testing.T) {
 	}
 	os.Stdout = w
 
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 	logger.Info().Str("tool", "test").Msg("this must not go to stdout")
 	logger.Error().Msg("neither should this")
 
@@ -117,7 +150,7 @@ func TestNewLogger_DoesNotWriteToStdout(t *testing.T) {
 // --- Test 4: Correlation ID ---
 
 func TestWithCorrelationId_ReturnsNewLogger(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 	correlated := logger.WithCorrelationId("test-req-123")
 
 	if correlated == nil {
@@ -130,7 +163,7 @@ func TestWithCorrelationId_ReturnsNewLogger(t *testing.T) {
 }
 
 func TestWithCorrelationId_FluentAPI(t *testing.T) {
-	logger := NewLogger("error")
+	logger := newTestLogger(t, "error")
 	correlated := logger.WithCorrelationId("test-req-456")
 	// Must not panic
 	correlated.Info().Str("tool", "portfolio_compliance").Msg("handler start")
@@ -142,7 +175,7 @@ func TestWithCorrelationId_FluentAPI(t *testing.T) {
 func TestGetMemoryLogsWithLimit_ReturnsEntries(t *testing.T) {
 	// Self-contained: creates its own logger which registers the memory writer.
 	// Valid regardless of test execution order.
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 
 	// Write some logs
 	logger.Info().Str("tool", "test1").Msg("first message")
@@ -164,7 +197,7 @@ func TestGetMemoryLogsWithLimit_ReturnsEntries(t *testing.T) {
 }
 
 func TestGetMemoryLogsForCorrelation_FiltersById(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 
 	// Write logs with different correlation IDs
 	c1 := logger.WithCorrelationId("req-AAA")
@@ -245,7 +278,7 @@ func TestLogLevel_InfoFilteredAtWarnLevel(t *testing.T) {
 // --- Test 7: Memory writer edge cases ---
 
 func TestGetMemoryLogsWithLimit_ZeroLimit_ReturnsEmpty(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 	logger.Info().Msg("test entry")
 
 	logs, err := logger.GetMemoryLogsWithLimit(0)
@@ -259,7 +292,7 @@ func TestGetMemoryLogsWithLimit_ZeroLimit_ReturnsEmpty(t *testing.T) {
 }
 
 func TestGetMemoryLogsWithLimit_NegativeLimit_ReturnsEmpty(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 	logger.Info().Msg("test entry")
 
 	logs, err := logger.GetMemoryLogsWithLimit(-1)
@@ -273,7 +306,7 @@ func TestGetMemoryLogsWithLimit_NegativeLimit_ReturnsEmpty(t *testing.T) {
 }
 
 func TestGetMemoryLogsForCorrelation_UnknownId_ReturnsEmpty(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 	logger.Info().Msg("test entry with no correlation")
 
 	logs, err := logger.GetMemoryLogsForCorrelation("nonexistent-id-12345")
@@ -289,7 +322,7 @@ func TestGetMemoryLogsForCorrelation_UnknownId_ReturnsEmpty(t *testing.T) {
 // --- Test 8: Concurrent access ---
 
 func TestConcurrentLogging_NoRaceOrPanic(t *testing.T) {
-	logger := NewLogger("info")
+	logger := newTestLogger(t, "info")
 
 	var wg sync.WaitGroup
 	goroutines := 10
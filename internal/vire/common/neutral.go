@@ -0,0 +1,86 @@
+package common
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultNeutralTerms maps directional market language to the neutral
+// phrasing shown to users in sentiment and impact text.
+var DefaultNeutralTerms = map[string]string{
+	"bullish":     "positive",
+	"bearish":     "negative",
+	"buy signal":  "positive signal",
+	"sell signal": "negative signal",
+}
+
+// NeutralLanguage rewrites directional terms in text to neutral ones. Terms
+// match whole words, ignoring case, and the replacement follows the case of
+// the match: "Bullish" becomes "Positive", "BEARISH" becomes "NEGATIVE".
+// A nil or empty NeutralLanguage leaves text unchanged.
+type NeutralLanguage struct {
+	pattern *regexp.Regexp
+	terms   map[string]string // lower-case term -> replacement
+}
+
+// NewNeutralLanguage builds a NeutralLanguage from term -> replacement
+// pairs. Blank terms are ignored; an empty map disables rewriting.
+func NewNeutralLanguage(terms map[string]string) *NeutralLanguage {
+	n := &NeutralLanguage{terms: make(map[string]string, len(terms))}
+	var keys []string
+	for term, repl := range terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" {
+			continue
+		}
+		if _, dup := n.terms[term]; !dup {
+			keys = append(keys, term)
+		}
+		n.terms[term] = repl
+	}
+	if len(keys) == 0 {
+		return n
+	}
+	// Longest first, so "buy signal" wins over a shorter overlapping term.
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	n.pattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	return n
+}
+
+// Replace returns s with every configured term rewritten.
+func (n *NeutralLanguage) Replace(s string) string {
+	if n == nil || n.pattern == nil || s == "" {
+		return s
+	}
+	return n.pattern.ReplaceAllStringFunc(s, func(match string) string {
+		return matchCase(match, n.terms[strings.ToLower(match)])
+	})
+}
+
+// matchCase returns repl in the case of match: all upper, capitalised, or
+// unchanged.
+func matchCase(match, repl string) string {
+	if repl == "" {
+		return ""
+	}
+	if len(match) > 1 && strings.ToUpper(match) == match && strings.ToLower(match) != match {
+		return strings.ToUpper(repl)
+	}
+	if r, _ := utf8.DecodeRuneInString(match); unicode.IsUpper(r) {
+		first, size := utf8.DecodeRuneInString(repl)
+		return string(unicode.ToUpper(first)) + repl[size:]
+	}
+	return repl
+}
@@ -0,0 +1,35 @@
+package common
+
+import "testing"
+
+func TestNeutralLanguage_DefaultTerms(t *testing.T) {
+	n := NewNeutralLanguage(DefaultNeutralTerms)
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"bullish", "positive"},
+		{"Bullish", "Positive"},
+		{"BEARISH", "NEGATIVE"},
+		{"Mildly bearish near term, bullish over the year", "Mildly negative near term, positive over the year"},
+		{"MACD buy signal", "MACD positive signal"},
+		{"Sell Signal confirmed", "Negative signal confirmed"},
+		{"bullishness", "bullishness"},
+		{"neutral", "neutral"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := n.Replace(tt.in); got != tt.want {
+			t.Errorf("Replace(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNeutralLanguage_Disabled(t *testing.T) {
+	var nilNeutral *NeutralLanguage
+	for _, n := range []*NeutralLanguage{nilNeutral, NewNeutralLanguage(nil), NewNeutralLanguage(map[string]string{" ": "x"})} {
+		if got := n.Replace("Bullish"); got != "Bullish" {
+			t.Errorf("expected text unchanged, got %q", got)
+		}
+	}
+}
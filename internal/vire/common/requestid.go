@@ -0,0 +1,20 @@
+package common
+
+import "context"
+
+// RequestIDHeader is the header carrying the request ID into the portal, back
+// to the client, and upstream to vire-server.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID stores the request ID in context.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID from context, or "" if absent.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -79,6 +79,7 @@ type Holding struct {
 	HoldingReturnNetPct   float64        `json:"holding_return_net_pct"`
 	Currency              string         `json:"currency"`          // Holding currency (AUD, USD)
 	Country               string         `json:"country,omitempty"` // Domicile country ISO code (e.g. "AU", "US")
+	Sector                string         `json:"sector,omitempty"`  // GICS sector, when vire-server knows it
 	Trades                []*NavexaTrade `json:"trades,omitempty"`
 	LastUpdated           time.Time      `json:"last_updated"`
 }
@@ -0,0 +1,4 @@
+// Package pages holds the portal's HTML templates, partials and static
+// assets. By default they are embedded so the binary can be deployed on its
+// own; build with -tags nopagesembed to read them from disk instead.
+package pages
@@ -0,0 +1,14 @@
+//go:build !nopagesembed
+
+package pages
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.html partials/*.html static
+var embedded embed.FS
+
+// FS is the embedded pages tree.
+var FS fs.FS = embedded
@@ -0,0 +1,9 @@
+//go:build nopagesembed
+
+package pages
+
+import "io/fs"
+
+// FS is nil without the embedded pages tree; handlers fall back to the
+// pages directory on disk.
+var FS fs.FS
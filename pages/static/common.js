@@ -19,12 +19,69 @@ window.debugError = function (component, message, error) {
     }
 };
 
+// Base path — set by head.html when the portal is mounted under a sub-path
+// (server.base_path). Root-relative fetch() URLs are prefixed with it.
+window.VIRE_BASE_PATH = window.VIRE_BASE_PATH || '';
+if (window.VIRE_BASE_PATH) {
+    const rootFetch = window.fetch.bind(window);
+    window.fetch = (input, init) => {
+        if (typeof input === 'string' && input.startsWith('/') && !input.startsWith('//')) {
+            input = window.VIRE_BASE_PATH + input;
+        }
+        return rootFetch(input, init);
+    };
+}
+
+// CSRF token — the server sets _csrf as a non-HttpOnly cookie on GET
+// responses. State-changing calls authenticated by the session cookie must
+// echo it, so fetch() adds it as X-CSRF-Token to the portal's own
+// root-relative non-GET requests.
+window.vireCSRFToken = function () {
+    const cookie = document.cookie.split('; ').find(c => c.startsWith('_csrf='));
+    return cookie ? cookie.split('=')[1] : '';
+};
+{
+    const plainFetch = window.fetch.bind(window);
+    window.fetch = (input, init) => {
+        const method = ((init && init.method) || 'GET').toUpperCase();
+        const safe = method === 'GET' || method === 'HEAD' || method === 'OPTIONS';
+        if (!safe && typeof input === 'string' && input.startsWith('/') && !input.startsWith('//')) {
+            const token = window.vireCSRFToken();
+            if (token) {
+                const headers = new Headers((init && init.headers) || {});
+                if (!headers.has('X-CSRF-Token')) headers.set('X-CSRF-Token', token);
+                init = { ...init, headers: headers };
+            }
+        }
+        return plainFetch(input, init);
+    };
+}
+
+// App path — the current pathname without the base path.
+window.vireAppPath = function () {
+    const path = window.location.pathname;
+    const base = window.VIRE_BASE_PATH;
+    return base && path.startsWith(base) ? path.substring(base.length) || '/' : path;
+};
+
+// Locale — head.html renders it as <meta name="vire-locale"> from the
+// vire_locale cookie or Accept-Language. Numbers and dates are formatted in it.
+window.VIRE_LOCALE = document.querySelector('meta[name="vire-locale"]')?.content || 'en-AU';
+
+// Portfolio preference — records the portfolio selected in the UI so it is
+// also the default for MCP tool calls. Best effort: failures are only logged.
+window.vireSavePortfolioPreference = function (name) {
+    if (!name) return;
+    fetch('/api/preferences/portfolio', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ name: name }),
+    }).catch(err => window.debugError('preferences', 'Failed to save portfolio preference', err));
+};
+
 // CSRF: inject _csrf hidden field into all POST forms from the _csrf cookie.
-// The server sets _csrf as a non-HttpOnly cookie on GET responses.
 document.addEventListener('DOMContentLoaded', () => {
-    const csrfCookie = document.cookie.split('; ').find(c => c.startsWith('_csrf='));
-    if (!csrfCookie) return;
-    const csrfToken = csrfCookie.split('=')[1];
+    const csrfToken = window.vireCSRFToken();
     if (!csrfToken) return;
 
     document.querySelectorAll('form[method="POST"]').forEach(form => {
@@ -216,6 +273,7 @@ function portfolioDashboard() {
         watchlist: [],
         glossary: {},
         refreshing: false,
+        syncing: false,
         growthData: [],
         hasGrowthData: false,
         chartInstance: null,
@@ -256,7 +314,7 @@ function portfolioDashboard() {
         },
 
         _getPortfolioFromURL() {
-            const path = window.location.pathname;
+            const path = window.vireAppPath();
             if (path.startsWith('/dashboard/')) {
                 return decodeURIComponent(path.substring('/dashboard/'.length));
             }
@@ -268,8 +326,8 @@ function portfolioDashboard() {
 
         _updateURL() {
             if (this.selected) {
-                const base = window.location.pathname.startsWith('/m') ? '/m/' : '/dashboard/';
-                const newPath = base + encodeURIComponent(this.selected);
+                const base = window.vireAppPath().startsWith('/m') ? '/m/' : '/dashboard/';
+                const newPath = window.VIRE_BASE_PATH + base + encodeURIComponent(this.selected);
                 if (window.location.pathname !== newPath) {
                     history.replaceState(null, '', newPath);
                 }
@@ -776,7 +834,7 @@ function portfolioDashboard() {
                                 },
                                 label: function(ctx) {
                                     if (ctx.raw == null) return null;
-                                    const val = Number(ctx.raw).toLocaleString('en-AU', { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+                                    const val = Number(ctx.raw).toLocaleString(window.VIRE_LOCALE, { minimumFractionDigits: 2, maximumFractionDigits: 2 });
                                     return ctx.dataset.label + ': $' + val;
                                 },
                             },
@@ -804,7 +862,7 @@ function portfolioDashboard() {
                                 font: { family: "'IBM Plex Mono', monospace", size: 10 },
                                 color: '#888',
                                 callback: function(val) {
-                                    return '$' + Number(val).toLocaleString('en-AU', { maximumFractionDigits: 0 });
+                                    return '$' + Number(val).toLocaleString(window.VIRE_LOCALE, { maximumFractionDigits: 0 });
                                 },
                             },
                             border: { display: false },
@@ -863,8 +921,28 @@ function portfolioDashboard() {
             }
         },
 
+        async syncPortfolio() {
+            if (this.syncing || !this.selected) return;
+            this.syncing = true;
+            try {
+                const res = await fetch('/api/portfolios/' + encodeURIComponent(this.selected) + '/sync', { method: 'POST' });
+                const data = await res.json().catch(() => ({}));
+                if (!res.ok) {
+                    window.dispatchEvent(new CustomEvent('toast', { detail: { msg: data.error || 'Sync failed' } }));
+                    return;
+                }
+                window.dispatchEvent(new CustomEvent('toast', { detail: { msg: data.summary || 'Portfolio synced' } }));
+                this.syncing = false;
+                await this.refreshPortfolio();
+            } catch (e) {
+                debugError('portfolioDashboard', 'syncPortfolio failed', e);
+            } finally {
+                this.syncing = false;
+            }
+        },
+
         fmt(val) {
-            return val != null ? Number(val).toLocaleString('en-AU', { minimumFractionDigits: 2, maximumFractionDigits: 2 }) : '-';
+            return val != null ? Number(val).toLocaleString(window.VIRE_LOCALE, { minimumFractionDigits: 2, maximumFractionDigits: 2 }) : '-';
         },
         pct(val) {
             return val != null ? Number(val).toFixed(2) + '%' : '-';
@@ -874,7 +952,7 @@ function portfolioDashboard() {
             try {
                 const d = new Date(utcStr);
                 if (isNaN(d.getTime())) return '';
-                return d.toLocaleString('en-AU', {
+                return d.toLocaleString(window.VIRE_LOCALE, {
                     day: 'numeric', month: 'short', year: 'numeric',
                     hour: '2-digit', minute: '2-digit', hour12: false
                 });
@@ -885,7 +963,7 @@ function portfolioDashboard() {
             try {
                 const d = new Date(utcStr);
                 if (isNaN(d.getTime())) return '';
-                return d.toLocaleTimeString('en-AU', { hour: '2-digit', minute: '2-digit', hour12: false });
+                return d.toLocaleTimeString(window.VIRE_LOCALE, { hour: '2-digit', minute: '2-digit', hour12: false });
             } catch { return ''; }
         },
         changePct(val) {
@@ -1142,13 +1220,13 @@ function cashTransactions() {
         },
 
         fmt(val) {
-            return val != null ? Number(val).toLocaleString('en-AU', { minimumFractionDigits: 2, maximumFractionDigits: 2 }) : '-';
+            return val != null ? Number(val).toLocaleString(window.VIRE_LOCALE, { minimumFractionDigits: 2, maximumFractionDigits: 2 }) : '-';
         },
         formatDate(dateStr) {
             if (!dateStr) return '-';
             const d = new Date(dateStr);
             if (isNaN(d.getTime())) return dateStr;
-            return d.toLocaleDateString('en-AU', { year: 'numeric', month: 'short', day: 'numeric' });
+            return d.toLocaleDateString(window.VIRE_LOCALE, { year: 'numeric', month: 'short', day: 'numeric' });
         },
     };
 }
@@ -1160,6 +1238,10 @@ function portfolioStrategy() {
         selected: '',
         defaultPortfolio: '',
         strategyHtml: '',
+        strategyText: '',
+        saveError: '',
+        saveConflict: false,
+        saving: false,
         planItems: [],
         loading: true,
         error: '',
@@ -1222,6 +1304,7 @@ function portfolioStrategy() {
                     this.renderStrategy(await strategyRes.json());
                 } else {
                     this.strategyHtml = '<p class="text-muted">No strategy defined.</p>';
+                    this.strategyText = '{}';
                 }
 
                 if (planRes.ok) {
@@ -1258,7 +1341,51 @@ function portfolioStrategy() {
             }
         },
 
+        async saveStrategy() {
+            if (!this.selected || this.saving) return;
+            this.saving = true;
+            this.saveError = '';
+            this.saveConflict = false;
+            const path = '/api/portfolios/' + encodeURIComponent(this.selected) + '/strategy';
+            try {
+                const res = await fetch(path, {
+                    method: 'PUT',
+                    headers: {'Content-Type': 'application/json'},
+                    body: this.strategyText,
+                });
+                const data = await res.json().catch(() => ({}));
+                if (res.status === 409 && data.error === 'version_conflict') {
+                    this.saveConflict = true;
+                    this.saveError = 'Strategy was changed elsewhere (now version ' + data.current_version +
+                        ', you loaded version ' + data.loaded_version + '). Reload to see the latest before saving.';
+                    return;
+                }
+                if (!res.ok) {
+                    let msg = data.error || ('Save failed (' + res.status + ')');
+                    if (data.line) msg += ' at line ' + data.line + ', column ' + data.column;
+                    this.saveError = msg;
+                    return;
+                }
+                vireStore.invalidate(path);
+                this.renderStrategy(data);
+                window.dispatchEvent(new CustomEvent('toast', { detail: { msg: 'Strategy saved' } }));
+            } catch (e) {
+                debugError('portfolioStrategy', 'saveStrategy failed', e);
+                this.saveError = 'Failed to connect to server';
+            } finally {
+                this.saving = false;
+            }
+        },
+
+        async reloadStrategy() {
+            vireStore.invalidate('/api/portfolios/' + encodeURIComponent(this.selected) + '/strategy');
+            this.saveError = '';
+            this.saveConflict = false;
+            await this.loadPortfolio();
+        },
+
         renderStrategy(data) {
+            this.strategyText = JSON.stringify(data || {}, null, 2);
             const notes = data.notes || '';
             if (notes && typeof marked !== 'undefined') {
                 this.strategyHtml = marked.parse(notes);
@@ -1274,3 +1401,38 @@ function portfolioStrategy() {
         },
     };
 }
+
+function keyTester(provider) {
+    return {
+        testing: false,
+        valid: false,
+        result: '',
+
+        async testKey() {
+            const key = (this.$refs.key.value || '').trim();
+            if (!key) {
+                this.valid = false;
+                this.result = 'Enter a key to test.';
+                return;
+            }
+            this.testing = true;
+            this.result = '';
+            try {
+                const res = await fetch('/api/settings/test-key', {
+                    method: 'POST',
+                    headers: {'Content-Type': 'application/json'},
+                    body: JSON.stringify({ provider: provider, key: key }),
+                });
+                const data = await res.json().catch(() => ({}));
+                this.valid = !!data.valid;
+                this.result = data.message || data.error || ('Key test failed (' + res.status + ')');
+            } catch (e) {
+                debugError('keyTester', 'testKey failed', e);
+                this.valid = false;
+                this.result = 'Failed to connect to server';
+            } finally {
+                this.testing = false;
+            }
+        },
+    };
+}
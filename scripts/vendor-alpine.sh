@@ -0,0 +1,21 @@
+#!/bin/bash
+set -euo pipefail
+
+# Vendor the Alpine.js bundle into pages/static/vendor/ so pages load it
+# from /static/ instead of the CDN (air-gapped deployments, tighter CSP).
+# Commit the downloaded file; it is embedded in the binary with the rest
+# of pages/. Keep ALPINE_VERSION in step with alpineVersion in
+# internal/handlers/templates.go.
+
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+PROJECT_ROOT="$(cd "$SCRIPT_DIR/.." && pwd)"
+
+ALPINE_VERSION="${ALPINE_VERSION:-3.14.9}"
+VENDOR_DIR="$PROJECT_ROOT/pages/static/vendor"
+TARGET="$VENDOR_DIR/alpine.min.js"
+URL="https://cdn.jsdelivr.net/npm/alpinejs@${ALPINE_VERSION}/dist/cdn.min.js"
+
+mkdir -p "$VENDOR_DIR"
+curl -fsSL "$URL" -o "$TARGET.tmp"
+mv "$TARGET.tmp" "$TARGET"
+echo "Vendored Alpine.js $ALPINE_VERSION to ${TARGET#$PROJECT_ROOT/}"
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -248,6 +250,36 @@ func ElementCount(ctx context.Context, selector string) (int, error) {
 	return count, err
 }
 
+// Box is an element's bounding client rect, in CSS pixels.
+type Box struct {
+	X, Y, Width, Height float64
+}
+
+// BoundingBox returns the bounding client rect of the first element matching
+// selector. found is false when no element matches.
+func BoundingBox(ctx context.Context, selector string) (box Box, found bool, err error) {
+	var rect *struct {
+		X      float64 `json:"x"`
+		Y      float64 `json:"y"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	}
+	err = chromedp.Run(ctx,
+		chromedp.Evaluate(fmt.Sprintf(`
+			(() => {
+				const el = document.querySelector('%s');
+				if (!el) return null;
+				const r = el.getBoundingClientRect();
+				return { x: r.x, y: r.y, width: r.width, height: r.height };
+			})()
+		`, escJS(selector)), &rect),
+	)
+	if err != nil || rect == nil {
+		return Box{}, false, err
+	}
+	return Box{X: rect.X, Y: rect.Y, Width: rect.Width, Height: rect.Height}, true, nil
+}
+
 func TextContains(ctx context.Context, selector, expected string) (bool, string, error) {
 	var actual string
 	err := chromedp.Run(ctx,
@@ -354,32 +386,68 @@ func RunCheck(ctx context.Context, selector, state string) CheckResult {
 		pass := evalCountExpr(state, count)
 		return CheckResult{Name: name, Pass: pass, Detail: fmt.Sprintf("count=%d", count)}
 
+	case strings.HasPrefix(state, "box="):
+		// box=w>300, box=h>0, box=x>=0, box=y<100
+		expr := state[4:]
+		if expr == "" {
+			return CheckResult{Name: name, Pass: false, Detail: "bad format, need box=<w|h|x|y><op><n>"}
+		}
+		box, found, err := BoundingBox(ctx, selector)
+		if err != nil {
+			return CheckResult{Name: name, Pass: false, Detail: err.Error()}
+		}
+		if !found {
+			return CheckResult{Name: name, Pass: false, Detail: "element not found"}
+		}
+		var actual float64
+		switch expr[0] {
+		case 'w':
+			actual = box.Width
+		case 'h':
+			actual = box.Height
+		case 'x':
+			actual = box.X
+		case 'y':
+			actual = box.Y
+		default:
+			return CheckResult{Name: name, Pass: false, Detail: fmt.Sprintf("unknown box dimension: %c", expr[0])}
+		}
+		pass := evalCompareExpr(expr[1:], actual)
+		return CheckResult{Name: name, Pass: pass, Detail: fmt.Sprintf("box=%.0fx%.0f at (%.0f,%.0f)", box.Width, box.Height, box.X, box.Y)}
+
 	default:
 		return CheckResult{Name: name, Pass: false, Detail: fmt.Sprintf("unknown state: %s", state)}
 	}
 }
 
 func evalCountExpr(expr string, actual int) bool {
-	expr = strings.TrimPrefix(expr, "count")
-	if strings.HasPrefix(expr, ">=") {
-		n, _ := strconv.Atoi(expr[2:])
-		return actual >= n
-	}
-	if strings.HasPrefix(expr, ">") {
-		n, _ := strconv.Atoi(expr[1:])
-		return actual > n
-	}
-	if strings.HasPrefix(expr, "<=") {
-		n, _ := strconv.Atoi(expr[2:])
-		return actual <= n
-	}
-	if strings.HasPrefix(expr, "<") {
-		n, _ := strconv.Atoi(expr[1:])
-		return actual < n
-	}
-	if strings.HasPrefix(expr, "=") {
-		n, _ := strconv.Atoi(expr[1:])
-		return actual == n
+	return evalCompareExpr(strings.TrimPrefix(expr, "count"), float64(actual))
+}
+
+// evalCompareExpr evaluates a comparator expression such as ">=3" or "<100"
+// against actual. Unknown operators or unparseable numbers fail.
+func evalCompareExpr(expr string, actual float64) bool {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		rest, ok := strings.CutPrefix(expr, op)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(rest, 64)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case ">=":
+			return actual >= n
+		case "<=":
+			return actual <= n
+		case ">":
+			return actual > n
+		case "<":
+			return actual < n
+		default:
+			return actual == n
+		}
 	}
 	return false
 }
@@ -416,18 +484,28 @@ type CheckRequest struct {
 	URL        string
 	Viewport   string
 	Screenshot string
-	WaitMs     int
-	Login      bool
-	Checks     []string
-	Clicks     []string
-	ClickNavs  []string
-	Evals      []string
+	// ScreenshotOnFail is a directory. When set, a screenshot named by URL
+	// and timestamp is written there only if one or more results fail.
+	// Independent of Screenshot; both may be set.
+	ScreenshotOnFail string
+	WaitMs           int
+	Login            bool
+	Checks           []string
+	Clicks           []string
+	ClickNavs        []string
+	Evals            []string
+	// AssertTitle and AssertURL, when set, are substrings that
+	// document.title and the final window.location.href must contain.
+	AssertTitle string
+	AssertURL   string
 }
 
 type CheckResponse struct {
 	Results []CheckResult
 	Passed  int
 	Failed  int
+	// FailScreenshot is the path written for ScreenshotOnFail, if any.
+	FailScreenshot string
 }
 
 func RunChecks(ctx context.Context, req CheckRequest) (*CheckResponse, error) {
@@ -545,15 +623,77 @@ func RunChecks(ctx context.Context, req CheckRequest) (*CheckResponse, error) {
 		}
 	}
 
+	if req.AssertTitle != "" {
+		r := assertPageString(ctx, "title", "document.title", req.AssertTitle)
+		resp.Results = append(resp.Results, r)
+		if r.Pass {
+			resp.Passed++
+		} else {
+			resp.Failed++
+		}
+	}
+
+	if req.AssertURL != "" {
+		r := assertPageString(ctx, "url", "window.location.href", req.AssertURL)
+		resp.Results = append(resp.Results, r)
+		if r.Pass {
+			resp.Passed++
+		} else {
+			resp.Failed++
+		}
+	}
+
 	if req.Screenshot != "" {
 		if err := Screenshot(ctx, req.Screenshot); err != nil {
 			return resp, fmt.Errorf("screenshot failed: %w", err)
 		}
 	}
 
+	if req.ScreenshotOnFail != "" && resp.Failed > 0 {
+		path := FailScreenshotPath(req.ScreenshotOnFail, req.URL, time.Now())
+		if err := os.MkdirAll(req.ScreenshotOnFail, 0755); err != nil {
+			return resp, fmt.Errorf("screenshot-on-fail dir: %w", err)
+		}
+		if err := Screenshot(ctx, path); err != nil {
+			return resp, fmt.Errorf("screenshot-on-fail failed: %w", err)
+		}
+		resp.FailScreenshot = path
+	}
+
 	return resp, nil
 }
 
+// assertPageString checks that the string JS expression expr contains want.
+// The actual value is reported in the detail either way.
+func assertPageString(ctx context.Context, label, expr, want string) CheckResult {
+	name := fmt.Sprintf("assert-%s(%s)", label, want)
+	var actual string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(expr, &actual)); err != nil {
+		return CheckResult{Name: name, Pass: false, Detail: err.Error()}
+	}
+	return CheckResult{Name: name, Pass: strings.Contains(actual, want), Detail: fmt.Sprintf("got: %s", truncate(actual, 80))}
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// FailScreenshotPath returns the screenshot path for a failed check run:
+// dir/<url>-<timestamp>.png, with the scheme dropped and other unsafe
+// characters replaced by "_".
+func FailScreenshotPath(dir, url string, now time.Time) string {
+	name := url
+	if i := strings.Index(name, "://"); i >= 0 {
+		name = name[i+3:]
+	}
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_.")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	if name == "" {
+		name = "page"
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s.png", name, now.Format("20060102-150405")))
+}
+
 func Truncate(s string, n int) string {
 	return truncate(s, n)
 }